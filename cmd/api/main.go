@@ -3,8 +3,14 @@ package main
 import (
 	"log"
 	nethttp "net/http"
+	"os"
+	_ "time/tzdata" // zonas horarias incluidas, por si el sistema no las trae
 
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/config"
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/domain"
 	"github.com/jfmg0509/sistema_libros_funcional_go/internal/infrastructure/db"
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/infrastructure/recommend"
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/infrastructure/search"
 	httptransport "github.com/jfmg0509/sistema_libros_funcional_go/internal/transport/http"
 	"github.com/jfmg0509/sistema_libros_funcional_go/internal/usecase"
 )
@@ -17,33 +23,149 @@ import (
    Este archivo es el PUNTO DE ENTRADA del sistema.
 
    Pasos:
-   1. Crear repositorios en memoria.
+   0. Leer la configuración (flags / variables de entorno).
+   1. Crear repositorios (en memoria, snapshots en archivos, WAL o SQL).
    2. Crear servicios (capa de negocio/usecase).
    3. Crear el handler HTTP.
    4. Registrar rutas en un ServeMux.
-   5. Iniciar el servidor HTTP en el puerto 8081.
+   5. Iniciar el servidor HTTP (por defecto en el puerto 8081).
 */
 
 func main() {
-	// 1. Crear repositorios (implementan interfaces del dominio).
-	userRepo := db.NewInMemoryUserRepo()
-	bookRepo := db.NewInMemoryBookRepo()
-	accessRepo := db.NewInMemoryAccessLogRepo()
+	// 0. Leer configuración.
+	cfg, err := config.Load(os.Args[1:])
+	if err != nil {
+		log.Fatalf("configuración inválida: %v", err)
+	}
+
+	// 1. Crear repositorios (implementan interfaces del dominio)
+	//    y la unidad de trabajo que los agrupa.
+	repos, uow, err := buildRepositories(cfg)
+	if err != nil {
+		log.Fatalf("error al abrir repositorios: %v", err)
+	}
 
 	// 2. Crear servicios de negocio, inyectando los repositorios.
-	userService := usecase.NewUserService(userRepo)
-	bookService := usecase.NewBookService(bookRepo, userRepo, accessRepo)
+	userService := usecase.NewUserService(repos.Users, uow)
+	bookService := usecase.NewBookService(repos.Books, repos.Users, repos.Access, uow, search.NewIndex(), recommend.NewEngine())
+	historyService := usecase.NewHistoryService(repos.Users, repos.Books, repos.Access)
+	analyticsService := usecase.NewAnalyticsService(repos.Users, repos.Books, repos.Access)
+	userService.AddUserObserver(analyticsService)
+	bookService.AddBookObserver(analyticsService)
+	bookService.AddAccessObserver(analyticsService)
+
+	// El índice de texto completo vive en memoria: se arma al arrancar.
+	if err := bookService.RebuildSearchIndex(); err != nil {
+		log.Fatalf("error al indexar libros: %v", err)
+	}
+	// La popularidad (autocompletado) se cuenta desde el registro de accesos.
+	if err := bookService.RebuildPopularity(); err != nil {
+		log.Fatalf("error al contar accesos: %v", err)
+	}
+	// Las tendencias y las recomendaciones también se arman desde el
+	// registro de accesos.
+	if err := bookService.RebuildTrending(); err != nil {
+		log.Fatalf("error al contar tendencias: %v", err)
+	}
+	if err := bookService.RebuildRecommendations(); err != nil {
+		log.Fatalf("error al calcular recomendaciones: %v", err)
+	}
+	// El tablero de analítica se arma con los tres repositorios.
+	if err := analyticsService.Rebuild(); err != nil {
+		log.Fatalf("error al calcular la analítica: %v", err)
+	}
 
 	// 3. Crear el handler HTTP, que usará los servicios.
-	handler := httptransport.NewHTTPHandler(userService, bookService)
+	handler := httptransport.NewHTTPHandler(userService, bookService, historyService, analyticsService)
 
 	// 4. Crear un enrutador (ServeMux) y registrar las rutas.
 	mux := nethttp.NewServeMux()
 	handler.RegisterRoutes(mux)
 
 	// 5. Levantar el servidor HTTP.
-	log.Println("Servidor HTTP iniciado en http://localhost:8081")
-	if err := nethttp.ListenAndServe(":8081", mux); err != nil {
+	log.Printf("Servidor HTTP iniciado en %s (almacenamiento: %s)", cfg.Addr, cfg.Store)
+	if err := nethttp.ListenAndServe(cfg.Addr, mux); err != nil {
 		log.Fatalf("error al iniciar servidor: %v", err)
 	}
 }
+
+// buildRepositories crea los tres repositorios según el almacenamiento elegido,
+// junto con la unidad de trabajo adecuada para ese almacenamiento.
+func buildRepositories(cfg config.Config) (domain.Repositories, domain.UnitOfWork, error) {
+	var repos domain.Repositories
+
+	switch cfg.Store {
+	case config.StoreFile:
+		userRepo, err := db.NewFileUserRepo(cfg.DataDir)
+		if err != nil {
+			return repos, nil, err
+		}
+		bookRepo, err := db.NewFileBookRepo(cfg.DataDir)
+		if err != nil {
+			return repos, nil, err
+		}
+		accessRepo, err := db.NewFileAccessLogRepo(cfg.DataDir)
+		if err != nil {
+			return repos, nil, err
+		}
+		repos = domain.Repositories{Users: userRepo, Books: bookRepo, Access: accessRepo}
+		return repos, db.NewInMemoryUnitOfWork(repos), nil
+
+	case config.StoreWAL:
+		userRepo, err := db.NewWALUserRepo(cfg.DataDir, cfg.CompactEvery)
+		if err != nil {
+			return repos, nil, err
+		}
+		bookRepo, err := db.NewWALBookRepo(cfg.DataDir, cfg.CompactEvery)
+		if err != nil {
+			return repos, nil, err
+		}
+		accessRepo, err := db.NewWALAccessLogRepo(cfg.DataDir, cfg.CompactEvery)
+		if err != nil {
+			return repos, nil, err
+		}
+		repos = domain.Repositories{Users: userRepo, Books: bookRepo, Access: accessRepo}
+		return repos, db.NewInMemoryUnitOfWork(repos), nil
+
+	case config.StoreSQL:
+		conn, err := db.OpenSQL(cfg.DBDriver, cfg.DBDSN)
+		if err != nil {
+			return repos, nil, err
+		}
+		applied, err := db.MigrateUp(conn)
+		if err != nil {
+			return repos, nil, err
+		}
+		if len(applied) > 0 {
+			log.Printf("migraciones aplicadas: %v", applied)
+		}
+		filled, err := db.BackfillSearchColumns(conn)
+		if err != nil {
+			return repos, nil, err
+		}
+		if filled > 0 {
+			log.Printf("columnas de búsqueda completadas en %d filas", filled)
+		}
+		sketches, err := db.BackfillReaderSketches(conn)
+		if err != nil {
+			return repos, nil, err
+		}
+		if sketches > 0 {
+			log.Printf("sketches de lectores armados: %d", sketches)
+		}
+		repos = domain.Repositories{
+			Users:  db.NewSQLUserRepo(conn),
+			Books:  db.NewSQLBookRepo(conn),
+			Access: db.NewSQLAccessLogRepo(conn),
+		}
+		return repos, db.NewSQLUnitOfWork(conn), nil
+
+	default:
+		repos = domain.Repositories{
+			Users:  db.NewInMemoryUserRepo(),
+			Books:  db.NewInMemoryBookRepo(),
+			Access: db.NewInMemoryAccessLogRepo(),
+		}
+		return repos, db.NewInMemoryUnitOfWork(repos), nil
+	}
+}
@@ -6,6 +6,11 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
+
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/domain"
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/textnorm"
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/usecase"
 )
 
 // ------------------------------------------------------------
@@ -49,10 +54,11 @@ type Book struct {
 
 // AccessEvent representa un evento de acceso de un usuario a un libro.
 type AccessEvent struct {
-	ID     int
-	BookID int
-	UserID int
-	Type   AccessType
+	ID        int
+	BookID    int
+	UserID    int
+	Type      AccessType
+	Timestamp time.Time
 }
 
 // ------------------------------------------------------------
@@ -83,7 +89,7 @@ func main() {
 		fmt.Println("4. Listar libros")
 		fmt.Println("5. Buscar libros por título/autor")
 		fmt.Println("6. Registrar acceso a un libro")
-		fmt.Println("7. Ver estadísticas de accesos de un libro")
+		fmt.Println("7. Ver estadísticas de accesos")
 		fmt.Println("0. Salir")
 		fmt.Print("Selecciona una opción: ")
 
@@ -301,7 +307,8 @@ func searchBooks(scanner *bufio.Scanner) {
 		fmt.Println("Error al leer el texto de búsqueda.")
 		return
 	}
-	query := strings.ToLower(strings.TrimSpace(scanner.Text()))
+	// Se compara sin mayúsculas ni acentos: "programacion" encuentra "Programación".
+	query := textnorm.FoldKey(scanner.Text())
 
 	if query == "" {
 		fmt.Println("La búsqueda no puede estar vacía.")
@@ -310,10 +317,10 @@ func searchBooks(scanner *bufio.Scanner) {
 
 	encontrados := 0
 	for _, b := range books {
-		titleLower := strings.ToLower(b.Title)
-		authorLower := strings.ToLower(b.Author)
+		titleFolded := textnorm.Fold(b.Title)
+		authorFolded := textnorm.Fold(b.Author)
 
-		if strings.Contains(titleLower, query) || strings.Contains(authorLower, query) {
+		if strings.Contains(titleFolded, query) || strings.Contains(authorFolded, query) {
 			encontrados++
 			fmt.Printf("ID: %d | Título: %s | Autor: %s | Año: %d\n",
 				b.ID, b.Title, b.Author, b.Year)
@@ -396,10 +403,11 @@ func registerAccess(scanner *bufio.Scanner) {
 	newID := len(accessEvents) + 1
 
 	event := AccessEvent{
-		ID:     newID,
-		BookID: book.ID,
-		UserID: user.ID,
-		Type:   accessType,
+		ID:        newID,
+		BookID:    book.ID,
+		UserID:    user.ID,
+		Type:      accessType,
+		Timestamp: time.Now(),
 	}
 
 	accessEvents = append(accessEvents, event)
@@ -408,8 +416,34 @@ func registerAccess(scanner *bufio.Scanner) {
 		user.Name, book.Title, accessType)
 }
 
-// showAccessStats muestra cuántos accesos tiene un libro por tipo.
+// showAccessStats es el menú de estadísticas: accesos de un libro por
+// tipo o el embudo de un libro o una categoría.
 func showAccessStats(scanner *bufio.Scanner) {
+	fmt.Println("=== Estadísticas de accesos ===")
+	fmt.Println("1. Accesos de un libro por tipo")
+	fmt.Println("2. Embudo APERTURA → LECTURA → DESCARGA")
+	fmt.Print("Selecciona una opción: ")
+
+	if !scanner.Scan() {
+		fmt.Println("Error al leer la opción.")
+		return
+	}
+	opcion := strings.TrimSpace(scanner.Text())
+
+	fmt.Println()
+
+	switch opcion {
+	case "1":
+		showBookAccessCounts(scanner)
+	case "2":
+		showFunnel(scanner)
+	default:
+		fmt.Println("Opción no válida.")
+	}
+}
+
+// showBookAccessCounts muestra cuántos accesos tiene un libro por tipo.
+func showBookAccessCounts(scanner *bufio.Scanner) {
 	fmt.Println("=== Estadísticas de accesos por libro ===")
 
 	fmt.Print("ID de libro: ")
@@ -450,6 +484,87 @@ func showAccessStats(scanner *bufio.Scanner) {
 	fmt.Printf("DESCARGA: %d\n", stats[AccessDescarga])
 }
 
+// showFunnel muestra el embudo APERTURA → LECTURA → DESCARGA de un libro
+// o de una categoría. El cálculo es el mismo que usa la API
+// (usecase.ComputeFunnel).
+func showFunnel(scanner *bufio.Scanner) {
+	fmt.Println("=== Embudo de conversión ===")
+
+	fmt.Print("ID de libro o nombre de categoría: ")
+	if !scanner.Scan() {
+		fmt.Println("Error al leer el libro o la categoría.")
+		return
+	}
+	scope := strings.TrimSpace(scanner.Text())
+	if scope == "" {
+		fmt.Println("Hay que indicar un libro o una categoría.")
+		return
+	}
+
+	// Un número es un ID de libro; cualquier otro texto, una categoría
+	// (sin distinguir mayúsculas ni acentos).
+	inScope := make(map[int]bool)
+	title := ""
+	if bookID, err := strconv.Atoi(scope); err == nil {
+		book := findBookByID(bookID)
+		if book == nil {
+			fmt.Println("No existe un libro con ese ID.")
+			return
+		}
+		inScope[book.ID] = true
+		title = fmt.Sprintf("el libro %s (ID %d)", book.Title, book.ID)
+	} else {
+		category := textnorm.FoldKey(scope)
+		for _, b := range books {
+			if textnorm.FoldKey(b.Category) == category {
+				inScope[b.ID] = true
+			}
+		}
+		if len(inScope) == 0 {
+			fmt.Println("No hay libros en esa categoría.")
+			return
+		}
+		title = "la categoría " + scope
+	}
+
+	fmt.Print("Ventana desde la apertura (ENTER = 7d; ej. 30m, 24h, 7d): ")
+	if !scanner.Scan() {
+		fmt.Println("Error al leer la ventana.")
+		return
+	}
+	window, err := domain.ParseFunnelWindow(strings.TrimSpace(scanner.Text()))
+	if err != nil {
+		fmt.Println("Ventana inválida:", err)
+		return
+	}
+
+	// Pasar los eventos al tipo del dominio para reusar el cálculo.
+	events := make([]*domain.AccessEvent, 0)
+	for _, e := range accessEvents {
+		if inScope[e.BookID] {
+			events = append(events, domain.RestoreAccessEvent(
+				domain.AccessEventID(e.ID), domain.BookID(e.BookID), domain.UserID(e.UserID),
+				domain.AccessType(e.Type), e.Timestamp,
+			))
+		}
+	}
+
+	funnel := usecase.ComputeFunnel(events, window)
+
+	fmt.Printf("Embudo para %s (ventana %s)\n", title, window)
+	for i, step := range funnel.Steps {
+		fmt.Printf("%-9s: %d usuarios", step.AccessType, step.Users)
+		if i > 0 {
+			fmt.Printf(" | conversión %.1f %%", step.Conversion*100)
+			if step.MedianTime != nil {
+				fmt.Printf(" | mediana %s", step.MedianTime.Round(time.Second))
+			}
+		}
+		fmt.Println()
+	}
+	fmt.Printf("Conversión total (APERTURA → DESCARGA): %.1f %%\n", funnel.Conversion*100)
+}
+
 // ------------------------------------------------------------
 // FUNCIONES AUXILIARES PARA BUSCAR POR ID
 // ------------------------------------------------------------
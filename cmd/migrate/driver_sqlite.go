@@ -0,0 +1,7 @@
+//go:build sqlite
+
+package main
+
+// Driver SQLite en Go puro (sin cgo) para cmd/migrate.
+// Se compila solo con: go build -tags sqlite ./cmd/migrate
+import _ "modernc.org/sqlite"
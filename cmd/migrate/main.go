@@ -0,0 +1,89 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/infrastructure/db"
+)
+
+/*
+   ==========================================================
+   cmd/migrate
+   ==========================================================
+
+   Herramienta para aplicar o revertir las migraciones SQL
+   embebidas en internal/infrastructure/db/migrations.
+
+   Uso:
+
+	go run -tags sqlite ./cmd/migrate -dsn=libros.db up
+	go run -tags sqlite ./cmd/migrate -dsn=libros.db down 1
+*/
+
+func main() {
+	driver := flag.String("driver", "sqlite", "driver de database/sql")
+	dsn := flag.String("dsn", "libros.db", "cadena de conexión")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "uso: migrate [-driver=sqlite] [-dsn=libros.db] up | down [pasos]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	conn, err := db.OpenSQL(*driver, *dsn)
+	if err != nil {
+		log.Fatalf("no se pudo abrir la base de datos: %v", err)
+	}
+	defer conn.Close()
+
+	switch flag.Arg(0) {
+	case "up":
+		applied, err := db.MigrateUp(conn)
+		if err != nil {
+			log.Fatalf("error al migrar: %v", err)
+		}
+		fmt.Println("migraciones aplicadas:", applied)
+
+		filled, err := db.BackfillSearchColumns(conn)
+		if err != nil {
+			log.Fatalf("error al completar columnas de búsqueda: %v", err)
+		}
+		if filled > 0 {
+			fmt.Println("columnas de búsqueda completadas en", filled, "filas")
+		}
+
+		sketches, err := db.BackfillReaderSketches(conn)
+		if err != nil {
+			log.Fatalf("error al armar los sketches de lectores: %v", err)
+		}
+		if sketches > 0 {
+			fmt.Println("sketches de lectores armados:", sketches)
+		}
+
+	case "down":
+		steps := 1
+		if flag.NArg() > 1 {
+			steps, err = strconv.Atoi(flag.Arg(1))
+			if err != nil || steps <= 0 {
+				log.Fatalf("la cantidad de pasos debe ser un número mayor que cero")
+			}
+		}
+		reverted, err := db.MigrateDown(conn, steps)
+		if err != nil {
+			log.Fatalf("error al revertir: %v", err)
+		}
+		fmt.Println("migraciones revertidas:", reverted)
+
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,110 @@
+package bookquery
+
+import (
+	"strconv"
+	"strings"
+)
+
+/*
+   ==========================================================
+   LENGUAJE DE CONSULTA DE LIBROS
+   ==========================================================
+
+   BookFilter solo combina con AND un conjunto fijo de campos.
+   Este paquete permite escribir consultas como:
+
+	author:"Baca Urbina" AND (tag:redes OR tag:seguridad) AND year>=2015 -tag:obsoleto
+
+   Gramática (AND se puede omitir: "a b" es "a AND b"):
+
+	consulta = o
+	o        = y { "OR" y }
+	y        = unario { ["AND"] unario }
+	unario   = ("NOT" | "-") unario | primario
+	primario = "(" o ")" | termino
+	termino  = campo ":" valor
+	         | "year" ("=" | ">" | ">=" | "<" | "<=") número
+	         | valor                        (título, autor o tag)
+	valor    = palabra | "texto entre comillas"
+
+   Campos: title, author, category, tag, isbn, year.
+   AND, OR y NOT van en MAYÚSCULAS; en minúsculas son palabras.
+
+   Parse devuelve el árbol (AST) o un *SyntaxError con la
+   columna del problema. Evaluar el árbol es tarea de cada
+   repositorio (ver internal/infrastructure/db/query_eval.go).
+*/
+
+// Expr es un nodo del árbol de la consulta.
+type Expr interface {
+	String() string
+	exprNode()
+}
+
+// And se cumple si se cumplen ambos lados.
+type And struct{ Left, Right Expr }
+
+// Or se cumple si se cumple alguno de los lados.
+type Or struct{ Left, Right Expr }
+
+// Not se cumple si NO se cumple X.
+type Not struct{ X Expr }
+
+// Field es el campo de un término.
+type Field string
+
+const (
+	FieldAny      Field = "" // sin campo: título, autor o tag
+	FieldTitle    Field = "title"
+	FieldAuthor   Field = "author"
+	FieldCategory Field = "category"
+	FieldTag      Field = "tag"
+	FieldISBN     Field = "isbn"
+	FieldYear     Field = "year"
+)
+
+// allowedFields es un ARRAY con los campos que se pueden escribir.
+var allowedFields = [6]Field{FieldTitle, FieldAuthor, FieldCategory, FieldTag, FieldISBN, FieldYear}
+
+// Op es la comparación de un término.
+type Op string
+
+const (
+	OpMatch Op = ":" // contiene (título, autor) o es igual (resto)
+	OpEq    Op = "="
+	OpGt    Op = ">"
+	OpGte   Op = ">="
+	OpLt    Op = "<"
+	OpLte   Op = "<="
+)
+
+// Term es una condición sobre un campo.
+// Para year, Number tiene el valor ya convertido a entero.
+type Term struct {
+	Field  Field
+	Op     Op
+	Value  string
+	Number int
+	Pos    int // columna (desde 1) donde empieza el término
+}
+
+func (*And) exprNode()  {}
+func (*Or) exprNode()   {}
+func (*Not) exprNode()  {}
+func (*Term) exprNode() {}
+
+// String escribe la consulta con paréntesis explícitos.
+func (e *And) String() string { return "(" + e.Left.String() + " AND " + e.Right.String() + ")" }
+func (e *Or) String() string  { return "(" + e.Left.String() + " OR " + e.Right.String() + ")" }
+func (e *Not) String() string { return "NOT " + e.X.String() }
+
+func (t *Term) String() string {
+	value := t.Value
+	if value == "" || strings.ContainsAny(value, " \t()\":<>=") {
+		value = strconv.Quote(value)
+	}
+	if t.Field == FieldAny {
+		return value
+	}
+	return string(t.Field) + string(t.Op) + value
+}
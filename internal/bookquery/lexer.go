@@ -0,0 +1,139 @@
+package bookquery
+
+import (
+	"strings"
+	"unicode"
+)
+
+/*
+   ==========================================================
+   ANALIZADOR LÉXICO
+   ==========================================================
+
+   Divide la consulta en TOKENS (palabras, textos entre
+   comillas, paréntesis, operadores...). Cada token recuerda
+   su columna para poder señalar dónde está un error.
+*/
+
+// tokenKind es el tipo de un token.
+type tokenKind int
+
+const (
+	tokEOF    tokenKind = iota
+	tokWord             // redes, 2015, 978-84
+	tokString           // "Baca Urbina"
+	tokLParen           // (
+	tokRParen           // )
+	tokMinus            // - (al comienzo de un término: NOT)
+	tokColon            // :
+	tokOp               // = > >= < <=
+	tokAnd              // AND
+	tokOr               // OR
+	tokNot              // NOT
+)
+
+// token es una pieza de la consulta.
+type token struct {
+	kind tokenKind
+	text string
+	pos  int // columna (desde 1)
+}
+
+// describe nombra un token para los mensajes de error.
+func (t token) describe() string {
+	switch t.kind {
+	case tokEOF:
+		return "el final de la consulta"
+	case tokString:
+		return "el texto \"" + t.text + "\""
+	default:
+		return "\"" + t.text + "\""
+	}
+}
+
+// isWordRune indica si r puede formar parte de una palabra.
+func isWordRune(r rune) bool {
+	return !unicode.IsSpace(r) && !strings.ContainsRune(`()":<>=`, r)
+}
+
+// lex convierte la consulta en tokens; el último siempre es tokEOF.
+func lex(input string) ([]token, error) {
+	runes := []rune(input)
+	var tokens []token
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		pos := i + 1
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+
+		case r == '(':
+			tokens = append(tokens, token{tokLParen, "(", pos})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{tokRParen, ")", pos})
+			i++
+		case r == ':':
+			tokens = append(tokens, token{tokColon, ":", pos})
+			i++
+		case r == '-':
+			tokens = append(tokens, token{tokMinus, "-", pos})
+			i++
+
+		case r == '<' || r == '>' || r == '=':
+			op := string(r)
+			i++
+			if r != '=' && i < len(runes) && runes[i] == '=' {
+				op += "="
+				i++
+			}
+			tokens = append(tokens, token{tokOp, op, pos})
+
+		case r == '"':
+			// Texto entre comillas; \" y \\ se escapan.
+			var sb strings.Builder
+			i++
+			closed := false
+			for i < len(runes) {
+				c := runes[i]
+				if c == '\\' && i+1 < len(runes) {
+					sb.WriteRune(runes[i+1])
+					i += 2
+					continue
+				}
+				i++
+				if c == '"' {
+					closed = true
+					break
+				}
+				sb.WriteRune(c)
+			}
+			if !closed {
+				return nil, &SyntaxError{Pos: pos, Msg: "faltan las comillas de cierre"}
+			}
+			tokens = append(tokens, token{tokString, sb.String(), pos})
+
+		default:
+			// Palabra: un "-" en el medio (978-84) es parte de ella.
+			start := i
+			for i < len(runes) && isWordRune(runes[i]) {
+				i++
+			}
+			text := string(runes[start:i])
+			kind := tokWord
+			switch text {
+			case "AND":
+				kind = tokAnd
+			case "OR":
+				kind = tokOr
+			case "NOT":
+				kind = tokNot
+			}
+			tokens = append(tokens, token{kind, text, pos})
+		}
+	}
+
+	return append(tokens, token{tokEOF, "", len(runes) + 1}), nil
+}
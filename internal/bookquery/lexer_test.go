@@ -0,0 +1,55 @@
+package bookquery
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestLex(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []token
+	}{
+		{`redes`, []token{{tokWord, "redes", 1}, {tokEOF, "", 6}}},
+		{`  tag:go `, []token{{tokWord, "tag", 3}, {tokColon, ":", 6}, {tokWord, "go", 7}, {tokEOF, "", 10}}},
+		// Un "-" al comienzo es NOT; en el medio es parte de la palabra.
+		{`-tag:x`, []token{{tokMinus, "-", 1}, {tokWord, "tag", 2}, {tokColon, ":", 5}, {tokWord, "x", 6}, {tokEOF, "", 7}}},
+		{`isbn:978-84`, []token{{tokWord, "isbn", 1}, {tokColon, ":", 5}, {tokWord, "978-84", 6}, {tokEOF, "", 12}}},
+		{`a -b`, []token{{tokWord, "a", 1}, {tokMinus, "-", 3}, {tokWord, "b", 4}, {tokEOF, "", 5}}},
+		{`year>=2015`, []token{{tokWord, "year", 1}, {tokOp, ">=", 5}, {tokWord, "2015", 7}, {tokEOF, "", 11}}},
+		{`year<2 year=3`, []token{
+			{tokWord, "year", 1}, {tokOp, "<", 5}, {tokWord, "2", 6},
+			{tokWord, "year", 8}, {tokOp, "=", 12}, {tokWord, "3", 13}, {tokEOF, "", 14},
+		}},
+		// Solo en MAYÚSCULAS son operadores.
+		{`a AND b or NOT c`, []token{
+			{tokWord, "a", 1}, {tokAnd, "AND", 3}, {tokWord, "b", 7},
+			{tokWord, "or", 9}, {tokNot, "NOT", 12}, {tokWord, "c", 16}, {tokEOF, "", 17},
+		}},
+		{`(a)`, []token{{tokLParen, "(", 1}, {tokWord, "a", 2}, {tokRParen, ")", 3}, {tokEOF, "", 4}}},
+		{`author:"Baca \"U\" \\"`, []token{
+			{tokWord, "author", 1}, {tokColon, ":", 7}, {tokString, `Baca "U" \`, 8}, {tokEOF, "", 23},
+		}},
+		// Las columnas cuentan letras, no bytes.
+		{`año ñu`, []token{{tokWord, "año", 1}, {tokWord, "ñu", 5}, {tokEOF, "", 7}}},
+	}
+	for _, tt := range tests {
+		got, err := lex(tt.input)
+		if err != nil {
+			t.Errorf("lex(%q): %v", tt.input, err)
+			continue
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("lex(%q)\n got %v\nwant %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestLexUnclosedString(t *testing.T) {
+	_, err := lex(`title:"sin cerrar`)
+	var syntaxErr *SyntaxError
+	if !errors.As(err, &syntaxErr) || syntaxErr.Pos != 7 {
+		t.Fatalf("se esperaba un error en la columna 7 (las comillas), vino %v", err)
+	}
+}
@@ -0,0 +1,223 @@
+package bookquery
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+/*
+   ==========================================================
+   ANALIZADOR SINTÁCTICO (descenso recursivo)
+   ==========================================================
+
+   Cada regla de la gramática (ver ast.go) es una función:
+   parseOr → parseAnd → parseUnary → parsePrimary → parseTerm.
+   OR tiene menor precedencia que AND, y AND menor que NOT:
+
+	a OR b c   →   a OR (b AND c)
+*/
+
+// maxDepth limita los paréntesis y NOT anidados.
+const maxDepth = 64
+
+// SyntaxError es un error de sintaxis con la columna (desde 1)
+// donde se detectó.
+type SyntaxError struct {
+	Pos int
+	Msg string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("columna %d: %s", e.Pos, e.Msg)
+}
+
+// parser recorre los tokens de una consulta.
+type parser struct {
+	tokens []token
+	pos    int
+	depth  int
+}
+
+/*
+Parse convierte una consulta en su árbol.
+
+	Parse(`author:"Baca Urbina" year>=2015 -tag:obsoleto`)
+
+Devuelve un *SyntaxError si la consulta no es válida.
+*/
+func Parse(input string) (Expr, error) {
+	tokens, err := lex(input)
+	if err != nil {
+		return nil, err
+	}
+	if tokens[0].kind == tokEOF {
+		return nil, &SyntaxError{Pos: 1, Msg: "la consulta está vacía"}
+	}
+
+	p := &parser{tokens: tokens}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		if tok.kind == tokRParen {
+			return nil, p.errorAt(tok, "paréntesis ')' sin abrir")
+		}
+		return nil, p.errorAt(tok, "no se esperaba "+tok.describe())
+	}
+	return expr, nil
+}
+
+func (p *parser) peek() token { return p.tokens[p.pos] }
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) errorAt(tok token, msg string) error {
+	return &SyntaxError{Pos: tok.pos, Msg: msg}
+}
+
+// parseOr: y { "OR" y }
+func (p *parser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokOr {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &Or{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+// parseAnd: unario { ["AND"] unario }
+func (p *parser) parseAnd() (Expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch p.peek().kind {
+		case tokAnd:
+			p.next()
+		case tokWord, tokString, tokLParen, tokMinus, tokNot:
+			// AND implícito: "redes go" es "redes AND go".
+		default:
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &And{Left: left, Right: right}
+	}
+}
+
+// parseUnary: ("NOT" | "-") unario | primario
+func (p *parser) parseUnary() (Expr, error) {
+	tok := p.peek()
+	if tok.kind != tokNot && tok.kind != tokMinus {
+		return p.parsePrimary()
+	}
+	p.next()
+
+	if p.depth++; p.depth > maxDepth {
+		return nil, p.errorAt(tok, "la consulta tiene demasiados niveles anidados")
+	}
+	x, err := p.parseUnary()
+	p.depth--
+	if err != nil {
+		return nil, err
+	}
+	return &Not{X: x}, nil
+}
+
+// parsePrimary: "(" o ")" | termino
+func (p *parser) parsePrimary() (Expr, error) {
+	tok := p.peek()
+	switch tok.kind {
+	case tokLParen:
+		p.next()
+		if p.depth++; p.depth > maxDepth {
+			return nil, p.errorAt(tok, "la consulta tiene demasiados niveles anidados")
+		}
+		expr, err := p.parseOr()
+		p.depth--
+		if err != nil {
+			return nil, err
+		}
+		if p.peek().kind != tokRParen {
+			return nil, p.errorAt(p.peek(), fmt.Sprintf(
+				"se esperaba ')' para cerrar el paréntesis de la columna %d, no %s", tok.pos, p.peek().describe()))
+		}
+		p.next()
+		return expr, nil
+
+	case tokWord, tokString:
+		return p.parseTerm()
+
+	case tokEOF:
+		return nil, p.errorAt(tok, "la consulta termina donde se esperaba un término")
+	case tokRParen:
+		return nil, p.errorAt(tok, "paréntesis vacío o ')' inesperado")
+	default:
+		return nil, p.errorAt(tok, "se esperaba un término y llegó "+tok.describe())
+	}
+}
+
+// parseTerm: campo ":" valor | "year" op número | valor
+func (p *parser) parseTerm() (Expr, error) {
+	first := p.next()
+
+	sep := p.peek()
+	if first.kind != tokWord || (sep.kind != tokColon && sep.kind != tokOp) {
+		// Sin campo: busca en título, autor y tags.
+		return &Term{Field: FieldAny, Op: OpMatch, Value: first.text, Pos: first.pos}, nil
+	}
+	p.next()
+
+	field, ok := lookupField(first.text)
+	if !ok {
+		return nil, p.errorAt(first, fmt.Sprintf(
+			"campo desconocido %q (use title, author, category, tag, isbn o year)", first.text))
+	}
+	if sep.kind == tokOp && field != FieldYear {
+		return nil, p.errorAt(sep, fmt.Sprintf("el campo %s no admite %q; use %s:valor", field, sep.text, field))
+	}
+
+	value := p.peek()
+	if value.kind != tokWord && value.kind != tokString {
+		return nil, p.errorAt(value, fmt.Sprintf("se esperaba un valor para %s y llegó %s", field, value.describe()))
+	}
+	p.next()
+
+	term := &Term{Field: field, Op: Op(sep.text), Value: value.text, Pos: first.pos}
+	if field == FieldYear {
+		n, err := strconv.Atoi(value.text)
+		if err != nil {
+			return nil, p.errorAt(value, fmt.Sprintf("year debe ser un número entero, no %s", value.describe()))
+		}
+		term.Number = n
+	}
+	return term, nil
+}
+
+// lookupField busca un campo sin distinguir mayúsculas.
+func lookupField(name string) (Field, bool) {
+	for _, f := range allowedFields {
+		if strings.EqualFold(string(f), name) {
+			return f, true
+		}
+	}
+	return "", false
+}
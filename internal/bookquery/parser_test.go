@@ -0,0 +1,151 @@
+package bookquery
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string // el árbol escrito con paréntesis explícitos
+	}{
+		{`redes`, `redes`},
+		{`"redes de computadoras"`, `"redes de computadoras"`},
+		{`title:redes`, `title:redes`},
+		{`TITLE:redes`, `title:redes`},
+		{`author:"Baca Urbina"`, `author:"Baca Urbina"`},
+		{`category:IA tag:go isbn:978-84`, `((category:IA AND tag:go) AND isbn:978-84)`},
+		{`year:2015 year=2015 year>2015`, `((year:2015 AND year=2015) AND year>2015)`},
+		{`year>=2015 year<2020 year<=2019`, `((year>=2015 AND year<2020) AND year<=2019)`},
+
+		// AND explícito o implícito, asociativo a izquierda.
+		{`a AND b`, `(a AND b)`},
+		{`a b c`, `((a AND b) AND c)`},
+		{`a OR b OR c`, `((a OR b) OR c)`},
+
+		// Precedencia: NOT > AND > OR.
+		{`a OR b c`, `(a OR (b AND c))`},
+		{`a b OR c`, `((a AND b) OR c)`},
+		{`a AND b OR c AND d`, `((a AND b) OR (c AND d))`},
+		{`-a b`, `(NOT a AND b)`},
+		{`NOT a OR b`, `(NOT a OR b)`},
+		{`NOT NOT a`, `NOT NOT a`},
+		{`(a OR b) c`, `((a OR b) AND c)`},
+		{`-(a OR b)`, `NOT (a OR b)`},
+		{`((a))`, `a`},
+
+		// "-" solo es NOT al comienzo de un término.
+		{`978-84`, `978-84`},
+		{`isbn:978-84-376`, `isbn:978-84-376`},
+		{`-978-84`, `NOT 978-84`},
+		{`a -b`, `(a AND NOT b)`},
+
+		// En minúsculas, and/or/not son palabras.
+		{`a or b`, `((a AND or) AND b)`},
+	}
+	for _, tt := range tests {
+		expr, err := Parse(tt.input)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.input, err)
+			continue
+		}
+		if got := expr.String(); got != tt.want {
+			t.Errorf("Parse(%q) = %s, se esperaba %s", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseYearNumber(t *testing.T) {
+	expr, err := Parse(`year>=-5`)
+	if err == nil {
+		t.Fatalf("year>=-5 debería fallar (el - es NOT), vino %s", expr)
+	}
+
+	expr, err = Parse(`year<=2015`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	term, ok := expr.(*Term)
+	if !ok || term.Field != FieldYear || term.Op != OpLte || term.Number != 2015 || term.Pos != 1 {
+		t.Fatalf("year<=2015 = %#v", expr)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		pos   int
+		msg   string // parte del mensaje
+	}{
+		{``, 1, "vacía"},
+		{`   `, 1, "vacía"},
+		{`a OR`, 5, "termina donde se esperaba un término"},
+		{`a AND`, 6, "termina donde se esperaba un término"},
+		{`NOT`, 4, "termina donde se esperaba un término"},
+		{`(a`, 3, "se esperaba ')' para cerrar el paréntesis de la columna 1"},
+		{`a (b c`, 7, "columna 3"},
+		{`a)`, 2, "paréntesis ')' sin abrir"},
+		{`()`, 2, "paréntesis vacío"},
+		{`OR a`, 1, `se esperaba un término y llegó "OR"`},
+		{`a OR OR b`, 6, `llegó "OR"`},
+		{`:a`, 1, `llegó ":"`},
+		{`>= 5`, 1, `llegó ">="`},
+		{`foo:bar`, 1, `campo desconocido "foo"`},
+		{`a title:`, 9, "se esperaba un valor para title y llegó el final"},
+		{`title:(x)`, 7, `se esperaba un valor para title y llegó "("`},
+		{`title:-x`, 7, `llegó "-"`},
+		{`title>x`, 6, `el campo title no admite ">"`},
+		{`year:dos`, 6, `year debe ser un número entero, no "dos"`},
+		{`a year>="2015x"`, 9, `no el texto "2015x"`},
+		{`title:"abierto`, 7, "faltan las comillas de cierre"},
+		{`año foo:x`, 5, `campo desconocido "foo"`},
+	}
+	for _, tt := range tests {
+		expr, err := Parse(tt.input)
+		var syntaxErr *SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			t.Errorf("Parse(%q) = %v, %v; se esperaba un *SyntaxError", tt.input, expr, err)
+			continue
+		}
+		if syntaxErr.Pos != tt.pos || !strings.Contains(syntaxErr.Msg, tt.msg) {
+			t.Errorf("Parse(%q) = %q; se esperaba columna %d con %q", tt.input, err, tt.pos, tt.msg)
+		}
+	}
+}
+
+func TestParseMaxDepth(t *testing.T) {
+	nest := func(n int, open, close string) string {
+		return strings.Repeat(open, n) + "a" + strings.Repeat(close, n)
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		ok    bool
+	}{
+		{"paréntesis en el límite", nest(maxDepth, "(", ")"), true},
+		{"paréntesis sobre el límite", nest(maxDepth+1, "(", ")"), false},
+		{"NOT en el límite", nest(maxDepth, "-", ""), true},
+		{"NOT sobre el límite", nest(maxDepth+1, "NOT ", ""), false},
+		{"mezcla sobre el límite", nest(maxDepth/2+1, "-(", ")"), false},
+		// El límite es de anidamiento, no de largo: hermanos no suman.
+		{"muchos hermanos", strings.Repeat(nest(maxDepth, "(", ")")+" ", 10), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.input)
+			if tt.ok {
+				if err != nil {
+					t.Fatalf("no debería fallar: %v", err)
+				}
+				return
+			}
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) || !strings.Contains(syntaxErr.Msg, "demasiados niveles") {
+				t.Fatalf("se esperaba el error de anidamiento, vino %v", err)
+			}
+		})
+	}
+}
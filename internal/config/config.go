@@ -0,0 +1,91 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+/*
+   ==========================================================
+   CONFIGURACIÓN DE LA API
+   ==========================================================
+
+   La configuración se lee de FLAGS de línea de comandos.
+   Cada flag tiene como valor por defecto una variable de
+   entorno (si existe) o un valor fijo:
+
+	-addr   (LIBROS_ADDR)      dirección del servidor  (":8081")
+	-store  (LIBROS_STORE)     tipo de almacenamiento  ("memory")
+	-data   (LIBROS_DATA_DIR)  carpeta de datos        ("./data")
+	-compact-every (LIBROS_COMPACT_EVERY)  registros del WAL antes de compactar (1000)
+	-db-driver (LIBROS_DB_DRIVER)  driver de database/sql para -store=sql ("sqlite")
+	-db-dsn    (LIBROS_DB_DSN)     cadena de conexión para -store=sql ("libros.db")
+
+   Ejemplo:
+
+	go run ./cmd/api -store=file -data=/var/lib/libros
+	go run ./cmd/api -store=wal  -data=/var/lib/libros
+	go run -tags sqlite ./cmd/api -store=sql -db-dsn=libros.db
+*/
+
+// Tipos de almacenamiento soportados.
+const (
+	StoreMemory = "memory" // todo en RAM, se pierde al reiniciar
+	StoreFile   = "file"   // snapshots JSON en la carpeta de datos
+	StoreWAL    = "wal"    // WAL de solo-agregar + snapshots periódicos
+	StoreSQL    = "sql"    // base de datos relacional vía database/sql
+)
+
+// Config agrupa la configuración del servidor.
+type Config struct {
+	Addr         string
+	Store        string
+	DataDir      string
+	CompactEvery int
+	DBDriver     string
+	DBDSN        string
+}
+
+// Load lee la configuración desde los argumentos (sin el nombre del programa).
+func Load(args []string) (Config, error) {
+	var cfg Config
+
+	fs := flag.NewFlagSet("api", flag.ContinueOnError)
+	fs.StringVar(&cfg.Addr, "addr", envOr("LIBROS_ADDR", ":8081"), "dirección HTTP del servidor")
+	fs.StringVar(&cfg.Store, "store", envOr("LIBROS_STORE", StoreMemory), "almacenamiento: memory | file | wal | sql")
+	fs.StringVar(&cfg.DataDir, "data", envOr("LIBROS_DATA_DIR", "./data"), "carpeta de datos para -store=file|wal")
+
+	compactDefault, err := strconv.Atoi(envOr("LIBROS_COMPACT_EVERY", "1000"))
+	if err != nil {
+		return Config{}, fmt.Errorf("LIBROS_COMPACT_EVERY debe ser un número: %w", err)
+	}
+	fs.IntVar(&cfg.CompactEvery, "compact-every", compactDefault, "registros del WAL antes de compactar en un snapshot")
+
+	fs.StringVar(&cfg.DBDriver, "db-driver", envOr("LIBROS_DB_DRIVER", "sqlite"), "driver de database/sql para -store=sql")
+	fs.StringVar(&cfg.DBDSN, "db-dsn", envOr("LIBROS_DB_DSN", "libros.db"), "cadena de conexión para -store=sql")
+
+	if err := fs.Parse(args); err != nil {
+		return Config{}, err
+	}
+
+	switch cfg.Store {
+	case StoreMemory, StoreFile, StoreWAL, StoreSQL:
+	default:
+		return Config{}, fmt.Errorf("almacenamiento no soportado: %q", cfg.Store)
+	}
+	if cfg.CompactEvery <= 0 {
+		return Config{}, fmt.Errorf("-compact-every debe ser mayor que cero")
+	}
+
+	return cfg, nil
+}
+
+// envOr devuelve la variable de entorno key o def si no está definida.
+func envOr(key, def string) string {
+	if v, ok := os.LookupEnv(key); ok && v != "" {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,118 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+/*
+   ==========================================================
+   ERRORES DEL DOMINIO
+   ==========================================================
+
+   En lugar de devolver solo textos, clasificamos los errores
+   en CATEGORÍAS (errores centinela). Las capas superiores
+   preguntan con errors.Is / errors.As de qué tipo es el error
+   sin depender del mensaje en español.
+
+   - ErrNotFound:   el recurso no existe.
+   - ErrConflict:   choca con el estado actual (ej. email repetido).
+   - ErrValidation: los datos de entrada no son válidos.
+   - ErrForbidden:  la operación no está permitida (ej. libro archivado).
+
+   ErrVersionConflict es un caso particular de ErrConflict: el
+   registro cambió desde que se leyó (actualización perdida).
+*/
+
+var (
+	ErrNotFound   = errors.New("recurso no encontrado")
+	ErrConflict   = errors.New("conflicto con el estado actual")
+	ErrValidation = errors.New("datos no válidos")
+	ErrForbidden  = errors.New("operación no permitida")
+
+	// errors.Is(ErrVersionConflict, ErrConflict) es true.
+	ErrVersionConflict = fmt.Errorf("versión desactualizada: %w", ErrConflict)
+)
+
+// Error es un error del dominio con un mensaje legible y una categoría.
+// errors.Is(err, ErrNotFound) funciona gracias a Unwrap.
+type Error struct {
+	Kind    error
+	Message string
+}
+
+func (e *Error) Error() string { return e.Message }
+func (e *Error) Unwrap() error { return e.Kind }
+
+// NotFound crea un error de la categoría ErrNotFound.
+func NotFound(msg string) error {
+	return &Error{Kind: ErrNotFound, Message: msg}
+}
+
+// Conflict crea un error de la categoría ErrConflict.
+func Conflict(msg string) error {
+	return &Error{Kind: ErrConflict, Message: msg}
+}
+
+// VersionConflict crea un error de la categoría ErrVersionConflict.
+func VersionConflict(msg string) error {
+	return &Error{Kind: ErrVersionConflict, Message: msg}
+}
+
+// Forbidden crea un error de la categoría ErrForbidden.
+func Forbidden(msg string) error {
+	return &Error{Kind: ErrForbidden, Message: msg}
+}
+
+/*
+   ==========================================================
+   ERRORES DE VALIDACIÓN POR CAMPO
+   ==========================================================
+*/
+
+// FieldError describe un problema en un campo concreto.
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+// ValidationError agrupa TODOS los errores de validación encontrados,
+// para que el cliente pueda corregirlos de una sola vez.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// Error une los mensajes de cada campo.
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		msgs = append(msgs, f.Message)
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Is permite usar errors.Is(err, ErrValidation).
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// Add agrega un error para un campo.
+func (e *ValidationError) Add(field, msg string) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: msg})
+}
+
+// Err devuelve el error solo si se agregó algún campo (si no, nil).
+func (e *ValidationError) Err() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+// NewValidationError crea un error de validación de un solo campo.
+func NewValidationError(field, msg string) error {
+	v := &ValidationError{}
+	v.Add(field, msg)
+	return v
+}
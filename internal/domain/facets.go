@@ -0,0 +1,76 @@
+package domain
+
+import "sort"
+
+/*
+   ==========================================================
+   FACETAS DE BÚSQUEDA
+   ==========================================================
+
+   Junto a una página de resultados, la interfaz muestra
+   CUÁNTOS libros del filtro actual hay por categoría, por
+   década / año y por tag, para que el lector pueda afinar la
+   búsqueda ("Redes (12)", "2010-2019 (40)", "go (7)").
+
+   Las facetas cuentan TODOS los libros que cumplen el filtro,
+   no solo los de la página (se ignoran orden, cursor y límite).
+   Las categorías y los tags se agrupan sin distinguir acentos
+   ni mayúsculas; se muestra la primera escritura encontrada.
+*/
+
+// MaxFacetValues es la cantidad máxima de categorías y de tags por faceta.
+const MaxFacetValues = 20
+
+// FacetCount es un valor de una faceta con su cantidad de libros.
+type FacetCount struct {
+	Value string
+	Count int
+}
+
+// YearFacetCount es un rango de años con su cantidad de libros.
+// Para una década From=2010 y To=2019; para un año From == To.
+type YearFacetCount struct {
+	From  int
+	To    int
+	Count int
+}
+
+// BookFacets son las facetas de un BookFilter.
+type BookFacets struct {
+	Categories []FacetCount
+	Decades    []YearFacetCount
+	Years      []YearFacetCount
+	Tags       []FacetCount
+}
+
+// SortFacetCounts ordena por cantidad descendente (el valor desempata)
+// y deja como máximo MaxFacetValues.
+func SortFacetCounts(counts []FacetCount) []FacetCount {
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Value < counts[j].Value
+	})
+	if len(counts) > MaxFacetValues {
+		counts = counts[:MaxFacetValues]
+	}
+	return counts
+}
+
+// YearFacets arma las facetas por año y por década a partir de un
+// MAP año → cantidad, ambas ordenadas por año ascendente.
+func YearFacets(byYear map[int]int) (decades, years []YearFacetCount) {
+	byDecade := make(map[int]int)
+	for year, count := range byYear {
+		years = append(years, YearFacetCount{From: year, To: year, Count: count})
+		byDecade[year-year%10] += count
+	}
+	for decade, count := range byDecade {
+		decades = append(decades, YearFacetCount{From: decade, To: decade + 9, Count: count})
+	}
+
+	sort.Slice(years, func(i, j int) bool { return years[i].From < years[j].From })
+	sort.Slice(decades, func(i, j int) bool { return decades[i].From < decades[j].From })
+	return decades, years
+}
@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+   ==========================================================
+   EMBUDO DE CONVERSIÓN
+   ==========================================================
+
+   Los tres tipos de acceso forman un EMBUDO natural:
+
+	APERTURA → LECTURA → DESCARGA
+
+   La ventana indica cuánto tiempo tiene un usuario, desde que
+   abre el libro, para llegar a los pasos siguientes.
+*/
+
+// FunnelSteps devuelve los pasos del embudo, en orden.
+func FunnelSteps() [3]AccessType {
+	return [3]AccessType{AccessTypeApertura, AccessTypeLectura, AccessTypeDescarga}
+}
+
+// Ventana del embudo: por defecto una semana, como máximo un año.
+const (
+	DefaultFunnelWindow = 7 * 24 * time.Hour
+	MaxFunnelWindow     = 365 * 24 * time.Hour
+)
+
+/*
+ParseFunnelWindow lee el parámetro "window" del embudo.
+
+	"30m", "2h", "1h30m" → duraciones de Go
+	"7d"                 → días
+
+Vacío = DefaultFunnelWindow.
+*/
+func ParseFunnelWindow(s string) (time.Duration, error) {
+	if s == "" {
+		return DefaultFunnelWindow, nil
+	}
+
+	var (
+		d   time.Duration
+		err error
+	)
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		var n int
+		if n, err = strconv.Atoi(days); err == nil && n > 0 && n <= 365 {
+			d = time.Duration(n) * 24 * time.Hour
+		}
+	} else {
+		d, err = time.ParseDuration(s)
+	}
+	if err != nil || d <= 0 || d > MaxFunnelWindow {
+		return 0, NewValidationError("window", "window debe ser una duración positiva de hasta 365d (30m, 24h, 7d...)")
+	}
+	return d, nil
+}
@@ -1,9 +1,12 @@
 package domain
 
 import (
-	"errors"
 	"strings"
 	"time"
+
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/bookquery"
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/hll"
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/textnorm"
 )
 
 /*
@@ -45,6 +48,9 @@ const (
 	AccessTypeDescarga AccessType = "DESCARGA"
 )
 
+// allowedAccessTypes es un ARRAY con los tipos de acceso permitidos.
+var allowedAccessTypes = [3]AccessType{AccessTypeApertura, AccessTypeLectura, AccessTypeDescarga}
+
 /*
    ==========================================================
    ENTIDAD: USER (USUARIO)
@@ -61,19 +67,14 @@ type User struct {
 	role      Role
 	active    bool
 	createdAt time.Time
+	version   int64 // se incrementa en cada Update (control de concurrencia optimista)
 }
 
 // NewUser es un CONSTRUCTOR de usuarios.
 // Recibe los datos, valida y devuelve (*User, error).
 func NewUser(name, email string, role Role) (*User, error) {
-	if strings.TrimSpace(name) == "" {
-		return nil, errors.New("el nombre no puede estar vacío")
-	}
-	if strings.TrimSpace(email) == "" {
-		return nil, errors.New("el email no puede estar vacío")
-	}
-	if !isValidRole(role) {
-		return nil, errors.New("rol no válido")
+	if err := validateUser(name, email, role); err != nil {
+		return nil, err
 	}
 
 	return &User{
@@ -86,6 +87,22 @@ func NewUser(name, email string, role Role) (*User, error) {
 	}, nil
 }
 
+// validateUser revisa todos los campos del usuario y devuelve
+// un *ValidationError con cada campo que tenga problemas.
+func validateUser(name, email string, role Role) error {
+	v := &ValidationError{}
+	if strings.TrimSpace(name) == "" {
+		v.Add("name", "el nombre no puede estar vacío")
+	}
+	if strings.TrimSpace(email) == "" {
+		v.Add("email", "el email no puede estar vacío")
+	}
+	if !isValidRole(role) {
+		v.Add("role", "rol no válido")
+	}
+	return v.Err()
+}
+
 // isValidRole revisa si el rol está dentro del ARRAY allowedRoles.
 func isValidRole(r Role) bool {
 	for _, allowed := range allowedRoles {
@@ -104,16 +121,45 @@ func (u *User) Email() string        { return u.email }
 func (u *User) Role() Role           { return u.role }
 func (u *User) Active() bool         { return u.active }
 func (u *User) CreatedAt() time.Time { return u.createdAt }
+func (u *User) Version() int64       { return u.version }
 
 // SetID permite asignar el ID desde el repositorio.
 func (u *User) SetID(id UserID) {
 	u.id = id
 }
 
+// SetVersion permite al repositorio asignar la versión guardada.
+func (u *User) SetVersion(v int64) {
+	u.version = v
+}
+
+// Clone devuelve una copia independiente del usuario.
+// Los repositorios guardan y entregan copias, así nadie modifica
+// lo guardado sin pasar por Update.
+func (u *User) Clone() *User {
+	c := *u
+	return &c
+}
+
+// RestoreUser reconstruye un usuario ya guardado (por ejemplo, leído
+// desde disco). No valida: los datos ya fueron validados al crearlo.
+// Solo debe usarse desde los repositorios.
+func RestoreUser(id UserID, name, email string, role Role, active bool, createdAt time.Time, version int64) *User {
+	return &User{
+		id:        id,
+		name:      name,
+		email:     email,
+		role:      role,
+		active:    active,
+		createdAt: createdAt,
+		version:   version,
+	}
+}
+
 // ChangeRole cambia el rol del usuario, validando que el nuevo rol sea permitido.
 func (u *User) ChangeRole(newRole Role) error {
 	if !isValidRole(newRole) {
-		return errors.New("nuevo rol no válido")
+		return NewValidationError("role", "nuevo rol no válido")
 	}
 	u.role = newRole
 	return nil
@@ -124,6 +170,25 @@ func (u *User) Deactivate() {
 	u.active = false
 }
 
+// Activate vuelve a marcar al usuario como activo.
+func (u *User) Activate() {
+	u.active = true
+}
+
+// ApplyChanges modifica nombre, email y rol del usuario.
+// Primero valida TODOS los datos y solo después los asigna,
+// para no dejar el usuario a medio actualizar si algo falla.
+func (u *User) ApplyChanges(name, email string, role Role) error {
+	if err := validateUser(name, email, role); err != nil {
+		return err
+	}
+
+	u.name = name
+	u.email = email
+	u.role = role
+	return nil
+}
+
 /*
    ==========================================================
    ENTIDAD: BOOK (LIBRO)
@@ -141,25 +206,14 @@ type Book struct {
 	tags       []string
 	active     bool
 	createdAt  time.Time
+	version    int64 // se incrementa en cada Update (control de concurrencia optimista)
 }
 
 // NewBook es el CONSTRUCTOR de libros.
 // Valida los datos de entrada y devuelve (*Book, error).
 func NewBook(title, author string, year int, isbn, categoryTI string, tags []string) (*Book, error) {
-	if strings.TrimSpace(title) == "" {
-		return nil, errors.New("el título no puede estar vacío")
-	}
-	if strings.TrimSpace(author) == "" {
-		return nil, errors.New("el autor no puede estar vacío")
-	}
-	if year <= 0 {
-		return nil, errors.New("el año debe ser mayor que cero")
-	}
-	if strings.TrimSpace(isbn) == "" {
-		return nil, errors.New("el ISBN no puede estar vacío")
-	}
-	if strings.TrimSpace(categoryTI) == "" {
-		return nil, errors.New("la categoría TI no puede estar vacía")
+	if err := validateBook(title, author, year, isbn, categoryTI); err != nil {
+		return nil, err
 	}
 
 	return &Book{
@@ -175,6 +229,28 @@ func NewBook(title, author string, year int, isbn, categoryTI string, tags []str
 	}, nil
 }
 
+// validateBook revisa todos los campos del libro y devuelve
+// un *ValidationError con cada campo que tenga problemas.
+func validateBook(title, author string, year int, isbn, categoryTI string) error {
+	v := &ValidationError{}
+	if strings.TrimSpace(title) == "" {
+		v.Add("title", "el título no puede estar vacío")
+	}
+	if strings.TrimSpace(author) == "" {
+		v.Add("author", "el autor no puede estar vacío")
+	}
+	if year <= 0 {
+		v.Add("year", "el año debe ser mayor que cero")
+	}
+	if strings.TrimSpace(isbn) == "" {
+		v.Add("isbn", "el ISBN no puede estar vacío")
+	}
+	if strings.TrimSpace(categoryTI) == "" {
+		v.Add("category_ti", "la categoría TI no puede estar vacía")
+	}
+	return v.Err()
+}
+
 // Getters del libro.
 
 func (b *Book) ID() BookID           { return b.id }
@@ -186,17 +262,72 @@ func (b *Book) CategoryTI() string   { return b.categoryTI }
 func (b *Book) Tags() []string       { return b.tags }
 func (b *Book) Active() bool         { return b.active }
 func (b *Book) CreatedAt() time.Time { return b.createdAt }
+func (b *Book) Version() int64       { return b.version }
 
 // SetID asigna el ID del libro desde el repositorio.
 func (b *Book) SetID(id BookID) {
 	b.id = id
 }
 
+// SetVersion permite al repositorio asignar la versión guardada.
+func (b *Book) SetVersion(v int64) {
+	b.version = v
+}
+
+// Clone devuelve una copia independiente del libro (incluida la slice de tags).
+func (b *Book) Clone() *Book {
+	c := *b
+	c.tags = append([]string(nil), b.tags...)
+	return &c
+}
+
+// RestoreBook reconstruye un libro ya guardado, sin validar.
+// Solo debe usarse desde los repositorios.
+func RestoreBook(
+	id BookID,
+	title, author string,
+	year int,
+	isbn, categoryTI string,
+	tags []string,
+	active bool,
+	createdAt time.Time,
+	version int64,
+) *Book {
+	return &Book{
+		id:         id,
+		title:      title,
+		author:     author,
+		year:       year,
+		isbn:       isbn,
+		categoryTI: categoryTI,
+		tags:       tags,
+		active:     active,
+		createdAt:  createdAt,
+		version:    version,
+	}
+}
+
 // Archive marca el libro como no activo (archivado).
 func (b *Book) Archive() {
 	b.active = false
 }
 
+// UpdateDetails reemplaza los datos editables del libro.
+// Aplica las mismas validaciones que NewBook antes de modificar nada.
+func (b *Book) UpdateDetails(title, author string, year int, isbn, categoryTI string, tags []string) error {
+	if err := validateBook(title, author, year, isbn, categoryTI); err != nil {
+		return err
+	}
+
+	b.title = title
+	b.author = author
+	b.year = year
+	b.isbn = isbn
+	b.categoryTI = categoryTI
+	b.tags = tags
+	return nil
+}
+
 /*
    ==========================================================
    ENTIDAD: ACCESSEVENT (REGISTRO DE ACCESO)
@@ -214,14 +345,20 @@ type AccessEvent struct {
 
 // NewAccessEvent crea un nuevo evento de acceso validando datos.
 func NewAccessEvent(bookID BookID, userID UserID, accessType AccessType) (*AccessEvent, error) {
+	v := &ValidationError{}
 	if bookID <= 0 {
-		return nil, errors.New("bookID debe ser mayor que cero")
+		v.Add("book_id", "bookID debe ser mayor que cero")
 	}
 	if userID <= 0 {
-		return nil, errors.New("userID debe ser mayor que cero")
+		v.Add("user_id", "userID debe ser mayor que cero")
 	}
 	if accessType == "" {
-		return nil, errors.New("el tipo de acceso no puede estar vacío")
+		v.Add("access_type", "el tipo de acceso no puede estar vacío")
+	} else if !isValidAccessType(accessType) {
+		v.Add("access_type", "tipo de acceso no válido")
+	}
+	if err := v.Err(); err != nil {
+		return nil, err
 	}
 
 	return &AccessEvent{
@@ -233,6 +370,16 @@ func NewAccessEvent(bookID BookID, userID UserID, accessType AccessType) (*Acces
 	}, nil
 }
 
+// isValidAccessType revisa si el tipo está dentro del ARRAY allowedAccessTypes.
+func isValidAccessType(t AccessType) bool {
+	for _, allowed := range allowedAccessTypes {
+		if allowed == t {
+			return true
+		}
+	}
+	return false
+}
+
 // Getters del evento de acceso.
 
 func (e *AccessEvent) ID() AccessEventID      { return e.id }
@@ -246,6 +393,24 @@ func (e *AccessEvent) SetID(id AccessEventID) {
 	e.id = id
 }
 
+// RestoreAccessEvent reconstruye un evento ya guardado, sin validar.
+// Solo debe usarse desde los repositorios.
+func RestoreAccessEvent(
+	id AccessEventID,
+	bookID BookID,
+	userID UserID,
+	accessType AccessType,
+	timestamp time.Time,
+) *AccessEvent {
+	return &AccessEvent{
+		id:         id,
+		bookID:     bookID,
+		userID:     userID,
+		accessType: accessType,
+		timestamp:  timestamp,
+	}
+}
+
 /*
    ==========================================================
    FILTRO DE BÚSQUEDA DE LIBROS
@@ -257,9 +422,78 @@ type BookFilter struct {
 	TitleContains  string
 	AuthorContains string
 	CategoryTI     string
+	ISBN           string // ISBN exacto (se ignoran guiones y espacios)
 	YearFrom       int
 	YearTo         int
 	Tags           []string
+	TagMode        TagMatchMode // cómo se combinan los Tags (por defecto "all")
+
+	// Fuzzy hace que título y autor toleren errores de tipeo
+	// ("Tanenbaun" encuentra "Tanenbaum").
+	Fuzzy bool
+
+	// Query es una consulta del lenguaje de internal/bookquery que se
+	// combina con AND con el resto de los campos (nil = sin consulta).
+	Query bookquery.Expr
+
+	// Orden y página (ver pagination.go).
+	SortBy   BookSortField
+	SortDesc bool
+	Limit    int     // 0 = sin límite
+	After    *Cursor // nil = desde el principio
+}
+
+// TagMatchMode indica cómo se aplican los tags del filtro.
+type TagMatchMode string
+
+const (
+	TagMatchAll  TagMatchMode = "all"  // el libro tiene TODOS los tags
+	TagMatchAny  TagMatchMode = "any"  // el libro tiene AL MENOS UNO
+	TagMatchNone TagMatchMode = "none" // el libro no tiene NINGUNO
+)
+
+// allowedTagMatchModes es un ARRAY con los modos permitidos.
+var allowedTagMatchModes = [3]TagMatchMode{TagMatchAll, TagMatchAny, TagMatchNone}
+
+// ParseTagMatchMode valida un modo recibido como texto.
+// Un texto vacío equivale a TagMatchAll.
+func ParseTagMatchMode(s string) (TagMatchMode, error) {
+	if s == "" {
+		return TagMatchAll, nil
+	}
+	mode := TagMatchMode(strings.ToLower(s))
+	for _, allowed := range allowedTagMatchModes {
+		if allowed == mode {
+			return mode, nil
+		}
+	}
+	return "", NewValidationError("tag_mode", "tag_mode debe ser all, any o none")
+}
+
+// NormalizeTag pasa un tag a su forma de comparación:
+// sin espacios en los extremos, en minúsculas y sin acentos.
+func NormalizeTag(tag string) string {
+	return textnorm.FoldKey(tag)
+}
+
+// MaxFilterTags limita los tags distintos de un filtro: en SQL cada
+// uno es una variable de la consulta.
+const MaxFilterTags = 50
+
+// NormalizedTags devuelve los tags del filtro normalizados,
+// sin vacíos ni repetidos.
+func (f BookFilter) NormalizedTags() []string {
+	seen := make(map[string]bool, len(f.Tags))
+	result := make([]string, 0, len(f.Tags))
+	for _, tag := range f.Tags {
+		t := NormalizeTag(tag)
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		result = append(result, t)
+	}
+	return result
 }
 
 /*
@@ -275,6 +509,7 @@ type UserRepository interface {
 	FindByID(id UserID) (*User, error)
 	FindByEmail(email string) (*User, error)
 	ListAll() ([]*User, error)
+	Search(query UserQuery) ([]*User, error)
 }
 
 // BookRepository define las operaciones de persistencia de libros.
@@ -284,11 +519,87 @@ type BookRepository interface {
 	FindByID(id BookID) (*Book, error)
 	SearchByFilters(filter BookFilter) ([]*Book, error)
 	ListAll() ([]*Book, error)
+
+	// SuggestSpelling corrige el título y el autor del filtro con
+	// las palabras más parecidas del catálogo ("¿Quisiste decir?").
+	SuggestSpelling(filter BookFilter) (SpellingSuggestion, error)
+
+	// Facets cuenta los libros del filtro por categoría, año y tag
+	// (ver facets.go). Se ignoran orden, cursor y límite.
+	Facets(filter BookFilter) (BookFacets, error)
+
+	// SuggestPrefix devuelve hasta limit completaciones de títulos,
+	// autores y tags para un prefijo, elegidas entre TODAS las que
+	// tiene: mayor rank primero (nil = todas valen 0), luego las de
+	// más libros y luego las más cortas.
+	SuggestPrefix(prefix string, limit int, rank SuggestionRank) ([]Suggestion, error)
 }
 
 // AccessLogRepository define cómo se guardan los eventos de acceso.
 type AccessLogRepository interface {
 	Store(event *AccessEvent) error
+
+	// ListByBook y ListByUser devuelven los eventos de un libro o de
+	// un usuario en orden cronológico (el ID desempata).
 	ListByBook(bookID BookID) ([]*AccessEvent, error)
 	ListByUser(userID UserID) ([]*AccessEvent, error)
+
+	// CountByBook devuelve cuántos accesos tiene cada libro.
+	CountByBook() (map[BookID]int, error)
+
+	// ListAll devuelve todos los eventos en orden de ID.
+	ListAll() ([]*AccessEvent, error)
+
+	// ListBetween devuelve los eventos con from <= timestamp < to,
+	// en orden cronológico.
+	ListBetween(from, to time.Time) ([]*AccessEvent, error)
+
+	// ReaderSketches devuelve, por día UTC ("2006-01-02") que toca
+	// [from, to), el sketch HyperLogLog de los usuarios que accedieron a
+	// alguno de los libros indicados (vacío = todos). Un from o to cero
+	// no limita. La granularidad es el día: un to a media tarde incluye
+	// ese día entero.
+	ReaderSketches(bookIDs []BookID, from, to time.Time) (map[string]*hll.Sketch, error)
+}
+
+/*
+   ==========================================================
+   UNIDAD DE TRABAJO (UNIT OF WORK)
+   ==========================================================
+
+   Algunos casos de uso hacen VARIAS operaciones que deben verse
+   como una sola (por ejemplo: leer el libro, leer el usuario y
+   guardar el evento). La unidad de trabajo ejecuta una función
+   con repositorios "transaccionales": mientras corre ninguna
+   otra unidad de trabajo puede intercalar cambios.
+
+   REGLA: fn valida todo primero y hace UNA SOLA escritura
+   (Create, Update o Store), como ÚLTIMA operación. Solo el
+   backend SQL confirma varias escrituras juntas (con una
+   transacción); el de memoria no tiene rollback: guarda la
+   única escritura y la aplica cuando fn termina sin error, y
+   rechaza una segunda. Los casos de uso siguen la regla para
+   poder correr sobre cualquiera de los dos.
+
+   Lo que depende del cambio confirmado (índices en memoria,
+   observadores) se agenda con AfterCommit: corre solo si la
+   unidad se confirmó, después de soltar la unidad, y las de
+   unidades distintas corren en el MISMO orden en que se
+   confirmaron.
+*/
+
+// Repositories agrupa los repositorios que se usan dentro de una unidad de trabajo.
+type Repositories struct {
+	Users  UserRepository
+	Books  BookRepository
+	Access AccessLogRepository
+
+	// AfterCommit agenda fn para después de confirmar la unidad de
+	// trabajo. Solo está disponible dentro de UnitOfWork.Do.
+	AfterCommit func(fn func())
+}
+
+// UnitOfWork ejecuta fn de forma atómica con los repositorios de la transacción.
+type UnitOfWork interface {
+	Do(fn func(repos Repositories) error) error
 }
@@ -0,0 +1,34 @@
+package domain
+
+/*
+   ==========================================================
+   OBSERVADORES
+   ==========================================================
+
+   Algunos cálculos (popularidad, tendencias, recomendaciones,
+   analítica) se mantienen en memoria y se ACTUALIZAN con cada
+   cambio, en lugar de recorrer los repositorios en cada
+   consulta.
+
+   Los servicios avisan a sus observadores DESPUÉS de guardar
+   el cambio con éxito, con Repositories.AfterCommit: así los
+   avisos llegan en el orden en que se confirmaron los cambios.
+   - BookService: cada acceso (AccessObserver) y cada libro
+     creado, editado o archivado (BookObserver).
+   - UserService: cada usuario registrado (UserObserver).
+*/
+
+// AccessObserver recibe cada acceso ya guardado.
+type AccessObserver interface {
+	ObserveAccess(event *AccessEvent)
+}
+
+// BookObserver recibe cada libro creado, editado o archivado, ya guardado.
+type BookObserver interface {
+	ObserveBook(book *Book)
+}
+
+// UserObserver recibe cada usuario registrado, ya guardado.
+type UserObserver interface {
+	ObserveUser(user *User)
+}
@@ -0,0 +1,230 @@
+package domain
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+/*
+   ==========================================================
+   ORDEN Y PAGINACIÓN POR CURSOR
+   ==========================================================
+
+   Los listados de libros y usuarios se devuelven en un orden
+   DETERMINISTA (campo elegido + ID para desempatar) y por
+   páginas de tamaño Limit.
+
+   En lugar de "página 3" usamos un CURSOR: un texto opaco que
+   recuerda la clave de orden y el ID del último elemento
+   entregado. La siguiente página empieza justo después de él,
+   así no se repiten ni se saltan elementos aunque se creen
+   libros nuevos entre una página y otra.
+
+   Las claves de orden son textos que se comparan byte a byte:
+   - textos (título, autor, nombre, email): minúsculas ASCII
+     (igual que LOWER() de SQLite, para que memoria y SQL
+     ordenen igual);
+   - años e IDs: números con ceros a la izquierda;
+   - fechas: formato de largo fijo en UTC.
+*/
+
+// Límites de página para los listados.
+const (
+	DefaultPageLimit = 50
+	MaxPageLimit     = 200
+)
+
+// BookSortField es el campo por el que se ordenan los libros.
+type BookSortField string
+
+const (
+	BookSortID        BookSortField = "id"
+	BookSortTitle     BookSortField = "title"
+	BookSortAuthor    BookSortField = "author"
+	BookSortYear      BookSortField = "year"
+	BookSortCreatedAt BookSortField = "created_at"
+)
+
+// allowedBookSortFields es un ARRAY con los campos de orden de libros.
+var allowedBookSortFields = [5]BookSortField{BookSortID, BookSortTitle, BookSortAuthor, BookSortYear, BookSortCreatedAt}
+
+// UserSortField es el campo por el que se ordenan los usuarios.
+type UserSortField string
+
+const (
+	UserSortID        UserSortField = "id"
+	UserSortName      UserSortField = "name"
+	UserSortEmail     UserSortField = "email"
+	UserSortCreatedAt UserSortField = "created_at"
+)
+
+// allowedUserSortFields es un ARRAY con los campos de orden de usuarios.
+var allowedUserSortFields = [4]UserSortField{UserSortID, UserSortName, UserSortEmail, UserSortCreatedAt}
+
+// UserQuery indica cómo listar usuarios (orden y página).
+type UserQuery struct {
+	SortBy   UserSortField
+	SortDesc bool
+	Limit    int     // 0 = sin límite
+	After    *Cursor // nil = desde el principio
+}
+
+// HistorySortTimestamp es el único orden del historial de un usuario:
+// por fecha del acceso ("timestamp" o "-timestamp").
+const HistorySortTimestamp = "timestamp"
+
+// HistoryQuery indica cómo listar el historial de accesos de un usuario.
+type HistoryQuery struct {
+	SortDesc bool    // false = cronológico (más viejo primero)
+	Limit    int     // 0 = tamaño por defecto
+	After    *Cursor // nil = desde el principio
+}
+
+/*
+ParseBookSort lee el parámetro "sort" de un listado de libros.
+
+	"title"  → por título ascendente
+	"-year"  → por año descendente
+
+Un texto vacío ordena por ID ascendente.
+*/
+func ParseBookSort(s string) (BookSortField, bool, error) {
+	name, desc := strings.CutPrefix(s, "-")
+	if name == "" {
+		return BookSortID, desc, nil
+	}
+	for _, allowed := range allowedBookSortFields {
+		if string(allowed) == name {
+			return allowed, desc, nil
+		}
+	}
+	return "", false, NewValidationError("sort", "sort debe ser id, title, author, year o created_at (con - para descendente)")
+}
+
+// ParseUserSort lee el parámetro "sort" de un listado de usuarios.
+func ParseUserSort(s string) (UserSortField, bool, error) {
+	name, desc := strings.CutPrefix(s, "-")
+	if name == "" {
+		return UserSortID, desc, nil
+	}
+	for _, allowed := range allowedUserSortFields {
+		if string(allowed) == name {
+			return allowed, desc, nil
+		}
+	}
+	return "", false, NewValidationError("sort", "sort debe ser id, name, email o created_at (con - para descendente)")
+}
+
+// ParseHistorySort lee el parámetro "sort" del historial de un usuario.
+// Devuelve true si el orden es descendente (más nuevo primero).
+func ParseHistorySort(s string) (bool, error) {
+	name, desc := strings.CutPrefix(s, "-")
+	if name == "" || name == HistorySortTimestamp {
+		return desc, nil
+	}
+	return false, NewValidationError("sort", "sort debe ser timestamp (con - para descendente)")
+}
+
+// sortTimeLayout es RFC 3339 con nanosegundos SIEMPRE presentes (largo fijo).
+const sortTimeLayout = "2006-01-02T15:04:05.000000000Z07:00"
+
+// SortFold pasa a minúsculas solo las letras ASCII (A-Z), igual que
+// la función LOWER() de SQLite.
+func SortFold(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'A' && r <= 'Z' {
+			return r + ('a' - 'A')
+		}
+		return r
+	}, s)
+}
+
+// BookSortKey devuelve la clave de orden de un libro para un campo.
+func BookSortKey(b *Book, field BookSortField) string {
+	switch field {
+	case BookSortTitle:
+		return SortFold(b.Title())
+	case BookSortAuthor:
+		return SortFold(b.Author())
+	case BookSortYear:
+		return fmt.Sprintf("%010d", b.Year())
+	case BookSortCreatedAt:
+		return b.CreatedAt().UTC().Format(sortTimeLayout)
+	default:
+		return fmt.Sprintf("%020d", b.ID())
+	}
+}
+
+// UserSortKey devuelve la clave de orden de un usuario para un campo.
+func UserSortKey(u *User, field UserSortField) string {
+	switch field {
+	case UserSortName:
+		return SortFold(u.Name())
+	case UserSortEmail:
+		return SortFold(u.Email())
+	case UserSortCreatedAt:
+		return u.CreatedAt().UTC().Format(sortTimeLayout)
+	default:
+		return fmt.Sprintf("%020d", u.ID())
+	}
+}
+
+// AccessEventSortKey devuelve la clave de orden de un acceso (su fecha).
+func AccessEventSortKey(e *AccessEvent) string {
+	return e.Timestamp().UTC().Format(sortTimeLayout)
+}
+
+/*
+   ==========================================================
+   CURSOR
+   ==========================================================
+*/
+
+// Cursor marca el último elemento entregado en una página.
+// Sort guarda el orden con el que se generó ("title", "-year"...),
+// porque un cursor solo tiene sentido con ese mismo orden.
+type Cursor struct {
+	Sort string `json:"s"`
+	Key  string `json:"k"`
+	ID   int64  `json:"id"`
+}
+
+// SortSpec escribe un orden como texto: "title" o "-title".
+func SortSpec(field string, desc bool) string {
+	if desc {
+		return "-" + field
+	}
+	return field
+}
+
+// IsAfter indica si (key, id) va DESPUÉS del cursor en el orden dado.
+func (c Cursor) IsAfter(key string, id int64, desc bool) bool {
+	if key != c.Key {
+		return (key > c.Key) != desc
+	}
+	if id == c.ID {
+		return false // es el propio elemento del cursor
+	}
+	return (id > c.ID) != desc
+}
+
+// EncodeCursor convierte un cursor en un texto opaco apto para URLs.
+func EncodeCursor(c Cursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor lee un cursor generado por EncodeCursor.
+func DecodeCursor(s string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, NewValidationError("cursor", "cursor inválido")
+	}
+	var c Cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID <= 0 {
+		return nil, NewValidationError("cursor", "cursor inválido")
+	}
+	return &c, nil
+}
@@ -0,0 +1,48 @@
+package domain
+
+/*
+   ==========================================================
+   RECOMENDACIONES ("otros lectores también abrieron")
+   ==========================================================
+
+   El registro de accesos dice qué libros abrió, leyó o
+   descargó cada usuario. Dos libros son PARECIDOS si los mismos
+   usuarios los consultan (filtrado colaborativo libro a libro).
+
+   El dominio define QUÉ se necesita; el cálculo (similitud
+   coseno sobre los accesos) vive en
+   internal/infrastructure/recommend.
+
+   El Recommender es un AccessObserver (ver observers.go): se
+   actualiza de a un evento, sin recorrer el registro completo.
+*/
+
+// AccessWeight es cuánto dice un tipo de acceso sobre el interés
+// del lector: descargar pesa más que leer, y leer más que abrir.
+func AccessWeight(t AccessType) float64 {
+	switch t {
+	case AccessTypeDescarga:
+		return 3
+	case AccessTypeLectura:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// ScoredBook es un libro recomendado con su puntaje.
+type ScoredBook struct {
+	BookID BookID
+	Score  float64
+}
+
+// Recommender calcula recomendaciones a partir de los accesos.
+// Los resultados vienen de mayor a menor puntaje.
+type Recommender interface {
+	AccessObserver
+	// Related devuelve los libros más parecidos a uno.
+	Related(bookID BookID) []ScoredBook
+	// ForUser devuelve libros que el usuario no consultó,
+	// parecidos a los que sí consultó.
+	ForUser(userID UserID) []ScoredBook
+}
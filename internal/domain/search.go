@@ -0,0 +1,100 @@
+package domain
+
+/*
+   ==========================================================
+   BÚSQUEDA DE TEXTO COMPLETO
+   ==========================================================
+
+   BookSearchIndex es la interfaz de un índice de texto
+   completo sobre el catálogo. El dominio solo define QUÉ se
+   necesita; la implementación (índice invertido con BM25)
+   vive en internal/infrastructure/search.
+
+   El índice solo contiene libros ACTIVOS: indexar un libro
+   archivado equivale a quitarlo.
+*/
+
+// SearchHit es un resultado de la búsqueda de texto completo.
+type SearchHit struct {
+	BookID BookID
+	Score  float64
+	// Highlights tiene, por campo ("title", "author", "tags"),
+	// un fragmento del texto con las coincidencias marcadas.
+	Highlights map[string]string
+}
+
+// BookSearchIndex define las operaciones del índice de texto completo.
+type BookSearchIndex interface {
+	Index(book *Book)
+	Remove(id BookID)
+	Search(query string, limit int) []SearchHit
+	// Similar devuelve los libros más parecidos a uno por sus
+	// metadatos (título, tags, categoría, autor y año).
+	Similar(id BookID, limit int) []ScoredBook
+}
+
+/*
+   ==========================================================
+   "¿QUISISTE DECIR?"
+   ==========================================================
+
+   Cuando una búsqueda por título o autor no encuentra nada,
+   el repositorio propone los mismos textos corregidos con
+   palabras que SÍ existen en el catálogo.
+*/
+
+// SpellingSuggestion son los textos corregidos de un BookFilter.
+// Un campo vacío significa que no hay corrección para él.
+type SpellingSuggestion struct {
+	Title  string
+	Author string
+}
+
+// IsEmpty indica si no se propuso ninguna corrección.
+func (s SpellingSuggestion) IsEmpty() bool {
+	return s.Title == "" && s.Author == ""
+}
+
+// Apply devuelve el filtro con los textos corregidos.
+func (s SpellingSuggestion) Apply(filter BookFilter) BookFilter {
+	if s.Title != "" {
+		filter.TitleContains = s.Title
+	}
+	if s.Author != "" {
+		filter.AuthorContains = s.Author
+	}
+	return filter
+}
+
+/*
+   ==========================================================
+   AUTOCOMPLETADO
+   ==========================================================
+
+   El cuadro de búsqueda pide completaciones mientras el lector
+   escribe: títulos, autores y tags que empiezan con el texto
+   escrito (o con alguna de sus palabras: "tanen" completa
+   "Andrew Tanenbaum").
+*/
+
+// SuggestionKind indica de qué campo sale una completación.
+type SuggestionKind string
+
+const (
+	SuggestTitle  SuggestionKind = "title"
+	SuggestAuthor SuggestionKind = "author"
+	SuggestTag    SuggestionKind = "tag"
+)
+
+// Suggestion es una completación con los libros activos que la tienen
+// (BookIDs sin un orden en particular) y el puntaje con que se ordenó.
+type Suggestion struct {
+	Kind    SuggestionKind
+	Text    string
+	BookIDs []BookID
+	Score   int
+}
+
+// SuggestionRank puntúa una completación a partir de sus libros
+// (mayor puntaje = antes). El servicio pasa la popularidad.
+type SuggestionRank func(bookIDs []BookID) int
@@ -0,0 +1,101 @@
+package domain
+
+import "time"
+
+/*
+   ==========================================================
+   SERIES DE TIEMPO DE ACCESOS
+   ==========================================================
+
+   Las estadísticas por período agrupan los accesos en CUBETAS
+   de una hora, un día, una semana (de lunes a domingo) o un mes.
+   Los límites de cada cubeta se calculan en la ZONA HORARIA
+   pedida: el "día" de un bibliotecario en Quito empieza a las
+   00:00 de Quito, no a las 00:00 UTC.
+*/
+
+// StatsInterval es el tamaño de cada cubeta.
+type StatsInterval string
+
+const (
+	StatsIntervalHour  StatsInterval = "hour"
+	StatsIntervalDay   StatsInterval = "day"
+	StatsIntervalWeek  StatsInterval = "week"
+	StatsIntervalMonth StatsInterval = "month"
+)
+
+// MaxSeriesBuckets limita el tamaño de una serie (ej. 41 días por hora).
+const MaxSeriesBuckets = 1000
+
+// allowedStatsIntervals es un ARRAY con los intervalos permitidos.
+var allowedStatsIntervals = [4]StatsInterval{StatsIntervalHour, StatsIntervalDay, StatsIntervalWeek, StatsIntervalMonth}
+
+// ParseStatsInterval lee el parámetro "interval". Vacío = por día.
+func ParseStatsInterval(s string) (StatsInterval, error) {
+	if s == "" {
+		return StatsIntervalDay, nil
+	}
+	interval := StatsInterval(s)
+	for _, allowed := range allowedStatsIntervals {
+		if allowed == interval {
+			return interval, nil
+		}
+	}
+	return "", NewValidationError("interval", "interval debe ser hour, day, week o month")
+}
+
+// BucketStart devuelve el inicio de la cubeta que contiene t, en la
+// zona horaria loc.
+func (i StatsInterval) BucketStart(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	y, m, d := t.Date()
+	switch i {
+	case StatsIntervalHour:
+		// Restar minutos y segundos (y no usar time.Date) respeta la
+		// hora repetida cuando se atrasa el reloj.
+		return t.Add(-time.Duration(t.Minute())*time.Minute -
+			time.Duration(t.Second())*time.Second -
+			time.Duration(t.Nanosecond()))
+	case StatsIntervalWeek:
+		monday := (int(t.Weekday()) + 6) % 7 // días desde el lunes
+		return startOfDay(y, m, d-monday, loc)
+	case StatsIntervalMonth:
+		return startOfDay(y, m, 1, loc)
+	default:
+		return startOfDay(y, m, d, loc)
+	}
+}
+
+// NextBucket devuelve el inicio de la cubeta que sigue a start.
+func (i StatsInterval) NextBucket(start time.Time) time.Time {
+	y, m, d := start.Date()
+	loc := start.Location()
+	switch i {
+	case StatsIntervalHour:
+		return start.Add(time.Hour)
+	case StatsIntervalWeek:
+		return startOfDay(y, m, d+7, loc)
+	case StatsIntervalMonth:
+		return startOfDay(y, m+1, 1, loc)
+	default:
+		return startOfDay(y, m, d+1, loc)
+	}
+}
+
+// startOfDay devuelve el primer instante del día (y, m, d) en loc.
+// En las zonas que adelantan el reloj a medianoche (ej. Santiago de
+// Chile) ese día no tiene 00:00 y time.Date puede caer en el día
+// ANTERIOR: entonces el día empieza con el cambio de hora.
+func startOfDay(y int, m time.Month, d int, loc *time.Location) time.Time {
+	t := time.Date(y, m, d, 0, 0, 0, 0, loc)
+	if noon := time.Date(y, m, d, 12, 0, 0, 0, loc); t.Day() != noon.Day() {
+		_, end := t.ZoneBounds()
+		return end
+	}
+	return t
+}
+
+// AccessTypes devuelve los tipos de acceso permitidos.
+func AccessTypes() [3]AccessType {
+	return allowedAccessTypes
+}
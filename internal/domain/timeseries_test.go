@@ -0,0 +1,137 @@
+package domain
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata" // zonas horarias fijas, sin depender del sistema
+)
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc
+}
+
+func utc(y int, m time.Month, d, h, min int) time.Time {
+	return time.Date(y, m, d, h, min, 0, 0, time.UTC)
+}
+
+func TestBucketStart(t *testing.T) {
+	quito := mustLoad(t, "America/Guayaquil")  // UTC-5, sin horario de verano
+	kolkata := mustLoad(t, "Asia/Kolkata")     // UTC+5:30
+	kathmandu := mustLoad(t, "Asia/Kathmandu") // UTC+5:45
+	newYork := mustLoad(t, "America/New_York")
+	santiago := mustLoad(t, "America/Santiago")
+
+	tests := []struct {
+		name     string
+		interval StatsInterval
+		t        time.Time
+		loc      *time.Location
+		want     time.Time // en UTC
+	}{
+		// 2026-03-04 03:30 UTC es martes 3 a las 22:30 en Quito.
+		{"hora en Quito", StatsIntervalHour, utc(2026, 3, 4, 3, 30), quito, utc(2026, 3, 4, 3, 0)},
+		{"día en Quito", StatsIntervalDay, utc(2026, 3, 4, 3, 30), quito, utc(2026, 3, 3, 5, 0)},
+		{"semana en Quito", StatsIntervalWeek, utc(2026, 3, 4, 3, 30), quito, utc(2026, 3, 2, 5, 0)},
+		{"mes en Quito", StatsIntervalMonth, utc(2026, 3, 1, 3, 30), quito, utc(2026, 2, 1, 5, 0)},
+		{"la misma hora en UTC", StatsIntervalDay, utc(2026, 3, 4, 3, 30), time.UTC, utc(2026, 3, 4, 0, 0)},
+		{"domingo cae en la semana del lunes anterior", StatsIntervalWeek, utc(2026, 3, 8, 12, 0), time.UTC, utc(2026, 3, 2, 0, 0)},
+		{"lunes empieza su semana", StatsIntervalWeek, utc(2026, 3, 9, 0, 0), time.UTC, utc(2026, 3, 9, 0, 0)},
+
+		// Media hora de diferencia: la hora local empieza a las :30 UTC.
+		{"hora en Kolkata", StatsIntervalHour, utc(2026, 3, 4, 5, 10), kolkata, utc(2026, 3, 4, 4, 30)},
+		{"hora en Kolkata, después de :30", StatsIntervalHour, utc(2026, 3, 4, 4, 40), kolkata, utc(2026, 3, 4, 4, 30)},
+		{"día en Kolkata", StatsIntervalDay, utc(2026, 3, 3, 19, 0), kolkata, utc(2026, 3, 3, 18, 30)},
+		{"mes en Kolkata", StatsIntervalMonth, utc(2026, 2, 28, 19, 0), kolkata, utc(2026, 2, 28, 18, 30)},
+		{"hora en Katmandú", StatsIntervalHour, utc(2026, 3, 4, 5, 0), kathmandu, utc(2026, 3, 4, 4, 15)},
+
+		// Nueva York adelanta el reloj el 2026-03-08 a las 2:00 (EST → EDT).
+		{"día del cambio de hora", StatsIntervalDay, utc(2026, 3, 8, 20, 0), newYork, utc(2026, 3, 8, 5, 0)},
+		{"hora después de adelantar", StatsIntervalHour, utc(2026, 3, 8, 7, 30), newYork, utc(2026, 3, 8, 7, 0)},
+		// Y lo atrasa el 2026-11-01 a las 2:00: la 1:00 se repite.
+		{"primera 1:30", StatsIntervalHour, utc(2026, 11, 1, 5, 30), newYork, utc(2026, 11, 1, 5, 0)},
+		{"segunda 1:30", StatsIntervalHour, utc(2026, 11, 1, 6, 30), newYork, utc(2026, 11, 1, 6, 0)},
+		{"día que dura 25 horas", StatsIntervalDay, utc(2026, 11, 2, 4, 30), newYork, utc(2026, 11, 1, 4, 0)},
+		// Santiago adelanta el reloj el 2026-09-06 a medianoche: ese día
+		// no tiene 00:00 y empieza a la 01:00 (-03).
+		{"día sin medianoche", StatsIntervalDay, utc(2026, 9, 6, 15, 0), santiago, utc(2026, 9, 6, 4, 0)},
+		{"semana con el día sin medianoche", StatsIntervalWeek, utc(2026, 9, 9, 15, 0), santiago, utc(2026, 9, 7, 3, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.interval.BucketStart(tt.t, tt.loc)
+			if !got.Equal(tt.want) {
+				t.Fatalf("BucketStart = %v (%v), se esperaba %v", got, got.UTC(), tt.want)
+			}
+			if got.Location() != tt.loc {
+				t.Fatalf("la cubeta está en %v, se pidió %v", got.Location(), tt.loc)
+			}
+		})
+	}
+}
+
+func TestNextBucketAcrossDST(t *testing.T) {
+	newYork := mustLoad(t, "America/New_York")
+	santiago := mustLoad(t, "America/Santiago")
+
+	tests := []struct {
+		name     string
+		interval StatsInterval
+		start    time.Time
+		length   time.Duration
+	}{
+		{"día de 23 horas", StatsIntervalDay, time.Date(2026, 3, 8, 0, 0, 0, 0, newYork), 23 * time.Hour},
+		{"día de 25 horas", StatsIntervalDay, time.Date(2026, 11, 1, 0, 0, 0, 0, newYork), 25 * time.Hour},
+		{"día común", StatsIntervalDay, time.Date(2026, 3, 9, 0, 0, 0, 0, newYork), 24 * time.Hour},
+		{"semana con el cambio", StatsIntervalWeek, time.Date(2026, 3, 2, 0, 0, 0, 0, newYork), 7*24*time.Hour - time.Hour},
+		{"marzo", StatsIntervalMonth, time.Date(2026, 3, 1, 0, 0, 0, 0, newYork), 31*24*time.Hour - time.Hour},
+		{"febrero", StatsIntervalMonth, time.Date(2026, 2, 1, 0, 0, 0, 0, newYork), 28 * 24 * time.Hour},
+		{"hora antes de adelantar", StatsIntervalHour, time.Date(2026, 3, 8, 1, 0, 0, 0, newYork), time.Hour},
+		{"hora repetida", StatsIntervalHour, utc(2026, 11, 1, 5, 0).In(newYork), time.Hour},
+		{"el día anterior no se come el siguiente", StatsIntervalDay, time.Date(2026, 9, 5, 0, 0, 0, 0, santiago), 24 * time.Hour},
+		{"día sin medianoche", StatsIntervalDay, utc(2026, 9, 6, 4, 0).In(santiago), 23 * time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := tt.interval.NextBucket(tt.start)
+			if got := next.Sub(tt.start); got != tt.length {
+				t.Fatalf("la cubeta dura %v, se esperaba %v", got, tt.length)
+			}
+		})
+	}
+}
+
+// Recorrer un año de cubetas en cada zona: cada inicio es su propia
+// cubeta, las cubetas no se superponen ni dejan huecos y cada una
+// avanza.
+func TestBucketsTileTheYear(t *testing.T) {
+	zones := []string{"UTC", "America/Guayaquil", "America/New_York", "Asia/Kolkata",
+		"Asia/Kathmandu", "Australia/Adelaide", "Europe/Madrid", "America/Santiago"}
+	intervals := []StatsInterval{StatsIntervalHour, StatsIntervalDay, StatsIntervalWeek, StatsIntervalMonth}
+
+	for _, name := range zones {
+		loc := mustLoad(t, name)
+		for _, interval := range intervals {
+			from := utc(2026, 1, 1, 0, 0)
+			to := utc(2027, 1, 1, 0, 0)
+			start := interval.BucketStart(from, loc)
+			for start.Before(to) {
+				if again := interval.BucketStart(start, loc); !again.Equal(start) {
+					t.Fatalf("%s/%s: BucketStart(%v) = %v", name, interval, start, again)
+				}
+				next := interval.NextBucket(start)
+				if !next.After(start) {
+					t.Fatalf("%s/%s: NextBucket(%v) = %v no avanza", name, interval, start, next)
+				}
+				if last := interval.BucketStart(next.Add(-time.Nanosecond), loc); !last.Equal(start) {
+					t.Fatalf("%s/%s: el final de la cubeta %v cae en %v", name, interval, start, last)
+				}
+				start = next
+			}
+		}
+	}
+}
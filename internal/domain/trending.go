@@ -0,0 +1,55 @@
+package domain
+
+import "time"
+
+/*
+   ==========================================================
+   VENTANAS DE TENDENCIAS
+   ==========================================================
+
+   Los libros en tendencia se cuentan sobre una ventana DESLIZANTE
+   que termina ahora: la última hora, el último día o la última
+   semana.
+*/
+
+// TrendWindow es el largo de la ventana de tendencias.
+type TrendWindow string
+
+const (
+	TrendWindowHour TrendWindow = "1h"
+	TrendWindowDay  TrendWindow = "24h"
+	TrendWindowWeek TrendWindow = "7d"
+)
+
+// MaxTrendWindow es la ventana más larga: los accesos más viejos
+// no cuentan para ninguna tendencia.
+const MaxTrendWindow = 7 * 24 * time.Hour
+
+// Duration devuelve el largo de la ventana.
+func (w TrendWindow) Duration() time.Duration {
+	switch w {
+	case TrendWindowHour:
+		return time.Hour
+	case TrendWindowWeek:
+		return MaxTrendWindow
+	default:
+		return 24 * time.Hour
+	}
+}
+
+// allowedTrendWindows es un ARRAY con las ventanas permitidas.
+var allowedTrendWindows = [3]TrendWindow{TrendWindowHour, TrendWindowDay, TrendWindowWeek}
+
+// ParseTrendWindow lee el parámetro "window". Vacío = último día.
+func ParseTrendWindow(s string) (TrendWindow, error) {
+	if s == "" {
+		return TrendWindowDay, nil
+	}
+	window := TrendWindow(s)
+	for _, allowed := range allowedTrendWindows {
+		if allowed == window {
+			return window, nil
+		}
+	}
+	return "", NewValidationError("window", "window debe ser 1h, 24h o 7d")
+}
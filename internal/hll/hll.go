@@ -0,0 +1,294 @@
+package hll
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"sort"
+)
+
+/*
+   ==========================================================
+   HYPERLOGLOG: CONTAR ELEMENTOS DISTINTOS APROXIMADAMENTE
+   ==========================================================
+
+   Contar lectores DISTINTOS de forma exacta obliga a guardar
+   todos los IDs vistos. Un sketch HyperLogLog guarda solo m
+   registros pequeños y estima la cantidad con un error típico
+   de 1.04 / √m (con la precisión por defecto, m = 4096 y
+   ~1,6 %), sin importar si son cien o cien millones.
+
+   Cómo funciona:
+   1. Cada elemento se convierte en un hash de 64 bits.
+   2. Los primeros p bits eligen un registro (m = 2^p).
+   3. El registro guarda el MÁXIMO de "ceros a la izquierda + 1"
+      del resto del hash. Ver muchos elementos distintos hace
+      probable ver rachas largas de ceros.
+   4. La estimación es una media armónica de 2^registro,
+      corregida con "linear counting" cuando hay pocos elementos.
+
+   Propiedades que usamos:
+   - Agregar el mismo elemento dos veces no cambia nada.
+   - MERGE: el máximo registro a registro da el sketch de la
+     UNIÓN. Así los sketches por día se combinan en semanas,
+     meses, o varios libros en una categoría.
+   - Se SERIALIZA en bytes para guardarlo (MarshalBinary).
+
+   Representación DISPERSA: con pocos elementos casi todos los
+   registros valen cero, así que se guardan solo los usados en
+   un map. Al pasar de m/16 se pasa a un arreglo denso de m bytes.
+*/
+
+// Límites de la precisión p (m = 2^p registros).
+const (
+	MinPrecision     = 4
+	MaxPrecision     = 16
+	DefaultPrecision = 12
+)
+
+// formatVersion es la versión del formato binario.
+const formatVersion = 1
+
+// Modos de la representación binaria.
+const (
+	modeSparse = 0
+	modeDense  = 1
+)
+
+// ErrPrecisionMismatch indica que se quiso combinar sketches de distinta precisión.
+var ErrPrecisionMismatch = errors.New("hll: los sketches tienen distinta precisión")
+
+// Sketch es un estimador HyperLogLog. No es seguro para usar desde
+// varias goroutines a la vez.
+type Sketch struct {
+	p      uint8
+	sparse map[uint32]uint8 // registros usados (nil en modo denso)
+	dense  []uint8          // m registros (nil en modo disperso)
+}
+
+// New crea un sketch vacío con la precisión por defecto.
+func New() *Sketch {
+	s, _ := NewWithPrecision(DefaultPrecision)
+	return s
+}
+
+// NewWithPrecision crea un sketch vacío con m = 2^p registros.
+func NewWithPrecision(p uint8) (*Sketch, error) {
+	if p < MinPrecision || p > MaxPrecision {
+		return nil, fmt.Errorf("hll: precisión %d fuera de rango [%d, %d]", p, MinPrecision, MaxPrecision)
+	}
+	return &Sketch{p: p, sparse: make(map[uint32]uint8)}, nil
+}
+
+// Precision devuelve p.
+func (s *Sketch) Precision() uint8 { return s.p }
+
+// registers devuelve m.
+func (s *Sketch) registers() uint32 { return 1 << s.p }
+
+// Hash64 mezcla los bits de un entero (finalizador de SplitMix64), para
+// que IDs consecutivos den hashes bien repartidos.
+func Hash64(v uint64) uint64 {
+	v += 0x9e3779b97f4a7c15
+	v = (v ^ (v >> 30)) * 0xbf58476d1ce4e5b9
+	v = (v ^ (v >> 27)) * 0x94d049bb133111eb
+	return v ^ (v >> 31)
+}
+
+// AddUint64 agrega un entero (por ejemplo, un ID de usuario).
+func (s *Sketch) AddUint64(v uint64) {
+	s.AddHash(Hash64(v))
+}
+
+// AddHash agrega un elemento ya convertido en hash de 64 bits.
+func (s *Sketch) AddHash(h uint64) {
+	idx := uint32(h >> (64 - s.p))
+	// El bit de guarda limita la racha a 64-p+1 aunque el resto sea cero.
+	rest := h<<s.p | 1<<(s.p-1)
+	rank := uint8(bits.LeadingZeros64(rest) + 1)
+	s.setMax(idx, rank)
+}
+
+// setMax guarda rank en el registro idx si es mayor que el actual.
+func (s *Sketch) setMax(idx uint32, rank uint8) {
+	if s.dense != nil {
+		if rank > s.dense[idx] {
+			s.dense[idx] = rank
+		}
+		return
+	}
+	if rank > s.sparse[idx] {
+		s.sparse[idx] = rank
+		if uint32(len(s.sparse)) > s.registers()/16 {
+			s.toDense()
+		}
+	}
+}
+
+// toDense pasa a la representación densa.
+func (s *Sketch) toDense() {
+	s.dense = make([]uint8, s.registers())
+	for idx, rank := range s.sparse {
+		s.dense[idx] = rank
+	}
+	s.sparse = nil
+}
+
+// Merge agrega al sketch todos los elementos de other (unión).
+func (s *Sketch) Merge(other *Sketch) error {
+	if s.p != other.p {
+		return ErrPrecisionMismatch
+	}
+	if other.dense != nil {
+		if s.dense == nil {
+			s.toDense()
+		}
+		for idx, rank := range other.dense {
+			if rank > s.dense[idx] {
+				s.dense[idx] = rank
+			}
+		}
+		return nil
+	}
+	for idx, rank := range other.sparse {
+		s.setMax(idx, rank)
+	}
+	return nil
+}
+
+// Clone devuelve una copia independiente.
+func (s *Sketch) Clone() *Sketch {
+	c := &Sketch{p: s.p}
+	if s.dense != nil {
+		c.dense = append([]uint8(nil), s.dense...)
+		return c
+	}
+	c.sparse = make(map[uint32]uint8, len(s.sparse))
+	for idx, rank := range s.sparse {
+		c.sparse[idx] = rank
+	}
+	return c
+}
+
+// Estimate devuelve la cantidad estimada de elementos distintos.
+func (s *Sketch) Estimate() uint64 {
+	m := float64(s.registers())
+
+	// sum = Σ 2^-registro; zeros = registros en cero.
+	var sum float64
+	var zeros int
+	if s.dense != nil {
+		for _, rank := range s.dense {
+			sum += math.Ldexp(1, -int(rank))
+			if rank == 0 {
+				zeros++
+			}
+		}
+	} else {
+		zeros = int(s.registers()) - len(s.sparse)
+		sum = float64(zeros)
+		for _, rank := range s.sparse {
+			sum += math.Ldexp(1, -int(rank))
+		}
+	}
+
+	alpha := 0.7213 / (1 + 1.079/m)
+	estimate := alpha * m * m / sum
+	if estimate <= 2.5*m && zeros > 0 {
+		// Pocos elementos: linear counting es más preciso.
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return uint64(estimate + 0.5)
+}
+
+/*
+MarshalBinary serializa el sketch (implementa encoding.BinaryMarshaler):
+
+	versión (1 byte) | precisión (1 byte) | modo (1 byte) | datos
+
+- disperso: cantidad (uint32) y pares registro (uint16) + valor (1 byte).
+- denso: los m registros, un byte cada uno.
+
+Los pares dispersos van ordenados por registro: el resultado es estable.
+*/
+func (s *Sketch) MarshalBinary() ([]byte, error) {
+	if s.dense != nil {
+		out := make([]byte, 0, 3+len(s.dense))
+		out = append(out, formatVersion, s.p, modeDense)
+		return append(out, s.dense...), nil
+	}
+
+	idxs := make([]uint32, 0, len(s.sparse))
+	for idx := range s.sparse {
+		idxs = append(idxs, idx)
+	}
+	sort.Slice(idxs, func(i, j int) bool { return idxs[i] < idxs[j] })
+
+	out := make([]byte, 0, 7+3*len(idxs))
+	out = append(out, formatVersion, s.p, modeSparse)
+	out = binary.BigEndian.AppendUint32(out, uint32(len(idxs)))
+	for _, idx := range idxs {
+		out = binary.BigEndian.AppendUint16(out, uint16(idx))
+		out = append(out, s.sparse[idx])
+	}
+	return out, nil
+}
+
+// UnmarshalBinary lee un sketch serializado con MarshalBinary
+// (implementa encoding.BinaryUnmarshaler).
+func (s *Sketch) UnmarshalBinary(data []byte) error {
+	if len(data) < 3 || data[0] != formatVersion {
+		return errors.New("hll: formato de sketch desconocido")
+	}
+	p, mode, payload := data[1], data[2], data[3:]
+	if p < MinPrecision || p > MaxPrecision {
+		return fmt.Errorf("hll: precisión %d fuera de rango", p)
+	}
+	m := uint32(1) << p
+	maxRank := uint8(64 - p + 1)
+
+	switch mode {
+	case modeDense:
+		if uint32(len(payload)) != m {
+			return errors.New("hll: sketch denso con largo incorrecto")
+		}
+		for _, rank := range payload {
+			if rank > maxRank {
+				return errors.New("hll: registro fuera de rango")
+			}
+		}
+		*s = Sketch{p: p, dense: append([]uint8(nil), payload...)}
+		return nil
+
+	case modeSparse:
+		if len(payload) < 4 {
+			return errors.New("hll: sketch disperso incompleto")
+		}
+		n := binary.BigEndian.Uint32(payload)
+		payload = payload[4:]
+		if uint64(len(payload)) != 3*uint64(n) || n > m {
+			return errors.New("hll: sketch disperso con largo incorrecto")
+		}
+		sk := Sketch{p: p, sparse: make(map[uint32]uint8, n)}
+		for i := uint32(0); i < n; i++ {
+			idx := uint32(binary.BigEndian.Uint16(payload[3*i:]))
+			rank := payload[3*i+2]
+			if idx >= m || rank == 0 || rank > maxRank {
+				return errors.New("hll: registro fuera de rango")
+			}
+			// Ordenados y sin repetir, como los escribe MarshalBinary.
+			if i > 0 && idx <= uint32(binary.BigEndian.Uint16(payload[3*(i-1):])) {
+				return errors.New("hll: registros dispersos desordenados o repetidos")
+			}
+			sk.sparse[idx] = rank
+		}
+		if uint32(len(sk.sparse)) > m/16 {
+			sk.toDense()
+		}
+		*s = sk
+		return nil
+	}
+	return errors.New("hll: modo de sketch desconocido")
+}
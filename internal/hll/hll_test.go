@@ -0,0 +1,288 @@
+package hll
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"slices"
+	"testing"
+)
+
+// filled devuelve un sketch de precisión p con los enteros [from, to).
+func filled(t *testing.T, p uint8, from, to uint64) *Sketch {
+	t.Helper()
+	s, err := NewWithPrecision(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for v := from; v < to; v++ {
+		s.AddUint64(v)
+	}
+	return s
+}
+
+// registersOf devuelve los m registros, sin importar la representación.
+func registersOf(s *Sketch) []uint8 {
+	if s.dense != nil {
+		return slices.Clone(s.dense)
+	}
+	regs := make([]uint8, s.registers())
+	for idx, rank := range s.sparse {
+		regs[idx] = rank
+	}
+	return regs
+}
+
+func TestNewWithPrecision(t *testing.T) {
+	for _, p := range []uint8{0, MinPrecision - 1, MaxPrecision + 1} {
+		if _, err := NewWithPrecision(p); err == nil {
+			t.Errorf("NewWithPrecision(%d) debería fallar", p)
+		}
+	}
+	if s := New(); s.Precision() != DefaultPrecision || s.Estimate() != 0 {
+		t.Fatalf("New() = p %d, estimación %d", s.Precision(), s.Estimate())
+	}
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	for _, p := range []uint8{MinPrecision, DefaultPrecision, MaxPrecision} {
+		threshold := uint64(1) << p / 16
+		for _, n := range []uint64{0, 1, 3, threshold, 4 * threshold, 20_000} {
+			s := filled(t, p, 0, n)
+			data, err := s.MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got Sketch
+			if err := got.UnmarshalBinary(data); err != nil {
+				t.Fatalf("p=%d n=%d: UnmarshalBinary: %v", p, n, err)
+			}
+			if got.Precision() != p || (got.dense != nil) != (s.dense != nil) {
+				t.Fatalf("p=%d n=%d: precisión %d, denso %v; se esperaba %d, %v",
+					p, n, got.Precision(), got.dense != nil, p, s.dense != nil)
+			}
+			if !slices.Equal(registersOf(&got), registersOf(s)) || got.Estimate() != s.Estimate() {
+				t.Fatalf("p=%d n=%d: los registros cambiaron al serializar", p, n)
+			}
+			// Estable: serializar de nuevo da los mismos bytes.
+			again, _ := got.MarshalBinary()
+			if !bytes.Equal(again, data) {
+				t.Fatalf("p=%d n=%d: la segunda serialización difiere", p, n)
+			}
+		}
+	}
+}
+
+func TestMarshalSizes(t *testing.T) {
+	sparse, _ := filled(t, DefaultPrecision, 0, 10).MarshalBinary()
+	if len(sparse) != 7+3*10 || sparse[2] != modeSparse {
+		t.Fatalf("disperso con 10 elementos: %d bytes, modo %d", len(sparse), sparse[2])
+	}
+	dense, _ := filled(t, DefaultPrecision, 0, 10_000).MarshalBinary()
+	if len(dense) != 3+1<<DefaultPrecision || dense[2] != modeDense {
+		t.Fatalf("denso: %d bytes, modo %d", len(dense), dense[2])
+	}
+}
+
+func TestUnmarshalRejectsBadInput(t *testing.T) {
+	valid, _ := filled(t, 4, 0, 1).MarshalBinary() // disperso: un registro
+	pair := valid[7:]
+
+	sparse := func(p uint8, n uint32, pairs ...[]byte) []byte {
+		out := []byte{formatVersion, p, modeSparse, byte(n >> 24), byte(n >> 16), byte(n >> 8), byte(n)}
+		for _, pr := range pairs {
+			out = append(out, pr...)
+		}
+		return out
+	}
+	dense := func(p uint8, regs ...uint8) []byte {
+		return append([]byte{formatVersion, p, modeDense}, regs...)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"vacío", nil},
+		{"sin modo", []byte{formatVersion, 4}},
+		{"versión desconocida", []byte{2, 4, modeSparse, 0, 0, 0, 0}},
+		{"precisión baja", sparse(MinPrecision-1, 0)},
+		{"precisión alta", sparse(MaxPrecision+1, 0)},
+		{"modo desconocido", []byte{formatVersion, 4, 7}},
+		{"disperso sin cantidad", []byte{formatVersion, 4, modeSparse, 0, 0}},
+		{"disperso corto", sparse(4, 2, pair)},
+		{"disperso largo", sparse(4, 0, pair)},
+		{"disperso con más registros que m", sparse(4, 17, bytes.Repeat(pair, 17))},
+		{"registro fuera de m", sparse(4, 1, []byte{0, 16, 1})},
+		{"valor cero", sparse(4, 1, []byte{0, 3, 0})},
+		{"valor mayor que 64-p+1", sparse(4, 1, []byte{0, 3, 62})},
+		{"registros repetidos", sparse(4, 2, []byte{0, 3, 1}, []byte{0, 3, 2})},
+		{"registros desordenados", sparse(4, 2, []byte{0, 5, 1}, []byte{0, 3, 2})},
+		{"denso corto", dense(4, make([]uint8, 15)...)},
+		{"denso largo", dense(4, make([]uint8, 17)...)},
+		{"denso con valor mayor que 64-p+1", dense(4, append(make([]uint8, 15), 62)...)},
+	}
+	for _, tt := range tests {
+		s := filled(t, DefaultPrecision, 0, 5)
+		before := s.Estimate()
+		if err := s.UnmarshalBinary(tt.data); err == nil {
+			t.Errorf("%s: UnmarshalBinary debería fallar", tt.name)
+		}
+		if s.Precision() != DefaultPrecision || s.Estimate() != before {
+			t.Errorf("%s: un error no debe modificar el sketch", tt.name)
+		}
+	}
+
+	// Los límites sí se aceptan.
+	var s Sketch
+	for _, data := range [][]byte{valid, sparse(4, 1, []byte{0, 15, 61}), dense(4, append(make([]uint8, 15), 61)...)} {
+		if err := s.UnmarshalBinary(data); err != nil {
+			t.Errorf("UnmarshalBinary(%v): %v", data, err)
+		}
+	}
+}
+
+// Al pasar de m/16 registros usados, el sketch pasa a denso sin
+// cambiar sus registros.
+func TestSparseToDense(t *testing.T) {
+	s := New()
+	threshold := int(s.registers() / 16)
+
+	var before []uint8
+	for v := uint64(0); s.dense == nil; v++ {
+		before = registersOf(s)
+		s.AddUint64(v)
+		if s.dense == nil && len(s.sparse) > threshold {
+			t.Fatalf("%d registros usados y sigue disperso", len(s.sparse))
+		}
+	}
+	if s.sparse != nil {
+		t.Fatal("el sketch denso conserva el map disperso")
+	}
+
+	used := 0
+	for _, rank := range s.dense {
+		if rank > 0 {
+			used++
+		}
+	}
+	if used != threshold+1 {
+		t.Fatalf("pasó a denso con %d registros usados, se esperaba %d", used, threshold+1)
+	}
+	changed := 0
+	for i, rank := range registersOf(s) {
+		if rank != before[i] {
+			changed++
+		}
+	}
+	if changed != 1 {
+		t.Fatalf("al pasar a denso cambiaron %d registros, se esperaba solo el nuevo", changed)
+	}
+
+	// Al leer uno disperso con demasiados registros, también se pasa a denso.
+	data := []byte{formatVersion, 4, modeSparse, 0, 0, 0, 2, 0, 1, 1, 0, 2, 1}
+	var small Sketch
+	if err := small.UnmarshalBinary(data); err != nil || small.dense == nil {
+		t.Fatalf("disperso con 2 de 16 registros: err %v, denso %v", err, small.dense != nil)
+	}
+}
+
+func TestMergeMixedModes(t *testing.T) {
+	small := func(from uint64) *Sketch { return filled(t, DefaultPrecision, from, from+50) }
+	large := func(from uint64) *Sketch { return filled(t, DefaultPrecision, from, from+5_000) }
+
+	tests := []struct {
+		name      string
+		a, b      *Sketch
+		wantDense bool
+	}{
+		{"disperso + disperso", small(0), small(25), false},
+		{"disperso + disperso que se llena", filled(t, DefaultPrecision, 0, 200), filled(t, DefaultPrecision, 200, 400), true},
+		{"disperso + denso", small(0), large(25), true},
+		{"denso + disperso", large(0), small(4_990), true},
+		{"denso + denso", large(0), large(2_500), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bRegs := registersOf(tt.b)
+			want := registersOf(tt.a)
+			for i, rank := range bRegs {
+				want[i] = max(want[i], rank)
+			}
+
+			if err := tt.a.Merge(tt.b); err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(registersOf(tt.a), want) {
+				t.Fatal("Merge no es el máximo registro a registro")
+			}
+			if (tt.a.dense != nil) != tt.wantDense {
+				t.Fatalf("denso = %v, se esperaba %v", tt.a.dense != nil, tt.wantDense)
+			}
+			if !slices.Equal(registersOf(tt.b), bRegs) {
+				t.Fatal("Merge modificó el otro sketch")
+			}
+		})
+	}
+
+	// La unión de dos mitades estima lo mismo que el sketch de todo.
+	a, b := filled(t, DefaultPrecision, 0, 30_000), filled(t, DefaultPrecision, 20_000, 50_000)
+	if err := a.Merge(b); err != nil {
+		t.Fatal(err)
+	}
+	if whole := filled(t, DefaultPrecision, 0, 50_000); a.Estimate() != whole.Estimate() {
+		t.Fatalf("Merge estima %d, el sketch de la unión %d", a.Estimate(), whole.Estimate())
+	}
+
+	other, _ := NewWithPrecision(10)
+	if err := New().Merge(other); !errors.Is(err, ErrPrecisionMismatch) {
+		t.Fatalf("Merge de precisiones distintas: %v", err)
+	}
+}
+
+func TestCloneIsIndependent(t *testing.T) {
+	for _, s := range []*Sketch{filled(t, DefaultPrecision, 0, 10), filled(t, DefaultPrecision, 0, 10_000)} {
+		c := s.Clone()
+		before := registersOf(s)
+		if err := c.Merge(filled(t, DefaultPrecision, 100_000, 200_000)); err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(registersOf(s), before) {
+			t.Fatal("modificar el clon cambió el original")
+		}
+	}
+}
+
+// El error relativo queda dentro de 3 errores típicos (1.04/√m).
+func TestEstimateError(t *testing.T) {
+	for _, p := range []uint8{10, DefaultPrecision, 14} {
+		bound := 3 * 1.04 / math.Sqrt(float64(uint64(1)<<p))
+		for _, n := range []uint64{1_000, 10_000, 100_000, 1_000_000} {
+			s := filled(t, p, 0, n)
+			relErr := math.Abs(float64(s.Estimate())-float64(n)) / float64(n)
+			if relErr > bound {
+				t.Errorf("p=%d n=%d: estimación %d, error %.2f%% > %.2f%%", p, n, s.Estimate(), 100*relErr, 100*bound)
+			}
+		}
+	}
+
+	// Con pocos elementos (linear counting) es exacta o casi: solo se
+	// pierden los que caen en un registro ya usado.
+	for _, tt := range []struct{ n, tolerance uint64 }{{0, 0}, {1, 0}, {2, 0}, {10, 0}, {50, 1}, {100, 3}} {
+		got := filled(t, DefaultPrecision, 0, tt.n).Estimate()
+		if math.Abs(float64(got)-float64(tt.n)) > float64(tt.tolerance) {
+			t.Errorf("n=%d: estimación %d", tt.n, got)
+		}
+	}
+
+	// Los repetidos no cuentan.
+	s := filled(t, DefaultPrecision, 0, 1_000)
+	est := s.Estimate()
+	for v := uint64(0); v < 1_000; v++ {
+		s.AddUint64(v)
+	}
+	if s.Estimate() != est {
+		t.Fatalf("agregar repetidos cambió la estimación: %d → %d", est, s.Estimate())
+	}
+}
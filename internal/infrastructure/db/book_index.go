@@ -0,0 +1,497 @@
+package db
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/domain"
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/textnorm"
+)
+
+/*
+   ==========================================================
+   ÍNDICES SECUNDARIOS DE LIBROS (InMemoryBookRepo)
+   ==========================================================
+
+   Recorrer TODOS los libros en cada búsqueda (y pasar a
+   minúsculas en cada comparación) es lento con catálogos
+   grandes. Por eso InMemoryBookRepo mantiene índices que se
+   actualizan en Create / Update:
+
+   - byISBN:      ISBN normalizado (sin guiones ni espacios)  → IDs
+   - byCategory:  categoría plegada (textnorm.FoldKey)        → IDs
+   - byYear:      año → IDs, más la lista ORDENADA de años
+                  para resolver rangos con búsqueda binaria
+   - byTag:       índice invertido tag normalizado            → IDs
+   - titleGrams / authorGrams: TRIGRAMAS (3 letras seguidas)  → IDs,
+                  para resolver "contiene" sin recorrer todo
+   - titleWords / authorWords: VOCABULARIO de los libros activos,
+                  para el "¿Quisiste decir?" (ver fuzzy.go)
+   - completions: TRIE de títulos, autores y tags de los libros
+                  activos, para el autocompletado (ver suggest_trie.go)
+
+   Además cada libro indexado guarda sus campos ya normalizados
+   (entries), así las comparaciones no vuelven a convertir textos.
+   Los textos se PLIEGAN (minúsculas, sin acentos, ñ → n): buscar
+   "programacion" encuentra "Programación".
+
+   PLAN de una búsqueda:
+   1. Se estima cuántos libros devuelve cada índice aplicable.
+   2. El más selectivo se usa como CONDUCTOR (candidatos).
+   3. Cada candidato se verifica contra el resto de condiciones.
+   Si ningún índice aplica (filtro vacío), se recorren todos.
+   Con Fuzzy los trigramas no sirven (un error de tipeo cambia
+   los trigramas), así que título y autor se verifican al final.
+*/
+
+// idSet es un conjunto de IDs de libros.
+type idSet map[domain.BookID]struct{}
+
+// indexedBook son los campos de un libro ya normalizados para buscar.
+type indexedBook struct {
+	title    string
+	author   string
+	category string
+	isbn     string
+	year     int
+	tags     []string
+	active   bool
+}
+
+// bookIndex agrupa los índices secundarios de InMemoryBookRepo.
+// No tiene mutex propio: lo protege el mutex del repositorio.
+type bookIndex struct {
+	entries     map[domain.BookID]indexedBook
+	byISBN      map[string]idSet
+	byCategory  map[string]idSet
+	byYear      map[int]idSet
+	years       []int
+	byTag       map[string]idSet
+	titleGrams  map[string]idSet
+	authorGrams map[string]idSet
+	titleWords  vocabulary
+	authorWords vocabulary
+	completions *suggestTrie
+}
+
+// newBookIndex crea índices vacíos.
+func newBookIndex() *bookIndex {
+	return &bookIndex{
+		entries:     make(map[domain.BookID]indexedBook),
+		byISBN:      make(map[string]idSet),
+		byCategory:  make(map[string]idSet),
+		byYear:      make(map[int]idSet),
+		byTag:       make(map[string]idSet),
+		titleGrams:  make(map[string]idSet),
+		authorGrams: make(map[string]idSet),
+		titleWords:  make(vocabulary),
+		authorWords: make(vocabulary),
+		completions: newSuggestTrie(),
+	}
+}
+
+// normalizeKey pliega un texto para compararlo (ver internal/textnorm).
+func normalizeKey(s string) string {
+	return textnorm.FoldKey(s)
+}
+
+// normalizeISBN quita guiones y espacios para comparar ISBN.
+func normalizeISBN(s string) string {
+	r := strings.NewReplacer("-", "", " ", "")
+	return strings.ToUpper(r.Replace(strings.TrimSpace(s)))
+}
+
+// trigrams devuelve los trigramas distintos de un texto ya normalizado.
+// Un texto de menos de 3 letras no tiene trigramas.
+func trigrams(s string) []string {
+	runes := []rune(s)
+	if len(runes) < 3 {
+		return nil
+	}
+
+	seen := make(map[string]bool, len(runes)-2)
+	result := make([]string, 0, len(runes)-2)
+	for i := 0; i+3 <= len(runes); i++ {
+		g := string(runes[i : i+3])
+		if !seen[g] {
+			seen[g] = true
+			result = append(result, g)
+		}
+	}
+	return result
+}
+
+// addToSet agrega id al conjunto de la clave k (lo crea si hace falta).
+func addToSet[K comparable](m map[K]idSet, k K, id domain.BookID) {
+	set, ok := m[k]
+	if !ok {
+		set = make(idSet)
+		m[k] = set
+	}
+	set[id] = struct{}{}
+}
+
+// removeFromSet quita id del conjunto de la clave k (y borra la clave si queda vacía).
+// Devuelve true si la clave desapareció.
+func removeFromSet[K comparable](m map[K]idSet, k K, id domain.BookID) bool {
+	set, ok := m[k]
+	if !ok {
+		return false
+	}
+	delete(set, id)
+	if len(set) == 0 {
+		delete(m, k)
+		return true
+	}
+	return false
+}
+
+// newIndexedBook normaliza los campos de un libro para buscar.
+func newIndexedBook(b *domain.Book) indexedBook {
+	entry := indexedBook{
+		title:    normalizeKey(b.Title()),
+		author:   normalizeKey(b.Author()),
+		category: normalizeKey(b.CategoryTI()),
+		isbn:     normalizeISBN(b.ISBN()),
+		year:     b.Year(),
+		active:   b.Active(),
+	}
+	for _, tag := range b.Tags() {
+		entry.tags = append(entry.tags, domain.NormalizeTag(tag))
+	}
+	return entry
+}
+
+// put indexa un libro, reemplazando su versión anterior si existía.
+func (ix *bookIndex) put(b *domain.Book) {
+	ix.remove(b.ID())
+
+	entry := newIndexedBook(b)
+	id := b.ID()
+	ix.entries[id] = entry
+
+	addToSet(ix.byISBN, entry.isbn, id)
+	addToSet(ix.byCategory, entry.category, id)
+	if _, ok := ix.byYear[entry.year]; !ok {
+		// Año nuevo: insertarlo en la lista ordenada.
+		pos := sort.SearchInts(ix.years, entry.year)
+		ix.years = append(ix.years, 0)
+		copy(ix.years[pos+1:], ix.years[pos:])
+		ix.years[pos] = entry.year
+	}
+	addToSet(ix.byYear, entry.year, id)
+	for _, tag := range entry.tags {
+		addToSet(ix.byTag, tag, id)
+	}
+	for _, g := range trigrams(entry.title) {
+		addToSet(ix.titleGrams, g, id)
+	}
+	for _, g := range trigrams(entry.author) {
+		addToSet(ix.authorGrams, g, id)
+	}
+	if entry.active {
+		ix.titleWords.add(b.Title())
+		ix.authorWords.add(b.Author())
+		ix.completions.add(domain.SuggestTitle, b.Title(), id)
+		ix.completions.add(domain.SuggestAuthor, b.Author(), id)
+		for _, tag := range b.Tags() {
+			ix.completions.add(domain.SuggestTag, tag, id)
+		}
+	}
+}
+
+// remove quita un libro de todos los índices.
+func (ix *bookIndex) remove(id domain.BookID) {
+	entry, ok := ix.entries[id]
+	if !ok {
+		return
+	}
+	delete(ix.entries, id)
+
+	removeFromSet(ix.byISBN, entry.isbn, id)
+	removeFromSet(ix.byCategory, entry.category, id)
+	if removeFromSet(ix.byYear, entry.year, id) {
+		pos := sort.SearchInts(ix.years, entry.year)
+		ix.years = append(ix.years[:pos], ix.years[pos+1:]...)
+	}
+	for _, tag := range entry.tags {
+		removeFromSet(ix.byTag, tag, id)
+	}
+	for _, g := range trigrams(entry.title) {
+		removeFromSet(ix.titleGrams, g, id)
+	}
+	for _, g := range trigrams(entry.author) {
+		removeFromSet(ix.authorGrams, g, id)
+	}
+	if entry.active {
+		ix.titleWords.remove(entry.title)
+		ix.authorWords.remove(entry.author)
+		ix.completions.remove(domain.SuggestTitle, entry.title, id)
+		ix.completions.remove(domain.SuggestAuthor, entry.author, id)
+		for _, tag := range entry.tags {
+			ix.completions.remove(domain.SuggestTag, tag, id)
+		}
+	}
+}
+
+// suggest corrige título y autor del filtro con el vocabulario.
+func (ix *bookIndex) suggest(filter domain.BookFilter) domain.SpellingSuggestion {
+	var s domain.SpellingSuggestion
+	if filter.TitleContains != "" {
+		s.Title, _ = ix.titleWords.correct(filter.TitleContains)
+	}
+	if filter.AuthorContains != "" {
+		s.Author, _ = ix.authorWords.correct(filter.AuthorContains)
+	}
+	return s
+}
+
+// bookQuery es un BookFilter con los textos ya normalizados.
+type bookQuery struct {
+	title    string
+	author   string
+	category string
+	isbn     string
+	yearFrom int
+	yearTo   int
+	tags     []string
+	tagMode  domain.TagMatchMode
+	fuzzy    bool
+	expr     queryMatcher // nil = sin consulta (ver query_eval.go)
+}
+
+// newBookQuery normaliza el filtro UNA vez por búsqueda.
+func newBookQuery(filter domain.BookFilter) bookQuery {
+	q := bookQuery{
+		title:    normalizeKey(filter.TitleContains),
+		author:   normalizeKey(filter.AuthorContains),
+		category: normalizeKey(filter.CategoryTI),
+		yearFrom: filter.YearFrom,
+		yearTo:   filter.YearTo,
+		tags:     filter.NormalizedTags(),
+		tagMode:  filter.TagMode,
+		fuzzy:    filter.Fuzzy,
+	}
+	if q.tagMode == "" {
+		q.tagMode = domain.TagMatchAll
+	}
+	if filter.ISBN != "" {
+		q.isbn = normalizeISBN(filter.ISBN)
+	}
+	if filter.Query != nil {
+		q.expr = compileQuery(filter.Query, filter.Fuzzy)
+	}
+	return q
+}
+
+// matches verifica TODAS las condiciones del filtro sobre un libro indexado.
+func (e indexedBook) matches(q bookQuery) bool {
+	// Solo libros activos.
+	if !e.active {
+		return false
+	}
+	if q.title != "" && !containsText(e.title, q.title, q.fuzzy) {
+		return false
+	}
+	if q.author != "" && !containsText(e.author, q.author, q.fuzzy) {
+		return false
+	}
+	if q.category != "" && e.category != q.category {
+		return false
+	}
+	if q.isbn != "" && e.isbn != q.isbn {
+		return false
+	}
+	if q.yearFrom > 0 && e.year < q.yearFrom {
+		return false
+	}
+	if q.yearTo > 0 && e.year > q.yearTo {
+		return false
+	}
+	if len(q.tags) > 0 && !e.matchesTags(q.tags, q.tagMode) {
+		return false
+	}
+	if q.expr != nil && !q.expr(e) {
+		return false
+	}
+	return true
+}
+
+// containsText compara "contiene", tolerando errores de tipeo si fuzzy.
+func containsText(text, query string, fuzzy bool) bool {
+	if fuzzy {
+		return fuzzyContains(text, query)
+	}
+	return strings.Contains(text, query)
+}
+
+// matchesTags aplica el modo de tags (all / any / none).
+func (e indexedBook) matchesTags(tags []string, mode domain.TagMatchMode) bool {
+	found := 0
+	for _, want := range tags {
+		for _, have := range e.tags {
+			if have == want {
+				found++
+				break
+			}
+		}
+	}
+
+	switch mode {
+	case domain.TagMatchAny:
+		return found > 0
+	case domain.TagMatchNone:
+		return found == 0
+	default:
+		return found == len(tags)
+	}
+}
+
+// search devuelve los IDs de los libros que cumplen el filtro.
+func (ix *bookIndex) search(filter domain.BookFilter) []domain.BookID {
+	q := newBookQuery(filter)
+
+	candidates, useIndex := ix.plan(q)
+
+	result := make([]domain.BookID, 0)
+	if !useIndex {
+		// Ningún índice aplica: recorrer todo.
+		for id, entry := range ix.entries {
+			if entry.matches(q) {
+				result = append(result, id)
+			}
+		}
+		return result
+	}
+
+	for id := range candidates {
+		if ix.entries[id].matches(q) {
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+// indexChoice es un índice aplicable con su tamaño estimado.
+type indexChoice struct {
+	estimate int
+	build    func() idSet
+}
+
+/*
+plan elige el índice más selectivo para el filtro y devuelve sus
+candidatos. useIndex es false si ningún índice aplica.
+*/
+func (ix *bookIndex) plan(q bookQuery) (candidates idSet, useIndex bool) {
+	var choices []indexChoice
+
+	if q.isbn != "" {
+		set := ix.byISBN[q.isbn]
+		choices = append(choices, indexChoice{len(set), func() idSet { return set }})
+	}
+	if q.category != "" {
+		set := ix.byCategory[q.category]
+		choices = append(choices, indexChoice{len(set), func() idSet { return set }})
+	}
+	if q.yearFrom > 0 || q.yearTo > 0 {
+		lo, hi := ix.yearRange(q.yearFrom, q.yearTo)
+		estimate := 0
+		for _, y := range ix.years[lo:hi] {
+			estimate += len(ix.byYear[y])
+		}
+		choices = append(choices, indexChoice{estimate, func() idSet {
+			union := make(idSet, estimate)
+			for _, y := range ix.years[lo:hi] {
+				for id := range ix.byYear[y] {
+					union[id] = struct{}{}
+				}
+			}
+			return union
+		}})
+	}
+	// "none" excluye libros: no sirve para elegir candidatos.
+	if len(q.tags) > 0 && q.tagMode == domain.TagMatchAll {
+		choices = append(choices, intersectChoice(ix.byTag, q.tags))
+	}
+	if len(q.tags) > 0 && q.tagMode == domain.TagMatchAny {
+		choices = append(choices, unionChoice(ix.byTag, q.tags))
+	}
+	if grams := trigrams(q.title); len(grams) > 0 && !q.fuzzy {
+		choices = append(choices, intersectChoice(ix.titleGrams, grams))
+	}
+	if grams := trigrams(q.author); len(grams) > 0 && !q.fuzzy {
+		choices = append(choices, intersectChoice(ix.authorGrams, grams))
+	}
+
+	if len(choices) == 0 {
+		return nil, false
+	}
+
+	best := choices[0]
+	for _, c := range choices[1:] {
+		if c.estimate < best.estimate {
+			best = c
+		}
+	}
+	return best.build(), true
+}
+
+// yearRange devuelve las posiciones [lo, hi) de ix.years dentro del rango.
+func (ix *bookIndex) yearRange(from, to int) (lo, hi int) {
+	lo, hi = 0, len(ix.years)
+	if from > 0 {
+		lo = sort.SearchInts(ix.years, from)
+	}
+	if to > 0 {
+		hi = sort.SearchInts(ix.years, to+1)
+	}
+	if hi < lo {
+		hi = lo
+	}
+	return lo, hi
+}
+
+// intersectChoice intersecta las listas de varias claves (trigramas de la
+// consulta o tags en modo "all"). El tamaño estimado es el de la más corta.
+func intersectChoice(index map[string]idSet, keys []string) indexChoice {
+	sets := make([]idSet, 0, len(keys))
+	for _, k := range keys {
+		sets = append(sets, index[k])
+	}
+	sort.Slice(sets, func(i, j int) bool { return len(sets[i]) < len(sets[j]) })
+
+	return indexChoice{len(sets[0]), func() idSet {
+		result := make(idSet, len(sets[0]))
+		for id := range sets[0] {
+			inAll := true
+			for _, other := range sets[1:] {
+				if _, ok := other[id]; !ok {
+					inAll = false
+					break
+				}
+			}
+			if inAll {
+				result[id] = struct{}{}
+			}
+		}
+		return result
+	}}
+}
+
+// unionChoice une las listas de varias claves (tags en modo "any").
+func unionChoice(index map[string]idSet, keys []string) indexChoice {
+	estimate := 0
+	for _, k := range keys {
+		estimate += len(index[k])
+	}
+
+	return indexChoice{estimate, func() idSet {
+		result := make(idSet, estimate)
+		for _, k := range keys {
+			for id := range index[k] {
+				result[id] = struct{}{}
+			}
+		}
+		return result
+	}}
+}
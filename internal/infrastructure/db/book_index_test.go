@@ -0,0 +1,184 @@
+package db
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/bookquery"
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/domain"
+)
+
+// scanIDs es la búsqueda SIN índices: verifica el filtro sobre todos los
+// libros indexados. El plan debe devolver exactamente lo mismo.
+func scanIDs(ix *bookIndex, filter domain.BookFilter) []domain.BookID {
+	q := newBookQuery(filter)
+	var ids []domain.BookID
+	for id, entry := range ix.entries {
+		if entry.matches(q) {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// scanBooks es SearchByFilters sin el plan: verifica el filtro sobre
+// todas las entradas y después ordena y clona igual que el repositorio.
+func scanBooks(r *InMemoryBookRepo, filter domain.BookFilter) []*domain.Book {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	q := newBookQuery(filter)
+	matched := make([]*domain.Book, 0)
+	for id, entry := range r.index.entries {
+		if entry.matches(q) {
+			matched = append(matched, r.books[id])
+		}
+	}
+
+	page := pageByKey(matched,
+		func(b *domain.Book) string { return domain.BookSortKey(b, filter.SortBy) },
+		func(b *domain.Book) int64 { return int64(b.ID()) },
+		filter.SortDesc, filter.After, filter.Limit)
+
+	result := make([]*domain.Book, 0, len(page))
+	for _, b := range page {
+		result = append(result, b.Clone())
+	}
+	return result
+}
+
+func mustParseQuery(t *testing.T, s string) bookquery.Expr {
+	t.Helper()
+	expr, err := bookquery.Parse(s)
+	if err != nil {
+		t.Fatalf("Parse(%q): %v", s, err)
+	}
+	return expr
+}
+
+func TestSearchPlanMatchesFullScan(t *testing.T) {
+	repo := syntheticRepo(t, 3000)
+
+	// Algunos libros archivados: ni el plan ni el recorrido los devuelven.
+	for id := domain.BookID(1); id <= 3000; id += 7 {
+		b, _ := repo.FindByID(id)
+		b.Archive()
+		if err := repo.Update(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	isbn := func(id int) string {
+		b, _ := repo.FindByID(domain.BookID(id))
+		return b.ISBN()
+	}
+
+	tests := []struct {
+		name    string
+		filter  domain.BookFilter
+		indexed bool // el plan debe usar un índice
+	}{
+		{"ISBN", domain.BookFilter{ISBN: isbn(42)}, true},
+		{"ISBN sin guiones ni espacios", domain.BookFilter{ISBN: " 978000000041 "}, true},
+		{"ISBN archivado", domain.BookFilter{ISBN: isbn(8)}, true},
+		{"ISBN inexistente", domain.BookFilter{ISBN: "123"}, true},
+		{"categoría", domain.BookFilter{CategoryTI: "Redes"}, true},
+		{"categoría plegada", domain.BookFilter{CategoryTI: "  BASES DE DATOS "}, true},
+		{"rango de años", domain.BookFilter{YearFrom: 1990, YearTo: 1999}, true},
+		{"solo desde", domain.BookFilter{YearFrom: 2020}, true},
+		{"solo hasta", domain.BookFilter{YearTo: 1975}, true},
+		{"rango vacío", domain.BookFilter{YearFrom: 2100}, true},
+		{"rango invertido", domain.BookFilter{YearFrom: 2000, YearTo: 1990}, true},
+		{"tags AND", domain.BookFilter{Tags: []string{"go", "concurrencia"}}, true},
+		{"tags AND sin libros", domain.BookFilter{Tags: []string{"go", "no-existe"}}, true},
+		{"tags OR", domain.BookFilter{Tags: []string{"kernel", "Linux"}, TagMode: domain.TagMatchAny}, true},
+		{"tags NONE", domain.BookFilter{Tags: []string{"tcp", "web"}, TagMode: domain.TagMatchNone}, false},
+		{"trigramas del título", domain.BookFilter{TitleContains: "compilad"}, true},
+		{"título con acentos", domain.BookFilter{TitleContains: "PROGRAMACION de"}, true},
+		{"título de dos letras", domain.BookFilter{TitleContains: "de"}, false},
+		{"trigramas del autor", domain.BookFilter{AuthorContains: "tanen"}, true},
+		{"autor con eñe", domain.BookFilter{AuthorContains: "nunez"}, true},
+		{"fuzzy sin trigramas", domain.BookFilter{TitleContains: "compiladres", Fuzzy: true}, false},
+		{"categoría + años + tags OR", domain.BookFilter{
+			CategoryTI: "Sistemas", YearFrom: 2000, YearTo: 2010,
+			Tags: []string{"kernel", "linux"}, TagMode: domain.TagMatchAny,
+		}, true},
+		{"título + autor", domain.BookFilter{TitleContains: "redes", AuthorContains: "kurose"}, true},
+		{"consulta", domain.BookFilter{Query: mustParseQuery(t, `tag:go OR (author:knuth -year>=1990)`)}, false},
+		{"consulta + categoría", domain.BookFilter{CategoryTI: "IA", Query: mustParseQuery(t, `aprendizaje OR tag:ml`)}, true},
+		{"sin filtro", domain.BookFilter{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, useIndex := repo.index.plan(newBookQuery(tt.filter)); useIndex != tt.indexed {
+				t.Errorf("plan usó índice = %v, se esperaba %v", useIndex, tt.indexed)
+			}
+
+			got := repo.index.search(tt.filter)
+			slices.Sort(got)
+			want := scanIDs(repo.index, tt.filter)
+			if !slices.Equal(got, want) {
+				t.Fatalf("el plan devolvió %d libros y el recorrido %d", len(got), len(want))
+			}
+		})
+	}
+}
+
+// El plan elige el índice más selectivo: con un ISBN, los candidatos
+// son ese libro aunque también haya categoría y años.
+func TestSearchPlanPicksMostSelectiveIndex(t *testing.T) {
+	repo := syntheticRepo(t, 3000)
+	b, _ := repo.FindByID(42)
+
+	q := newBookQuery(domain.BookFilter{ISBN: b.ISBN(), CategoryTI: b.CategoryTI(), YearFrom: 1970})
+	candidates, useIndex := repo.index.plan(q)
+	if !useIndex || len(candidates) != 1 {
+		t.Fatalf("candidatos = %d (índice %v), se esperaba solo el del ISBN", len(candidates), useIndex)
+	}
+	if _, ok := candidates[42]; !ok {
+		t.Fatalf("el candidato no es el libro del ISBN: %v", candidates)
+	}
+}
+
+/*
+   ----------------------------------------------------------
+   Benchmarks: búsqueda con índices vs. recorrido completo
+   ----------------------------------------------------------
+
+	go test -run=^$ -bench=SearchByFilters ./internal/infrastructure/db
+*/
+
+func BenchmarkSearchByFilters(b *testing.B) {
+	const size = 200_000
+	repo := syntheticRepo(b, size)
+	isbn := func(id int) string {
+		book, _ := repo.FindByID(domain.BookID(id))
+		return book.ISBN()
+	}
+
+	filters := []struct {
+		name   string
+		filter domain.BookFilter
+	}{
+		{"isbn", domain.BookFilter{ISBN: isbn(size / 2)}},
+		{"titulo", domain.BookFilter{TitleContains: "compiladores de criptografía"}},
+		{"autor", domain.BookFilter{AuthorContains: "tanenbaum"}},
+		{"categoria+años", domain.BookFilter{CategoryTI: "redes", YearFrom: 2000, YearTo: 2004}},
+		{"tags-and", domain.BookFilter{Tags: []string{"go", "concurrencia", "testing"}}},
+		{"tags-or", domain.BookFilter{Tags: []string{"kernel", "linux"}, TagMode: domain.TagMatchAny}},
+	}
+	for _, f := range filters {
+		b.Run(f.name+"/indexado", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := repo.SearchByFilters(f.filter); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(f.name+"/recorrido", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				scanBooks(repo, f.filter)
+			}
+		})
+	}
+}
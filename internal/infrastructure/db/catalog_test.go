@@ -0,0 +1,76 @@
+package db
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/domain"
+)
+
+/*
+   ==========================================================
+   CATÁLOGO SINTÉTICO PARA PRUEBAS Y BENCHMARKS
+   ==========================================================
+
+   Genera libros con títulos, autores, categorías, años y tags
+   armados con listas fijas de palabras y una semilla fija: dos
+   llamadas con el mismo n generan el mismo catálogo.
+*/
+
+var (
+	catalogTitleWords = [...]string{
+		"redes", "sistemas", "operativos", "programación", "bases", "datos", "seguridad",
+		"algoritmos", "estructuras", "compiladores", "inteligencia", "artificial", "distribuidos",
+		"computadoras", "análisis", "diseño", "ingeniería", "software", "criptografía", "teoría",
+		"concurrencia", "lenguajes", "arquitectura", "nube", "móviles", "gráficos", "aprendizaje",
+	}
+	catalogAuthors = [...]string{
+		"Andrew Tanenbaum", "Abraham Silberschatz", "Donald Knuth", "Alan Donovan", "Brian Kernighan",
+		"Gabriel Baca Urbina", "Niklaus Wirth", "Edsger Dijkstra", "Bjarne Stroustrup", "Robert Martin",
+		"Martin Fowler", "Ian Sommerville", "William Stallings", "Thomas Cormen", "Peter Norvig",
+		"Ramez Elmasri", "James Kurose", "Ross Anderson", "Alfred Aho", "Patricia Núñez",
+	}
+	catalogCategories = [...]string{
+		"Redes", "Sistemas", "Programación", "Bases de Datos", "Seguridad", "Algoritmos", "IA",
+	}
+	catalogTags = [...]string{
+		"tcp", "kernel", "go", "sql", "criptografía", "grafos", "compiladores", "concurrencia",
+		"linux", "web", "nube", "testing", "patrones", "ml", "clásico", "introductorio",
+	}
+)
+
+// syntheticBooks genera n libros válidos (sin guardar).
+func syntheticBooks(tb testing.TB, n int) []*domain.Book {
+	tb.Helper()
+	rng := rand.New(rand.NewSource(42))
+	pick := func(words []string) string { return words[rng.Intn(len(words))] }
+
+	books := make([]*domain.Book, 0, n)
+	for i := 0; i < n; i++ {
+		title := fmt.Sprintf("%s de %s %d", pick(catalogTitleWords[:]), pick(catalogTitleWords[:]), i)
+		tags := make([]string, 0, 3)
+		for t := rng.Intn(4); t > 0; t-- {
+			tags = append(tags, pick(catalogTags[:]))
+		}
+		b, err := domain.NewBook(title, pick(catalogAuthors[:]), 1970+rng.Intn(56),
+			fmt.Sprintf("978-%09d", i), pick(catalogCategories[:]), tags)
+		if err != nil {
+			tb.Fatalf("NewBook: %v", err)
+		}
+		books = append(books, b)
+	}
+	return books
+}
+
+// syntheticRepo guarda n libros sintéticos en un InMemoryBookRepo.
+func syntheticRepo(tb testing.TB, n int) *InMemoryBookRepo {
+	tb.Helper()
+	repo := NewInMemoryBookRepo()
+	for _, b := range syntheticBooks(tb, n) {
+		if err := repo.Create(b); err != nil {
+			tb.Fatalf("Create: %v", err)
+		}
+	}
+	return repo
+}
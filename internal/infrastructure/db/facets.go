@@ -0,0 +1,81 @@
+package db
+
+import (
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/domain"
+)
+
+/*
+   ==========================================================
+   CONTEO DE FACETAS EN MEMORIA
+   ==========================================================
+
+   facetCounter recorre los libros de un resultado y cuenta por
+   categoría, año y tag. Lo usa InMemoryBookRepo, y SQLBookRepo
+   cuando la búsqueda es Fuzzy (SQL no puede filtrar con errores
+   de tipeo, ver sql_repo.go).
+*/
+
+// facetValue es un valor contado, con la escritura que se muestra.
+type facetValue struct {
+	display string
+	count   int
+}
+
+// facetCounter acumula las facetas de varios libros.
+type facetCounter struct {
+	categories map[string]*facetValue // categoría plegada → valor
+	tags       map[string]*facetValue // tag normalizado → valor
+	years      map[int]int
+}
+
+func newFacetCounter() *facetCounter {
+	return &facetCounter{
+		categories: make(map[string]*facetValue),
+		tags:       make(map[string]*facetValue),
+		years:      make(map[int]int),
+	}
+}
+
+// countValue suma 1 al valor de la clave (lo crea si hace falta).
+func countValue(m map[string]*facetValue, key, display string) {
+	v, ok := m[key]
+	if !ok {
+		v = &facetValue{display: display}
+		m[key] = v
+	}
+	v.count++
+}
+
+// add cuenta un libro. Los tags repetidos en un mismo libro cuentan una vez.
+func (c *facetCounter) add(b *domain.Book) {
+	countValue(c.categories, normalizeKey(b.CategoryTI()), b.CategoryTI())
+	c.years[b.Year()]++
+
+	seen := make(map[string]bool, len(b.Tags()))
+	for _, tag := range b.Tags() {
+		key := domain.NormalizeTag(tag)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		countValue(c.tags, key, tag)
+	}
+}
+
+// facets devuelve el resultado ordenado (ver domain.BookFacets).
+func (c *facetCounter) facets() domain.BookFacets {
+	var f domain.BookFacets
+	f.Categories = toFacetCounts(c.categories)
+	f.Tags = toFacetCounts(c.tags)
+	f.Decades, f.Years = domain.YearFacets(c.years)
+	return f
+}
+
+// toFacetCounts convierte el MAP de valores en una slice ordenada.
+func toFacetCounts(m map[string]*facetValue) []domain.FacetCount {
+	counts := make([]domain.FacetCount, 0, len(m))
+	for _, v := range m {
+		counts = append(counts, domain.FacetCount{Value: v.display, Count: v.count})
+	}
+	return domain.SortFacetCounts(counts)
+}
@@ -0,0 +1,166 @@
+package db
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+/*
+   ==========================================================
+   REPOSITORIOS PERSISTENTES EN ARCHIVOS
+   ==========================================================
+
+   Estos repositorios REUTILIZAN los repositorios en memoria
+   (los "envuelven") y guardan un snapshot atómico en la
+   carpeta de datos en cada cambio:
+
+   - users.json          → FileUserRepo
+   - books.json          → FileBookRepo
+   - access_events.json  → FileAccessLogRepo
+
+   El snapshot con el cambio YA incluido se escribe ANTES de
+   tocar los mapas en memoria (es el journal del repositorio,
+   igual que el WAL de wal.go pero sin log). Si la escritura
+   falla, la memoria queda como estaba: el registro no existe,
+   no consume un ID y la versión no cambia.
+
+   Las lecturas (FindByID, ListAll, etc.) se resuelven en
+   memoria, igual que antes. Al arrancar se carga el snapshot
+   y se restaura la secuencia de IDs.
+*/
+
+// Nombres de archivo dentro de la carpeta de datos.
+const (
+	usersSnapshotFile        = "users.json"
+	booksSnapshotFile        = "books.json"
+	accessEventsSnapshotFile = "access_events.json"
+)
+
+// ensureDataDir crea la carpeta de datos si no existe.
+func ensureDataDir(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("no se pudo crear la carpeta de datos %s: %w", dir, err)
+	}
+	return nil
+}
+
+/*
+   ==========================================================
+   FileUserRepo
+   ==========================================================
+*/
+
+// FileUserRepo implementa domain.UserRepository con persistencia en disco.
+type FileUserRepo struct {
+	*InMemoryUserRepo
+	path string
+}
+
+// NewFileUserRepo abre (o crea) el repositorio de usuarios en dir.
+func NewFileUserRepo(dir string) (*FileUserRepo, error) {
+	if err := ensureDataDir(dir); err != nil {
+		return nil, err
+	}
+
+	repo := &FileUserRepo{
+		InMemoryUserRepo: NewInMemoryUserRepo(),
+		path:             filepath.Join(dir, usersSnapshotFile),
+	}
+
+	var snap userSnapshot
+	found, err := readSnapshot(repo.path, &snap)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		repo.InMemoryUserRepo.restore(snap)
+	}
+
+	mem := repo.InMemoryUserRepo
+	mem.journal = &journal{
+		snapshotPath: repo.path,
+		snapshotWith: func(data any) any { return mem.snapshotLocked().with(data.(userRecord)) },
+	}
+	return repo, nil
+}
+
+/*
+   ==========================================================
+   FileBookRepo
+   ==========================================================
+*/
+
+// FileBookRepo implementa domain.BookRepository con persistencia en disco.
+type FileBookRepo struct {
+	*InMemoryBookRepo
+	path string
+}
+
+// NewFileBookRepo abre (o crea) el repositorio de libros en dir.
+func NewFileBookRepo(dir string) (*FileBookRepo, error) {
+	if err := ensureDataDir(dir); err != nil {
+		return nil, err
+	}
+
+	repo := &FileBookRepo{
+		InMemoryBookRepo: NewInMemoryBookRepo(),
+		path:             filepath.Join(dir, booksSnapshotFile),
+	}
+
+	var snap bookSnapshot
+	found, err := readSnapshot(repo.path, &snap)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		repo.InMemoryBookRepo.restore(snap)
+	}
+
+	mem := repo.InMemoryBookRepo
+	mem.journal = &journal{
+		snapshotPath: repo.path,
+		snapshotWith: func(data any) any { return mem.snapshotLocked().with(data.(bookRecord)) },
+	}
+	return repo, nil
+}
+
+/*
+   ==========================================================
+   FileAccessLogRepo
+   ==========================================================
+*/
+
+// FileAccessLogRepo implementa domain.AccessLogRepository con persistencia en disco.
+type FileAccessLogRepo struct {
+	*InMemoryAccessLogRepo
+	path string
+}
+
+// NewFileAccessLogRepo abre (o crea) el repositorio de accesos en dir.
+func NewFileAccessLogRepo(dir string) (*FileAccessLogRepo, error) {
+	if err := ensureDataDir(dir); err != nil {
+		return nil, err
+	}
+
+	repo := &FileAccessLogRepo{
+		InMemoryAccessLogRepo: NewInMemoryAccessLogRepo(),
+		path:                  filepath.Join(dir, accessEventsSnapshotFile),
+	}
+
+	var snap accessEventSnapshot
+	found, err := readSnapshot(repo.path, &snap)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		repo.InMemoryAccessLogRepo.restore(snap)
+	}
+
+	mem := repo.InMemoryAccessLogRepo
+	mem.journal = &journal{
+		snapshotPath: repo.path,
+		snapshotWith: func(data any) any { return mem.snapshotLocked().with(data.(accessEventRecord)) },
+	}
+	return repo, nil
+}
@@ -0,0 +1,237 @@
+package db
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/domain"
+)
+
+// tempFiles lista los temporales de snapshot que quedaron en dir.
+func tempFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	matches, err := filepath.Glob(filepath.Join(dir, ".*.tmp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return matches
+}
+
+func TestFileReposRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	users, err := NewFileUserRepo(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	books, err := NewFileBookRepo(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	access, err := NewFileAccessLogRepo(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ana, _ := domain.NewUser("Ana", "ana@example.com", domain.RoleReader)
+	beto, _ := domain.NewUser("Beto", "beto@example.com", domain.RoleAdmin)
+	for _, u := range []*domain.User{ana, beto} {
+		if err := users.Create(u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ana.ApplyChanges("Ana María", "ana.maria@example.com", domain.RoleReader); err != nil {
+		t.Fatal(err)
+	}
+	if err := users.Update(ana); err != nil {
+		t.Fatal(err)
+	}
+
+	redes, _ := domain.NewBook("Redes", "Tanenbaum", 2012, "978-1", "Redes", []string{"tcp", "clásico"})
+	so, _ := domain.NewBook("Sistemas Operativos", "Tanenbaum", 2009, "978-2", "Sistemas", nil)
+	for _, b := range []*domain.Book{redes, so} {
+		if err := books.Create(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	so.Archive()
+	if err := books.Update(so); err != nil {
+		t.Fatal(err)
+	}
+
+	ts := time.Date(2026, 3, 10, 9, 30, 0, 0, time.UTC)
+	ev := domain.RestoreAccessEvent(0, redes.ID(), ana.ID(), domain.AccessTypeLectura, ts)
+	if err := access.Store(ev); err != nil {
+		t.Fatal(err)
+	}
+
+	if leftover := tempFiles(t, dir); len(leftover) != 0 {
+		t.Fatalf("quedaron temporales: %v", leftover)
+	}
+
+	// Reabrir desde los snapshots.
+	users, _ = NewFileUserRepo(dir)
+	books, _ = NewFileBookRepo(dir)
+	access, _ = NewFileAccessLogRepo(dir)
+
+	u, err := users.FindByEmail("ana.maria@example.com")
+	if err != nil || u.ID() != ana.ID() || u.Name() != "Ana María" || u.Version() != 2 {
+		t.Fatalf("usuario restaurado = %+v, %v", u, err)
+	}
+	if old, _ := users.FindByEmail("ana@example.com"); old != nil {
+		t.Fatal("el email viejo no debería encontrarse")
+	}
+
+	b, err := books.FindByID(redes.ID())
+	if err != nil || !slices.Equal(b.Tags(), []string{"tcp", "clásico"}) || !b.Active() {
+		t.Fatalf("libro restaurado = %+v, %v", b, err)
+	}
+	if b, _ := books.FindByID(so.ID()); b.Active() || b.Version() != 2 {
+		t.Fatalf("el libro archivado volvió activo %v, versión %d", b.Active(), b.Version())
+	}
+	// Los índices se reconstruyen al restaurar.
+	if found, _ := books.SearchByFilters(domain.BookFilter{Tags: []string{"tcp"}}); len(found) != 1 {
+		t.Fatalf("búsqueda por tag después de reabrir: %d libros", len(found))
+	}
+
+	events, err := access.ListByBook(redes.ID())
+	if err != nil || len(events) != 1 || !events[0].Timestamp().Equal(ts) || events[0].UserID() != ana.ID() {
+		t.Fatalf("accesos restaurados = %v, %v", events, err)
+	}
+
+	// Las secuencias siguen donde quedaron.
+	carla, _ := domain.NewUser("Carla", "carla@example.com", domain.RoleReader)
+	if err := users.Create(carla); err != nil || carla.ID() != 3 {
+		t.Fatalf("siguiente usuario: ID %d, %v", carla.ID(), err)
+	}
+	nuevo, _ := domain.NewBook("Go", "Donovan", 2016, "978-3", "Programación", nil)
+	if err := books.Create(nuevo); err != nil || nuevo.ID() != 3 {
+		t.Fatalf("siguiente libro: ID %d, %v", nuevo.ID(), err)
+	}
+	ev2 := domain.RestoreAccessEvent(0, redes.ID(), ana.ID(), domain.AccessTypeDescarga, ts)
+	if err := access.Store(ev2); err != nil || ev2.ID() != 2 {
+		t.Fatalf("siguiente acceso: ID %d, %v", ev2.ID(), err)
+	}
+}
+
+// Un temporal que quedó de una escritura interrumpida no se lee ni
+// impide arrancar; la próxima escritura reemplaza el snapshot.
+func TestFileRepoIgnoresLeftoverTempFile(t *testing.T) {
+	dir := t.TempDir()
+	books, _ := NewFileBookRepo(dir)
+	b, _ := domain.NewBook("Redes", "Tanenbaum", 2012, "978-1", "Redes", nil)
+	if err := books.Create(b); err != nil {
+		t.Fatal(err)
+	}
+
+	leftover := filepath.Join(dir, "."+booksSnapshotFile+"-123.tmp")
+	if err := os.WriteFile(leftover, []byte(`{"seq": 99, "books": [`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	books, err := NewFileBookRepo(dir)
+	if err != nil {
+		t.Fatalf("el temporal impidió abrir el repositorio: %v", err)
+	}
+	all, _ := books.ListAll()
+	if len(all) != 1 || all[0].Title() != "Redes" {
+		t.Fatalf("libros = %v", all)
+	}
+	next, _ := domain.NewBook("Go", "Donovan", 2016, "978-3", "Programación", nil)
+	if err := books.Create(next); err != nil || next.ID() != 2 {
+		t.Fatalf("siguiente libro: ID %d, %v", next.ID(), err)
+	}
+}
+
+func TestWriteSnapshotAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "snap.json")
+
+	if err := writeSnapshotAtomic(path, bookSnapshot{Seq: 1}); err != nil {
+		t.Fatal(err)
+	}
+	// Un valor que no se puede serializar: falla sin tocar el snapshot
+	// anterior ni dejar el temporal.
+	if err := writeSnapshotAtomic(path, map[string]any{"x": make(chan int)}); err == nil {
+		t.Fatal("se esperaba un error al serializar")
+	}
+	var snap bookSnapshot
+	if found, err := readSnapshot(path, &snap); !found || err != nil || snap.Seq != 1 {
+		t.Fatalf("snapshot anterior = %+v, %v, %v", snap, found, err)
+	}
+	if leftover := tempFiles(t, dir); len(leftover) != 0 {
+		t.Fatalf("quedaron temporales: %v", leftover)
+	}
+
+	// Un snapshot a medias (escrito fuera de writeSnapshotAtomic) se informa.
+	if err := os.WriteFile(path, []byte(`{"seq": 1, "books": [`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readSnapshot(path, &snap); err == nil {
+		t.Fatal("un snapshot dañado debería dar error")
+	}
+}
+
+// El snapshot se escribe ANTES de tocar la memoria: si falla, el cambio
+// no queda visible, no consume un ID y la versión no cambia.
+func TestFileRepoWriteFailureLeavesMemoryUntouched(t *testing.T) {
+	dir := t.TempDir()
+	books, _ := NewFileBookRepo(dir)
+	access, _ := NewFileAccessLogRepo(dir)
+	redes, _ := domain.NewBook("Redes", "Tanenbaum", 2012, "978-1", "Redes", []string{"tcp"})
+	if err := books.Create(redes); err != nil {
+		t.Fatal(err)
+	}
+
+	// Sin carpeta no se puede crear el temporal: toda escritura falla.
+	broken := func(j *journal) func() {
+		saved := j.snapshotPath
+		j.snapshotPath = filepath.Join(dir, "no-existe", filepath.Base(saved))
+		return func() { j.snapshotPath = saved }
+	}
+
+	restore := broken(books.journal)
+	so, _ := domain.NewBook("Sistemas Operativos", "Tanenbaum", 2009, "978-2", "Sistemas", nil)
+	if err := books.Create(so); err == nil {
+		t.Fatal("Create debería fallar")
+	}
+	if so.ID() != 0 || so.Version() != 0 {
+		t.Fatalf("el libro rechazado quedó con ID %d y versión %d", so.ID(), so.Version())
+	}
+	edit := redes.Clone()
+	if err := edit.UpdateDetails("Redes 2", redes.Author(), 2013, redes.ISBN(), redes.CategoryTI(), []string{"udp"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := books.Update(edit); err == nil {
+		t.Fatal("Update debería fallar")
+	}
+	if all, _ := books.ListAll(); len(all) != 1 || all[0].Title() != "Redes" || all[0].Version() != 1 {
+		t.Fatalf("la memoria cambió: %v", all)
+	}
+	if found, _ := books.SearchByFilters(domain.BookFilter{Tags: []string{"udp"}}); len(found) != 0 {
+		t.Fatal("el índice tomó los tags de la edición fallida")
+	}
+	restore()
+
+	if err := books.Create(so); err != nil || so.ID() != 2 {
+		t.Fatalf("después del fallo, el ID siguiente es %d (%v), se esperaba 2", so.ID(), err)
+	}
+
+	restore = broken(access.journal)
+	ev := domain.RestoreAccessEvent(0, redes.ID(), 1, domain.AccessTypeLectura, time.Now())
+	if err := access.Store(ev); err == nil || ev.ID() != 0 {
+		t.Fatalf("Store: ID %d, %v; se esperaba un error sin ID", ev.ID(), err)
+	}
+	if events, _ := access.ListByBook(redes.ID()); len(events) != 0 {
+		t.Fatalf("el acceso rechazado quedó guardado: %v", events)
+	}
+	restore()
+
+	// Lo que quedó en disco es exactamente lo confirmado.
+	reopened, _ := NewFileBookRepo(dir)
+	if all, _ := reopened.ListAll(); len(all) != 2 || all[0].Title() != "Redes" {
+		t.Fatalf("en disco: %v", all)
+	}
+}
@@ -0,0 +1,205 @@
+package db
+
+import (
+	"strings"
+
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/textnorm"
+)
+
+/*
+   ==========================================================
+   BÚSQUEDA TOLERANTE A ERRORES DE TIPEO
+   ==========================================================
+
+   Los lectores escriben "Tanenbaun" en vez de "Tanenbaum".
+   Para tolerarlo se usa la DISTANCIA DE LEVENSHTEIN: cuántas
+   letras hay que insertar, borrar o cambiar para pasar de una
+   palabra a otra ("tanenbaun" → "tanenbaum" = 1).
+
+   La distancia se calcula ACOTADA: apenas se sabe que supera
+   el máximo permitido se deja de calcular. El máximo depende
+   del largo de la palabra buscada (maxEdits): las palabras
+   cortas deben escribirse bien.
+
+   Se usa en dos lugares:
+   - BookFilter.Fuzzy: título / autor aceptan palabras con
+     errores de tipeo (fuzzyContains).
+   - "¿Quisiste decir?": si una búsqueda no encuentra nada, se
+     corrige cada palabra con el vocabulario del catálogo
+     (vocabulary.correct).
+*/
+
+// maxEdits es la cantidad de errores tolerados según el largo de la palabra.
+func maxEdits(word string) int {
+	n := len([]rune(word))
+	switch {
+	case n <= 3:
+		return 0
+	case n <= 6:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// levenshtein devuelve la distancia entre a y b, o max+1 si es mayor que max.
+func levenshtein(a, b string, max int) int {
+	ra, rb := []rune(a), []rune(b)
+	if d := len(ra) - len(rb); d > max || -d > max {
+		return max + 1
+	}
+
+	// Solo se guardan dos filas de la tabla de programación dinámica.
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		rowMin := curr[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			rowMin = min(rowMin, curr[j])
+		}
+		if rowMin > max {
+			return max + 1 // ya no puede bajar: cortar
+		}
+		prev, curr = curr, prev
+	}
+
+	if prev[len(rb)] > max {
+		return max + 1
+	}
+	return prev[len(rb)]
+}
+
+/*
+fuzzyContains indica si el texto (ya plegado) contiene la consulta
+(ya plegada) tolerando errores: cada palabra de la consulta debe
+aparecer en el texto, ya sea como parte de una palabra o a una
+distancia de Levenshtein aceptable de alguna palabra del texto.
+*/
+func fuzzyContains(text, query string) bool {
+	if strings.Contains(text, query) {
+		return true
+	}
+
+	textWords := textnorm.Words(text)
+	for _, qw := range textnorm.Words(query) {
+		limit := maxEdits(qw.Text)
+		found := false
+		for _, tw := range textWords {
+			if strings.Contains(tw.Text, qw.Text) || levenshtein(qw.Text, tw.Text, limit) <= limit {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+   ==========================================================
+   VOCABULARIO ("¿Quisiste decir?")
+   ==========================================================
+*/
+
+// vocabWord es una palabra del catálogo con su forma original
+// (para mostrarla) y cuántas veces aparece.
+type vocabWord struct {
+	display string
+	count   int
+}
+
+// vocabulary es el MAP palabra plegada → datos de la palabra.
+type vocabulary map[string]*vocabWord
+
+// add suma las palabras de un texto al vocabulario.
+func (v vocabulary) add(text string) {
+	for _, w := range textnorm.Words(text) {
+		key := textnorm.Fold(w.Text)
+		if textnorm.IsStopword(key) {
+			continue
+		}
+		entry, ok := v[key]
+		if !ok {
+			entry = &vocabWord{display: w.Text}
+			v[key] = entry
+		}
+		entry.count++
+	}
+}
+
+// remove resta las palabras de un texto del vocabulario.
+func (v vocabulary) remove(text string) {
+	for _, w := range textnorm.Words(text) {
+		key := textnorm.Fold(w.Text)
+		entry, ok := v[key]
+		if !ok {
+			continue
+		}
+		entry.count--
+		if entry.count <= 0 {
+			delete(v, key)
+		}
+	}
+}
+
+/*
+correct corrige cada palabra de la consulta con la palabra más
+parecida del vocabulario (menor distancia; a igual distancia, la
+más frecuente). Devuelve false si no hubo nada que corregir.
+*/
+func (v vocabulary) correct(query string) (string, bool) {
+	words := textnorm.Words(query)
+	if len(words) == 0 {
+		return "", false
+	}
+
+	changed := false
+	result := make([]string, 0, len(words))
+	for _, w := range words {
+		key := textnorm.Fold(w.Text)
+		if _, known := v[key]; known || textnorm.IsStopword(key) {
+			result = append(result, w.Text)
+			continue
+		}
+
+		limit := maxEdits(key)
+		var (
+			best     *vocabWord
+			bestDist = limit + 1
+		)
+		for candidate, entry := range v {
+			d := levenshtein(key, candidate, limit)
+			if d > limit {
+				continue
+			}
+			if d < bestDist || (d == bestDist && (entry.count > best.count ||
+				(entry.count == best.count && entry.display < best.display))) {
+				best, bestDist = entry, d
+			}
+		}
+
+		if best == nil {
+			result = append(result, w.Text)
+			continue
+		}
+		result = append(result, best.display)
+		changed = true
+	}
+
+	if !changed {
+		return "", false
+	}
+	return strings.Join(result, " "), true
+}
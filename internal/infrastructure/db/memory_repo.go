@@ -1,11 +1,13 @@
 package db
 
 import (
-	"errors"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/jfmg0509/sistema_libros_funcional_go/internal/domain"
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/hll"
 )
 
 /*
@@ -16,9 +18,19 @@ import (
    Implementación EN MEMORIA de UserRepository usando MAPS.
 
    - users:      map[UserID]*User
-   - emailIndex: map[string]UserID (para buscar rápido por email)
+   - emailIndex: map[string]UserID (para buscar rápido por email,
+     en minúsculas: el email no distingue mayúsculas)
 
    Ideal para prácticas y prototipos sin base de datos real.
+
+   Opcionalmente puede tener un journal (WAL, ver wal.go): si
+   lo tiene, cada cambio se escribe primero en el log y luego
+   en los mapas.
+
+   Los repositorios en memoria guardan y devuelven COPIAS
+   (Clone) de las entidades: así un cambio solo llega al mapa
+   a través de Update, que además compara la versión guardada
+   con la del objeto (control de concurrencia optimista).
 */
 
 // InMemoryUserRepo implementa domain.UserRepository usando mapas en memoria.
@@ -27,6 +39,7 @@ type InMemoryUserRepo struct {
 	seq        domain.UserID // secuencia para generar IDs
 	users      map[domain.UserID]*domain.User
 	emailIndex map[string]domain.UserID
+	journal    *journal // nil si el repositorio es solo en memoria
 }
 
 // NewInMemoryUserRepo crea un repositorio vacío listo para usar.
@@ -37,6 +50,11 @@ func NewInMemoryUserRepo() *InMemoryUserRepo {
 	}
 }
 
+// emailKey es la clave de un email en emailIndex (sin mayúsculas).
+func emailKey(email string) string {
+	return strings.ToLower(email)
+}
+
 // nextID incrementa la secuencia y devuelve un nuevo ID de usuario.
 func (r *InMemoryUserRepo) nextID() domain.UserID {
 	r.seq++
@@ -49,41 +67,68 @@ func (r *InMemoryUserRepo) Create(user *domain.User) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if _, exists := r.emailIndex[user.Email()]; exists {
-		return errors.New("ya existe un usuario con ese email")
+	if _, exists := r.emailIndex[emailKey(user.Email())]; exists {
+		return domain.Conflict("ya existe un usuario con ese email")
 	}
 
 	id := r.nextID()
 	user.SetID(id)
+	user.SetVersion(1)
+
+	// Primero al WAL; si falla, deshacemos la asignación del ID.
+	if err := r.journal.record(walOpCreate, newUserRecord(user)); err != nil {
+		r.seq--
+		user.SetID(0)
+		user.SetVersion(0)
+		return err
+	}
 
-	r.users[id] = user
-	r.emailIndex[user.Email()] = id
+	r.users[id] = user.Clone()
+	r.emailIndex[emailKey(user.Email())] = id
+	r.journal.afterWrite(func() any { return r.snapshotLocked() })
 	return nil
 }
 
 // Update actualiza un usuario ya existente.
+// Si la versión del usuario no coincide con la guardada, alguien lo
+// modificó desde que se leyó: se rechaza con ErrVersionConflict.
 func (r *InMemoryUserRepo) Update(user *domain.User) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
 	if user.ID() == 0 {
-		return errors.New("el usuario no tiene ID asignado")
+		return domain.NewValidationError("id", "el usuario no tiene ID asignado")
+	}
+
+	stored, exists := r.users[user.ID()]
+	if !exists {
+		return domain.NotFound("no existe un usuario con ese ID")
+	}
+	if stored.Version() != user.Version() {
+		return domain.VersionConflict("el usuario fue modificado por otra operación")
+	}
+
+	// El email nuevo no puede ser el de OTRO usuario (igual que en Create).
+	if owner, exists := r.emailIndex[emailKey(user.Email())]; exists && owner != user.ID() {
+		return domain.Conflict("ya existe un usuario con ese email")
 	}
 
-	if _, exists := r.users[user.ID()]; !exists {
-		return errors.New("no existe un usuario con ese ID")
+	next := user.Clone()
+	next.SetVersion(user.Version() + 1)
+
+	if err := r.journal.record(walOpUpdate, newUserRecord(next)); err != nil {
+		return err
 	}
 
 	// Actualizar el índice de email si cambió el correo.
-	for email, id := range r.emailIndex {
-		if id == user.ID() && email != user.Email() {
-			delete(r.emailIndex, email)
-			r.emailIndex[user.Email()] = user.ID()
-			break
-		}
+	if stored.Email() != next.Email() {
+		delete(r.emailIndex, emailKey(stored.Email()))
+		r.emailIndex[emailKey(next.Email())] = next.ID()
 	}
 
-	r.users[user.ID()] = user
+	r.users[next.ID()] = next
+	user.SetVersion(next.Version())
+	r.journal.afterWrite(func() any { return r.snapshotLocked() })
 	return nil
 }
 
@@ -96,7 +141,7 @@ func (r *InMemoryUserRepo) FindByID(id domain.UserID) (*domain.User, error) {
 	if !ok {
 		return nil, nil
 	}
-	return user, nil
+	return user.Clone(), nil
 }
 
 // FindByEmail busca un usuario por su email usando el índice.
@@ -104,7 +149,7 @@ func (r *InMemoryUserRepo) FindByEmail(email string) (*domain.User, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	id, ok := r.emailIndex[strings.ToLower(email)]
+	id, ok := r.emailIndex[emailKey(email)]
 	if !ok {
 		return nil, nil
 	}
@@ -112,17 +157,40 @@ func (r *InMemoryUserRepo) FindByEmail(email string) (*domain.User, error) {
 	if !ok {
 		return nil, nil
 	}
-	return user, nil
+	return user.Clone(), nil
 }
 
-// ListAll devuelve todos los usuarios en una slice.
+// ListAll devuelve todos los usuarios ordenados por ID.
 func (r *InMemoryUserRepo) ListAll() ([]*domain.User, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	result := make([]*domain.User, 0, len(r.users))
 	for _, u := range r.users {
-		result = append(result, u)
+		result = append(result, u.Clone())
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID() < result[j].ID() })
+	return result, nil
+}
+
+// Search devuelve una página de usuarios en el orden pedido.
+func (r *InMemoryUserRepo) Search(query domain.UserQuery) ([]*domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	all := make([]*domain.User, 0, len(r.users))
+	for _, u := range r.users {
+		all = append(all, u)
+	}
+
+	page := pageByKey(all,
+		func(u *domain.User) string { return domain.UserSortKey(u, query.SortBy) },
+		func(u *domain.User) int64 { return int64(u.ID()) },
+		query.SortDesc, query.After, query.Limit)
+
+	result := make([]*domain.User, 0, len(page))
+	for _, u := range page {
+		result = append(result, u.Clone())
 	}
 	return result, nil
 }
@@ -134,16 +202,20 @@ func (r *InMemoryUserRepo) ListAll() ([]*domain.User, error) {
 */
 
 // InMemoryBookRepo implementa BookRepository usando mapas en memoria.
+// Las búsquedas usan los índices secundarios de book_index.go.
 type InMemoryBookRepo struct {
-	mu    sync.RWMutex
-	seq   domain.BookID
-	books map[domain.BookID]*domain.Book
+	mu      sync.RWMutex
+	seq     domain.BookID
+	books   map[domain.BookID]*domain.Book
+	index   *bookIndex
+	journal *journal
 }
 
 // NewInMemoryBookRepo crea un repositorio de libros en memoria.
 func NewInMemoryBookRepo() *InMemoryBookRepo {
 	return &InMemoryBookRepo{
 		books: make(map[domain.BookID]*domain.Book),
+		index: newBookIndex(),
 	}
 }
 
@@ -160,23 +232,48 @@ func (r *InMemoryBookRepo) Create(book *domain.Book) error {
 
 	id := r.nextID()
 	book.SetID(id)
-	r.books[id] = book
+	book.SetVersion(1)
+
+	if err := r.journal.record(walOpCreate, newBookRecord(book)); err != nil {
+		r.seq--
+		book.SetID(0)
+		book.SetVersion(0)
+		return err
+	}
+
+	r.books[id] = book.Clone()
+	r.index.put(book)
+	r.journal.afterWrite(func() any { return r.snapshotLocked() })
 	return nil
 }
 
-// Update actualiza un libro existente.
+// Update actualiza un libro existente, rechazando versiones desactualizadas.
 func (r *InMemoryBookRepo) Update(book *domain.Book) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
 	if book.ID() == 0 {
-		return errors.New("el libro no tiene ID asignado")
+		return domain.NewValidationError("id", "el libro no tiene ID asignado")
 	}
-	if _, exists := r.books[book.ID()]; !exists {
-		return errors.New("no existe un libro con ese ID")
+	stored, exists := r.books[book.ID()]
+	if !exists {
+		return domain.NotFound("no existe un libro con ese ID")
 	}
+	if stored.Version() != book.Version() {
+		return domain.VersionConflict("el libro fue modificado por otra operación")
+	}
+
+	next := book.Clone()
+	next.SetVersion(book.Version() + 1)
 
-	r.books[book.ID()] = book
+	if err := r.journal.record(walOpUpdate, newBookRecord(next)); err != nil {
+		return err
+	}
+
+	r.books[next.ID()] = next
+	r.index.put(next)
+	book.SetVersion(next.Version())
+	r.journal.afterWrite(func() any { return r.snapshotLocked() })
 	return nil
 }
 
@@ -189,66 +286,79 @@ func (r *InMemoryBookRepo) FindByID(id domain.BookID) (*domain.Book, error) {
 	if !ok {
 		return nil, nil
 	}
-	return book, nil
+	return book.Clone(), nil
 }
 
-// SearchByFilters aplica filtros básicos sobre todos los libros.
+// SearchByFilters devuelve los libros activos que cumplen el filtro.
+// El índice elige los candidatos y verifica las condiciones.
 func (r *InMemoryBookRepo) SearchByFilters(filter domain.BookFilter) ([]*domain.Book, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	result := make([]*domain.Book, 0)
-	for _, b := range r.books {
-		// Solo libros activos.
-		if !b.Active() {
-			continue
-		}
-
-		// Filtro por título.
-		if filter.TitleContains != "" &&
-			!strings.Contains(strings.ToLower(b.Title()), strings.ToLower(filter.TitleContains)) {
-			continue
-		}
+	ids := r.index.search(filter)
 
-		// Filtro por autor.
-		if filter.AuthorContains != "" &&
-			!strings.Contains(strings.ToLower(b.Author()), strings.ToLower(filter.AuthorContains)) {
-			continue
-		}
+	matched := make([]*domain.Book, 0, len(ids))
+	for _, id := range ids {
+		matched = append(matched, r.books[id])
+	}
 
-		// Filtro por categoría TI.
-		if filter.CategoryTI != "" &&
-			!strings.EqualFold(b.CategoryTI(), filter.CategoryTI) {
-			continue
-		}
+	// Orden determinista, cursor y límite; se clonan solo los de la página.
+	page := pageByKey(matched,
+		func(b *domain.Book) string { return domain.BookSortKey(b, filter.SortBy) },
+		func(b *domain.Book) int64 { return int64(b.ID()) },
+		filter.SortDesc, filter.After, filter.Limit)
 
-		// Filtro por año desde.
-		if filter.YearFrom > 0 && b.Year() < filter.YearFrom {
-			continue
-		}
+	result := make([]*domain.Book, 0, len(page))
+	for _, b := range page {
+		result = append(result, b.Clone())
+	}
+	return result, nil
+}
 
-		// Filtro por año hasta.
-		if filter.YearTo > 0 && b.Year() > filter.YearTo {
-			continue
-		}
+// Facets cuenta por categoría, año y tag los libros activos que cumplen
+// el filtro. Se recorren en orden de ID para que la escritura mostrada
+// de cada categoría o tag sea siempre la misma.
+func (r *InMemoryBookRepo) Facets(filter domain.BookFilter) (domain.BookFacets, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 
-		// (Opcional) Filtro por tags: aquí podrías validar si contiene ciertos tags.
+	ids := r.index.search(filter)
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
 
-		result = append(result, b)
+	counter := newFacetCounter()
+	for _, id := range ids {
+		counter.add(r.books[id])
 	}
+	return counter.facets(), nil
+}
 
-	return result, nil
+// SuggestSpelling corrige título y autor del filtro con el vocabulario
+// de los libros activos (ver fuzzy.go).
+func (r *InMemoryBookRepo) SuggestSpelling(filter domain.BookFilter) (domain.SpellingSuggestion, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.index.suggest(filter), nil
+}
+
+// SuggestPrefix completa títulos, autores y tags con el trie del índice.
+func (r *InMemoryBookRepo) SuggestPrefix(prefix string, limit int, rank domain.SuggestionRank) ([]domain.Suggestion, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.index.completions.lookup(prefix, limit, rank), nil
 }
 
-// ListAll devuelve todos los libros.
+// ListAll devuelve todos los libros ordenados por ID.
 func (r *InMemoryBookRepo) ListAll() ([]*domain.Book, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	result := make([]*domain.Book, 0, len(r.books))
 	for _, b := range r.books {
-		result = append(result, b)
+		result = append(result, b.Clone())
 	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID() < result[j].ID() })
 	return result, nil
 }
 
@@ -260,15 +370,18 @@ func (r *InMemoryBookRepo) ListAll() ([]*domain.Book, error) {
 
 // InMemoryAccessLogRepo implementa AccessLogRepository en memoria.
 type InMemoryAccessLogRepo struct {
-	mu     sync.RWMutex
-	seq    domain.AccessEventID
-	events map[domain.AccessEventID]*domain.AccessEvent
+	mu      sync.RWMutex
+	seq     domain.AccessEventID
+	events  map[domain.AccessEventID]*domain.AccessEvent
+	readers readerSketches // lectores distintos por libro y día (ver reader_sketches.go)
+	journal *journal
 }
 
 // NewInMemoryAccessLogRepo crea un repositorio de accesos vacío.
 func NewInMemoryAccessLogRepo() *InMemoryAccessLogRepo {
 	return &InMemoryAccessLogRepo{
-		events: make(map[domain.AccessEventID]*domain.AccessEvent),
+		events:  make(map[domain.AccessEventID]*domain.AccessEvent),
+		readers: make(readerSketches),
 	}
 }
 
@@ -285,11 +398,21 @@ func (r *InMemoryAccessLogRepo) Store(event *domain.AccessEvent) error {
 
 	id := r.nextID()
 	event.SetID(id)
+
+	if err := r.journal.record(walOpStore, newAccessEventRecord(event)); err != nil {
+		r.seq--
+		event.SetID(0)
+		return err
+	}
+
 	r.events[id] = event
+	r.readers.add(event)
+	r.journal.afterWrite(func() any { return r.snapshotLocked() })
 	return nil
 }
 
-// ListByBook devuelve todos los eventos para un libro.
+// ListByBook devuelve todos los eventos de un libro en orden
+// cronológico (el ID desempata), igual que SQLAccessLogRepo.
 func (r *InMemoryAccessLogRepo) ListByBook(bookID domain.BookID) ([]*domain.AccessEvent, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -300,10 +423,74 @@ func (r *InMemoryAccessLogRepo) ListByBook(bookID domain.BookID) ([]*domain.Acce
 			result = append(result, ev)
 		}
 	}
+	sortEventsByTime(result)
 	return result, nil
 }
 
-// ListByUser devuelve todos los eventos para un usuario (no lo usamos aún, pero está listo).
+// ListAll devuelve todos los eventos ordenados por ID.
+func (r *InMemoryAccessLogRepo) ListAll() ([]*domain.AccessEvent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]*domain.AccessEvent, 0, len(r.events))
+	for _, ev := range r.events {
+		result = append(result, ev)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID() < result[j].ID() })
+	return result, nil
+}
+
+// ListBetween devuelve los eventos con from <= timestamp < to,
+// ordenados por fecha (el ID desempata).
+func (r *InMemoryAccessLogRepo) ListBetween(from, to time.Time) ([]*domain.AccessEvent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]*domain.AccessEvent, 0)
+	for _, ev := range r.events {
+		ts := ev.Timestamp()
+		if !ts.Before(from) && ts.Before(to) {
+			result = append(result, ev)
+		}
+	}
+	sortEventsByTime(result)
+	return result, nil
+}
+
+// sortEventsByTime ordena eventos por fecha (el ID desempata).
+func sortEventsByTime(events []*domain.AccessEvent) {
+	sort.Slice(events, func(i, j int) bool {
+		ti, tj := events[i].Timestamp(), events[j].Timestamp()
+		if !ti.Equal(tj) {
+			return ti.Before(tj)
+		}
+		return events[i].ID() < events[j].ID()
+	})
+}
+
+// ReaderSketches combina los sketches de lectores de los libros pedidos
+// (vacío = todos) para cada día UTC que toca [from, to).
+func (r *InMemoryAccessLogRepo) ReaderSketches(bookIDs []domain.BookID, from, to time.Time) (map[string]*hll.Sketch, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.readers.byDay(bookIDs, from, to)
+}
+
+// CountByBook devuelve cuántos accesos tiene cada libro.
+func (r *InMemoryAccessLogRepo) CountByBook() (map[domain.BookID]int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	counts := make(map[domain.BookID]int)
+	for _, ev := range r.events {
+		counts[ev.BookID()]++
+	}
+	return counts, nil
+}
+
+// ListByUser devuelve todos los eventos de un usuario en orden
+// cronológico (el ID desempata), igual que SQLAccessLogRepo.
+// Lo usa el historial del usuario (usecase.HistoryService).
 func (r *InMemoryAccessLogRepo) ListByUser(userID domain.UserID) ([]*domain.AccessEvent, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -314,5 +501,6 @@ func (r *InMemoryAccessLogRepo) ListByUser(userID domain.UserID) ([]*domain.Acce
 			result = append(result, ev)
 		}
 	}
+	sortEventsByTime(result)
 	return result, nil
 }
@@ -0,0 +1,65 @@
+package db
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/domain"
+)
+
+// ListByBook y ListByUser devuelven el mismo orden que el repositorio
+// SQL (ORDER BY timestamp, id), aunque los eventos se guarden
+// desordenados y con horarios repetidos.
+func TestInMemoryAccessLogOrder(t *testing.T) {
+	access := NewInMemoryAccessLogRepo()
+	base := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	// Horas en orden inverso y de a pares iguales: el ID desempata.
+	var stored []*domain.AccessEvent
+	for i := 0; i < 40; i++ {
+		ts := base.Add(time.Duration(20-i/2) * time.Minute)
+		ev := domain.RestoreAccessEvent(0, domain.BookID(1+i%2), domain.UserID(1+i%3), domain.AccessTypeLectura, ts)
+		if err := access.Store(ev); err != nil {
+			t.Fatal(err)
+		}
+		stored = append(stored, ev)
+	}
+
+	want := func(keep func(*domain.AccessEvent) bool) []domain.AccessEventID {
+		var events []*domain.AccessEvent
+		for _, ev := range stored {
+			if keep(ev) {
+				events = append(events, ev)
+			}
+		}
+		sortEventsByTime(events)
+		ids := make([]domain.AccessEventID, 0, len(events))
+		for _, ev := range events {
+			ids = append(ids, ev.ID())
+		}
+		return ids
+	}
+	ids := func(events []*domain.AccessEvent) []domain.AccessEventID {
+		out := make([]domain.AccessEventID, 0, len(events))
+		for _, ev := range events {
+			out = append(out, ev.ID())
+		}
+		return out
+	}
+
+	for book := domain.BookID(1); book <= 2; book++ {
+		got, err := access.ListByBook(book)
+		expected := want(func(ev *domain.AccessEvent) bool { return ev.BookID() == book })
+		if err != nil || !slices.Equal(ids(got), expected) {
+			t.Fatalf("ListByBook(%d) = %v, se esperaba %v", book, ids(got), expected)
+		}
+	}
+	for user := domain.UserID(1); user <= 3; user++ {
+		got, err := access.ListByUser(user)
+		expected := want(func(ev *domain.AccessEvent) bool { return ev.UserID() == user })
+		if err != nil || !slices.Equal(ids(got), expected) {
+			t.Fatalf("ListByUser(%d) = %v, se esperaba %v", user, ids(got), expected)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+   ==========================================================
+   MIGRACIONES DE ESQUEMA
+   ==========================================================
+
+   Las migraciones son archivos SQL EMBEBIDOS en el binario
+   (carpeta migrations/), con el formato:
+
+	0001_init.up.sql     → aplica la versión 1
+	0001_init.down.sql   → revierte la versión 1
+
+   La tabla schema_migrations guarda qué versiones ya se
+   aplicaron. Cada migración corre dentro de su propia
+   transacción: o se aplica completa o no se aplica.
+*/
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Migration es una versión del esquema con su SQL de ida y vuelta.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// LoadMigrations lee las migraciones embebidas, ordenadas por versión.
+func LoadMigrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		fileName := entry.Name()
+
+		// Formato: <versión>_<nombre>.<up|down>.sql
+		base := strings.TrimSuffix(fileName, ".sql")
+		direction := path.Ext(base) // ".up" o ".down"
+		base = strings.TrimSuffix(base, direction)
+
+		versionStr, name, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("nombre de migración inválido: %s", fileName)
+		}
+		version, err := strconv.Atoi(versionStr)
+		if err != nil {
+			return nil, fmt.Errorf("versión de migración inválida en %s: %w", fileName, err)
+		}
+
+		content, err := migrationFiles.ReadFile("migrations/" + fileName)
+		if err != nil {
+			return nil, err
+		}
+
+		m, exists := byVersion[version]
+		if !exists {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		}
+
+		switch direction {
+		case ".up":
+			m.Up = string(content)
+		case ".down":
+			m.Down = string(content)
+		default:
+			return nil, fmt.Errorf("la migración %s debe terminar en .up.sql o .down.sql", fileName)
+		}
+	}
+
+	result := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("la migración %04d_%s necesita archivo up y down", m.Version, m.Name)
+		}
+		result = append(result, *m)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Version < result[j].Version })
+	return result, nil
+}
+
+// MigrateUp aplica todas las migraciones pendientes.
+// Devuelve las versiones aplicadas.
+func MigrateUp(db *sql.DB) ([]int, error) {
+	ctx := context.Background()
+
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := appliedVersions(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []int
+	for _, m := range migrations {
+		if applied[m.Version] {
+			continue
+		}
+
+		err := runInTx(ctx, db, m.Up, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx,
+				"INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)",
+				m.Version, m.Name, formatSQLTime(time.Now()),
+			)
+			return err
+		})
+		if err != nil {
+			return done, fmt.Errorf("migración %04d_%s: %w", m.Version, m.Name, err)
+		}
+		done = append(done, m.Version)
+	}
+	return done, nil
+}
+
+// MigrateDown revierte las últimas `steps` migraciones aplicadas.
+// Devuelve las versiones revertidas.
+func MigrateDown(db *sql.DB, steps int) ([]int, error) {
+	ctx := context.Background()
+
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := appliedVersions(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []int
+	// Recorremos de la más nueva a la más vieja.
+	for i := len(migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		m := migrations[i]
+		if !applied[m.Version] {
+			continue
+		}
+
+		err := runInTx(ctx, db, m.Down, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = ?", m.Version)
+			return err
+		})
+		if err != nil {
+			return done, fmt.Errorf("revertir migración %04d_%s: %w", m.Version, m.Name, err)
+		}
+		done = append(done, m.Version)
+	}
+	return done, nil
+}
+
+// appliedVersions crea la tabla schema_migrations si hace falta
+// y devuelve el conjunto de versiones aplicadas.
+func appliedVersions(ctx context.Context, db *sql.DB) (map[int]bool, error) {
+	_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version    INTEGER PRIMARY KEY,
+    name       TEXT NOT NULL,
+    applied_at TEXT NOT NULL
+)`)
+	if err != nil {
+		return nil, fmt.Errorf("no se pudo crear schema_migrations: %w", err)
+	}
+
+	rows, err := db.QueryContext(ctx, "SELECT version FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]bool)
+	for rows.Next() {
+		var v int
+		if err := rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		applied[v] = true
+	}
+	return applied, rows.Err()
+}
+
+// runInTx ejecuta el script SQL y luego `after`, todo en una transacción.
+func runInTx(ctx context.Context, db *sql.DB, script string, after func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	for _, stmt := range splitSQLStatements(script) {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	if err := after(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// splitSQLStatements separa un script en sentencias terminadas en ";".
+// Quita las líneas de comentario ("--"). Es suficiente para nuestras
+// migraciones, que no usan ";" dentro de textos ni triggers.
+func splitSQLStatements(script string) []string {
+	var (
+		stmts   []string
+		current strings.Builder
+	)
+
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+
+		if strings.HasSuffix(trimmed, ";") {
+			stmts = append(stmts, strings.TrimSpace(current.String()))
+			current.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		stmts = append(stmts, rest)
+	}
+	return stmts
+}
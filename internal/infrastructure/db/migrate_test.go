@@ -0,0 +1,117 @@
+//go:build sqlite
+
+package db
+
+import (
+	"database/sql"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+// Estas pruebas necesitan el driver SQLite:
+//
+//	go test -tags sqlite ./internal/infrastructure/db
+
+// openTestConn abre una base SQLite vacía en un archivo temporal.
+func openTestConn(t *testing.T) *sql.DB {
+	t.Helper()
+	conn, err := OpenSQL("sqlite", filepath.Join(t.TempDir(), "libros.db"))
+	if err != nil {
+		t.Fatalf("no se pudo abrir la base: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// tableExists indica si la tabla existe en la base.
+func tableExists(t *testing.T, conn *sql.DB, name string) bool {
+	t.Helper()
+	var n int
+	err := conn.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", name).Scan(&n)
+	if err != nil {
+		t.Fatalf("consultar sqlite_master: %v", err)
+	}
+	return n > 0
+}
+
+func TestMigrateUpAndDown(t *testing.T) {
+	conn := openTestConn(t)
+
+	migrations, err := LoadMigrations()
+	if err != nil {
+		t.Fatalf("LoadMigrations: %v", err)
+	}
+	var all []int
+	for _, m := range migrations {
+		all = append(all, m.Version)
+	}
+
+	applied, err := MigrateUp(conn)
+	if err != nil {
+		t.Fatalf("MigrateUp: %v", err)
+	}
+	if !slices.Equal(applied, all) {
+		t.Fatalf("MigrateUp aplicó %v, se esperaba %v", applied, all)
+	}
+	for _, table := range []string{"users", "books", "book_tags", "access_events", "access_reader_sketches"} {
+		if !tableExists(t, conn, table) {
+			t.Errorf("falta la tabla %s después de MigrateUp", table)
+		}
+	}
+
+	// Una segunda llamada no tiene nada pendiente.
+	applied, err = MigrateUp(conn)
+	if err != nil {
+		t.Fatalf("MigrateUp repetido: %v", err)
+	}
+	if len(applied) != 0 {
+		t.Fatalf("MigrateUp repetido aplicó %v", applied)
+	}
+
+	// Revertir solo la última.
+	last := all[len(all)-1]
+	reverted, err := MigrateDown(conn, 1)
+	if err != nil {
+		t.Fatalf("MigrateDown(1): %v", err)
+	}
+	if !slices.Equal(reverted, []int{last}) {
+		t.Fatalf("MigrateDown(1) revirtió %v, se esperaba [%d]", reverted, last)
+	}
+	if tableExists(t, conn, "access_reader_sketches") {
+		t.Error("access_reader_sketches sigue existiendo después de revertir 0004")
+	}
+
+	// Volver a subir aplica solo la revertida.
+	applied, err = MigrateUp(conn)
+	if err != nil {
+		t.Fatalf("MigrateUp después de revertir: %v", err)
+	}
+	if !slices.Equal(applied, []int{last}) {
+		t.Fatalf("MigrateUp aplicó %v, se esperaba [%d]", applied, last)
+	}
+
+	// Revertir todo (pedir de más no es un error).
+	reverted, err = MigrateDown(conn, len(all)+5)
+	if err != nil {
+		t.Fatalf("MigrateDown(todas): %v", err)
+	}
+	want := slices.Clone(all)
+	slices.Reverse(want)
+	if !slices.Equal(reverted, want) {
+		t.Fatalf("MigrateDown revirtió %v, se esperaba %v", reverted, want)
+	}
+	for _, table := range []string{"users", "books", "book_tags", "access_events"} {
+		if tableExists(t, conn, table) {
+			t.Errorf("la tabla %s sigue existiendo después de revertir todo", table)
+		}
+	}
+}
+
+func TestOpenSQLUnknownDriver(t *testing.T) {
+	if _, err := OpenSQL("no-existe", "x"); err == nil {
+		t.Fatal("OpenSQL con un driver no enlazado debería fallar")
+	}
+}
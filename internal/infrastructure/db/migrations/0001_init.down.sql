@@ -0,0 +1,4 @@
+DROP TABLE access_events;
+DROP TABLE book_tags;
+DROP TABLE books;
+DROP TABLE users;
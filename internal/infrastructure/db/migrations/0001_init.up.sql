@@ -0,0 +1,46 @@
+-- Esquema inicial: usuarios, libros (con sus tags) y eventos de acceso.
+-- Dialecto: SQLite (ver sql_repo.go).
+
+CREATE TABLE users (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    name       TEXT    NOT NULL,
+    email      TEXT    NOT NULL UNIQUE,
+    role       TEXT    NOT NULL,
+    active     INTEGER NOT NULL DEFAULT 1,
+    created_at TEXT    NOT NULL
+);
+
+CREATE TABLE books (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    title       TEXT    NOT NULL,
+    author      TEXT    NOT NULL,
+    year        INTEGER NOT NULL,
+    isbn        TEXT    NOT NULL,
+    category_ti TEXT    NOT NULL,
+    active      INTEGER NOT NULL DEFAULT 1,
+    created_at  TEXT    NOT NULL
+);
+
+CREATE INDEX idx_books_category ON books (category_ti);
+CREATE INDEX idx_books_year ON books (year);
+
+CREATE TABLE book_tags (
+    book_id  INTEGER NOT NULL REFERENCES books (id),
+    position INTEGER NOT NULL,
+    tag      TEXT    NOT NULL,
+    PRIMARY KEY (book_id, position)
+);
+
+CREATE INDEX idx_book_tags_tag ON book_tags (tag);
+
+CREATE TABLE access_events (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    book_id     INTEGER NOT NULL REFERENCES books (id),
+    user_id     INTEGER NOT NULL REFERENCES users (id),
+    access_type TEXT    NOT NULL,
+    timestamp   TEXT    NOT NULL
+);
+
+CREATE INDEX idx_access_events_book ON access_events (book_id);
+CREATE INDEX idx_access_events_user ON access_events (user_id);
+CREATE INDEX idx_access_events_timestamp ON access_events (timestamp);
@@ -0,0 +1,4 @@
+-- Versión de cada fila para el control de concurrencia optimista:
+-- cada UPDATE exitoso la incrementa en 1.
+ALTER TABLE users ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE books ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
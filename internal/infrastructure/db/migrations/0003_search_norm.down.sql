@@ -0,0 +1,6 @@
+DROP INDEX idx_book_tags_tag_norm;
+DROP INDEX idx_books_category_norm;
+ALTER TABLE book_tags DROP COLUMN tag_norm;
+ALTER TABLE books DROP COLUMN category_norm;
+ALTER TABLE books DROP COLUMN author_norm;
+ALTER TABLE books DROP COLUMN title_norm;
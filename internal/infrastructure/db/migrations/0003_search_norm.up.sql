@@ -0,0 +1,12 @@
+-- Columnas PLEGADAS (minúsculas, sin acentos, ñ → n) para filtrar
+-- sin distinguir acentos. SQLite no sabe plegar acentos, así que
+-- los valores los calcula Go (textnorm.Fold) al insertar/actualizar.
+-- Las filas que ya existían se completan al arrancar
+-- (ver BackfillSearchColumns en sql_repo.go).
+ALTER TABLE books ADD COLUMN title_norm TEXT;
+ALTER TABLE books ADD COLUMN author_norm TEXT;
+ALTER TABLE books ADD COLUMN category_norm TEXT;
+ALTER TABLE book_tags ADD COLUMN tag_norm TEXT;
+
+CREATE INDEX idx_books_category_norm ON books (category_norm);
+CREATE INDEX idx_book_tags_tag_norm ON book_tags (tag_norm);
@@ -0,0 +1,2 @@
+DROP INDEX idx_access_reader_sketches_day;
+DROP TABLE access_reader_sketches;
@@ -0,0 +1,13 @@
+-- Sketch HyperLogLog de los usuarios distintos que accedieron a
+-- cada libro cada día (UTC, "2006-01-02"), serializado con
+-- hll.Sketch.MarshalBinary. Se actualiza en cada acceso; los días
+-- anteriores a la migración se completan al arrancar
+-- (ver BackfillReaderSketches en sql_repo.go).
+CREATE TABLE access_reader_sketches (
+    book_id INTEGER NOT NULL REFERENCES books (id),
+    day     TEXT    NOT NULL,
+    sketch  BLOB    NOT NULL,
+    PRIMARY KEY (book_id, day)
+);
+
+CREATE INDEX idx_access_reader_sketches_day ON access_reader_sketches (day);
@@ -0,0 +1,132 @@
+package db
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/domain"
+)
+
+/*
+   ==========================================================
+   ORDEN Y PÁGINAS (memoria y SQL)
+   ==========================================================
+
+   Ambos tipos de repositorio ordenan por (clave, ID) con las
+   claves de domain.BookSortKey / domain.UserSortKey, así un
+   cursor generado con uno sirve para pedir la página siguiente
+   sin saltar ni repetir elementos.
+*/
+
+// keyed es un elemento con su clave de orden ya calculada.
+type keyed[T any] struct {
+	item T
+	key  string
+	id   int64
+}
+
+// pageByKey ordena los elementos por (clave, id), descarta los que no
+// van después del cursor y se queda con los primeros `limit` (0 = todos).
+func pageByKey[T any](items []T, keyOf func(T) string, idOf func(T) int64, desc bool, after *domain.Cursor, limit int) []T {
+	all := make([]keyed[T], 0, len(items))
+	for _, it := range items {
+		k := keyed[T]{item: it, key: keyOf(it), id: idOf(it)}
+		if after != nil && !after.IsAfter(k.key, k.id, desc) {
+			continue
+		}
+		all = append(all, k)
+	}
+
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].key != all[j].key {
+			return (all[i].key < all[j].key) != desc
+		}
+		return (all[i].id < all[j].id) != desc
+	})
+
+	if limit > 0 && len(all) > limit {
+		all = all[:limit]
+	}
+
+	result := make([]T, 0, len(all))
+	for _, k := range all {
+		result = append(result, k.item)
+	}
+	return result
+}
+
+// sqlSortColumn describe cómo ordenar por un campo en SQL.
+type sqlSortColumn struct {
+	expr    string // expresión SQL que produce la clave
+	numeric bool   // la clave del cursor es un número
+	idExpr  string // columna del ID para desempatar
+	isID    bool   // el campo es el propio ID
+}
+
+// bookSortColumn traduce un campo de orden de libros a SQL.
+func bookSortColumn(field domain.BookSortField) sqlSortColumn {
+	switch field {
+	case domain.BookSortTitle:
+		return sqlSortColumn{expr: "LOWER(b.title)", idExpr: "b.id"}
+	case domain.BookSortAuthor:
+		return sqlSortColumn{expr: "LOWER(b.author)", idExpr: "b.id"}
+	case domain.BookSortYear:
+		return sqlSortColumn{expr: "b.year", numeric: true, idExpr: "b.id"}
+	case domain.BookSortCreatedAt:
+		return sqlSortColumn{expr: "b.created_at", idExpr: "b.id"}
+	default:
+		return sqlSortColumn{expr: "b.id", idExpr: "b.id", isID: true}
+	}
+}
+
+// userSortColumn traduce un campo de orden de usuarios a SQL.
+func userSortColumn(field domain.UserSortField) sqlSortColumn {
+	switch field {
+	case domain.UserSortName:
+		return sqlSortColumn{expr: "LOWER(name)", idExpr: "id"}
+	case domain.UserSortEmail:
+		return sqlSortColumn{expr: "LOWER(email)", idExpr: "id"}
+	case domain.UserSortCreatedAt:
+		return sqlSortColumn{expr: "created_at", idExpr: "id"}
+	default:
+		return sqlSortColumn{expr: "id", idExpr: "id", isID: true}
+	}
+}
+
+// afterClause devuelve la condición "después del cursor" con sus argumentos.
+func (c sqlSortColumn) afterClause(after *domain.Cursor, desc bool) (string, []any, error) {
+	op := ">"
+	if desc {
+		op = "<"
+	}
+	if c.isID {
+		return c.idExpr + " " + op + " ?", []any{after.ID}, nil
+	}
+
+	var key any = after.Key
+	if c.numeric {
+		n, err := strconv.Atoi(after.Key)
+		if err != nil {
+			return "", nil, domain.NewValidationError("cursor", "cursor inválido")
+		}
+		key = n
+	}
+	clause := "(" + c.expr + " " + op + " ? OR (" + c.expr + " = ? AND " + c.idExpr + " " + op + " ?))"
+	return clause, []any{key, key, after.ID}, nil
+}
+
+// orderBy devuelve el ORDER BY (y LIMIT si corresponde) para el campo.
+func (c sqlSortColumn) orderBy(desc bool, limit int) string {
+	dir := "ASC"
+	if desc {
+		dir = "DESC"
+	}
+	clause := " ORDER BY " + c.expr + " " + dir
+	if !c.isID {
+		clause += ", " + c.idExpr + " " + dir
+	}
+	if limit > 0 {
+		clause += " LIMIT " + strconv.Itoa(limit)
+	}
+	return clause
+}
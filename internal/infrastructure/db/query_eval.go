@@ -0,0 +1,107 @@
+package db
+
+import (
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/bookquery"
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/domain"
+)
+
+/*
+   ==========================================================
+   EVALUADOR DE CONSULTAS (lenguaje de internal/bookquery)
+   ==========================================================
+
+   El árbol de la consulta se COMPILA una vez por búsqueda en
+   una función queryMatcher: los valores de cada término se
+   normalizan al compilar, así comparar cada libro no vuelve a
+   plegar textos.
+
+	title:x    → el título contiene x (tolera errores con Fuzzy)
+	author:x   → el autor contiene x (tolera errores con Fuzzy)
+	category:x → la categoría es x
+	tag:x      → el libro tiene el tag x
+	isbn:x     → el ISBN es x (sin guiones ni espacios)
+	year>=n    → comparación numérica del año
+	x          → título o autor contienen x, o el libro tiene el tag x
+
+   Lo usa InMemoryBookRepo y también SQLBookRepo, que verifica
+   la consulta en Go (ver sql_repo.go).
+*/
+
+// queryMatcher indica si un libro indexado cumple la consulta.
+type queryMatcher func(e indexedBook) bool
+
+// compileQuery convierte el árbol en un queryMatcher.
+func compileQuery(expr bookquery.Expr, fuzzy bool) queryMatcher {
+	switch x := expr.(type) {
+	case *bookquery.And:
+		left, right := compileQuery(x.Left, fuzzy), compileQuery(x.Right, fuzzy)
+		return func(e indexedBook) bool { return left(e) && right(e) }
+	case *bookquery.Or:
+		left, right := compileQuery(x.Left, fuzzy), compileQuery(x.Right, fuzzy)
+		return func(e indexedBook) bool { return left(e) || right(e) }
+	case *bookquery.Not:
+		inner := compileQuery(x.X, fuzzy)
+		return func(e indexedBook) bool { return !inner(e) }
+	case *bookquery.Term:
+		return compileTerm(x, fuzzy)
+	default:
+		// El parser no produce otros nodos.
+		return func(indexedBook) bool { return false }
+	}
+}
+
+// compileTerm compila una condición sobre un campo.
+func compileTerm(t *bookquery.Term, fuzzy bool) queryMatcher {
+	switch t.Field {
+	case bookquery.FieldTitle:
+		value := normalizeKey(t.Value)
+		return func(e indexedBook) bool { return containsText(e.title, value, fuzzy) }
+	case bookquery.FieldAuthor:
+		value := normalizeKey(t.Value)
+		return func(e indexedBook) bool { return containsText(e.author, value, fuzzy) }
+	case bookquery.FieldCategory:
+		value := normalizeKey(t.Value)
+		return func(e indexedBook) bool { return e.category == value }
+	case bookquery.FieldTag:
+		value := domain.NormalizeTag(t.Value)
+		return func(e indexedBook) bool { return e.hasTag(value) }
+	case bookquery.FieldISBN:
+		value := normalizeISBN(t.Value)
+		return func(e indexedBook) bool { return e.isbn == value }
+	case bookquery.FieldYear:
+		return compileYear(t.Op, t.Number)
+	default:
+		value := normalizeKey(t.Value)
+		return func(e indexedBook) bool {
+			return containsText(e.title, value, fuzzy) ||
+				containsText(e.author, value, fuzzy) ||
+				e.hasTag(value)
+		}
+	}
+}
+
+// compileYear compila una comparación del año.
+func compileYear(op bookquery.Op, n int) queryMatcher {
+	switch op {
+	case bookquery.OpGt:
+		return func(e indexedBook) bool { return e.year > n }
+	case bookquery.OpGte:
+		return func(e indexedBook) bool { return e.year >= n }
+	case bookquery.OpLt:
+		return func(e indexedBook) bool { return e.year < n }
+	case bookquery.OpLte:
+		return func(e indexedBook) bool { return e.year <= n }
+	default: // ":" y "="
+		return func(e indexedBook) bool { return e.year == n }
+	}
+}
+
+// hasTag indica si el libro tiene el tag (ya normalizado).
+func (e indexedBook) hasTag(tag string) bool {
+	for _, have := range e.tags {
+		if have == tag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,100 @@
+package db
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/bookquery"
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/domain"
+)
+
+func TestQueryEvaluation(t *testing.T) {
+	repo := NewInMemoryBookRepo()
+	mk := func(title, author string, year int, isbn, cat string, tags ...string) *domain.Book {
+		b, err := domain.NewBook(title, author, year, isbn, cat, tags)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := repo.Create(b); err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	mk("Redes de Computadoras", "Andrew Tanenbaum", 2012, "978-84-1", "Redes", "tcp", "clásico")
+	mk("Sistemas Operativos Modernos", "Andrew Tanenbaum", 2009, "978-84-2", "Sistemas", "kernel", "clásico")
+	mk("Evaluación de Proyectos", "Gabriel Baca Urbina", 2016, "978-60-3", "Gestión", "finanzas")
+	mk("El Lenguaje de Programación Go", "Alan Donovan", 2016, "978-01-4", "Programación", "go", "concurrencia")
+	mk("Seguridad en Redes", "William Stallings", 2019, "978-84-5", "Seguridad", "criptografía", "redes")
+	archived := mk("Redes Obsoletas", "Andrew Tanenbaum", 1990, "978-84-6", "Redes", "tcp")
+	archived.Archive()
+	if err := repo.Update(archived); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		query string
+		fuzzy bool
+		want  []string // títulos, en orden alfabético
+	}{
+		// Términos sin campo: título, autor o tag.
+		{query: `redes`, want: []string{"Redes de Computadoras", "Seguridad en Redes"}},
+		{query: `tanenbaum`, want: []string{"Redes de Computadoras", "Sistemas Operativos Modernos"}},
+		{query: `concurrencia`, want: []string{"El Lenguaje de Programación Go"}},
+		{query: `"lenguaje de"`, want: []string{"El Lenguaje de Programación Go"}},
+
+		// Campos.
+		{query: `title:REDES`, want: []string{"Redes de Computadoras", "Seguridad en Redes"}},
+		{query: `title:programacion`, want: []string{"El Lenguaje de Programación Go"}},
+		{query: `author:"baca urbina"`, want: []string{"Evaluación de Proyectos"}},
+		{query: `category:redes`, want: []string{"Redes de Computadoras"}},
+		{query: `category:red`, want: nil}, // la categoría es exacta
+		{query: `tag:Clásico`, want: []string{"Redes de Computadoras", "Sistemas Operativos Modernos"}},
+		{query: `tag:clas`, want: nil}, // el tag es exacto
+		{query: `isbn:97884-1`, want: []string{"Redes de Computadoras"}},
+		{query: `isbn:"978 84 5"`, want: []string{"Seguridad en Redes"}},
+
+		// Años.
+		{query: `year:2016`, want: []string{"El Lenguaje de Programación Go", "Evaluación de Proyectos"}},
+		{query: `year=2009`, want: []string{"Sistemas Operativos Modernos"}},
+		{query: `year>2012`, want: []string{"El Lenguaje de Programación Go", "Evaluación de Proyectos", "Seguridad en Redes"}},
+		{query: `year>=2016 year<2019`, want: []string{"El Lenguaje de Programación Go", "Evaluación de Proyectos"}},
+		{query: `year<=2009`, want: []string{"Sistemas Operativos Modernos"}},
+
+		// Operadores.
+		{query: `tanenbaum tag:tcp`, want: []string{"Redes de Computadoras"}},
+		{query: `tag:go OR tag:finanzas`, want: []string{"El Lenguaje de Programación Go", "Evaluación de Proyectos"}},
+		{query: `redes -tanenbaum`, want: []string{"Seguridad en Redes"}},
+		{query: `NOT tag:clásico year<2017`, want: []string{"El Lenguaje de Programación Go", "Evaluación de Proyectos"}},
+		{query: `author:tanenbaum OR author:donovan year>2010`, want: []string{
+			"El Lenguaje de Programación Go", "Redes de Computadoras", "Sistemas Operativos Modernos",
+		}},
+		{query: `(author:tanenbaum OR author:donovan) year>2010`, want: []string{
+			"El Lenguaje de Programación Go", "Redes de Computadoras",
+		}},
+		{query: `-(tag:clásico OR year>2015)`, want: nil},
+
+		// Fuzzy tolera errores de tipeo en título y autor.
+		{query: `title:computadora`, want: []string{"Redes de Computadoras"}},
+		{query: `title:compuatdoras`, want: nil},
+		{query: `title:compuatdoras`, fuzzy: true, want: []string{"Redes de Computadoras"}},
+		{query: `author:tanenbuam`, fuzzy: true, want: []string{"Redes de Computadoras", "Sistemas Operativos Modernos"}},
+	}
+	for _, tt := range tests {
+		expr, err := bookquery.Parse(tt.query)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.query, err)
+		}
+		books, err := repo.SearchByFilters(domain.BookFilter{Query: expr, Fuzzy: tt.fuzzy})
+		if err != nil {
+			t.Fatalf("SearchByFilters(%q): %v", tt.query, err)
+		}
+		var got []string
+		for _, b := range books {
+			got = append(got, b.Title())
+		}
+		slices.Sort(got)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%q (fuzzy %v) = %q, se esperaba %q", tt.query, tt.fuzzy, got, tt.want)
+		}
+	}
+}
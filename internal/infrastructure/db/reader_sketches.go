@@ -0,0 +1,115 @@
+package db
+
+import (
+	"sort"
+	"time"
+
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/domain"
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/hll"
+)
+
+/*
+   ==========================================================
+   LECTORES DISTINTOS (sketches HyperLogLog)
+   ==========================================================
+
+   Para cada (libro, día UTC) se guarda un sketch HyperLogLog
+   (ver internal/hll) con los usuarios que accedieron a ese
+   libro ese día. Se actualiza en cada Store.
+
+   Los lectores distintos de un libro en un mes, de una
+   categoría o de todo el catálogo salen de COMBINAR (merge)
+   los sketches de los días y libros que correspondan, sin
+   leer los eventos.
+
+   Agregar dos veces el mismo usuario no cambia el sketch, así
+   que reaplicar un evento (por ejemplo, al reproducir el WAL)
+   no cuenta de más.
+
+   La granularidad es el DÍA: un rango [from, to) incluye todos
+   los días UTC que toca. from se redondea hacia atrás al
+   comienzo de su día y to hacia adelante al día siguiente,
+   salvo que ya sea medianoche (to = 2024-05-02T10:00Z incluye
+   el 2 de mayo; to = 2024-05-02T00:00Z, no).
+*/
+
+// readerDay es el día UTC de un instante, como clave de los sketches.
+func readerDay(t time.Time) string {
+	return t.UTC().Format(time.DateOnly)
+}
+
+// readerDayEnd es el primer día que NO entra en un rango que termina en
+// to: el de to si es medianoche UTC, si no el siguiente.
+func readerDayEnd(to time.Time) string {
+	end := to.UTC().Truncate(24 * time.Hour)
+	if end.Before(to) {
+		end = end.AddDate(0, 0, 1)
+	}
+	return readerDay(end)
+}
+
+// dayInRange indica si el día toca [from, to); un límite cero no limita.
+func dayInRange(day string, from, to time.Time) bool {
+	if !from.IsZero() && day < readerDay(from) {
+		return false
+	}
+	if !to.IsZero() && day >= readerDayEnd(to) {
+		return false
+	}
+	return true
+}
+
+// readerSketches son los sketches de un repositorio en memoria:
+// día → libro → sketch.
+type readerSketches map[string]map[domain.BookID]*hll.Sketch
+
+// add suma el usuario del evento al sketch de su libro y su día.
+func (rs readerSketches) add(ev *domain.AccessEvent) {
+	day := readerDay(ev.Timestamp())
+	books, ok := rs[day]
+	if !ok {
+		books = make(map[domain.BookID]*hll.Sketch)
+		rs[day] = books
+	}
+	sk, ok := books[ev.BookID()]
+	if !ok {
+		sk = hll.New()
+		books[ev.BookID()] = sk
+	}
+	sk.AddUint64(uint64(ev.UserID()))
+}
+
+// byDay combina, para cada día del rango, los sketches de los libros
+// pedidos (vacío = todos). Devuelve copias: el llamador puede modificarlas.
+func (rs readerSketches) byDay(bookIDs []domain.BookID, from, to time.Time) (map[string]*hll.Sketch, error) {
+	days := make([]string, 0, len(rs))
+	for day := range rs {
+		if dayInRange(day, from, to) {
+			days = append(days, day)
+		}
+	}
+	sort.Strings(days)
+
+	result := make(map[string]*hll.Sketch, len(days))
+	for _, day := range days {
+		merged := hll.New()
+		books := rs[day]
+		if len(bookIDs) == 0 {
+			for _, sk := range books {
+				if err := merged.Merge(sk); err != nil {
+					return nil, err
+				}
+			}
+		} else {
+			for _, id := range bookIDs {
+				if sk, ok := books[id]; ok {
+					if err := merged.Merge(sk); err != nil {
+						return nil, err
+					}
+				}
+			}
+		}
+		result[day] = merged
+	}
+	return result, nil
+}
@@ -0,0 +1,70 @@
+package db
+
+import (
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/domain"
+)
+
+func TestReaderDayEnd(t *testing.T) {
+	tests := []struct {
+		to   time.Time
+		want string
+	}{
+		{time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC), "2026-03-10"},
+		{time.Date(2026, 3, 10, 0, 0, 0, 1, time.UTC), "2026-03-11"},
+		{time.Date(2026, 3, 10, 17, 30, 0, 0, time.UTC), "2026-03-11"},
+		{time.Date(2026, 12, 31, 23, 59, 59, 0, time.UTC), "2027-01-01"},
+		// 2026-03-10T00:00-05:00 es 05:00 UTC: a mitad del día UTC.
+		{time.Date(2026, 3, 10, 0, 0, 0, 0, time.FixedZone("EC", -5*3600)), "2026-03-11"},
+		// 2026-03-10T02:00+02:00 es medianoche UTC.
+		{time.Date(2026, 3, 10, 2, 0, 0, 0, time.FixedZone("EET", 2*3600)), "2026-03-10"},
+	}
+	for _, tt := range tests {
+		if got := readerDayEnd(tt.to); got != tt.want {
+			t.Errorf("readerDayEnd(%v) = %s, se esperaba %s", tt.to, got, tt.want)
+		}
+	}
+}
+
+// Los rangos se amplían a días completos: un to a mitad del día
+// incluye ese día; uno a medianoche, no.
+func TestInMemoryReaderSketchesRange(t *testing.T) {
+	repo := NewInMemoryAccessLogRepo()
+	day1 := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+	day3 := day2.AddDate(0, 0, 1)
+	for _, ts := range []time.Time{day1.Add(9 * time.Hour), day2.Add(9 * time.Hour), day3.Add(9 * time.Hour)} {
+		if err := repo.Store(domain.RestoreAccessEvent(0, 1, 1, domain.AccessTypeLectura, ts)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		from, to time.Time
+		want     []string
+	}{
+		{"sin límites", time.Time{}, time.Time{}, []string{"2026-03-10", "2026-03-11", "2026-03-12"}},
+		{"días completos", day1, day2, []string{"2026-03-10"}},
+		{"to a mitad del día", day1, day2.Add(2 * time.Hour), []string{"2026-03-10", "2026-03-11"}},
+		{"to antes del acceso del día", time.Time{}, day2.Add(time.Hour), []string{"2026-03-10", "2026-03-11"}},
+		{"from a mitad del día", day2.Add(20 * time.Hour), time.Time{}, []string{"2026-03-11", "2026-03-12"}},
+		{"dentro de un día", day2.Add(time.Hour), day2.Add(2 * time.Hour), []string{"2026-03-11"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sketches, err := repo.ReaderSketches(nil, tt.from, tt.to)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := slices.Sorted(maps.Keys(sketches))
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("días = %v, se esperaba %v", got, tt.want)
+			}
+		})
+	}
+}
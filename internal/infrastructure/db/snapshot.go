@@ -0,0 +1,340 @@
+package db
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/domain"
+)
+
+/*
+   ==========================================================
+   SNAPSHOTS EN DISCO
+   ==========================================================
+
+   Un "snapshot" es una foto completa del contenido de un
+   repositorio en memoria, guardada como JSON:
+
+	{
+	  "seq": 3,
+	  "users": [ {...}, {...}, {...} ]
+	}
+
+   Guardamos también "seq" para que, al reiniciar, los IDs
+   nuevos sigan desde donde quedaron y no se repitan.
+
+   La escritura es ATÓMICA: se escribe un archivo temporal en
+   la misma carpeta y luego se renombra sobre el definitivo.
+   Si el proceso se cae a mitad de camino, queda el snapshot
+   anterior intacto (nunca un archivo a medias).
+*/
+
+// userRecord es la forma en disco de un domain.User.
+type userRecord struct {
+	ID        domain.UserID `json:"id"`
+	Name      string        `json:"name"`
+	Email     string        `json:"email"`
+	Role      domain.Role   `json:"role"`
+	Active    bool          `json:"active"`
+	CreatedAt time.Time     `json:"created_at"`
+	Version   int64         `json:"version"`
+}
+
+// bookRecord es la forma en disco de un domain.Book.
+type bookRecord struct {
+	ID         domain.BookID `json:"id"`
+	Title      string        `json:"title"`
+	Author     string        `json:"author"`
+	Year       int           `json:"year"`
+	ISBN       string        `json:"isbn"`
+	CategoryTI string        `json:"category_ti"`
+	Tags       []string      `json:"tags"`
+	Active     bool          `json:"active"`
+	CreatedAt  time.Time     `json:"created_at"`
+	Version    int64         `json:"version"`
+}
+
+// accessEventRecord es la forma en disco de un domain.AccessEvent.
+type accessEventRecord struct {
+	ID         domain.AccessEventID `json:"id"`
+	BookID     domain.BookID        `json:"book_id"`
+	UserID     domain.UserID        `json:"user_id"`
+	AccessType domain.AccessType    `json:"access_type"`
+	Timestamp  time.Time            `json:"timestamp"`
+}
+
+// Contenido completo de cada archivo de snapshot.
+
+type userSnapshot struct {
+	Seq   domain.UserID `json:"seq"`
+	Users []userRecord  `json:"users"`
+}
+
+type bookSnapshot struct {
+	Seq   domain.BookID `json:"seq"`
+	Books []bookRecord  `json:"books"`
+}
+
+type accessEventSnapshot struct {
+	Seq    domain.AccessEventID `json:"seq"`
+	Events []accessEventRecord  `json:"events"`
+}
+
+// Conversiones dominio <-> registro.
+
+func newUserRecord(u *domain.User) userRecord {
+	return userRecord{
+		ID:        u.ID(),
+		Name:      u.Name(),
+		Email:     u.Email(),
+		Role:      u.Role(),
+		Active:    u.Active(),
+		CreatedAt: u.CreatedAt(),
+		Version:   u.Version(),
+	}
+}
+
+func (rec userRecord) toDomain() *domain.User {
+	return domain.RestoreUser(rec.ID, rec.Name, rec.Email, rec.Role, rec.Active, rec.CreatedAt, recordVersion(rec.Version))
+}
+
+func newBookRecord(b *domain.Book) bookRecord {
+	return bookRecord{
+		ID:         b.ID(),
+		Title:      b.Title(),
+		Author:     b.Author(),
+		Year:       b.Year(),
+		ISBN:       b.ISBN(),
+		CategoryTI: b.CategoryTI(),
+		Tags:       b.Tags(),
+		Active:     b.Active(),
+		CreatedAt:  b.CreatedAt(),
+		Version:    b.Version(),
+	}
+}
+
+func (rec bookRecord) toDomain() *domain.Book {
+	return domain.RestoreBook(
+		rec.ID, rec.Title, rec.Author, rec.Year, rec.ISBN, rec.CategoryTI,
+		rec.Tags, rec.Active, rec.CreatedAt, recordVersion(rec.Version),
+	)
+}
+
+// recordVersion trata los archivos viejos (sin "version") como versión 1.
+func recordVersion(v int64) int64 {
+	if v <= 0 {
+		return 1
+	}
+	return v
+}
+
+func newAccessEventRecord(e *domain.AccessEvent) accessEventRecord {
+	return accessEventRecord{
+		ID:         e.ID(),
+		BookID:     e.BookID(),
+		UserID:     e.UserID(),
+		AccessType: e.AccessType(),
+		Timestamp:  e.Timestamp(),
+	}
+}
+
+func (rec accessEventRecord) toDomain() *domain.AccessEvent {
+	return domain.RestoreAccessEvent(rec.ID, rec.BookID, rec.UserID, rec.AccessType, rec.Timestamp)
+}
+
+/*
+   ==========================================================
+   LECTURA / ESCRITURA ATÓMICA
+   ==========================================================
+*/
+
+// writeSnapshotAtomic serializa v como JSON y lo guarda en path
+// usando "escribir en temporal + fsync + rename".
+func writeSnapshotAtomic(path string, v any) error {
+	dir := filepath.Dir(path)
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+"-*.tmp")
+	if err != nil {
+		return fmt.Errorf("no se pudo crear el archivo temporal: %w", err)
+	}
+	tmpName := tmp.Name()
+
+	// Si algo falla, borramos el temporal para no dejar basura.
+	cleanup := func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmpName)
+	}
+
+	if err := json.NewEncoder(tmp).Encode(v); err != nil {
+		cleanup()
+		return fmt.Errorf("no se pudo serializar el snapshot: %w", err)
+	}
+	// Sync asegura que los datos llegaron al disco antes del rename.
+	if err := tmp.Sync(); err != nil {
+		cleanup()
+		return fmt.Errorf("no se pudo sincronizar el snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmpName)
+		return fmt.Errorf("no se pudo cerrar el snapshot: %w", err)
+	}
+	if err := os.Rename(tmpName, path); err != nil {
+		_ = os.Remove(tmpName)
+		return fmt.Errorf("no se pudo reemplazar el snapshot: %w", err)
+	}
+
+	// Sincronizar la carpeta para que el rename también sea durable.
+	// Algunos sistemas no lo permiten, por eso ignoramos el error.
+	if d, err := os.Open(dir); err == nil {
+		_ = d.Sync()
+		_ = d.Close()
+	}
+	return nil
+}
+
+// readSnapshot carga el JSON de path en v.
+// Devuelve (false, nil) si el archivo todavía no existe.
+func readSnapshot(path string, v any) (bool, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("no se pudo abrir el snapshot %s: %w", path, err)
+	}
+	defer f.Close()
+
+	if err := json.NewDecoder(f).Decode(v); err != nil {
+		return false, fmt.Errorf("snapshot %s dañado: %w", path, err)
+	}
+	return true, nil
+}
+
+/*
+   ==========================================================
+   EXPORTAR / RESTAURAR LOS REPOSITORIOS EN MEMORIA
+   ==========================================================
+
+   Estos métodos son internos del paquete: los usan los
+   repositorios con persistencia para tomar la foto del
+   contenido y para cargarlo al arrancar.
+*/
+
+// snapshotLocked devuelve una copia del contenido del repositorio de
+// usuarios. El llamador ya tiene el mutex tomado.
+func (r *InMemoryUserRepo) snapshotLocked() userSnapshot {
+	snap := userSnapshot{Seq: r.seq, Users: make([]userRecord, 0, len(r.users))}
+	for _, u := range r.users {
+		snap.Users = append(snap.Users, newUserRecord(u))
+	}
+	return snap
+}
+
+// with devuelve el snapshot con el registro insertado o reemplazado.
+func (s userSnapshot) with(rec userRecord) userSnapshot {
+	for i := range s.Users {
+		if s.Users[i].ID == rec.ID {
+			s.Users[i] = rec
+			return s
+		}
+	}
+	s.Users = append(s.Users, rec)
+	s.Seq = max(s.Seq, rec.ID)
+	return s
+}
+
+// restore reemplaza el contenido del repositorio por el del snapshot.
+func (r *InMemoryUserRepo) restore(snap userSnapshot) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.seq = snap.Seq
+	r.users = make(map[domain.UserID]*domain.User, len(snap.Users))
+	r.emailIndex = make(map[string]domain.UserID, len(snap.Users))
+	for _, rec := range snap.Users {
+		r.users[rec.ID] = rec.toDomain()
+		r.emailIndex[emailKey(rec.Email)] = rec.ID
+		// Por seguridad, la secuencia nunca queda por debajo de un ID existente.
+		if rec.ID > r.seq {
+			r.seq = rec.ID
+		}
+	}
+}
+
+// snapshotLocked devuelve una copia del contenido del repositorio de
+// libros. El llamador ya tiene el mutex tomado.
+func (r *InMemoryBookRepo) snapshotLocked() bookSnapshot {
+	snap := bookSnapshot{Seq: r.seq, Books: make([]bookRecord, 0, len(r.books))}
+	for _, b := range r.books {
+		snap.Books = append(snap.Books, newBookRecord(b))
+	}
+	return snap
+}
+
+// with devuelve el snapshot con el registro insertado o reemplazado.
+func (s bookSnapshot) with(rec bookRecord) bookSnapshot {
+	for i := range s.Books {
+		if s.Books[i].ID == rec.ID {
+			s.Books[i] = rec
+			return s
+		}
+	}
+	s.Books = append(s.Books, rec)
+	s.Seq = max(s.Seq, rec.ID)
+	return s
+}
+
+// restore reemplaza el contenido del repositorio por el del snapshot.
+func (r *InMemoryBookRepo) restore(snap bookSnapshot) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.seq = snap.Seq
+	r.books = make(map[domain.BookID]*domain.Book, len(snap.Books))
+	r.index = newBookIndex()
+	for _, rec := range snap.Books {
+		r.books[rec.ID] = rec.toDomain()
+		r.index.put(r.books[rec.ID])
+		if rec.ID > r.seq {
+			r.seq = rec.ID
+		}
+	}
+}
+
+// snapshotLocked devuelve una copia del contenido del repositorio de
+// accesos. El llamador ya tiene el mutex tomado.
+func (r *InMemoryAccessLogRepo) snapshotLocked() accessEventSnapshot {
+	snap := accessEventSnapshot{Seq: r.seq, Events: make([]accessEventRecord, 0, len(r.events))}
+	for _, ev := range r.events {
+		snap.Events = append(snap.Events, newAccessEventRecord(ev))
+	}
+	return snap
+}
+
+// with devuelve el snapshot con el evento agregado (los eventos no se editan).
+func (s accessEventSnapshot) with(rec accessEventRecord) accessEventSnapshot {
+	s.Events = append(s.Events, rec)
+	s.Seq = max(s.Seq, rec.ID)
+	return s
+}
+
+// restore reemplaza el contenido del repositorio por el del snapshot.
+func (r *InMemoryAccessLogRepo) restore(snap accessEventSnapshot) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.seq = snap.Seq
+	r.events = make(map[domain.AccessEventID]*domain.AccessEvent, len(snap.Events))
+	r.readers = make(readerSketches)
+	for _, rec := range snap.Events {
+		r.events[rec.ID] = rec.toDomain()
+		r.readers.add(r.events[rec.ID])
+		if rec.ID > r.seq {
+			r.seq = rec.ID
+		}
+	}
+}
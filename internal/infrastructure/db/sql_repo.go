@@ -0,0 +1,1212 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/domain"
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/hll"
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/textnorm"
+)
+
+/*
+   ==========================================================
+   REPOSITORIOS SQL (database/sql)
+   ==========================================================
+
+   Implementaciones de los TRES repositorios del dominio sobre
+   una base de datos relacional, usando solo database/sql.
+
+   - Tablas: users, books, book_tags, access_events
+     (ver migrations/0001_init.up.sql).
+   - El SQL está escrito para SQLite (placeholders "?",
+     AUTOINCREMENT). El driver se elige en cmd/api; con
+     "-tags sqlite" se enlaza modernc.org/sqlite (Go puro).
+   - Las fechas se guardan como TEXT en UTC con un formato de
+     largo FIJO, para que el orden alfabético sea el cronológico.
+   - Los textos por los que se filtra se guardan también
+     PLEGADOS (title_norm, author_norm, category_norm,
+     tag_norm): SQLite no sabe ignorar acentos.
+   - users y books tienen una columna version: los UPDATE
+     exigen la versión leída y la incrementan en 1
+     (ver migrations/0002_versions.up.sql).
+   - access_reader_sketches guarda un sketch HyperLogLog de
+     lectores por (libro, día), actualizado en cada acceso
+     (ver migrations/0004_reader_sketches.up.sql).
+
+   Los repositorios reciben un sqlQuerier, que puede ser la
+   conexión (*sql.DB) o una transacción (*sql.Tx).
+*/
+
+// OpenSQL abre la base de datos y verifica que responda. Si el driver
+// no está enlazado en el binario (por ejemplo, "sqlite" sin -tags sqlite)
+// devuelve un error que lo explica en lugar del genérico de database/sql.
+func OpenSQL(driver, dsn string) (*sql.DB, error) {
+	if !slices.Contains(sql.Drivers(), driver) {
+		return nil, fmt.Errorf("el driver %q no está enlazado en este binario (para SQLite compilar con -tags sqlite)", driver)
+	}
+	conn, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.Ping(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// sqlQuerier es lo que tienen en común *sql.DB y *sql.Tx.
+type sqlQuerier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// maxSQLInList es la cantidad máxima de valores de un "IN (?, ...)".
+// SQLite acepta a lo sumo 32766 variables por sentencia: las listas
+// que dependen del tamaño del catálogo se consultan por tramos.
+const maxSQLInList = 500
+
+// sqlPlaceholders devuelve "?, ?, ..." con n marcas.
+func sqlPlaceholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+// sqlTimeLayout es RFC 3339 con nanosegundos SIEMPRE presentes.
+const sqlTimeLayout = "2006-01-02T15:04:05.000000000Z07:00"
+
+// formatSQLTime convierte una fecha al formato guardado en la base.
+func formatSQLTime(t time.Time) string {
+	return t.UTC().Format(sqlTimeLayout)
+}
+
+// parseSQLTime lee una fecha guardada con formatSQLTime.
+func parseSQLTime(s string) (time.Time, error) {
+	t, err := time.Parse(sqlTimeLayout, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("fecha inválida en la base de datos %q: %w", s, err)
+	}
+	return t, nil
+}
+
+// withTx ejecuta fn dentro de una transacción.
+// Si q ya es una transacción, fn corre dentro de ella.
+func withTx(ctx context.Context, q sqlQuerier, fn func(q sqlQuerier) error) error {
+	conn, ok := q.(*sql.DB)
+	if !ok {
+		return fn(q)
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// isUniqueViolation detecta errores de clave única (cada driver los
+// reporta distinto, pero todos mencionan "UNIQUE" en el mensaje).
+func isUniqueViolation(err error) bool {
+	return err != nil && strings.Contains(strings.ToUpper(err.Error()), "UNIQUE")
+}
+
+// escapeLike escapa los comodines de LIKE para buscar el texto literal.
+func escapeLike(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return r.Replace(s)
+}
+
+// rowScanner es lo común entre *sql.Row y *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+/*
+   ==========================================================
+   SQLUserRepo
+   ==========================================================
+*/
+
+// SQLUserRepo implementa domain.UserRepository con database/sql.
+type SQLUserRepo struct {
+	db sqlQuerier
+}
+
+// NewSQLUserRepo crea el repositorio de usuarios sobre la conexión dada.
+func NewSQLUserRepo(conn *sql.DB) *SQLUserRepo {
+	return &SQLUserRepo{db: conn}
+}
+
+const userColumns = "id, name, email, role, active, created_at, version"
+
+// scanUser lee una fila de users.
+func scanUser(row rowScanner) (*domain.User, error) {
+	var (
+		rec       userRecord
+		createdAt string
+	)
+	if err := row.Scan(&rec.ID, &rec.Name, &rec.Email, &rec.Role, &rec.Active, &createdAt, &rec.Version); err != nil {
+		return nil, err
+	}
+	t, err := parseSQLTime(createdAt)
+	if err != nil {
+		return nil, err
+	}
+	rec.CreatedAt = t
+	return rec.toDomain(), nil
+}
+
+// Create inserta un usuario nuevo y le asigna el ID generado.
+func (r *SQLUserRepo) Create(user *domain.User) error {
+	ctx := context.Background()
+
+	existing, err := r.FindByEmail(user.Email())
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return domain.Conflict("ya existe un usuario con ese email")
+	}
+
+	res, err := r.db.ExecContext(ctx,
+		"INSERT INTO users (name, email, role, active, created_at) VALUES (?, ?, ?, ?, ?)",
+		user.Name(), user.Email(), string(user.Role()), user.Active(), formatSQLTime(user.CreatedAt()),
+	)
+	if isUniqueViolation(err) {
+		return domain.Conflict("ya existe un usuario con ese email")
+	}
+	if err != nil {
+		return err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	user.SetID(domain.UserID(id))
+	user.SetVersion(1)
+	return nil
+}
+
+// Update guarda los cambios de un usuario existente.
+// Solo actualiza la fila si su versión sigue siendo la del usuario.
+func (r *SQLUserRepo) Update(user *domain.User) error {
+	if user.ID() == 0 {
+		return domain.NewValidationError("id", "el usuario no tiene ID asignado")
+	}
+	ctx := context.Background()
+
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE users SET name = ?, email = ?, role = ?, active = ?, version = version + 1
+		 WHERE id = ? AND version = ?`,
+		user.Name(), user.Email(), string(user.Role()), user.Active(),
+		int64(user.ID()), user.Version(),
+	)
+	if isUniqueViolation(err) {
+		return domain.Conflict("ya existe un usuario con ese email")
+	}
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return staleOrMissing(ctx, r.db, "users", int64(user.ID()),
+			domain.NotFound("no existe un usuario con ese ID"),
+			domain.VersionConflict("el usuario fue modificado por otra operación"))
+	}
+	user.SetVersion(user.Version() + 1)
+	return nil
+}
+
+// staleOrMissing distingue por qué un UPDATE con versión no afectó filas:
+// si la fila existe, la versión estaba desactualizada; si no, no existe.
+func staleOrMissing(ctx context.Context, q sqlQuerier, table string, id int64, notFound, stale error) error {
+	var exists int
+	err := q.QueryRowContext(ctx, "SELECT 1 FROM "+table+" WHERE id = ?", id).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return notFound
+	}
+	if err != nil {
+		return err
+	}
+	return stale
+}
+
+// FindByID busca un usuario por su ID. Devuelve (nil, nil) si no existe.
+func (r *SQLUserRepo) FindByID(id domain.UserID) (*domain.User, error) {
+	row := r.db.QueryRowContext(context.Background(),
+		"SELECT "+userColumns+" FROM users WHERE id = ?", int64(id))
+
+	user, err := scanUser(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return user, err
+}
+
+// FindByEmail busca un usuario por email (sin distinguir mayúsculas).
+func (r *SQLUserRepo) FindByEmail(email string) (*domain.User, error) {
+	row := r.db.QueryRowContext(context.Background(),
+		"SELECT "+userColumns+" FROM users WHERE LOWER(email) = LOWER(?)", email)
+
+	user, err := scanUser(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return user, err
+}
+
+// ListAll devuelve todos los usuarios ordenados por ID.
+func (r *SQLUserRepo) ListAll() ([]*domain.User, error) {
+	rows, err := r.db.QueryContext(context.Background(),
+		"SELECT "+userColumns+" FROM users ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]*domain.User, 0)
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, user)
+	}
+	return result, rows.Err()
+}
+
+// Search devuelve una página de usuarios en el orden pedido.
+func (r *SQLUserRepo) Search(query domain.UserQuery) ([]*domain.User, error) {
+	col := userSortColumn(query.SortBy)
+
+	sqlQuery := "SELECT " + userColumns + " FROM users"
+	var args []any
+	if query.After != nil {
+		clause, clauseArgs, err := col.afterClause(query.After, query.SortDesc)
+		if err != nil {
+			return nil, err
+		}
+		sqlQuery += " WHERE " + clause
+		args = clauseArgs
+	}
+	sqlQuery += col.orderBy(query.SortDesc, query.Limit)
+
+	rows, err := r.db.QueryContext(context.Background(), sqlQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]*domain.User, 0)
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, user)
+	}
+	return result, rows.Err()
+}
+
+/*
+   ==========================================================
+   SQLBookRepo
+   ==========================================================
+*/
+
+// SQLBookRepo implementa domain.BookRepository con database/sql.
+// Los tags se guardan en la tabla book_tags (una fila por tag).
+type SQLBookRepo struct {
+	db sqlQuerier
+}
+
+// NewSQLBookRepo crea el repositorio de libros sobre la conexión dada.
+func NewSQLBookRepo(conn *sql.DB) *SQLBookRepo {
+	return &SQLBookRepo{db: conn}
+}
+
+const bookColumns = "b.id, b.title, b.author, b.year, b.isbn, b.category_ti, b.active, b.created_at, b.version"
+
+// Create inserta el libro y sus tags en una misma transacción.
+func (r *SQLBookRepo) Create(book *domain.Book) error {
+	ctx := context.Background()
+
+	return withTx(ctx, r.db, func(q sqlQuerier) error {
+		res, err := q.ExecContext(ctx,
+			`INSERT INTO books (title, author, year, isbn, category_ti, active, created_at,
+			                    title_norm, author_norm, category_norm)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			book.Title(), book.Author(), book.Year(), book.ISBN(), book.CategoryTI(),
+			book.Active(), formatSQLTime(book.CreatedAt()),
+			textnorm.FoldKey(book.Title()), textnorm.FoldKey(book.Author()), textnorm.FoldKey(book.CategoryTI()),
+		)
+		if err != nil {
+			return err
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		if err := insertBookTags(ctx, q, domain.BookID(id), book.Tags()); err != nil {
+			return err
+		}
+		book.SetID(domain.BookID(id))
+		book.SetVersion(1)
+		return nil
+	})
+}
+
+// Update guarda los cambios del libro y reemplaza sus tags.
+// Igual que con los usuarios, exige que la versión no haya cambiado.
+func (r *SQLBookRepo) Update(book *domain.Book) error {
+	if book.ID() == 0 {
+		return domain.NewValidationError("id", "el libro no tiene ID asignado")
+	}
+	ctx := context.Background()
+
+	return withTx(ctx, r.db, func(q sqlQuerier) error {
+		res, err := q.ExecContext(ctx,
+			`UPDATE books SET title = ?, author = ?, year = ?, isbn = ?, category_ti = ?, active = ?,
+			        title_norm = ?, author_norm = ?, category_norm = ?,
+			        version = version + 1
+			 WHERE id = ? AND version = ?`,
+			book.Title(), book.Author(), book.Year(), book.ISBN(), book.CategoryTI(), book.Active(),
+			textnorm.FoldKey(book.Title()), textnorm.FoldKey(book.Author()), textnorm.FoldKey(book.CategoryTI()),
+			int64(book.ID()), book.Version(),
+		)
+		if err != nil {
+			return err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return staleOrMissing(ctx, q, "books", int64(book.ID()),
+				domain.NotFound("no existe un libro con ese ID"),
+				domain.VersionConflict("el libro fue modificado por otra operación"))
+		}
+
+		if _, err := q.ExecContext(ctx, "DELETE FROM book_tags WHERE book_id = ?", int64(book.ID())); err != nil {
+			return err
+		}
+		if err := insertBookTags(ctx, q, book.ID(), book.Tags()); err != nil {
+			return err
+		}
+		book.SetVersion(book.Version() + 1)
+		return nil
+	})
+}
+
+// insertBookTags guarda los tags de un libro conservando su orden.
+func insertBookTags(ctx context.Context, q sqlQuerier, id domain.BookID, tags []string) error {
+	for pos, tag := range tags {
+		if _, err := q.ExecContext(ctx,
+			"INSERT INTO book_tags (book_id, position, tag, tag_norm) VALUES (?, ?, ?, ?)",
+			int64(id), pos, tag, domain.NormalizeTag(tag),
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FindByID busca un libro por ID. Devuelve (nil, nil) si no existe.
+func (r *SQLBookRepo) FindByID(id domain.BookID) (*domain.Book, error) {
+	books, err := r.queryBooks(context.Background(),
+		"SELECT "+bookColumns+" FROM books b WHERE b.id = ?", int64(id))
+	if err != nil {
+		return nil, err
+	}
+	if len(books) == 0 {
+		return nil, nil
+	}
+	return books[0], nil
+}
+
+/*
+SearchByFilters traduce el BookFilter a SQL:
+
+- TitleContains / AuthorContains → col_norm LIKE '%texto%' (sin acentos)
+- CategoryTI                     → comparación con category_norm
+- ISBN                           → comparación sin guiones ni espacios
+- YearFrom / YearTo              → rango de años
+- Tags (modo "all")              → un EXISTS por tag (debe tener TODOS)
+- Tags (modo "any")              → EXISTS con IN (al menos uno)
+- Tags (modo "none")             → NOT EXISTS con IN (ninguno)
+- SortBy / After / Limit         → ORDER BY (clave, id), cursor y LIMIT (ver paging.go)
+
+Con Fuzzy o Query, SQL no alcanza (no sabe comparar con errores de
+tipeo ni evaluar el lenguaje de consulta): esas condiciones se verifican
+en Go, con el mismo evaluador que InMemoryBookRepo (indexedBook.matches),
+y el cursor y el límite se aplican después, con pageByKey.
+*/
+func (r *SQLBookRepo) SearchByFilters(filter domain.BookFilter) ([]*domain.Book, error) {
+	where, args, inGo := bookFilterConditions(filter)
+
+	col := bookSortColumn(filter.SortBy)
+	if inGo {
+		return r.searchInGo(filter, where, args, col)
+	}
+	if filter.After != nil {
+		clause, clauseArgs, err := col.afterClause(filter.After, filter.SortDesc)
+		if err != nil {
+			return nil, err
+		}
+		where = append(where, clause)
+		args = append(args, clauseArgs...)
+	}
+
+	query := "SELECT " + bookColumns + " FROM books b WHERE " +
+		strings.Join(where, " AND ") + col.orderBy(filter.SortDesc, filter.Limit)
+
+	return r.queryBooks(context.Background(), query, args...)
+}
+
+// bookFilterConditions arma las condiciones WHERE (sobre "b") de un filtro,
+// sin orden ni cursor. inGo indica que hay condiciones (Fuzzy, Query)
+// que NO están en el WHERE y deben verificarse en Go.
+func bookFilterConditions(filter domain.BookFilter) (where []string, args []any, inGo bool) {
+	where = []string{"b.active = 1"}
+	fuzzy := filter.Fuzzy && (filter.TitleContains != "" || filter.AuthorContains != "")
+	inGo = fuzzy || filter.Query != nil
+
+	if filter.TitleContains != "" && !fuzzy {
+		where = append(where, `b.title_norm LIKE ? ESCAPE '\'`)
+		args = append(args, "%"+escapeLike(textnorm.FoldKey(filter.TitleContains))+"%")
+	}
+	if filter.AuthorContains != "" && !fuzzy {
+		where = append(where, `b.author_norm LIKE ? ESCAPE '\'`)
+		args = append(args, "%"+escapeLike(textnorm.FoldKey(filter.AuthorContains))+"%")
+	}
+	if filter.CategoryTI != "" {
+		where = append(where, "b.category_norm = ?")
+		args = append(args, textnorm.FoldKey(filter.CategoryTI))
+	}
+	if filter.ISBN != "" {
+		where = append(where, "UPPER(REPLACE(REPLACE(b.isbn, '-', ''), ' ', '')) = ?")
+		args = append(args, normalizeISBN(filter.ISBN))
+	}
+	if filter.YearFrom > 0 {
+		where = append(where, "b.year >= ?")
+		args = append(args, filter.YearFrom)
+	}
+	if filter.YearTo > 0 {
+		where = append(where, "b.year <= ?")
+		args = append(args, filter.YearTo)
+	}
+	if tags := filter.NormalizedTags(); len(tags) > 0 {
+		switch filter.TagMode {
+		case domain.TagMatchAny, domain.TagMatchNone:
+			// A lo sumo domain.MaxFilterTags (lo valida el caso de uso).
+			for _, tag := range tags {
+				args = append(args, tag)
+			}
+			cond := "EXISTS (SELECT 1 FROM book_tags t WHERE t.book_id = b.id AND t.tag_norm IN (" +
+				sqlPlaceholders(len(tags)) + "))"
+			if filter.TagMode == domain.TagMatchNone {
+				cond = "NOT " + cond
+			}
+			where = append(where, cond)
+		default:
+			for _, tag := range tags {
+				where = append(where,
+					"EXISTS (SELECT 1 FROM book_tags t WHERE t.book_id = b.id AND t.tag_norm = ?)")
+				args = append(args, tag)
+			}
+		}
+	}
+	return where, args, inGo
+}
+
+// searchInGo trae los libros que cumplen las condiciones SQL y
+// verifica el resto en Go antes de paginar.
+func (r *SQLBookRepo) searchInGo(filter domain.BookFilter, where []string, args []any, col sqlSortColumn) ([]*domain.Book, error) {
+	matched, err := r.matchInGo(filter, where, args, col.orderBy(filter.SortDesc, 0))
+	if err != nil {
+		return nil, err
+	}
+
+	return pageByKey(matched,
+		func(b *domain.Book) string { return domain.BookSortKey(b, filter.SortBy) },
+		func(b *domain.Book) int64 { return int64(b.ID()) },
+		filter.SortDesc, filter.After, filter.Limit), nil
+}
+
+// matchInGo ejecuta las condiciones del WHERE y verifica en Go el
+// filtro completo con el evaluador en memoria (ver book_index.go).
+func (r *SQLBookRepo) matchInGo(filter domain.BookFilter, where []string, args []any, orderBy string) ([]*domain.Book, error) {
+	query := "SELECT " + bookColumns + " FROM books b WHERE " +
+		strings.Join(where, " AND ") + orderBy
+
+	books, err := r.queryBooks(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	q := newBookQuery(filter)
+	matched := make([]*domain.Book, 0, len(books))
+	for _, b := range books {
+		if newIndexedBook(b).matches(q) {
+			matched = append(matched, b)
+		}
+	}
+	return matched, nil
+}
+
+/*
+Facets cuenta los libros del filtro con tres GROUP BY (categoría, año
+y tag) sobre las mismas condiciones que SearchByFilters. Con Fuzzy o
+Query los libros se traen y se cuentan en Go (facetCounter).
+*/
+func (r *SQLBookRepo) Facets(filter domain.BookFilter) (domain.BookFacets, error) {
+	where, args, inGo := bookFilterConditions(filter)
+	if inGo {
+		books, err := r.matchInGo(filter, where, args, " ORDER BY b.id")
+		if err != nil {
+			return domain.BookFacets{}, err
+		}
+		counter := newFacetCounter()
+		for _, b := range books {
+			counter.add(b)
+		}
+		return counter.facets(), nil
+	}
+
+	ctx := context.Background()
+	cond := strings.Join(where, " AND ")
+	var (
+		facets domain.BookFacets
+		err    error
+	)
+
+	// MIN(...) elige una escritura fija para cada grupo.
+	facets.Categories, err = r.queryFacetCounts(ctx,
+		"SELECT MIN(b.category_ti), COUNT(*) FROM books b WHERE "+cond+
+			" GROUP BY b.category_norm", args...)
+	if err != nil {
+		return domain.BookFacets{}, err
+	}
+	facets.Tags, err = r.queryFacetCounts(ctx,
+		"SELECT MIN(t.tag), COUNT(DISTINCT b.id) FROM books b JOIN book_tags t ON t.book_id = b.id WHERE "+
+			cond+" GROUP BY t.tag_norm", args...)
+	if err != nil {
+		return domain.BookFacets{}, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, "SELECT b.year, COUNT(*) FROM books b WHERE "+cond+" GROUP BY b.year", args...)
+	if err != nil {
+		return domain.BookFacets{}, err
+	}
+	defer rows.Close()
+
+	byYear := make(map[int]int)
+	for rows.Next() {
+		var year, count int
+		if err := rows.Scan(&year, &count); err != nil {
+			return domain.BookFacets{}, err
+		}
+		byYear[year] = count
+	}
+	if err := rows.Err(); err != nil {
+		return domain.BookFacets{}, err
+	}
+	facets.Decades, facets.Years = domain.YearFacets(byYear)
+	return facets, nil
+}
+
+// queryFacetCounts lee filas (valor, cantidad) y las ordena.
+func (r *SQLBookRepo) queryFacetCounts(ctx context.Context, query string, args ...any) ([]domain.FacetCount, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var counts []domain.FacetCount
+	for rows.Next() {
+		var fc domain.FacetCount
+		if err := rows.Scan(&fc.Value, &fc.Count); err != nil {
+			return nil, err
+		}
+		counts = append(counts, fc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return domain.SortFacetCounts(counts), nil
+}
+
+/*
+SuggestSpelling arma el vocabulario de títulos y autores de los libros
+activos y corrige con él los textos del filtro (ver fuzzy.go).
+
+Solo se usa cuando una búsqueda no encontró nada, por eso se lee el
+vocabulario en cada llamada en lugar de mantenerlo en una tabla.
+*/
+func (r *SQLBookRepo) SuggestSpelling(filter domain.BookFilter) (domain.SpellingSuggestion, error) {
+	var s domain.SpellingSuggestion
+	if filter.TitleContains == "" && filter.AuthorContains == "" {
+		return s, nil
+	}
+
+	rows, err := r.db.QueryContext(context.Background(), "SELECT title, author FROM books WHERE active = 1")
+	if err != nil {
+		return s, err
+	}
+	defer rows.Close()
+
+	titles, authors := make(vocabulary), make(vocabulary)
+	for rows.Next() {
+		var title, author string
+		if err := rows.Scan(&title, &author); err != nil {
+			return s, err
+		}
+		titles.add(title)
+		authors.add(author)
+	}
+	if err := rows.Err(); err != nil {
+		return s, err
+	}
+
+	if filter.TitleContains != "" {
+		s.Title, _ = titles.correct(filter.TitleContains)
+	}
+	if filter.AuthorContains != "" {
+		s.Author, _ = authors.correct(filter.AuthorContains)
+	}
+	return s, nil
+}
+
+/*
+SuggestPrefix completa títulos, autores y tags con LIKE sobre las
+columnas plegadas: el texto empieza con el prefijo, o alguna de sus
+palabras (separadas por espacios) lo hace. Cada consulta agrupa por
+texto y trae TODAS las completaciones; se puntúan con rank y se eligen
+las mejores con el mismo montículo que el trie en memoria (rankedKeys).
+*/
+func (r *SQLBookRepo) SuggestPrefix(prefix string, limit int, rank domain.SuggestionRank) ([]domain.Suggestion, error) {
+	p := escapeLike(textnorm.FoldKey(prefix))
+	if p == "" || limit <= 0 {
+		return nil, nil
+	}
+	ctx := context.Background()
+	starts, wordStarts := p+"%", "% "+p+"%"
+
+	sources := []struct {
+		kind  domain.SuggestionKind
+		query string
+	}{
+		{domain.SuggestTitle, `SELECT MIN(b.title), b.title_norm, GROUP_CONCAT(b.id) FROM books b
+			WHERE b.active = 1 AND (b.title_norm LIKE ? ESCAPE '\' OR b.title_norm LIKE ? ESCAPE '\')
+			GROUP BY b.title_norm`},
+		{domain.SuggestAuthor, `SELECT MIN(b.author), b.author_norm, GROUP_CONCAT(b.id) FROM books b
+			WHERE b.active = 1 AND (b.author_norm LIKE ? ESCAPE '\' OR b.author_norm LIKE ? ESCAPE '\')
+			GROUP BY b.author_norm`},
+		{domain.SuggestTag, `SELECT MIN(t.tag), t.tag_norm, GROUP_CONCAT(DISTINCT b.id) FROM book_tags t
+			JOIN books b ON b.id = t.book_id
+			WHERE b.active = 1 AND (t.tag_norm LIKE ? ESCAPE '\' OR t.tag_norm LIKE ? ESCAPE '\')
+			GROUP BY t.tag_norm`},
+	}
+
+	found := make(map[suggestKey]domain.Suggestion)
+	top := newRankedKeys(limit)
+	for _, src := range sources {
+		rows, err := r.db.QueryContext(ctx, src.query, starts, wordStarts)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var text, norm, ids string
+			if err := rows.Scan(&text, &norm, &ids); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			s := domain.Suggestion{Kind: src.kind, Text: text}
+			for _, idStr := range strings.Split(ids, ",") {
+				if id, err := strconv.ParseInt(idStr, 10, 64); err == nil {
+					s.BookIDs = append(s.BookIDs, domain.BookID(id))
+				}
+			}
+			if rank != nil {
+				s.Score = rank(s.BookIDs)
+			}
+			key := suggestKey{kind: src.kind, text: norm}
+			found[key] = s
+			top.offer(rankedKey{key: key, score: s.Score, books: len(s.BookIDs)})
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	ranked := top.sorted()
+	result := make([]domain.Suggestion, 0, len(ranked))
+	for _, k := range ranked {
+		result = append(result, found[k.key])
+	}
+	return result, nil
+}
+
+// ListAll devuelve todos los libros (activos y archivados) ordenados por ID.
+func (r *SQLBookRepo) ListAll() ([]*domain.Book, error) {
+	return r.queryBooks(context.Background(), "SELECT "+bookColumns+" FROM books b ORDER BY b.id")
+}
+
+// queryBooks ejecuta una consulta sobre books y completa los tags
+// de todos los libros con una sola consulta adicional.
+func (r *SQLBookRepo) queryBooks(ctx context.Context, query string, args ...any) ([]*domain.Book, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []bookRecord
+	for rows.Next() {
+		var (
+			rec       bookRecord
+			createdAt string
+		)
+		if err := rows.Scan(&rec.ID, &rec.Title, &rec.Author, &rec.Year, &rec.ISBN,
+			&rec.CategoryTI, &rec.Active, &createdAt, &rec.Version); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if rec.CreatedAt, err = parseSQLTime(createdAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		records = append(records, rec)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	tags, err := r.loadTags(ctx, records)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*domain.Book, 0, len(records))
+	for _, rec := range records {
+		rec.Tags = tags[rec.ID]
+		result = append(result, rec.toDomain())
+	}
+	return result, nil
+}
+
+// loadTags trae los tags de los libros indicados, en su orden original.
+// Consulta de a maxSQLInList libros: records puede ser todo el catálogo.
+func (r *SQLBookRepo) loadTags(ctx context.Context, records []bookRecord) (map[domain.BookID][]string, error) {
+	result := make(map[domain.BookID][]string, len(records))
+	for chunk := range slices.Chunk(records, maxSQLInList) {
+		if err := r.loadTagsChunk(ctx, chunk, result); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// loadTagsChunk agrega a result los tags de un tramo de libros.
+func (r *SQLBookRepo) loadTagsChunk(ctx context.Context, records []bookRecord, result map[domain.BookID][]string) error {
+	args := make([]any, len(records))
+	for i, rec := range records {
+		args[i] = int64(rec.ID)
+	}
+
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT book_id, tag FROM book_tags WHERE book_id IN ("+sqlPlaceholders(len(records))+
+			") ORDER BY book_id, position", args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			id  domain.BookID
+			tag string
+		)
+		if err := rows.Scan(&id, &tag); err != nil {
+			return err
+		}
+		result[id] = append(result[id], tag)
+	}
+	return rows.Err()
+}
+
+/*
+BackfillSearchColumns completa las columnas plegadas (title_norm,
+author_norm, category_norm, tag_norm) de las filas creadas antes de
+la migración 0003. Solo toca filas con NULL, así que se puede llamar
+en cada arranque. Devuelve cuántas filas actualizó.
+*/
+func BackfillSearchColumns(conn *sql.DB) (int, error) {
+	ctx := context.Background()
+	updated := 0
+
+	err := withTx(ctx, conn, func(q sqlQuerier) error {
+		rows, err := q.QueryContext(ctx,
+			"SELECT id, title, author, category_ti FROM books WHERE title_norm IS NULL")
+		if err != nil {
+			return err
+		}
+		var books []bookRecord
+		for rows.Next() {
+			var rec bookRecord
+			if err := rows.Scan(&rec.ID, &rec.Title, &rec.Author, &rec.CategoryTI); err != nil {
+				rows.Close()
+				return err
+			}
+			books = append(books, rec)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for _, rec := range books {
+			if _, err := q.ExecContext(ctx,
+				"UPDATE books SET title_norm = ?, author_norm = ?, category_norm = ? WHERE id = ?",
+				textnorm.FoldKey(rec.Title), textnorm.FoldKey(rec.Author), textnorm.FoldKey(rec.CategoryTI),
+				int64(rec.ID),
+			); err != nil {
+				return err
+			}
+			updated++
+		}
+
+		tagRows, err := q.QueryContext(ctx,
+			"SELECT book_id, position, tag FROM book_tags WHERE tag_norm IS NULL")
+		if err != nil {
+			return err
+		}
+		type tagRow struct {
+			bookID   int64
+			position int
+			tag      string
+		}
+		var tags []tagRow
+		for tagRows.Next() {
+			var t tagRow
+			if err := tagRows.Scan(&t.bookID, &t.position, &t.tag); err != nil {
+				tagRows.Close()
+				return err
+			}
+			tags = append(tags, t)
+		}
+		tagRows.Close()
+		if err := tagRows.Err(); err != nil {
+			return err
+		}
+
+		for _, t := range tags {
+			if _, err := q.ExecContext(ctx,
+				"UPDATE book_tags SET tag_norm = ? WHERE book_id = ? AND position = ?",
+				domain.NormalizeTag(t.tag), t.bookID, t.position,
+			); err != nil {
+				return err
+			}
+			updated++
+		}
+		return nil
+	})
+	return updated, err
+}
+
+/*
+BackfillReaderSketches arma los sketches de lectores de los accesos
+guardados antes de la migración 0004. Solo actúa si la tabla de
+sketches está vacía y hay accesos, así que se puede llamar en cada
+arranque. Devuelve cuántos sketches (libro, día) guardó.
+*/
+func BackfillReaderSketches(conn *sql.DB) (int, error) {
+	ctx := context.Background()
+	saved := 0
+
+	err := withTx(ctx, conn, func(q sqlQuerier) error {
+		var hasSketches, hasEvents bool
+		if err := q.QueryRowContext(ctx,
+			"SELECT EXISTS (SELECT 1 FROM access_reader_sketches), EXISTS (SELECT 1 FROM access_events)",
+		).Scan(&hasSketches, &hasEvents); err != nil {
+			return err
+		}
+		if hasSketches || !hasEvents {
+			return nil
+		}
+
+		events, err := (&SQLAccessLogRepo{db: q}).queryEvents(ctx,
+			"SELECT "+accessEventColumns+" FROM access_events ORDER BY id")
+		if err != nil {
+			return err
+		}
+		readers := make(readerSketches)
+		for _, ev := range events {
+			readers.add(ev)
+		}
+
+		for day, books := range readers {
+			for bookID, sk := range books {
+				if err := saveReaderSketch(ctx, q, bookID, day, sk); err != nil {
+					return err
+				}
+				saved++
+			}
+		}
+		return nil
+	})
+	return saved, err
+}
+
+/*
+   ==========================================================
+   SQLAccessLogRepo
+   ==========================================================
+*/
+
+// SQLAccessLogRepo implementa domain.AccessLogRepository con database/sql.
+type SQLAccessLogRepo struct {
+	db sqlQuerier
+}
+
+// NewSQLAccessLogRepo crea el repositorio de accesos sobre la conexión dada.
+func NewSQLAccessLogRepo(conn *sql.DB) *SQLAccessLogRepo {
+	return &SQLAccessLogRepo{db: conn}
+}
+
+const accessEventColumns = "id, book_id, user_id, access_type, timestamp"
+
+// Store inserta un evento de acceso, le asigna el ID generado y suma
+// el usuario al sketch de lectores del libro y el día, todo en la
+// misma transacción.
+func (r *SQLAccessLogRepo) Store(event *domain.AccessEvent) error {
+	ctx := context.Background()
+	var id int64
+	err := withTx(ctx, r.db, func(q sqlQuerier) error {
+		res, err := q.ExecContext(ctx,
+			"INSERT INTO access_events (book_id, user_id, access_type, timestamp) VALUES (?, ?, ?, ?)",
+			int64(event.BookID()), int64(event.UserID()), string(event.AccessType()),
+			formatSQLTime(event.Timestamp()),
+		)
+		if err != nil {
+			return err
+		}
+		if id, err = res.LastInsertId(); err != nil {
+			return err
+		}
+		return addReader(ctx, q, event)
+	})
+	if err != nil {
+		return err
+	}
+	event.SetID(domain.AccessEventID(id))
+	return nil
+}
+
+// addReader lee el sketch del libro y el día del evento (o crea uno
+// vacío), le suma el usuario y lo vuelve a guardar.
+func addReader(ctx context.Context, q sqlQuerier, event *domain.AccessEvent) error {
+	day := readerDay(event.Timestamp())
+	sk := hll.New()
+
+	var data []byte
+	err := q.QueryRowContext(ctx,
+		"SELECT sketch FROM access_reader_sketches WHERE book_id = ? AND day = ?",
+		int64(event.BookID()), day,
+	).Scan(&data)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+	case err != nil:
+		return err
+	default:
+		if err := sk.UnmarshalBinary(data); err != nil {
+			return err
+		}
+	}
+
+	sk.AddUint64(uint64(event.UserID()))
+	return saveReaderSketch(ctx, q, event.BookID(), day, sk)
+}
+
+// saveReaderSketch inserta o reemplaza el sketch de un libro y un día.
+func saveReaderSketch(ctx context.Context, q sqlQuerier, bookID domain.BookID, day string, sk *hll.Sketch) error {
+	data, err := sk.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	_, err = q.ExecContext(ctx,
+		`INSERT INTO access_reader_sketches (book_id, day, sketch) VALUES (?, ?, ?)
+		 ON CONFLICT (book_id, day) DO UPDATE SET sketch = excluded.sketch`,
+		int64(bookID), day, data,
+	)
+	return err
+}
+
+// ReaderSketches lee los sketches de los días que toca [from, to)
+// (filtrando por libro si se piden) y los combina por día. Una categoría
+// puede tener miles de libros: se consultan de a maxSQLInList.
+func (r *SQLAccessLogRepo) ReaderSketches(bookIDs []domain.BookID, from, to time.Time) (map[string]*hll.Sketch, error) {
+	result := make(map[string]*hll.Sketch)
+	if len(bookIDs) == 0 {
+		return result, r.mergeReaderSketches(result, nil, from, to)
+	}
+	for chunk := range slices.Chunk(bookIDs, maxSQLInList) {
+		if err := r.mergeReaderSketches(result, chunk, from, to); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// mergeReaderSketches combina en result, por día, los sketches de los
+// libros indicados (vacío = todos).
+func (r *SQLAccessLogRepo) mergeReaderSketches(result map[string]*hll.Sketch, bookIDs []domain.BookID, from, to time.Time) error {
+	var (
+		where []string
+		args  []any
+	)
+	if !from.IsZero() {
+		where = append(where, "day >= ?")
+		args = append(args, readerDay(from))
+	}
+	if !to.IsZero() {
+		where = append(where, "day < ?")
+		args = append(args, readerDayEnd(to))
+	}
+	if len(bookIDs) > 0 {
+		for _, id := range bookIDs {
+			args = append(args, int64(id))
+		}
+		where = append(where, "book_id IN ("+sqlPlaceholders(len(bookIDs))+")")
+	}
+
+	query := "SELECT day, sketch FROM access_reader_sketches"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	rows, err := r.db.QueryContext(context.Background(), query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			day  string
+			data []byte
+		)
+		if err := rows.Scan(&day, &data); err != nil {
+			return err
+		}
+		sk := hll.New()
+		if err := sk.UnmarshalBinary(data); err != nil {
+			return err
+		}
+		merged, ok := result[day]
+		if !ok {
+			result[day] = sk
+			continue
+		}
+		if err := merged.Merge(sk); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// ListByBook devuelve los eventos de un libro en orden cronológico.
+func (r *SQLAccessLogRepo) ListByBook(bookID domain.BookID) ([]*domain.AccessEvent, error) {
+	return r.queryEvents(context.Background(),
+		"SELECT "+accessEventColumns+" FROM access_events WHERE book_id = ? ORDER BY timestamp, id",
+		int64(bookID))
+}
+
+// ListAll devuelve todos los eventos ordenados por ID.
+func (r *SQLAccessLogRepo) ListAll() ([]*domain.AccessEvent, error) {
+	return r.queryEvents(context.Background(),
+		"SELECT "+accessEventColumns+" FROM access_events ORDER BY id")
+}
+
+// ListBetween devuelve los eventos de un rango de fechas en orden
+// cronológico. Las fechas se guardan en UTC con largo fijo, así la
+// comparación de textos es una comparación de fechas y usa el índice
+// idx_access_events_timestamp.
+func (r *SQLAccessLogRepo) ListBetween(from, to time.Time) ([]*domain.AccessEvent, error) {
+	return r.queryEvents(context.Background(),
+		"SELECT "+accessEventColumns+" FROM access_events WHERE timestamp >= ? AND timestamp < ? ORDER BY timestamp, id",
+		formatSQLTime(from), formatSQLTime(to))
+}
+
+// CountByBook cuenta los accesos de cada libro con un GROUP BY.
+func (r *SQLAccessLogRepo) CountByBook() (map[domain.BookID]int, error) {
+	rows, err := r.db.QueryContext(context.Background(),
+		"SELECT book_id, COUNT(*) FROM access_events GROUP BY book_id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[domain.BookID]int)
+	for rows.Next() {
+		var (
+			id    int64
+			count int
+		)
+		if err := rows.Scan(&id, &count); err != nil {
+			return nil, err
+		}
+		counts[domain.BookID(id)] = count
+	}
+	return counts, rows.Err()
+}
+
+// ListByUser devuelve los eventos de un usuario en orden cronológico.
+func (r *SQLAccessLogRepo) ListByUser(userID domain.UserID) ([]*domain.AccessEvent, error) {
+	return r.queryEvents(context.Background(),
+		"SELECT "+accessEventColumns+" FROM access_events WHERE user_id = ? ORDER BY timestamp, id",
+		int64(userID))
+}
+
+// queryEvents ejecuta una consulta sobre access_events.
+func (r *SQLAccessLogRepo) queryEvents(ctx context.Context, query string, args ...any) ([]*domain.AccessEvent, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]*domain.AccessEvent, 0)
+	for rows.Next() {
+		var (
+			rec accessEventRecord
+			ts  string
+		)
+		if err := rows.Scan(&rec.ID, &rec.BookID, &rec.UserID, &rec.AccessType, &ts); err != nil {
+			return nil, err
+		}
+		if rec.Timestamp, err = parseSQLTime(ts); err != nil {
+			return nil, err
+		}
+		result = append(result, rec.toDomain())
+	}
+	return result, rows.Err()
+}
@@ -0,0 +1,715 @@
+//go:build sqlite
+
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/domain"
+)
+
+/*
+   ==========================================================
+   PRUEBAS DE LOS REPOSITORIOS SQL
+   ==========================================================
+
+   Corren contra una base SQLite real (archivo temporal) con
+   todas las migraciones aplicadas:
+
+	go test -tags sqlite ./internal/infrastructure/db
+*/
+
+// openTestDB abre una base temporal con todas las migraciones aplicadas.
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	conn := openTestConn(t)
+	if _, err := MigrateUp(conn); err != nil {
+		t.Fatalf("MigrateUp: %v", err)
+	}
+	return conn
+}
+
+func mustUser(t *testing.T, repo domain.UserRepository, name, email string) *domain.User {
+	t.Helper()
+	u, err := domain.NewUser(name, email, domain.RoleReader)
+	if err != nil {
+		t.Fatalf("NewUser: %v", err)
+	}
+	if err := repo.Create(u); err != nil {
+		t.Fatalf("Create(%s): %v", email, err)
+	}
+	return u
+}
+
+func mustBook(t *testing.T, repo domain.BookRepository, title, author string, year int, isbn, category string, tags ...string) *domain.Book {
+	t.Helper()
+	b, err := domain.NewBook(title, author, year, isbn, category, tags)
+	if err != nil {
+		t.Fatalf("NewBook: %v", err)
+	}
+	if err := repo.Create(b); err != nil {
+		t.Fatalf("Create(%s): %v", title, err)
+	}
+	return b
+}
+
+func mustStore(t *testing.T, repo domain.AccessLogRepository, bookID domain.BookID, userID domain.UserID, typ domain.AccessType, ts time.Time) *domain.AccessEvent {
+	t.Helper()
+	ev := domain.RestoreAccessEvent(0, bookID, userID, typ, ts)
+	if err := repo.Store(ev); err != nil {
+		t.Fatalf("Store: %v", err)
+	}
+	return ev
+}
+
+// bookIDs devuelve los IDs de los libros en el orden recibido.
+func bookIDs(books []*domain.Book) []domain.BookID {
+	ids := make([]domain.BookID, len(books))
+	for i, b := range books {
+		ids[i] = b.ID()
+	}
+	return ids
+}
+
+/*
+   ----------------------------------------------------------
+   Usuarios
+   ----------------------------------------------------------
+*/
+
+func TestSQLUserRepoCreateAndFind(t *testing.T) {
+	repo := NewSQLUserRepo(openTestDB(t))
+
+	ana := mustUser(t, repo, "Ana", "ana@example.com")
+	if ana.ID() == 0 || ana.Version() != 1 {
+		t.Fatalf("Create asignó ID %d y versión %d", ana.ID(), ana.Version())
+	}
+
+	dup, _ := domain.NewUser("Otra Ana", "ANA@example.com", domain.RoleReader)
+	if err := repo.Create(dup); !errors.Is(err, domain.ErrConflict) {
+		t.Fatalf("Create con email repetido: se esperaba ErrConflict, vino %v", err)
+	}
+
+	got, err := repo.FindByID(ana.ID())
+	if err != nil || got == nil {
+		t.Fatalf("FindByID: %v, %v", got, err)
+	}
+	if got.Name() != "Ana" || got.Email() != "ana@example.com" || got.Role() != domain.RoleReader || !got.Active() {
+		t.Fatalf("FindByID devolvió %+v", got)
+	}
+	if !got.CreatedAt().Equal(ana.CreatedAt()) {
+		t.Fatalf("CreatedAt %v, se esperaba %v", got.CreatedAt(), ana.CreatedAt())
+	}
+
+	if got, err := repo.FindByID(999); got != nil || err != nil {
+		t.Fatalf("FindByID inexistente: %v, %v", got, err)
+	}
+
+	byEmail, err := repo.FindByEmail("Ana@Example.COM")
+	if err != nil || byEmail == nil || byEmail.ID() != ana.ID() {
+		t.Fatalf("FindByEmail sin distinguir mayúsculas: %v, %v", byEmail, err)
+	}
+	if got, err := repo.FindByEmail("nadie@example.com"); got != nil || err != nil {
+		t.Fatalf("FindByEmail inexistente: %v, %v", got, err)
+	}
+}
+
+func TestSQLUserRepoUpdate(t *testing.T) {
+	repo := NewSQLUserRepo(openTestDB(t))
+	ana := mustUser(t, repo, "Ana", "ana@example.com")
+	mustUser(t, repo, "Beto", "beto@example.com")
+
+	stale := ana.Clone()
+	if err := ana.ApplyChanges("Ana María", "ana.maria@example.com", domain.RoleAdmin); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Update(ana); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if ana.Version() != 2 {
+		t.Fatalf("versión después de Update = %d, se esperaba 2", ana.Version())
+	}
+	got, _ := repo.FindByID(ana.ID())
+	if got.Name() != "Ana María" || got.Email() != "ana.maria@example.com" || got.Role() != domain.RoleAdmin || got.Version() != 2 {
+		t.Fatalf("FindByID después de Update: %+v", got)
+	}
+
+	// La copia leída antes tiene la versión vieja.
+	stale.Deactivate()
+	if err := repo.Update(stale); !errors.Is(err, domain.ErrVersionConflict) {
+		t.Fatalf("Update con versión vieja: se esperaba ErrVersionConflict, vino %v", err)
+	}
+
+	// El email de otro usuario es un conflicto.
+	if err := got.ApplyChanges(got.Name(), "beto@example.com", got.Role()); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Update(got); !errors.Is(err, domain.ErrConflict) {
+		t.Fatalf("Update con email de otro usuario: se esperaba ErrConflict, vino %v", err)
+	}
+
+	missing := domain.RestoreUser(999, "Nadie", "nadie@example.com", domain.RoleReader, true, time.Now(), 1)
+	if err := repo.Update(missing); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("Update de un usuario inexistente: se esperaba ErrNotFound, vino %v", err)
+	}
+
+	noID, _ := domain.NewUser("Sin ID", "sinid@example.com", domain.RoleReader)
+	if err := repo.Update(noID); !errors.Is(err, domain.ErrValidation) {
+		t.Fatalf("Update sin ID: se esperaba ErrValidation, vino %v", err)
+	}
+}
+
+func TestSQLUserRepoListAllAndSearch(t *testing.T) {
+	repo := NewSQLUserRepo(openTestDB(t))
+	carla := mustUser(t, repo, "Carla", "carla@example.com")
+	ana := mustUser(t, repo, "Ana", "ana@example.com")
+	beto := mustUser(t, repo, "Beto", "beto@example.com")
+
+	all, err := repo.ListAll()
+	if err != nil {
+		t.Fatalf("ListAll: %v", err)
+	}
+	var ids []domain.UserID
+	for _, u := range all {
+		ids = append(ids, u.ID())
+	}
+	if !slices.Equal(ids, []domain.UserID{carla.ID(), ana.ID(), beto.ID()}) {
+		t.Fatalf("ListAll en orden de ID: %v", ids)
+	}
+
+	// Primera página por nombre, de a dos.
+	page, err := repo.Search(domain.UserQuery{SortBy: domain.UserSortName, Limit: 2})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(page) != 2 || page[0].ID() != ana.ID() || page[1].ID() != beto.ID() {
+		t.Fatalf("primera página por nombre: %v", page)
+	}
+
+	// La siguiente página empieza después del último.
+	last := page[len(page)-1]
+	after := &domain.Cursor{Sort: string(domain.UserSortName), Key: domain.UserSortKey(last, domain.UserSortName), ID: int64(last.ID())}
+	page, err = repo.Search(domain.UserQuery{SortBy: domain.UserSortName, Limit: 2, After: after})
+	if err != nil {
+		t.Fatalf("Search con cursor: %v", err)
+	}
+	if len(page) != 1 || page[0].ID() != carla.ID() {
+		t.Fatalf("segunda página por nombre: %v", page)
+	}
+
+	desc, err := repo.Search(domain.UserQuery{SortBy: domain.UserSortName, SortDesc: true})
+	if err != nil {
+		t.Fatalf("Search descendente: %v", err)
+	}
+	if len(desc) != 3 || desc[0].ID() != carla.ID() || desc[2].ID() != ana.ID() {
+		t.Fatalf("orden descendente por nombre: %v", desc)
+	}
+}
+
+/*
+   ----------------------------------------------------------
+   Libros
+   ----------------------------------------------------------
+*/
+
+func TestSQLBookRepoCreateUpdateFind(t *testing.T) {
+	repo := NewSQLBookRepo(openTestDB(t))
+	b := mustBook(t, repo, "Redes de Computadoras", "Andrew Tanenbaum", 2011, "978-84-1234-567-8", "Redes", "tcp", "Protocolos")
+	if b.ID() == 0 || b.Version() != 1 {
+		t.Fatalf("Create asignó ID %d y versión %d", b.ID(), b.Version())
+	}
+
+	got, err := repo.FindByID(b.ID())
+	if err != nil || got == nil {
+		t.Fatalf("FindByID: %v, %v", got, err)
+	}
+	if got.Title() != b.Title() || got.Author() != b.Author() || got.Year() != 2011 ||
+		got.ISBN() != b.ISBN() || got.CategoryTI() != "Redes" || !got.Active() {
+		t.Fatalf("FindByID devolvió %+v", got)
+	}
+	if !slices.Equal(got.Tags(), []string{"tcp", "Protocolos"}) {
+		t.Fatalf("tags %v, se esperaba el orden original", got.Tags())
+	}
+	if got, err := repo.FindByID(999); got != nil || err != nil {
+		t.Fatalf("FindByID inexistente: %v, %v", got, err)
+	}
+
+	stale := got.Clone()
+	if err := got.UpdateDetails("Redes", "A. Tanenbaum", 2012, got.ISBN(), "Redes", []string{"udp"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Update(got); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	again, _ := repo.FindByID(b.ID())
+	if again.Title() != "Redes" || again.Year() != 2012 || again.Version() != 2 || !slices.Equal(again.Tags(), []string{"udp"}) {
+		t.Fatalf("después de Update: %+v", again)
+	}
+
+	stale.Archive()
+	if err := repo.Update(stale); !errors.Is(err, domain.ErrVersionConflict) {
+		t.Fatalf("Update con versión vieja: se esperaba ErrVersionConflict, vino %v", err)
+	}
+	// Un Update rechazado no toca los tags.
+	again, _ = repo.FindByID(b.ID())
+	if !slices.Equal(again.Tags(), []string{"udp"}) || !again.Active() {
+		t.Fatalf("el Update rechazado cambió el libro: %+v", again)
+	}
+
+	missing := domain.RestoreBook(999, "X", "Y", 2000, "1", "Z", nil, true, time.Now(), 1)
+	if err := repo.Update(missing); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("Update de un libro inexistente: se esperaba ErrNotFound, vino %v", err)
+	}
+}
+
+// sqlCatalog carga un catálogo chico para las búsquedas.
+func sqlCatalog(t *testing.T, repo *SQLBookRepo) map[string]*domain.Book {
+	t.Helper()
+	books := map[string]*domain.Book{
+		"redes":   mustBook(t, repo, "Redes de Computadoras", "Andrew Tanenbaum", 2011, "978-84-1234-567-8", "Redes", "tcp", "protocolos"),
+		"so":      mustBook(t, repo, "Sistemas Operativos Modernos", "Andrew Tanenbaum", 2009, "978-0-13-600663-3", "Sistemas", "kernel"),
+		"go":      mustBook(t, repo, "El Lenguaje de Programación Go", "Alan Donovan", 2015, "978-0-13-419044-0", "Programación", "go", "concurrencia"),
+		"cien":    mustBook(t, repo, "Cálculo al 100% de eficiencia", "Pérez Núñez", 1999, "111", "Matemática"),
+		"archivo": mustBook(t, repo, "Redes Antiguas", "Anónimo", 1980, "222", "Redes", "tcp"),
+	}
+	books["archivo"].Archive()
+	if err := repo.Update(books["archivo"]); err != nil {
+		t.Fatalf("archivar: %v", err)
+	}
+	return books
+}
+
+func TestSQLBookRepoSearchByFilters(t *testing.T) {
+	repo := NewSQLBookRepo(openTestDB(t))
+	books := sqlCatalog(t, repo)
+
+	tests := []struct {
+		name   string
+		filter domain.BookFilter
+		want   []string
+	}{
+		{"sin filtro excluye archivados", domain.BookFilter{}, []string{"redes", "so", "go", "cien"}},
+		{"título sin acentos ni mayúsculas", domain.BookFilter{TitleContains: "PROGRAMACION"}, []string{"go"}},
+		{"autor", domain.BookFilter{AuthorContains: "tanenbaum"}, []string{"redes", "so"}},
+		{"autor con acentos", domain.BookFilter{AuthorContains: "nunez"}, []string{"cien"}},
+		{"porcentaje literal", domain.BookFilter{TitleContains: "100%"}, []string{"cien"}},
+		{"guion bajo literal", domain.BookFilter{TitleContains: "_"}, nil},
+		{"categoría", domain.BookFilter{CategoryTI: "programacion"}, []string{"go"}},
+		{"ISBN sin guiones", domain.BookFilter{ISBN: "9780136006633"}, []string{"so"}},
+		{"rango de años", domain.BookFilter{YearFrom: 2009, YearTo: 2011}, []string{"redes", "so"}},
+		{"tags all", domain.BookFilter{Tags: []string{"TCP", "protocolos"}}, []string{"redes"}},
+		{"tags any", domain.BookFilter{Tags: []string{"kernel", "go"}, TagMode: domain.TagMatchAny}, []string{"so", "go"}},
+		{"tags none", domain.BookFilter{Tags: []string{"tcp", "go"}, TagMode: domain.TagMatchNone}, []string{"so", "cien"}},
+		{"fuzzy", domain.BookFilter{AuthorContains: "Tanenbaun", Fuzzy: true}, []string{"redes", "so"}},
+		{"orden por año descendente", domain.BookFilter{SortBy: domain.BookSortYear, SortDesc: true}, []string{"go", "redes", "so", "cien"}},
+		{"límite", domain.BookFilter{Limit: 2}, []string{"redes", "so"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := repo.SearchByFilters(tt.filter)
+			if err != nil {
+				t.Fatalf("SearchByFilters: %v", err)
+			}
+			var want []domain.BookID
+			for _, key := range tt.want {
+				want = append(want, books[key].ID())
+			}
+			if ids := bookIDs(got); !slices.Equal(ids, want) && (len(ids) > 0 || len(want) > 0) {
+				t.Fatalf("SearchByFilters = %v, se esperaba %v", ids, want)
+			}
+		})
+	}
+}
+
+func TestSQLBookRepoListAll(t *testing.T) {
+	repo := NewSQLBookRepo(openTestDB(t))
+	books := sqlCatalog(t, repo)
+
+	all, err := repo.ListAll()
+	if err != nil {
+		t.Fatalf("ListAll: %v", err)
+	}
+	want := []domain.BookID{books["redes"].ID(), books["so"].ID(), books["go"].ID(), books["cien"].ID(), books["archivo"].ID()}
+	if !slices.Equal(bookIDs(all), want) {
+		t.Fatalf("ListAll = %v, se esperaba %v (incluye archivados)", bookIDs(all), want)
+	}
+}
+
+// Con más libros que variables admite SQLite por sentencia (32766),
+// los tags y los sketches se siguen leyendo por tramos.
+func TestSQLReposLargeCatalog(t *testing.T) {
+	if testing.Short() {
+		t.Skip("catálogo grande")
+	}
+	conn := openTestDB(t)
+	const size = 33_000
+
+	tx, err := conn.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	txBooks := &SQLBookRepo{db: tx}
+	for _, b := range syntheticBooks(t, size) {
+		if err := txBooks.Create(b); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	books, access := NewSQLBookRepo(conn), NewSQLAccessLogRepo(conn)
+	all, err := books.ListAll()
+	if err != nil || len(all) != size {
+		t.Fatalf("ListAll: %d libros, %v", len(all), err)
+	}
+	want := syntheticBooks(t, size)
+	for i, b := range all {
+		if !slices.Equal(b.Tags(), want[i].Tags()) {
+			t.Fatalf("libro %d: tags %v, se esperaba %v", b.ID(), b.Tags(), want[i].Tags())
+		}
+	}
+
+	// Las consultas se verifican en Go: cargan los tags de todo el catálogo.
+	query := domain.BookFilter{Query: mustParseQuery(t, "tag:go OR title:redez"), Fuzzy: true}
+	if found, err := books.SearchByFilters(query); err != nil || len(found) == 0 {
+		t.Fatalf("búsqueda con consulta: %d libros, %v", len(found), err)
+	}
+
+	day := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+	mustStore(t, access, 1, 1, domain.AccessTypeLectura, day)
+	mustStore(t, access, size, 2, domain.AccessTypeLectura, day)
+	sketches, err := access.ReaderSketches(bookIDs(all), time.Time{}, time.Time{})
+	if err != nil || len(sketches) != 1 || sketches["2026-03-10"].Estimate() != 2 {
+		t.Fatalf("ReaderSketches de todo el catálogo: %v, %v", sketches, err)
+	}
+}
+
+func TestSQLBookRepoFacets(t *testing.T) {
+	repo := NewSQLBookRepo(openTestDB(t))
+	sqlCatalog(t, repo)
+
+	facets, err := repo.Facets(domain.BookFilter{AuthorContains: "tanenbaum"})
+	if err != nil {
+		t.Fatalf("Facets: %v", err)
+	}
+	wantCategories := []domain.FacetCount{{Value: "Redes", Count: 1}, {Value: "Sistemas", Count: 1}}
+	if !slices.Equal(facets.Categories, wantCategories) {
+		t.Errorf("categorías = %v, se esperaba %v", facets.Categories, wantCategories)
+	}
+	wantDecades := []domain.YearFacetCount{{From: 2000, To: 2009, Count: 1}, {From: 2010, To: 2019, Count: 1}}
+	if !slices.Equal(facets.Decades, wantDecades) {
+		t.Errorf("décadas = %v, se esperaba %v", facets.Decades, wantDecades)
+	}
+	wantTags := []domain.FacetCount{{Value: "kernel", Count: 1}, {Value: "protocolos", Count: 1}, {Value: "tcp", Count: 1}}
+	if !slices.Equal(facets.Tags, wantTags) {
+		t.Errorf("tags = %v, se esperaba %v", facets.Tags, wantTags)
+	}
+
+	// Con Fuzzy se cuenta en Go y el resultado es el mismo.
+	fuzzy, err := repo.Facets(domain.BookFilter{AuthorContains: "Tanenbaun", Fuzzy: true})
+	if err != nil {
+		t.Fatalf("Facets fuzzy: %v", err)
+	}
+	if !slices.Equal(fuzzy.Categories, wantCategories) || !slices.Equal(fuzzy.Tags, wantTags) {
+		t.Errorf("Facets fuzzy = %+v, se esperaba lo mismo que sin fuzzy", fuzzy)
+	}
+}
+
+func TestSQLBookRepoSuggestSpelling(t *testing.T) {
+	repo := NewSQLBookRepo(openTestDB(t))
+	sqlCatalog(t, repo)
+
+	s, err := repo.SuggestSpelling(domain.BookFilter{TitleContains: "sistemas operatvos", AuthorContains: "tanenbaun"})
+	if err != nil {
+		t.Fatalf("SuggestSpelling: %v", err)
+	}
+	if s.Title != "sistemas Operativos" || s.Author != "Tanenbaum" {
+		t.Fatalf("SuggestSpelling = %+v", s)
+	}
+
+	empty, err := repo.SuggestSpelling(domain.BookFilter{})
+	if err != nil || !empty.IsEmpty() {
+		t.Fatalf("SuggestSpelling sin textos: %+v, %v", empty, err)
+	}
+}
+
+func TestSQLBookRepoSuggestPrefix(t *testing.T) {
+	repo := NewSQLBookRepo(openTestDB(t))
+	books := sqlCatalog(t, repo)
+
+	type want struct {
+		kind  domain.SuggestionKind
+		text  string
+		books []string
+	}
+	tests := []struct {
+		name   string
+		prefix string
+		want   []want
+	}{
+		{"palabra del autor", "TANEN", []want{{domain.SuggestAuthor, "Andrew Tanenbaum", []string{"redes", "so"}}}},
+		{"sin archivados", "redes", []want{{domain.SuggestTitle, "Redes de Computadoras", []string{"redes"}}}},
+		{"tag", "concu", []want{{domain.SuggestTag, "concurrencia", []string{"go"}}}},
+		{"sin acentos", "calculo", []want{{domain.SuggestTitle, "Cálculo al 100% de eficiencia", []string{"cien"}}}},
+		{"porcentaje literal", "100%", []want{{domain.SuggestTitle, "Cálculo al 100% de eficiencia", []string{"cien"}}}},
+		{"comodín de LIKE", "%", nil},
+		{"guion bajo literal", "_", nil},
+		{"sin coincidencias", "xyz", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := repo.SuggestPrefix(tt.prefix, 10, nil)
+			if err != nil {
+				t.Fatalf("SuggestPrefix(%q): %v", tt.prefix, err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("SuggestPrefix(%q) = %+v, se esperaban %d completaciones", tt.prefix, got, len(tt.want))
+			}
+			for i, w := range tt.want {
+				var ids []domain.BookID
+				for _, key := range w.books {
+					ids = append(ids, books[key].ID())
+				}
+				gotIDs := slices.Sorted(slices.Values(got[i].BookIDs))
+				if got[i].Kind != w.kind || got[i].Text != w.text || !slices.Equal(gotIDs, ids) {
+					t.Errorf("completación %d = %+v, se esperaba %s %q con %v", i, got[i], w.kind, w.text, ids)
+				}
+			}
+		})
+	}
+
+	// Sin rank: las de más libros primero y luego las más cortas.
+	got, err := repo.SuggestPrefix("a", 2, nil)
+	if err != nil {
+		t.Fatalf("SuggestPrefix con límite: %v", err)
+	}
+	if len(got) != 2 || got[0].Text != "Andrew Tanenbaum" || got[1].Text != "Alan Donovan" {
+		t.Fatalf("SuggestPrefix(\"a\", 2, nil) = %+v", got)
+	}
+
+	// Con rank gana la completación más popular aunque sea la más larga.
+	popular := func(ids []domain.BookID) int {
+		if slices.Contains(ids, books["cien"].ID()) {
+			return 7
+		}
+		return 0
+	}
+	got, err = repo.SuggestPrefix("a", 1, popular)
+	if err != nil {
+		t.Fatalf("SuggestPrefix con rank: %v", err)
+	}
+	if len(got) != 1 || got[0].Text != "Cálculo al 100% de eficiencia" || got[0].Score != 7 {
+		t.Fatalf("SuggestPrefix(\"a\", 1, popular) = %+v", got)
+	}
+}
+
+func TestBackfillSearchColumns(t *testing.T) {
+	conn := openTestDB(t)
+	repo := NewSQLBookRepo(conn)
+
+	// Un libro guardado antes de la migración 0003: columnas plegadas en NULL.
+	if _, err := conn.Exec(`INSERT INTO books (title, author, year, isbn, category_ti, active, created_at)
+		VALUES ('Álgebra Lineal', 'Gilbert Strang', 2016, '333', 'Matemática', 1, ?)`, formatSQLTime(time.Now())); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.Exec(`INSERT INTO book_tags (book_id, position, tag) VALUES (1, 0, 'Matrices')`); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := BackfillSearchColumns(conn)
+	if err != nil {
+		t.Fatalf("BackfillSearchColumns: %v", err)
+	}
+	if n != 2 {
+		t.Fatalf("BackfillSearchColumns actualizó %d filas, se esperaban 2", n)
+	}
+	got, err := repo.SearchByFilters(domain.BookFilter{TitleContains: "algebra", Tags: []string{"matrices"}})
+	if err != nil || len(got) != 1 {
+		t.Fatalf("búsqueda después del backfill: %v, %v", bookIDs(got), err)
+	}
+
+	if n, err := BackfillSearchColumns(conn); err != nil || n != 0 {
+		t.Fatalf("segundo BackfillSearchColumns: %d, %v", n, err)
+	}
+}
+
+/*
+   ----------------------------------------------------------
+   Accesos
+   ----------------------------------------------------------
+*/
+
+func TestSQLAccessLogRepoQueries(t *testing.T) {
+	conn := openTestDB(t)
+	books, access := NewSQLBookRepo(conn), NewSQLAccessLogRepo(conn)
+	b1 := mustBook(t, books, "Uno", "A", 2000, "1", "X")
+	b2 := mustBook(t, books, "Dos", "B", 2001, "2", "X")
+
+	base := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	e1 := mustStore(t, access, b1.ID(), 1, domain.AccessTypeApertura, base.Add(2*time.Hour))
+	e2 := mustStore(t, access, b1.ID(), 2, domain.AccessTypeLectura, base)
+	e3 := mustStore(t, access, b2.ID(), 1, domain.AccessTypeDescarga, base.Add(24*time.Hour))
+	if e1.ID() == 0 || e2.ID() <= e1.ID() || e3.ID() <= e2.ID() {
+		t.Fatalf("IDs asignados por Store: %d, %d, %d", e1.ID(), e2.ID(), e3.ID())
+	}
+
+	ids := func(events []*domain.AccessEvent) []domain.AccessEventID {
+		out := make([]domain.AccessEventID, len(events))
+		for i, ev := range events {
+			out[i] = ev.ID()
+		}
+		return out
+	}
+
+	all, err := access.ListAll()
+	if err != nil || !slices.Equal(ids(all), []domain.AccessEventID{e1.ID(), e2.ID(), e3.ID()}) {
+		t.Fatalf("ListAll = %v, %v", ids(all), err)
+	}
+	if !all[0].Timestamp().Equal(e1.Timestamp()) || all[0].AccessType() != domain.AccessTypeApertura || all[0].UserID() != 1 {
+		t.Fatalf("ListAll leyó mal el evento: %+v", all[0])
+	}
+
+	byBook, err := access.ListByBook(b1.ID())
+	if err != nil || !slices.Equal(ids(byBook), []domain.AccessEventID{e2.ID(), e1.ID()}) {
+		t.Fatalf("ListByBook (cronológico) = %v, %v", ids(byBook), err)
+	}
+
+	byUser, err := access.ListByUser(1)
+	if err != nil || !slices.Equal(ids(byUser), []domain.AccessEventID{e1.ID(), e3.ID()}) {
+		t.Fatalf("ListByUser = %v, %v", ids(byUser), err)
+	}
+
+	between, err := access.ListBetween(base, base.Add(24*time.Hour))
+	if err != nil || !slices.Equal(ids(between), []domain.AccessEventID{e2.ID(), e1.ID()}) {
+		t.Fatalf("ListBetween [from, to) = %v, %v", ids(between), err)
+	}
+
+	counts, err := access.CountByBook()
+	if err != nil || len(counts) != 2 || counts[b1.ID()] != 2 || counts[b2.ID()] != 1 {
+		t.Fatalf("CountByBook = %v, %v", counts, err)
+	}
+}
+
+func TestSQLAccessLogRepoReaderSketches(t *testing.T) {
+	conn := openTestDB(t)
+	books, access := NewSQLBookRepo(conn), NewSQLAccessLogRepo(conn)
+	b1 := mustBook(t, books, "Uno", "A", 2000, "1", "X")
+	b2 := mustBook(t, books, "Dos", "B", 2001, "2", "X")
+
+	day1 := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+	mustStore(t, access, b1.ID(), 1, domain.AccessTypeApertura, day1.Add(time.Hour))
+	mustStore(t, access, b1.ID(), 1, domain.AccessTypeLectura, day1.Add(2*time.Hour)) // mismo lector
+	mustStore(t, access, b1.ID(), 2, domain.AccessTypeLectura, day1.Add(3*time.Hour))
+	mustStore(t, access, b2.ID(), 3, domain.AccessTypeApertura, day1.Add(4*time.Hour))
+	mustStore(t, access, b2.ID(), 1, domain.AccessTypeApertura, day2.Add(time.Hour))
+
+	all, err := access.ReaderSketches(nil, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("ReaderSketches: %v", err)
+	}
+	if len(all) != 2 || all["2026-03-10"].Estimate() != 3 || all["2026-03-11"].Estimate() != 1 {
+		t.Fatalf("ReaderSketches de todos los libros: %v", all)
+	}
+
+	onlyB1, err := access.ReaderSketches([]domain.BookID{b1.ID()}, time.Time{}, time.Time{})
+	if err != nil || len(onlyB1) != 1 || onlyB1["2026-03-10"].Estimate() != 2 {
+		t.Fatalf("ReaderSketches de un libro: %v, %v", onlyB1, err)
+	}
+
+	firstDay, err := access.ReaderSketches(nil, day1, day2)
+	if err != nil || len(firstDay) != 1 || firstDay["2026-03-10"] == nil {
+		t.Fatalf("ReaderSketches [day1, day2): %v, %v", firstDay, err)
+	}
+
+	// Un to a mitad del día incluye ese día entero.
+	partial, err := access.ReaderSketches(nil, day1.Add(5*time.Hour), day2.Add(30*time.Minute))
+	if err != nil || len(partial) != 2 || partial["2026-03-11"].Estimate() != 1 {
+		t.Fatalf("ReaderSketches con to a mitad del día: %v, %v", partial, err)
+	}
+}
+
+func TestBackfillReaderSketches(t *testing.T) {
+	conn := openTestDB(t)
+	books, access := NewSQLBookRepo(conn), NewSQLAccessLogRepo(conn)
+	b := mustBook(t, books, "Uno", "A", 2000, "1", "X")
+
+	// Accesos guardados antes de la migración 0004: sin sketches.
+	ts := formatSQLTime(time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC))
+	for _, user := range []int{1, 2, 2} {
+		if _, err := conn.Exec("INSERT INTO access_events (book_id, user_id, access_type, timestamp) VALUES (?, ?, 'LECTURA', ?)",
+			int64(b.ID()), user, ts); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	n, err := BackfillReaderSketches(conn)
+	if err != nil || n != 1 {
+		t.Fatalf("BackfillReaderSketches = %d, %v; se esperaba 1 sketch", n, err)
+	}
+	sketches, err := access.ReaderSketches(nil, time.Time{}, time.Time{})
+	if err != nil || sketches["2026-03-10"].Estimate() != 2 {
+		t.Fatalf("sketches después del backfill: %v, %v", sketches, err)
+	}
+
+	if n, err := BackfillReaderSketches(conn); err != nil || n != 0 {
+		t.Fatalf("segundo BackfillReaderSketches: %d, %v", n, err)
+	}
+}
+
+/*
+   ----------------------------------------------------------
+   Unidad de trabajo
+   ----------------------------------------------------------
+*/
+
+func TestSQLUnitOfWork(t *testing.T) {
+	conn := openTestDB(t)
+	uow := NewSQLUnitOfWork(conn)
+	users := NewSQLUserRepo(conn)
+
+	var committed []string
+	errFail := errors.New("falla a propósito")
+	err := uow.Do(func(repos domain.Repositories) error {
+		u, _ := domain.NewUser("Ana", "ana@example.com", domain.RoleReader)
+		if err := repos.Users.Create(u); err != nil {
+			return err
+		}
+		repos.AfterCommit(func() { committed = append(committed, "fallida") })
+		return errFail
+	})
+	if !errors.Is(err, errFail) {
+		t.Fatalf("Do devolvió %v, se esperaba el error de fn", err)
+	}
+	if u, _ := users.FindByEmail("ana@example.com"); u != nil {
+		t.Fatal("el usuario de una unidad de trabajo fallida quedó guardado")
+	}
+	if len(committed) != 0 {
+		t.Fatalf("AfterCommit corrió en una unidad revertida: %v", committed)
+	}
+
+	err = uow.Do(func(repos domain.Repositories) error {
+		repos.AfterCommit(func() {
+			// Ya confirmado: se ve desde fuera de la transacción.
+			if u, _ := users.FindByEmail("ana@example.com"); u != nil {
+				committed = append(committed, "confirmada")
+			}
+		})
+		u, _ := domain.NewUser("Ana", "ana@example.com", domain.RoleReader)
+		return repos.Users.Create(u)
+	})
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if u, _ := users.FindByEmail("ana@example.com"); u == nil {
+		t.Fatal("el usuario de una unidad de trabajo confirmada no se guardó")
+	}
+	if !slices.Equal(committed, []string{"confirmada"}) {
+		t.Fatalf("AfterCommit de la unidad confirmada: %v", committed)
+	}
+}
@@ -0,0 +1,398 @@
+package db
+
+import (
+	"container/heap"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/domain"
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/textnorm"
+)
+
+/*
+   ==========================================================
+   TRIE DE AUTOCOMPLETADO (InMemoryBookRepo)
+   ==========================================================
+
+   Un TRIE es un árbol de letras: cada camino desde la raíz
+   deletrea una palabra. Para completar "tan" se baja por
+   t → a → n y todo lo que cuelga de ese nodo empieza con "tan".
+
+   Este trie es COMPRIMIDO (radix): cada arista guarda un texto
+   y no una sola letra, así las colas únicas ("...tanenbaum")
+   son una sola arista en lugar de una cadena de nodos.
+
+   Se guarda cada título, autor y tag de los libros ACTIVOS
+   (plegados, ver internal/textnorm) y además cada palabra
+   suelta que no sea vacía ("computadoras" lleva a "Redes de
+   Computadoras").
+
+   Al buscar se juntan TODAS las completaciones del subárbol
+   del prefijo y se puntúa cada una con la función de ranking
+   que recibe SuggestPrefix (la popularidad de sus libros). Un
+   MONTÍCULO (heap) acotado al límite guarda las mejores, así
+   no se ordena la lista entera (ver rankedKeys).
+
+   Un prefijo corto puede tener miles de completaciones debajo,
+   así que cada nodo GUARDA (cache) la lista de su subárbol.
+   Agregar o quitar una completación borra la cache de los
+   nodos de su camino, que son los únicos cuyo subárbol cambió.
+   El puntaje NO se guarda: la popularidad cambia con cada
+   acceso y se calcula de nuevo en cada consulta.
+*/
+
+// suggestKey identifica una completación: campo + texto plegado.
+type suggestKey struct {
+	kind domain.SuggestionKind
+	text string
+}
+
+// completion es lo que se muestra y los libros que la tienen.
+// ids es la lista que recibe la función de ranking; books guarda
+// la posición de cada libro en ids para quitarlo sin recorrerla.
+type completion struct {
+	key     suggestKey
+	display string
+	ids     []domain.BookID
+	books   map[domain.BookID]int
+
+	// seen marca la última recolección (collect) que la juntó.
+	seen uint64
+}
+
+// trieNode es un nodo del trie. edge es el texto de la arista que
+// llega a él; children se indexa por la primera letra de la arista.
+type trieNode struct {
+	edge     string
+	children map[rune]*trieNode
+	ends     map[suggestKey]*completion // completaciones cuyo camino termina aquí
+
+	// Cache de lookup: todas las completaciones del subárbol
+	// (cached == false: no hay cache).
+	cache  []*completion
+	cached bool
+}
+
+// invalidate borra la cache del nodo.
+func (n *trieNode) invalidate() {
+	n.cache, n.cached = nil, false
+}
+
+/*
+suggestTrie agrupa el árbol y las completaciones.
+
+Lo protege el mutex del repositorio (escrituras exclusivas, lecturas
+compartidas); como varias lecturas pueden llenar caches a la vez,
+cacheMu protege los campos cache / cached durante lookup.
+*/
+type suggestTrie struct {
+	root        *trieNode
+	completions map[suggestKey]*completion
+	cacheMu     sync.Mutex
+	collects    uint64 // cantidad de recolecciones (ver collect)
+}
+
+func newSuggestTrie() *suggestTrie {
+	return &suggestTrie{
+		root:        &trieNode{},
+		completions: make(map[suggestKey]*completion),
+	}
+}
+
+// paths devuelve los caminos de una completación: el texto completo
+// y cada palabra posterior que no sea vacía (solo la palabra, para que
+// el trie no crezca con el largo de los títulos).
+func (k suggestKey) paths() []string {
+	paths := []string{k.text}
+	for i, w := range textnorm.Words(k.text) {
+		if i == 0 || textnorm.IsStopword(w.Text) {
+			continue
+		}
+		paths = append(paths, w.Text)
+	}
+	return paths
+}
+
+// add registra que el libro id tiene el texto display en el campo kind.
+func (t *suggestTrie) add(kind domain.SuggestionKind, display string, id domain.BookID) {
+	key := suggestKey{kind: kind, text: normalizeKey(display)}
+	if key.text == "" {
+		return
+	}
+
+	c, ok := t.completions[key]
+	if !ok {
+		c = &completion{key: key, display: strings.TrimSpace(display), books: make(map[domain.BookID]int)}
+		t.completions[key] = c
+		for _, path := range key.paths() {
+			t.insert(path, c)
+		}
+	}
+	if _, ok := c.books[id]; !ok {
+		c.books[id] = len(c.ids)
+		c.ids = append(c.ids, id)
+	}
+}
+
+// remove quita el libro id de la completación (texto ya plegado).
+// Si la completación queda sin libros, se borra del árbol.
+func (t *suggestTrie) remove(kind domain.SuggestionKind, text string, id domain.BookID) {
+	key := suggestKey{kind: kind, text: text}
+	c, ok := t.completions[key]
+	if !ok {
+		return
+	}
+	pos, ok := c.books[id]
+	if !ok {
+		return
+	}
+	// El último libro de la lista ocupa el lugar del que se va.
+	last := c.ids[len(c.ids)-1]
+	c.ids[pos], c.books[last] = last, pos
+	c.ids = c.ids[:len(c.ids)-1]
+	delete(c.books, id)
+	if len(c.ids) > 0 {
+		return
+	}
+
+	delete(t.completions, key)
+	for _, path := range key.paths() {
+		t.delete(t.root, path, key)
+	}
+}
+
+// firstRune devuelve la primera letra de un texto no vacío.
+func firstRune(s string) rune {
+	r, _ := utf8.DecodeRuneInString(s)
+	return r
+}
+
+// commonPrefixLen devuelve cuántos bytes iniciales comparten a y b
+// (sin cortar una letra a la mitad).
+func commonPrefixLen(a, b string) int {
+	n := 0
+	for n < len(a) && n < len(b) {
+		ra, size := utf8.DecodeRuneInString(a[n:])
+		rb, _ := utf8.DecodeRuneInString(b[n:])
+		if ra != rb {
+			break
+		}
+		n += size
+	}
+	return n
+}
+
+// insert agrega la completación c al final del camino path.
+func (t *suggestTrie) insert(path string, c *completion) {
+	node := t.root
+	for path != "" {
+		node.invalidate()
+		r := firstRune(path)
+		child, ok := node.children[r]
+		if !ok {
+			// No hay arista con esa letra: una hoja nueva con todo el resto.
+			child = &trieNode{edge: path}
+			if node.children == nil {
+				node.children = make(map[rune]*trieNode)
+			}
+			node.children[r] = child
+			node = child
+			break
+		}
+
+		n := commonPrefixLen(path, child.edge)
+		if n < len(child.edge) {
+			// El camino se separa a mitad de la arista: partirla en dos.
+			mid := &trieNode{edge: child.edge[:n], children: map[rune]*trieNode{}}
+			child.edge = child.edge[n:]
+			mid.children[firstRune(child.edge)] = child
+			node.children[r] = mid
+			child = mid
+		}
+		node = child
+		path = path[n:]
+	}
+
+	node.invalidate()
+	if node.ends == nil {
+		node.ends = make(map[suggestKey]*completion)
+	}
+	node.ends[c.key] = c
+}
+
+// delete quita key del camino y reacomoda el árbol: un nodo vacío se
+// borra y uno sin completaciones con un solo hijo se une a él.
+// Devuelve true si node quedó vacío.
+func (t *suggestTrie) delete(node *trieNode, path string, key suggestKey) bool {
+	node.invalidate()
+	if path == "" {
+		delete(node.ends, key)
+	} else {
+		r := firstRune(path)
+		child, ok := node.children[r]
+		if !ok || !strings.HasPrefix(path, child.edge) {
+			return false
+		}
+		if t.delete(child, path[len(child.edge):], key) {
+			delete(node.children, r)
+		} else if len(child.ends) == 0 && len(child.children) == 1 {
+			for _, grandchild := range child.children {
+				grandchild.edge = child.edge + grandchild.edge
+				node.children[r] = grandchild
+			}
+		}
+	}
+	return node != t.root && len(node.ends) == 0 && len(node.children) == 0
+}
+
+// lookup devuelve hasta limit completaciones para el prefijo,
+// ordenadas por rank (ver rankedKey.before).
+func (t *suggestTrie) lookup(prefix string, limit int, rank domain.SuggestionRank) []domain.Suggestion {
+	// Bajar por el prefijo; puede terminar a mitad de una arista.
+	node := t.root
+	rest := normalizeKey(prefix)
+	for rest != "" {
+		child, ok := node.children[firstRune(rest)]
+		if !ok {
+			return nil
+		}
+		n := commonPrefixLen(rest, child.edge)
+		if n < len(rest) && n < len(child.edge) {
+			return nil
+		}
+		node = child
+		rest = rest[n:]
+	}
+
+	t.cacheMu.Lock()
+	if !node.cached {
+		node.cache, node.cached = t.collect(node), true
+	}
+	all := node.cache
+	t.cacheMu.Unlock()
+
+	top := newRankedKeys(limit)
+	for _, c := range all {
+		score := 0
+		if rank != nil {
+			score = rank(c.ids)
+		}
+		top.offer(rankedKey{key: c.key, score: score, books: len(c.ids)})
+	}
+
+	ranked := top.sorted()
+	result := make([]domain.Suggestion, 0, len(ranked))
+	for _, r := range ranked {
+		s := t.completions[r.key].suggestion()
+		s.Score = r.score
+		result = append(result, s)
+	}
+	return result
+}
+
+// collect devuelve todas las completaciones del subárbol de node, sin
+// repetidos: una completación puede colgar de varios caminos, y en
+// lugar de un MAP de vistas se marca cada una con el número de esta
+// recolección. Se llama con cacheMu tomado.
+func (t *suggestTrie) collect(node *trieNode) []*completion {
+	t.collects++
+	mark := t.collects
+
+	var result []*completion
+	stack := []*trieNode{node}
+	for len(stack) > 0 {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, c := range n.ends {
+			if c.seen != mark {
+				c.seen = mark
+				result = append(result, c)
+			}
+		}
+		for _, child := range n.children {
+			stack = append(stack, child)
+		}
+	}
+	return result
+}
+
+// suggestion convierte la completación al tipo del dominio.
+func (c *completion) suggestion() domain.Suggestion {
+	return domain.Suggestion{Kind: c.key.kind, Text: c.display, BookIDs: append([]domain.BookID(nil), c.ids...)}
+}
+
+/*
+   ----------------------------------------------------------
+   Las mejores completaciones (container/heap)
+   ----------------------------------------------------------
+
+   También lo usa SQLBookRepo.SuggestPrefix, así los dos
+   repositorios ordenan igual.
+*/
+
+// rankedKey es una completación con su puntaje y su cantidad de libros.
+type rankedKey struct {
+	key   suggestKey
+	score int
+	books int
+}
+
+// before indica si a va antes que b: más puntaje, más libros, texto
+// más corto y, por último, orden alfabético y de campo.
+func (a rankedKey) before(b rankedKey) bool {
+	if a.score != b.score {
+		return a.score > b.score
+	}
+	if a.books != b.books {
+		return a.books > b.books
+	}
+	if len(a.key.text) != len(b.key.text) {
+		return len(a.key.text) < len(b.key.text)
+	}
+	if a.key.text != b.key.text {
+		return a.key.text < b.key.text
+	}
+	return a.key.kind < b.key.kind
+}
+
+// rankedKeys guarda las limit mejores completaciones vistas. Es un
+// montículo con la PEOR arriba: una nueva solo entra si le gana.
+type rankedKeys struct {
+	items []rankedKey
+	limit int
+}
+
+func newRankedKeys(limit int) *rankedKeys {
+	return &rankedKeys{items: make([]rankedKey, 0, limit), limit: limit}
+}
+
+// offer considera una completación.
+func (r *rankedKeys) offer(k rankedKey) {
+	switch {
+	case r.limit <= 0:
+	case len(r.items) < r.limit:
+		heap.Push(r, k)
+	case k.before(r.items[0]):
+		r.items[0] = k
+		heap.Fix(r, 0)
+	}
+}
+
+// sorted devuelve las completaciones guardadas, la mejor primero.
+func (r *rankedKeys) sorted() []rankedKey {
+	sort.Slice(r.items, func(i, j int) bool { return r.items[i].before(r.items[j]) })
+	return r.items
+}
+
+func (r *rankedKeys) Len() int           { return len(r.items) }
+func (r *rankedKeys) Less(i, j int) bool { return r.items[j].before(r.items[i]) }
+func (r *rankedKeys) Swap(i, j int)      { r.items[i], r.items[j] = r.items[j], r.items[i] }
+func (r *rankedKeys) Push(x any)         { r.items = append(r.items, x.(rankedKey)) }
+func (r *rankedKeys) Pop() any {
+	old := r.items
+	item := old[len(old)-1]
+	r.items = old[:len(old)-1]
+	return item
+}
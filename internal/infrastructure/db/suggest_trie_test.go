@@ -0,0 +1,177 @@
+package db
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/domain"
+)
+
+// countsRank puntúa una completación con la suma de accesos de sus libros,
+// igual que la popularidad del servicio.
+func countsRank(counts map[domain.BookID]int) domain.SuggestionRank {
+	return func(ids []domain.BookID) int {
+		total := 0
+		for _, id := range ids {
+			total += counts[id]
+		}
+		return total
+	}
+}
+
+func TestSuggestPrefixRanksEveryCompletion(t *testing.T) {
+	repo := NewInMemoryBookRepo()
+
+	// Muchas completaciones cortas sin accesos...
+	for i := 0; i < 500; i++ {
+		b, _ := domain.NewBook(fmt.Sprintf("Go %03d", i), "Autor", 2000, fmt.Sprint(i), "X", nil)
+		if err := repo.Create(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// ...y una larga, la última en orden de largo, que es la más popular.
+	popular, _ := domain.NewBook("Go: programación concurrente para sistemas distribuidos", "Autor", 2020, "999", "X", nil)
+	if err := repo.Create(popular); err != nil {
+		t.Fatal(err)
+	}
+	rank := countsRank(map[domain.BookID]int{popular.ID(): 3})
+
+	got, err := repo.SuggestPrefix("go", 5, rank)
+	if err != nil {
+		t.Fatalf("SuggestPrefix: %v", err)
+	}
+	if len(got) != 5 || got[0].Text != popular.Title() || got[0].Score != 3 {
+		t.Fatalf("la completación más popular debería ir primero: %+v", got)
+	}
+	// El resto empata en 0 accesos y 1 libro: las más cortas, en orden alfabético.
+	for i, want := range []string{"Go 000", "Go 001", "Go 002", "Go 003"} {
+		if got[i+1].Text != want {
+			t.Errorf("completación %d = %q, se esperaba %q", i+1, got[i+1].Text, want)
+		}
+	}
+
+	// El puntaje no queda en la cache: cambia con cada consulta.
+	got, _ = repo.SuggestPrefix("go", 1, countsRank(nil))
+	if len(got) != 1 || got[0].Text != "Go 000" {
+		t.Fatalf("sin accesos debería ir primero la más corta: %+v", got)
+	}
+}
+
+func TestSuggestPrefixFollowsCatalogChanges(t *testing.T) {
+	repo := NewInMemoryBookRepo()
+	mk := func(title, author string, tags ...string) *domain.Book {
+		b, _ := domain.NewBook(title, author, 2000, title, "X", tags)
+		if err := repo.Create(b); err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	redes := mk("Redes de Computadoras", "Andrew Tanenbaum", "tcp")
+	so := mk("Sistemas Operativos", "Andrew Tanenbaum", "kernel")
+	mk("Estructuras", "Niklaus Wirth")
+
+	lookup := func(prefix string) []domain.Suggestion {
+		t.Helper()
+		got, err := repo.SuggestPrefix(prefix, 10, nil)
+		if err != nil {
+			t.Fatalf("SuggestPrefix(%q): %v", prefix, err)
+		}
+		return got
+	}
+
+	// Palabra interior del autor, con los dos libros.
+	got := lookup("tanen")
+	if len(got) != 1 || got[0].Kind != domain.SuggestAuthor ||
+		!slices.Equal(slices.Sorted(slices.Values(got[0].BookIDs)), []domain.BookID{redes.ID(), so.ID()}) {
+		t.Fatalf("tanen = %+v", got)
+	}
+
+	// Archivar un libro lo quita de la completación compartida
+	// (la lectura anterior dejó el nodo en cache).
+	redes.Archive()
+	if err := repo.Update(redes); err != nil {
+		t.Fatal(err)
+	}
+	got = lookup("tanen")
+	if len(got) != 1 || !slices.Equal(got[0].BookIDs, []domain.BookID{so.ID()}) {
+		t.Fatalf("tanen después de archivar = %+v", got)
+	}
+	if got := lookup("redes"); len(got) != 0 {
+		t.Fatalf("un libro archivado no se completa: %+v", got)
+	}
+	if got := lookup("tcp"); len(got) != 0 {
+		t.Fatalf("los tags de un libro archivado no se completan: %+v", got)
+	}
+
+	// Cambiar los tags mueve las completaciones.
+	if err := so.UpdateDetails(so.Title(), so.Author(), so.Year(), so.ISBN(), so.CategoryTI(), []string{"Concurrencia"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Update(so); err != nil {
+		t.Fatal(err)
+	}
+	if got := lookup("kern"); len(got) != 0 {
+		t.Fatalf("un tag quitado no se completa: %+v", got)
+	}
+	if got := lookup("concu"); len(got) != 1 || got[0].Text != "Concurrencia" {
+		t.Fatalf("concu = %+v", got)
+	}
+
+	if got := lookup("xyz"); len(got) != 0 {
+		t.Fatalf("xyz = %+v", got)
+	}
+}
+
+/*
+   ----------------------------------------------------------
+   Benchmarks del autocompletado
+   ----------------------------------------------------------
+
+	go test -run=^$ -bench=SuggestPrefix ./internal/infrastructure/db
+
+   El cuadro de búsqueda necesita respuestas de menos de 10 ms.
+   "tibio" repite el prefijo (nodo en cache); "frío" borra las
+   caches antes de cada consulta, como después de un Create.
+*/
+
+// invalidateAll borra la cache de todos los nodos del trie.
+func invalidateAll(n *trieNode) {
+	n.invalidate()
+	for _, child := range n.children {
+		invalidateAll(child)
+	}
+}
+
+func BenchmarkSuggestPrefix(b *testing.B) {
+	const size = 50_000
+	repo := syntheticRepo(b, size)
+
+	rng := rand.New(rand.NewSource(7))
+	counts := make(map[domain.BookID]int, size)
+	for id := 1; id <= size; id++ {
+		counts[domain.BookID(id)] = rng.Intn(100)
+	}
+	rank := countsRank(counts)
+
+	for _, prefix := range []string{"a", "re", "tanen", "concu"} {
+		b.Run("tibio/"+prefix, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := repo.SuggestPrefix(prefix, 10, rank); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run("frío/"+prefix, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				invalidateAll(repo.index.completions.root)
+				b.StartTimer()
+				if _, err := repo.SuggestPrefix(prefix, 10, rank); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,251 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/domain"
+)
+
+/*
+   ==========================================================
+   UNIDADES DE TRABAJO
+   ==========================================================
+
+   Implementaciones de domain.UnitOfWork:
+
+   - InMemoryUnitOfWork: para los repositorios en memoria (y los
+     de archivos / WAL, que son los mismos mapas por dentro).
+     Usa UN solo candado: mientras una unidad de trabajo corre,
+     ninguna otra puede intercalar sus operaciones. No hay
+     rollback, así que NO da atomicidad entre varias escrituras:
+     admite UNA sola (la regla de domain.UnitOfWork), la GUARDA
+     mientras corre fn y la aplica recién cuando fn termina sin
+     error. Si fn falla no queda nada escrito; una segunda
+     escritura se rechaza con errSecondWrite.
+
+   - SQLUnitOfWork: abre una transacción de la base de datos y
+     entrega repositorios SQL que trabajan sobre ella. Si fn
+     devuelve error se hace ROLLBACK; si no, COMMIT. Es la ÚNICA
+     que confirma varias escrituras (en uno o varios
+     repositorios) todas juntas o ninguna.
+
+   Las funciones agendadas con AfterCommit corren DESPUÉS de
+   soltar el candado (en SQL, el que envuelve COMMIT), así una
+   unidad no espera a los observadores de la anterior. Cada
+   confirmación toma un turno en una commitQueue y sus funciones
+   esperan a que terminen las del turno anterior: corren en el
+   orden de confirmación. No deben abrir otra unidad de trabajo
+   y esperarla: esa unidad esperaría su turno, que es el
+   siguiente.
+*/
+
+// InMemoryUnitOfWork serializa las unidades de trabajo con un candado global.
+type InMemoryUnitOfWork struct {
+	mu    sync.Mutex
+	repos domain.Repositories
+	queue commitQueue
+}
+
+// NewInMemoryUnitOfWork crea la unidad de trabajo sobre los repositorios dados.
+func NewInMemoryUnitOfWork(repos domain.Repositories) *InMemoryUnitOfWork {
+	return &InMemoryUnitOfWork{repos: repos}
+}
+
+// Do ejecuta fn con el candado tomado, aplica su escritura y, ya sin el
+// candado, lo agendado con AfterCommit.
+func (u *InMemoryUnitOfWork) Do(fn func(repos domain.Repositories) error) error {
+	turn, after, err := u.commit(fn)
+	if err != nil {
+		return err
+	}
+	turn.run(after)
+	return nil
+}
+
+// commit corre fn y aplica la escritura guardada, todo con el candado.
+func (u *InMemoryUnitOfWork) commit(fn func(repos domain.Repositories) error) (commitTurn, afterCommit, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	var (
+		write stagedWrite
+		after afterCommit
+	)
+	repos := domain.Repositories{
+		Users:       stagedUserRepo{UserRepository: u.repos.Users, write: &write},
+		Books:       stagedBookRepo{BookRepository: u.repos.Books, write: &write},
+		Access:      stagedAccessLogRepo{AccessLogRepository: u.repos.Access, write: &write},
+		AfterCommit: after.add,
+	}
+	if err := fn(repos); err != nil {
+		return commitTurn{}, nil, err
+	}
+	if err := write.apply(); err != nil {
+		return commitTurn{}, nil, err
+	}
+	return u.queue.next(), after, nil
+}
+
+// SQLUnitOfWork ejecuta cada unidad de trabajo en una transacción SQL.
+type SQLUnitOfWork struct {
+	db       *sql.DB
+	commitMu sync.Mutex // envuelve COMMIT y la toma del turno
+	queue    commitQueue
+}
+
+// NewSQLUnitOfWork crea la unidad de trabajo sobre la conexión dada.
+func NewSQLUnitOfWork(conn *sql.DB) *SQLUnitOfWork {
+	return &SQLUnitOfWork{db: conn}
+}
+
+// Do abre una transacción, ejecuta fn y confirma o revierte según el resultado.
+func (u *SQLUnitOfWork) Do(fn func(repos domain.Repositories) error) error {
+	tx, err := u.db.BeginTx(context.Background(), nil)
+	if err != nil {
+		return err
+	}
+
+	var after afterCommit
+	repos := domain.Repositories{
+		Users:       &SQLUserRepo{db: tx},
+		Books:       &SQLBookRepo{db: tx},
+		Access:      &SQLAccessLogRepo{db: tx},
+		AfterCommit: after.add,
+	}
+
+	if err := fn(repos); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	turn, err := u.commit(tx)
+	if err != nil {
+		return err
+	}
+	turn.run(after)
+	return nil
+}
+
+// commit confirma la transacción y toma el turno en el mismo paso.
+func (u *SQLUnitOfWork) commit(tx *sql.Tx) (commitTurn, error) {
+	u.commitMu.Lock()
+	defer u.commitMu.Unlock()
+	if err := tx.Commit(); err != nil {
+		return commitTurn{}, err
+	}
+	return u.queue.next(), nil
+}
+
+// afterCommit junta las funciones agendadas con AfterCommit.
+type afterCommit []func()
+
+func (a *afterCommit) add(fn func()) { *a = append(*a, fn) }
+
+/*
+   ----------------------------------------------------------
+   Orden de confirmación
+   ----------------------------------------------------------
+*/
+
+// commitQueue reparte turnos en el orden de confirmación. Se llama a
+// next con el candado de la unidad de trabajo tomado.
+type commitQueue struct {
+	last chan struct{} // se cierra cuando termina el último turno repartido
+}
+
+// commitTurn es el turno de una confirmación: espera a prev y avisa con done.
+type commitTurn struct {
+	prev <-chan struct{}
+	done chan struct{}
+}
+
+// next reparte el turno siguiente.
+func (q *commitQueue) next() commitTurn {
+	turn := commitTurn{prev: q.last, done: make(chan struct{})}
+	q.last = turn.done
+	return turn
+}
+
+// run espera a que termine el turno anterior y corre las funciones.
+func (t commitTurn) run(after afterCommit) {
+	defer close(t.done)
+	if t.prev != nil {
+		<-t.prev
+	}
+	for _, fn := range after {
+		fn()
+	}
+}
+
+/*
+   ----------------------------------------------------------
+   Una sola escritura por unidad de trabajo (en memoria)
+   ----------------------------------------------------------
+*/
+
+// errSecondWrite se devuelve ante la segunda escritura de una unidad.
+var errSecondWrite = errors.New("la unidad de trabajo en memoria admite una sola escritura")
+
+// stagedWrite guarda la escritura de una unidad de trabajo hasta confirmarla.
+type stagedWrite struct {
+	pending func() error
+}
+
+// stage guarda una escritura; la segunda falla.
+func (w *stagedWrite) stage(fn func() error) error {
+	if w.pending != nil {
+		return errSecondWrite
+	}
+	w.pending = fn
+	return nil
+}
+
+// apply hace la escritura guardada, si hay una.
+func (w *stagedWrite) apply() error {
+	if w.pending == nil {
+		return nil
+	}
+	return w.pending()
+}
+
+// Los repositorios de la unidad en memoria: las lecturas pasan
+// directo y cada escritura se guarda en el stagedWrite. Los errores
+// de la escritura (versión, email repetido, disco) los devuelve Do.
+
+type stagedUserRepo struct {
+	domain.UserRepository
+	write *stagedWrite
+}
+
+func (r stagedUserRepo) Create(user *domain.User) error {
+	return r.write.stage(func() error { return r.UserRepository.Create(user) })
+}
+
+func (r stagedUserRepo) Update(user *domain.User) error {
+	return r.write.stage(func() error { return r.UserRepository.Update(user) })
+}
+
+type stagedBookRepo struct {
+	domain.BookRepository
+	write *stagedWrite
+}
+
+func (r stagedBookRepo) Create(book *domain.Book) error {
+	return r.write.stage(func() error { return r.BookRepository.Create(book) })
+}
+
+func (r stagedBookRepo) Update(book *domain.Book) error {
+	return r.write.stage(func() error { return r.BookRepository.Update(book) })
+}
+
+type stagedAccessLogRepo struct {
+	domain.AccessLogRepository
+	write *stagedWrite
+}
+
+func (r stagedAccessLogRepo) Store(event *domain.AccessEvent) error {
+	return r.write.stage(func() error { return r.AccessLogRepository.Store(event) })
+}
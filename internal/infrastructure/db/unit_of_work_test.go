@@ -0,0 +1,200 @@
+package db
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/domain"
+)
+
+func newMemoryUnitOfWork() (*InMemoryUnitOfWork, domain.Repositories) {
+	repos := domain.Repositories{
+		Users:  NewInMemoryUserRepo(),
+		Books:  NewInMemoryBookRepo(),
+		Access: NewInMemoryAccessLogRepo(),
+	}
+	return NewInMemoryUnitOfWork(repos), repos
+}
+
+func TestInMemoryUnitOfWorkAfterCommit(t *testing.T) {
+	uow, _ := newMemoryUnitOfWork()
+
+	var ran []string
+	err := uow.Do(func(repos domain.Repositories) error {
+		repos.AfterCommit(func() { ran = append(ran, "a") })
+		repos.AfterCommit(func() { ran = append(ran, "b") })
+		return nil
+	})
+	if err != nil || !slices.Equal(ran, []string{"a", "b"}) {
+		t.Fatalf("Do confirmado: err=%v, corrió %v", err, ran)
+	}
+
+	ran = nil
+	errFail := errors.New("falla a propósito")
+	err = uow.Do(func(repos domain.Repositories) error {
+		repos.AfterCommit(func() { ran = append(ran, "c") })
+		return errFail
+	})
+	if !errors.Is(err, errFail) || len(ran) != 0 {
+		t.Fatalf("Do fallido: err=%v, corrió %v", err, ran)
+	}
+}
+
+// Sin rollback, la unidad en memoria es atómica porque aplica su única
+// escritura al confirmar: si fn falla, no queda nada guardado.
+func TestInMemoryUnitOfWorkSingleWrite(t *testing.T) {
+	uow, repos := newMemoryUnitOfWork()
+
+	err := uow.Do(func(tx domain.Repositories) error {
+		u, _ := domain.NewUser("Ana", "ana@example.com", domain.RoleReader)
+		if err := tx.Users.Create(u); err != nil {
+			return err
+		}
+		b, _ := domain.NewBook("Uno", "A", 2000, "1", "X", nil)
+		return tx.Books.Create(b)
+	})
+	if !errors.Is(err, errSecondWrite) {
+		t.Fatalf("la segunda escritura debería rechazarse, vino %v", err)
+	}
+	if users, _ := repos.Users.ListAll(); len(users) != 0 {
+		t.Fatalf("la primera escritura de una unidad rechazada quedó guardada: %d usuarios", len(users))
+	}
+	if books, _ := repos.Books.ListAll(); len(books) != 0 {
+		t.Fatalf("la segunda escritura quedó guardada: %d libros", len(books))
+	}
+
+	// Una escritura y después un error de fn: tampoco se guarda.
+	errFail := errors.New("falla a propósito")
+	err = uow.Do(func(tx domain.Repositories) error {
+		b, _ := domain.NewBook("Uno", "A", 2000, "1", "X", nil)
+		if err := tx.Books.Create(b); err != nil {
+			return err
+		}
+		return errFail
+	})
+	if !errors.Is(err, errFail) {
+		t.Fatalf("Do devolvió %v, se esperaba el error de fn", err)
+	}
+	if books, _ := repos.Books.ListAll(); len(books) != 0 {
+		t.Fatalf("la escritura de una unidad fallida quedó guardada: %d libros", len(books))
+	}
+
+	// Las lecturas no cuentan.
+	var created *domain.Book
+	err = uow.Do(func(tx domain.Repositories) error {
+		if _, err := tx.Users.FindByEmail("ana@example.com"); err != nil {
+			return err
+		}
+		created, _ = domain.NewBook("Uno", "A", 2000, "1", "X", nil)
+		return tx.Books.Create(created)
+	})
+	if err != nil {
+		t.Fatalf("lectura + una escritura: %v", err)
+	}
+	if created.ID() != 1 {
+		t.Fatalf("el libro confirmado tiene ID %d, se esperaba 1", created.ID())
+	}
+}
+
+// Si la escritura guardada falla al aplicarse, Do devuelve ese error y
+// lo agendado con AfterCommit no corre.
+func TestInMemoryUnitOfWorkApplyError(t *testing.T) {
+	uow, repos := newMemoryUnitOfWork()
+	ana, _ := domain.NewUser("Ana", "ana@example.com", domain.RoleReader)
+	if err := repos.Users.Create(ana); err != nil {
+		t.Fatal(err)
+	}
+
+	ran := false
+	err := uow.Do(func(tx domain.Repositories) error {
+		dup, _ := domain.NewUser("Otra Ana", "ana@example.com", domain.RoleReader)
+		if err := tx.Users.Create(dup); err != nil {
+			return err
+		}
+		tx.AfterCommit(func() { ran = true })
+		return nil
+	})
+	if !errors.Is(err, domain.ErrConflict) {
+		t.Fatalf("Do devolvió %v, se esperaba el conflicto del email", err)
+	}
+	if ran {
+		t.Fatal("AfterCommit corrió aunque la escritura falló")
+	}
+}
+
+// Los AfterCommit de unidades concurrentes corren en el orden en que se
+// confirmaron: acá, el orden de los IDs asignados.
+func TestInMemoryUnitOfWorkCommitOrder(t *testing.T) {
+	uow, _ := newMemoryUnitOfWork()
+
+	var (
+		mu       sync.Mutex
+		observed []domain.AccessEventID
+		wg       sync.WaitGroup
+	)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = uow.Do(func(repos domain.Repositories) error {
+				ev, _ := domain.NewAccessEvent(1, 1, domain.AccessTypeLectura)
+				if err := repos.Access.Store(ev); err != nil {
+					return err
+				}
+				repos.AfterCommit(func() {
+					mu.Lock()
+					observed = append(observed, ev.ID())
+					mu.Unlock()
+				})
+				return nil
+			})
+		}()
+	}
+	wg.Wait()
+
+	if len(observed) != 50 || !slices.IsSorted(observed) {
+		t.Fatalf("AfterCommit fuera de orden: %v", observed)
+	}
+}
+
+// AfterCommit corre sin el candado: otra unidad puede confirmar mientras
+// tanto, y sus funciones esperan a que terminen las de la anterior.
+func TestInMemoryUnitOfWorkAfterCommitRunsUnlocked(t *testing.T) {
+	uow, _ := newMemoryUnitOfWork()
+
+	var (
+		order     []string
+		committed = make(chan struct{})
+		second    = make(chan error)
+	)
+	err := uow.Do(func(repos domain.Repositories) error {
+		repos.AfterCommit(func() {
+			go func() {
+				second <- uow.Do(func(repos domain.Repositories) error {
+					close(committed)
+					repos.AfterCommit(func() { order = append(order, "segunda") })
+					return nil
+				})
+			}()
+			select {
+			case <-committed:
+			case <-time.After(5 * time.Second):
+				t.Error("la segunda unidad no pudo correr mientras corría AfterCommit")
+			}
+			order = append(order, "primera")
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := <-second; err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(order, []string{"primera", "segunda"}) {
+		t.Fatalf("AfterCommit fuera de orden: %v", order)
+	}
+}
@@ -0,0 +1,533 @@
+package db
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+/*
+   ==========================================================
+   WAL (WRITE-AHEAD LOG)
+   ==========================================================
+
+   Los snapshots de file_repo.go reescriben TODO el archivo en
+   cada cambio, lo que es caro cuando hay muchos accesos.
+
+   El WAL es un archivo de solo-agregar ("append-only"): cada
+   Create / Update / Store agrega UN registro al final del log
+   ANTES de modificar los mapas en memoria. Al arrancar:
+
+   1. Se carga el último snapshot (si existe).
+   2. Se "reproducen" los registros del WAL encima.
+
+   Formato binario de cada registro:
+
+	[4 bytes: largo del payload][4 bytes: CRC32 del payload][payload JSON]
+
+   El CRC32 (Castagnoli) detecta registros dañados. Si el
+   proceso se cae mientras escribe, el ÚLTIMO registro puede
+   quedar incompleto ("torn write"): ese registro se descarta
+   y el archivo se trunca en el último registro válido. Un
+   registro dañado que NO es el último (con registros válidos
+   detrás) detiene el arranque con errCorruptWAL: truncar ahí
+   borraría los registros buenos que siguen.
+
+   Cada cierta cantidad de registros se COMPACTA: se guarda un
+   snapshot atómico con todo el contenido y se vacía el WAL.
+*/
+
+// walHeaderSize son los 8 bytes de largo + checksum.
+const walHeaderSize = 8
+
+// maxWALRecordSize evita reservar memoria absurda si el largo está dañado.
+const maxWALRecordSize = 16 << 20
+
+// Operaciones que se registran en el WAL.
+const (
+	walOpCreate = "create"
+	walOpUpdate = "update"
+	walOpStore  = "store"
+)
+
+// Nombres de archivo de cada WAL dentro de la carpeta de datos.
+const (
+	usersWALFile        = "users.wal"
+	booksWALFile        = "books.wal"
+	accessEventsWALFile = "access_events.wal"
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// errCorruptWAL indica un registro dañado que NO está al final del archivo.
+var errCorruptWAL = errors.New("WAL dañado")
+
+// walFile es lo que el WAL usa de *os.File (las pruebas lo envuelven
+// para simular fallas de escritura).
+type walFile interface {
+	io.ReadWriteSeeker
+	io.ReaderAt
+	Stat() (os.FileInfo, error)
+	Sync() error
+	Truncate(size int64) error
+	Close() error
+}
+
+// wal es el archivo de log de solo-agregar.
+type wal struct {
+	f    walFile
+	path string
+}
+
+// openWAL abre (o crea) el archivo de WAL.
+func openWAL(path string) (*wal, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("no se pudo abrir el WAL %s: %w", path, err)
+	}
+	return &wal{f: f, path: path}, nil
+}
+
+// append agrega un registro y hace fsync para que sea durable.
+// Si algo falla, el archivo se trunca al largo que tenía antes: un
+// registro a medias en el MEDIO del WAL impediría arrancar, porque
+// los registros siguientes quedarían detrás de él.
+func (w *wal) append(payload []byte) error {
+	buf := make([]byte, walHeaderSize+len(payload))
+	binary.LittleEndian.PutUint32(buf[0:4], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(buf[4:8], crc32.Checksum(payload, crcTable))
+	copy(buf[walHeaderSize:], payload)
+
+	info, err := w.f.Stat()
+	if err != nil {
+		return fmt.Errorf("no se pudo leer el largo del WAL: %w", err)
+	}
+	size := info.Size()
+
+	if _, err := w.f.Write(buf); err != nil {
+		return w.rollback(size, fmt.Errorf("no se pudo escribir en el WAL: %w", err))
+	}
+	if err := w.f.Sync(); err != nil {
+		return w.rollback(size, fmt.Errorf("no se pudo sincronizar el WAL: %w", err))
+	}
+	return nil
+}
+
+// rollback descarta lo escrito por un append fallido (vuelve a size) y
+// devuelve cause. Si ni siquiera se puede truncar, se informan ambos errores.
+func (w *wal) rollback(size int64, cause error) error {
+	if err := w.f.Truncate(size); err != nil {
+		return errors.Join(cause, fmt.Errorf("no se pudo deshacer el registro en el WAL: %w", err))
+	}
+	return cause
+}
+
+// replay lee todos los registros válidos y llama a fn con cada payload.
+// Si el último registro está incompleto o dañado, se descarta y el
+// archivo se trunca. Devuelve la cantidad de registros reproducidos.
+func (w *wal) replay(fn func(payload []byte) error) (int, error) {
+	info, err := w.f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	size := info.Size()
+
+	if _, err := w.f.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	reader := bufio.NewReader(w.f)
+
+	var (
+		offset int64 // posición del final del último registro válido
+		count  int
+		header [walHeaderSize]byte
+	)
+
+	for {
+		if _, err := io.ReadFull(reader, header[:]); err != nil {
+			if errors.Is(err, io.EOF) {
+				break // fin limpio del archivo
+			}
+			if errors.Is(err, io.ErrUnexpectedEOF) {
+				return count, w.truncateTail(offset) // encabezado incompleto
+			}
+			return count, err
+		}
+
+		length := binary.LittleEndian.Uint32(header[0:4])
+		checksum := binary.LittleEndian.Uint32(header[4:8])
+		end := offset + walHeaderSize + int64(length)
+
+		if length > maxWALRecordSize {
+			// append nunca escribe un largo así: el encabezado está dañado.
+			return count, fmt.Errorf("%w: largo inválido en %s (posición %d)", errCorruptWAL, w.path, offset)
+		}
+		if end > size {
+			// El registro dice ser más largo que lo que queda: es una
+			// escritura cortada solo si después no hay registros válidos.
+			if err := w.checkTornTail(offset, size); err != nil {
+				return count, err
+			}
+			return count, w.truncateTail(offset)
+		}
+
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(reader, payload); err != nil {
+			return count, w.truncateTail(offset)
+		}
+
+		if crc32.Checksum(payload, crcTable) != checksum {
+			if end == size {
+				// Último registro dañado: lo tratamos como escritura cortada.
+				return count, w.truncateTail(offset)
+			}
+			return count, fmt.Errorf("%w: checksum inválido en %s (posición %d)", errCorruptWAL, w.path, offset)
+		}
+
+		if err := fn(payload); err != nil {
+			return count, fmt.Errorf("%w: registro ilegible en %s (posición %d): %v", errCorruptWAL, w.path, offset, err)
+		}
+
+		offset = end
+		count++
+	}
+
+	return count, nil
+}
+
+// checkTornTail verifica que lo que hay desde offset sea UN registro
+// cortado. Si desde offset cabe más de un registro, o dentro de esos
+// bytes empieza otro registro válido, el largo de offset está dañado y
+// truncar borraría registros buenos: devuelve errCorruptWAL.
+func (w *wal) checkTornTail(offset, size int64) error {
+	corrupt := fmt.Errorf("%w: largo inválido en %s (posición %d)", errCorruptWAL, w.path, offset)
+	if size-offset > walHeaderSize+maxWALRecordSize {
+		return corrupt
+	}
+
+	tail := make([]byte, size-offset)
+	if _, err := w.f.ReadAt(tail, offset); err != nil {
+		return err
+	}
+	for p := 1; p+walHeaderSize < len(tail); p++ {
+		length := int(binary.LittleEndian.Uint32(tail[p:]))
+		start := p + walHeaderSize
+		// Los payloads son objetos JSON: empiezan con '{'.
+		if length == 0 || length > len(tail)-start || tail[start] != '{' {
+			continue
+		}
+		if crc32.Checksum(tail[start:start+length], crcTable) == binary.LittleEndian.Uint32(tail[p+4:]) {
+			return corrupt
+		}
+	}
+	return nil
+}
+
+// truncateTail corta el archivo en offset, descartando un registro incompleto.
+func (w *wal) truncateTail(offset int64) error {
+	log.Printf("WAL %s: se descarta un registro incompleto al final (posición %d)", w.path, offset)
+	if err := w.f.Truncate(offset); err != nil {
+		return fmt.Errorf("no se pudo truncar el WAL: %w", err)
+	}
+	return w.f.Sync()
+}
+
+// reset vacía el WAL (se llama después de guardar un snapshot).
+func (w *wal) reset() error {
+	if err := w.f.Truncate(0); err != nil {
+		return fmt.Errorf("no se pudo vaciar el WAL: %w", err)
+	}
+	return w.f.Sync()
+}
+
+// close cierra el archivo del WAL.
+func (w *wal) close() error {
+	return w.f.Close()
+}
+
+/*
+   ==========================================================
+   JOURNAL: WAL + COMPACTACIÓN EN SNAPSHOT
+   ==========================================================
+
+   Cada repositorio en memoria tiene un campo journal. Si es
+   nil (repositorio solo en memoria) sus métodos no hacen nada.
+
+   Los repositorios de ARCHIVOS (file_repo.go) usan un journal
+   sin WAL: record guarda directamente el snapshot completo con
+   el cambio ya incluido, también ANTES de tocar la memoria.
+*/
+
+// walEntry es el contenido JSON de cada registro del WAL.
+type walEntry struct {
+	Op   string          `json:"op"`
+	Data json.RawMessage `json:"data"`
+}
+
+// journal une el WAL con el snapshot donde se compacta.
+type journal struct {
+	wal          *wal
+	snapshotPath string
+	compactEvery int
+	pending      int // registros en el WAL desde la última compactación
+
+	// snapshotWith, si no es nil, reemplaza al WAL: devuelve el
+	// contenido actual con el registro data aplicado (el llamador
+	// tiene el lock) y record lo guarda como snapshot.
+	snapshotWith func(data any) any
+}
+
+// record escribe una operación en el WAL (debe llamarse ANTES de mutar).
+// Si falla, el llamador NO debe aplicar el cambio en memoria.
+func (j *journal) record(op string, data any) error {
+	if j == nil {
+		return nil
+	}
+	if j.snapshotWith != nil {
+		return writeSnapshotAtomic(j.snapshotPath, j.snapshotWith(data))
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("no se pudo serializar el registro del WAL: %w", err)
+	}
+	payload, err := json.Marshal(walEntry{Op: op, Data: raw})
+	if err != nil {
+		return fmt.Errorf("no se pudo serializar el registro del WAL: %w", err)
+	}
+	return j.wal.append(payload)
+}
+
+// afterWrite cuenta el registro y compacta si se llegó al límite.
+// snapshot debe devolver el contenido actual (el llamador tiene el lock).
+// Si la compactación falla, los datos siguen seguros en el WAL,
+// así que solo se registra el error y se reintenta en la próxima escritura.
+func (j *journal) afterWrite(snapshot func() any) {
+	if j == nil || j.wal == nil {
+		return
+	}
+
+	j.pending++
+	if j.compactEvery <= 0 || j.pending < j.compactEvery {
+		return
+	}
+	if err := j.compact(snapshot()); err != nil {
+		log.Printf("no se pudo compactar el WAL %s: %v", j.wal.path, err)
+	}
+}
+
+// compact guarda el snapshot y recién después vacía el WAL.
+// Si el proceso se cae entre ambos pasos, al arrancar se reproduce el
+// WAL encima del snapshot nuevo: como cada registro lleva su ID completo,
+// volver a aplicarlo deja el mismo resultado.
+func (j *journal) compact(snap any) error {
+	if err := writeSnapshotAtomic(j.snapshotPath, snap); err != nil {
+		return err
+	}
+	if err := j.wal.reset(); err != nil {
+		return err
+	}
+	j.pending = 0
+	return nil
+}
+
+// close cierra el WAL del journal.
+func (j *journal) close() error {
+	if j == nil || j.wal == nil {
+		return nil
+	}
+	return j.wal.close()
+}
+
+// openJournal abre el WAL en dir, reproduce sus registros con apply
+// y devuelve el journal listo para seguir escribiendo.
+func openJournal(dir, walFile, snapshotFile string, compactEvery int, apply func(walEntry) error) (*journal, error) {
+	w, err := openWAL(filepath.Join(dir, walFile))
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := w.replay(func(payload []byte) error {
+		var entry walEntry
+		if err := json.Unmarshal(payload, &entry); err != nil {
+			return err
+		}
+		return apply(entry)
+	})
+	if err != nil {
+		_ = w.close()
+		return nil, err
+	}
+
+	return &journal{
+		wal:          w,
+		snapshotPath: filepath.Join(dir, snapshotFile),
+		compactEvery: compactEvery,
+		pending:      count,
+	}, nil
+}
+
+/*
+   ==========================================================
+   CONSTRUCTORES DE REPOSITORIOS CON WAL
+   ==========================================================
+
+   Devuelven los MISMOS repositorios en memoria de siempre,
+   pero con el journal conectado.
+*/
+
+// NewWALUserRepo abre el repositorio de usuarios con WAL en dir.
+func NewWALUserRepo(dir string, compactEvery int) (*InMemoryUserRepo, error) {
+	if err := ensureDataDir(dir); err != nil {
+		return nil, err
+	}
+
+	repo := NewInMemoryUserRepo()
+
+	var snap userSnapshot
+	found, err := readSnapshot(filepath.Join(dir, usersSnapshotFile), &snap)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		repo.restore(snap)
+	}
+
+	j, err := openJournal(dir, usersWALFile, usersSnapshotFile, compactEvery, func(entry walEntry) error {
+		var rec userRecord
+		if err := json.Unmarshal(entry.Data, &rec); err != nil {
+			return err
+		}
+		repo.applyLocked(rec)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	repo.journal = j
+	return repo, nil
+}
+
+// NewWALBookRepo abre el repositorio de libros con WAL en dir.
+func NewWALBookRepo(dir string, compactEvery int) (*InMemoryBookRepo, error) {
+	if err := ensureDataDir(dir); err != nil {
+		return nil, err
+	}
+
+	repo := NewInMemoryBookRepo()
+
+	var snap bookSnapshot
+	found, err := readSnapshot(filepath.Join(dir, booksSnapshotFile), &snap)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		repo.restore(snap)
+	}
+
+	j, err := openJournal(dir, booksWALFile, booksSnapshotFile, compactEvery, func(entry walEntry) error {
+		var rec bookRecord
+		if err := json.Unmarshal(entry.Data, &rec); err != nil {
+			return err
+		}
+		repo.applyLocked(rec)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	repo.journal = j
+	return repo, nil
+}
+
+// NewWALAccessLogRepo abre el repositorio de accesos con WAL en dir.
+func NewWALAccessLogRepo(dir string, compactEvery int) (*InMemoryAccessLogRepo, error) {
+	if err := ensureDataDir(dir); err != nil {
+		return nil, err
+	}
+
+	repo := NewInMemoryAccessLogRepo()
+
+	var snap accessEventSnapshot
+	found, err := readSnapshot(filepath.Join(dir, accessEventsSnapshotFile), &snap)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		repo.restore(snap)
+	}
+
+	j, err := openJournal(dir, accessEventsWALFile, accessEventsSnapshotFile, compactEvery, func(entry walEntry) error {
+		var rec accessEventRecord
+		if err := json.Unmarshal(entry.Data, &rec); err != nil {
+			return err
+		}
+		repo.applyLocked(rec)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	repo.journal = j
+	return repo, nil
+}
+
+/*
+   ==========================================================
+   APLICAR REGISTROS DEL WAL
+   ==========================================================
+
+   Cada registro trae la entidad completa con su ID, así que
+   "create" y "update" se aplican igual (insertar o reemplazar).
+   Durante el arranque nadie más usa el repositorio, por eso
+   no se toma el mutex.
+*/
+
+// applyLocked inserta o reemplaza un usuario desde un registro.
+func (r *InMemoryUserRepo) applyLocked(rec userRecord) {
+	if old, ok := r.users[rec.ID]; ok && old.Email() != rec.Email {
+		delete(r.emailIndex, emailKey(old.Email()))
+	}
+	r.users[rec.ID] = rec.toDomain()
+	r.emailIndex[emailKey(rec.Email)] = rec.ID
+	if rec.ID > r.seq {
+		r.seq = rec.ID
+	}
+}
+
+// applyLocked inserta o reemplaza un libro desde un registro.
+func (r *InMemoryBookRepo) applyLocked(rec bookRecord) {
+	r.books[rec.ID] = rec.toDomain()
+	r.index.put(r.books[rec.ID])
+	if rec.ID > r.seq {
+		r.seq = rec.ID
+	}
+}
+
+// applyLocked inserta un evento de acceso desde un registro.
+func (r *InMemoryAccessLogRepo) applyLocked(rec accessEventRecord) {
+	r.events[rec.ID] = rec.toDomain()
+	r.readers.add(r.events[rec.ID])
+	if rec.ID > r.seq {
+		r.seq = rec.ID
+	}
+}
+
+// Close cierra el WAL del repositorio (si tiene).
+func (r *InMemoryUserRepo) Close() error { return r.journal.close() }
+
+// Close cierra el WAL del repositorio (si tiene).
+func (r *InMemoryBookRepo) Close() error { return r.journal.close() }
+
+// Close cierra el WAL del repositorio (si tiene).
+func (r *InMemoryAccessLogRepo) Close() error { return r.journal.close() }
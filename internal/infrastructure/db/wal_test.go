@@ -0,0 +1,265 @@
+package db
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/domain"
+)
+
+// walPayloads son registros de ejemplo (objetos JSON, como los del journal).
+var walPayloads = []string{`{"op":"create","n":1}`, `{"op":"update","n":2}`, `{"op":"store","n":3}`}
+
+// writeTestWAL crea un WAL en un directorio temporal con los payloads y
+// devuelve su ruta y la posición donde empieza cada registro.
+func writeTestWAL(t *testing.T, payloads ...string) (string, []int64) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.wal")
+	w, err := openWAL(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.close()
+
+	var offsets []int64
+	var offset int64
+	for _, p := range payloads {
+		offsets = append(offsets, offset)
+		if err := w.append([]byte(p)); err != nil {
+			t.Fatalf("append: %v", err)
+		}
+		offset += walHeaderSize + int64(len(p))
+	}
+	return path, offsets
+}
+
+// replayTestWAL reabre el WAL y devuelve los payloads reproducidos.
+func replayTestWAL(t *testing.T, path string) ([]string, error) {
+	t.Helper()
+	w, err := openWAL(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.close()
+
+	var got []string
+	_, err = w.replay(func(payload []byte) error {
+		got = append(got, string(payload))
+		return nil
+	})
+	return got, err
+}
+
+// patchFile sobrescribe bytes del archivo en la posición indicada.
+func patchFile(t *testing.T, path string, at int64, data []byte) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteAt(data, at); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func fileSize(t *testing.T, path string) int64 {
+	t.Helper()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return info.Size()
+}
+
+func TestWALReplayRoundTrip(t *testing.T) {
+	path, _ := writeTestWAL(t, walPayloads...)
+
+	got, err := replayTestWAL(t, path)
+	if err != nil || !slices.Equal(got, walPayloads) {
+		t.Fatalf("replay = %q, %v", got, err)
+	}
+
+	// Reproducir no modifica el archivo: una segunda vez da lo mismo.
+	if got, err := replayTestWAL(t, path); err != nil || !slices.Equal(got, walPayloads) {
+		t.Fatalf("segundo replay = %q, %v", got, err)
+	}
+}
+
+// Una escritura cortada deja el último registro incompleto: se descarta
+// y el archivo se trunca en el último registro válido.
+func TestWALReplayTruncatesTornTail(t *testing.T) {
+	full := func(p string) []byte {
+		buf := make([]byte, walHeaderSize+len(p))
+		binary.LittleEndian.PutUint32(buf, uint32(len(p)))
+		binary.LittleEndian.PutUint32(buf[4:], 0xdeadbeef)
+		copy(buf[walHeaderSize:], p)
+		return buf
+	}
+	tests := []struct {
+		name string
+		tail []byte
+	}{
+		{"encabezado cortado", []byte{40, 0, 0}},
+		{"payload cortado", full(`{"op":"create","n":4}`)[:walHeaderSize+5]},
+		{"último registro con checksum inválido", full(`{"op":"create","n":4}`)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, _ := writeTestWAL(t, walPayloads...)
+			valid := fileSize(t, path)
+			patchFile(t, path, valid, tt.tail)
+
+			got, err := replayTestWAL(t, path)
+			if err != nil || !slices.Equal(got, walPayloads) {
+				t.Fatalf("replay = %q, %v", got, err)
+			}
+			if size := fileSize(t, path); size != valid {
+				t.Fatalf("el archivo mide %d, se esperaba truncado a %d", size, valid)
+			}
+		})
+	}
+}
+
+// Un registro dañado en el MEDIO no se trunca: se perderían los
+// registros buenos que siguen.
+func TestWALReplayRejectsCorruptionInTheMiddle(t *testing.T) {
+	tests := []struct {
+		name  string
+		patch func(path string, second int64)
+	}{
+		{"checksum inválido", func(path string, second int64) {
+			patchFile(t, path, second+walHeaderSize+2, []byte("X"))
+		}},
+		{"largo más allá del final", func(path string, second int64) {
+			patchFile(t, path, second, binary.LittleEndian.AppendUint32(nil, 1000))
+		}},
+		{"largo mayor que el máximo", func(path string, second int64) {
+			patchFile(t, path, second, binary.LittleEndian.AppendUint32(nil, maxWALRecordSize+1))
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, offsets := writeTestWAL(t, walPayloads...)
+			before := fileSize(t, path)
+			tt.patch(path, offsets[1])
+
+			got, err := replayTestWAL(t, path)
+			if !errors.Is(err, errCorruptWAL) {
+				t.Fatalf("replay = %q, %v; se esperaba errCorruptWAL", got, err)
+			}
+			if !slices.Equal(got, walPayloads[:1]) {
+				t.Fatalf("antes del error se reprodujeron %q", got)
+			}
+			if size := fileSize(t, path); size != before {
+				t.Fatalf("el WAL dañado se truncó de %d a %d bytes", before, size)
+			}
+		})
+	}
+}
+
+// failingFile escribe solo la mitad de lo pedido y devuelve un error,
+// como un disco lleno.
+type failingFile struct {
+	*os.File
+	fail bool
+}
+
+func (f *failingFile) Write(p []byte) (int, error) {
+	if !f.fail {
+		return f.File.Write(p)
+	}
+	n, _ := f.File.Write(p[:len(p)/2])
+	return n, errors.New("disco lleno")
+}
+
+// Un append fallido no deja un registro a medias: el WAL vuelve al
+// largo que tenía y los registros siguientes se reproducen.
+func TestWALAppendRollsBackOnFailure(t *testing.T) {
+	path, _ := writeTestWAL(t, walPayloads[0])
+	before := fileSize(t, path)
+
+	w, err := openWAL(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := &failingFile{File: w.f.(*os.File), fail: true}
+	w.f = file
+
+	if err := w.append([]byte(walPayloads[1])); err == nil {
+		t.Fatal("append debería fallar")
+	}
+	if size := fileSize(t, path); size != before {
+		t.Fatalf("después del fallo el WAL mide %d, se esperaba %d", size, before)
+	}
+
+	file.fail = false
+	if err := w.append([]byte(walPayloads[2])); err != nil {
+		t.Fatal(err)
+	}
+	w.close()
+
+	got, err := replayTestWAL(t, path)
+	if err != nil || !slices.Equal(got, []string{walPayloads[0], walPayloads[2]}) {
+		t.Fatalf("replay = %q, %v", got, err)
+	}
+}
+
+// Cada compactEvery registros se guarda un snapshot y se vacía el WAL;
+// al reabrir se carga el snapshot y se reproduce el resto.
+func TestWALCompaction(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := NewWALBookRepo(dir, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= 4; i++ {
+		b, _ := domain.NewBook(fmt.Sprintf("Libro %d", i), "Autor", 2000+i, fmt.Sprint(i), "X", []string{"go"})
+		if err := repo.Create(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	b2, _ := repo.FindByID(2)
+	b2.Archive()
+	if err := repo.Update(b2); err != nil {
+		t.Fatal(err)
+	}
+
+	// 3 registros compactados; quedan 2 en el WAL.
+	var snap bookSnapshot
+	if found, err := readSnapshot(filepath.Join(dir, booksSnapshotFile), &snap); !found || err != nil {
+		t.Fatalf("no se guardó el snapshot: %v", err)
+	}
+	if len(snap.Books) != 3 || snap.Seq != 3 {
+		t.Fatalf("snapshot con %d libros y seq %d, se esperaban 3 y 3", len(snap.Books), snap.Seq)
+	}
+	if repo.journal.pending != 2 {
+		t.Fatalf("registros pendientes en el WAL = %d, se esperaban 2", repo.journal.pending)
+	}
+	if err := repo.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := NewWALBookRepo(dir, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+
+	books, _ := reopened.ListAll()
+	if len(books) != 4 {
+		t.Fatalf("al reabrir hay %d libros, se esperaban 4", len(books))
+	}
+	if b, _ := reopened.FindByID(2); b.Active() || b.Version() != 2 {
+		t.Fatalf("la edición del WAL no se aplicó: activo %v, versión %d", b.Active(), b.Version())
+	}
+	next, _ := domain.NewBook("Libro 5", "Autor", 2005, "5", "X", nil)
+	if err := reopened.Create(next); err != nil || next.ID() != 5 {
+		t.Fatalf("el siguiente ID es %d (%v), se esperaba 5", next.ID(), err)
+	}
+}
@@ -0,0 +1,154 @@
+package recommend
+
+import (
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/domain"
+)
+
+/*
+   ==========================================================
+   FILTRADO COLABORATIVO LIBRO A LIBRO
+   ==========================================================
+
+   Implementa domain.Recommender.
+
+   Cada usuario tiene un PESO por libro: el del acceso más
+   fuerte que hizo (APERTURA 1, LECTURA 2, DESCARGA 3; ver
+   domain.AccessWeight). Abrir diez veces un libro no pesa más
+   que descargarlo una vez.
+
+   SIMILITUD COSENO entre dos libros a y b:
+
+	sim(a, b) = Σu w(u,a)·w(u,b) / ( √Σu w(u,a)² · √Σu w(u,b)² )
+
+   El numerador (dot) y las sumas de cuadrados (norm2) se
+   guardan y se ACTUALIZAN con cada acceso: si el peso de u en
+   el libro b pasa de viejo a nuevo, solo cambian norm2[b] y
+   dot[b][c] para los libros c que u ya consultó. Así cada
+   evento cuesta lo que la historia de ese usuario, no lo que
+   el registro completo.
+
+   RECOMENDACIONES para un usuario: cada libro que no consultó
+   suma sim(b, c)·w(u,b) por cada libro b que sí consultó.
+*/
+
+// Engine guarda los pesos y las sumas de la similitud coseno.
+type Engine struct {
+	mu        sync.RWMutex
+	userBooks map[domain.UserID]map[domain.BookID]float64 // w(u, b)
+	dot       map[domain.BookID]map[domain.BookID]float64 // Σu w(u,a)·w(u,b), simétrico
+	norm2     map[domain.BookID]float64                   // Σu w(u,b)²
+}
+
+// NewEngine crea un motor de recomendaciones vacío.
+func NewEngine() *Engine {
+	return &Engine{
+		userBooks: make(map[domain.UserID]map[domain.BookID]float64),
+		dot:       make(map[domain.BookID]map[domain.BookID]float64),
+		norm2:     make(map[domain.BookID]float64),
+	}
+}
+
+// ObserveAccess actualiza las sumas con un acceso nuevo.
+func (e *Engine) ObserveAccess(event *domain.AccessEvent) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	userID, bookID := event.UserID(), event.BookID()
+	books, ok := e.userBooks[userID]
+	if !ok {
+		books = make(map[domain.BookID]float64)
+		e.userBooks[userID] = books
+	}
+
+	old := books[bookID]
+	weight := domain.AccessWeight(event.AccessType())
+	if weight <= old {
+		return // el usuario ya tenía un acceso igual o más fuerte
+	}
+
+	delta := weight - old
+	for other, w := range books {
+		if other == bookID {
+			continue
+		}
+		e.addDot(bookID, other, delta*w)
+		e.addDot(other, bookID, delta*w)
+	}
+	e.norm2[bookID] += weight*weight - old*old
+	books[bookID] = weight
+}
+
+// addDot suma v a dot[a][b].
+func (e *Engine) addDot(a, b domain.BookID, v float64) {
+	row, ok := e.dot[a]
+	if !ok {
+		row = make(map[domain.BookID]float64)
+		e.dot[a] = row
+	}
+	row[b] += v
+}
+
+// similarity es la similitud coseno entre a y b (con dot ya leído).
+func (e *Engine) similarity(a, b domain.BookID, dot float64) float64 {
+	den := math.Sqrt(e.norm2[a] * e.norm2[b])
+	if den == 0 {
+		return 0
+	}
+	return dot / den
+}
+
+// Related devuelve los libros consultados por los mismos usuarios,
+// del más parecido al menos parecido.
+func (e *Engine) Related(bookID domain.BookID) []domain.ScoredBook {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	result := make([]domain.ScoredBook, 0, len(e.dot[bookID]))
+	for other, dot := range e.dot[bookID] {
+		if sim := e.similarity(bookID, other, dot); sim > 0 {
+			result = append(result, domain.ScoredBook{BookID: other, Score: sim})
+		}
+	}
+	sortScored(result)
+	return result
+}
+
+// ForUser recomienda libros que el usuario no consultó.
+func (e *Engine) ForUser(userID domain.UserID) []domain.ScoredBook {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	seen := e.userBooks[userID]
+	scores := make(map[domain.BookID]float64)
+	for book, w := range seen {
+		for other, dot := range e.dot[book] {
+			if _, already := seen[other]; already {
+				continue
+			}
+			scores[other] += w * e.similarity(book, other, dot)
+		}
+	}
+
+	result := make([]domain.ScoredBook, 0, len(scores))
+	for id, score := range scores {
+		if score > 0 {
+			result = append(result, domain.ScoredBook{BookID: id, Score: score})
+		}
+	}
+	sortScored(result)
+	return result
+}
+
+// sortScored ordena por puntaje descendente (el ID desempata).
+func sortScored(books []domain.ScoredBook) {
+	sort.Slice(books, func(i, j int) bool {
+		if books[i].Score != books[j].Score {
+			return books[i].Score > books[j].Score
+		}
+		return books[i].BookID < books[j].BookID
+	})
+}
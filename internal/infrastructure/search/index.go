@@ -0,0 +1,246 @@
+package search
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/domain"
+)
+
+/*
+   ==========================================================
+   ÍNDICE INVERTIDO CON BM25
+   ==========================================================
+
+   Implementa domain.BookSearchIndex.
+
+   ÍNDICE INVERTIDO: para cada término guarda en qué libros
+   aparece y cuántas veces en cada campo:
+
+	"go" → { libro 1: título 1, tags 1 ; libro 7: título 1 }
+
+   PUNTAJE (BM25 por campo): para cada término de la consulta
+
+	idf   = ln(1 + (N - df + 0.5) / (df + 0.5))
+	campo = tf·(k1+1) / (tf + k1·(1 - b + b·largo/largoPromedio))
+	score += boost(campo) · idf · campo
+
+   - N:  libros indexados; df: libros que tienen el término.
+   - Los términos raros (idf alto) pesan más que los comunes.
+   - Un campo corto con el término pesa más que uno largo.
+   - BOOSTS: el título pesa más que los tags, y los tags más
+     que el autor.
+
+   El mismo índice guarda los RASGOS de cada libro para buscar
+   libros parecidos a uno dado (ver similar.go).
+*/
+
+// Campos indexados de cada libro.
+const (
+	fieldTitle = iota
+	fieldTags
+	fieldAuthor
+	fieldCount
+)
+
+// fieldNames son los nombres de los campos en los resaltados.
+var fieldNames = [fieldCount]string{"title", "tags", "author"}
+
+// fieldBoosts es el peso de cada campo: título > tags > autor.
+var fieldBoosts = [fieldCount]float64{3.0, 2.0, 1.0}
+
+// Parámetros clásicos de BM25.
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// document es un libro indexado.
+type document struct {
+	texts   [fieldCount]string // textos originales (para resaltar)
+	lengths [fieldCount]int    // cantidad de tokens por campo
+	terms   []string           // términos distintos (para poder quitarlo)
+
+	features map[string]float64 // rasgos para Similar (ver similar.go)
+}
+
+// termFreq es cuántas veces aparece un término en cada campo de un libro.
+type termFreq [fieldCount]int
+
+// Index es el índice de texto completo de libros, seguro para
+// usar desde varias goroutines.
+type Index struct {
+	mu       sync.RWMutex
+	docs     map[domain.BookID]*document
+	postings map[string]map[domain.BookID]*termFreq
+	totalLen [fieldCount]int
+
+	// features: rasgo → libro → peso (sin idf), para Similar.
+	features map[string]map[domain.BookID]float64
+}
+
+// NewIndex crea un índice vacío.
+func NewIndex() *Index {
+	return &Index{
+		docs:     make(map[domain.BookID]*document),
+		postings: make(map[string]map[domain.BookID]*termFreq),
+		features: make(map[string]map[domain.BookID]float64),
+	}
+}
+
+// Index agrega o reemplaza un libro. Un libro archivado se quita.
+func (ix *Index) Index(book *domain.Book) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	ix.removeLocked(book.ID())
+	if !book.Active() {
+		return
+	}
+
+	doc := &document{}
+	doc.texts[fieldTitle] = book.Title()
+	doc.texts[fieldTags] = strings.Join(book.Tags(), ", ")
+	doc.texts[fieldAuthor] = book.Author()
+
+	freqs := make(map[string]*termFreq)
+	for f := 0; f < fieldCount; f++ {
+		terms := tokenize(doc.texts[f])
+		doc.lengths[f] = len(terms)
+		ix.totalLen[f] += len(terms)
+
+		for _, t := range terms {
+			tf, ok := freqs[t]
+			if !ok {
+				tf = &termFreq{}
+				freqs[t] = tf
+				doc.terms = append(doc.terms, t)
+			}
+			tf[f]++
+		}
+	}
+
+	id := book.ID()
+	for t, tf := range freqs {
+		list, ok := ix.postings[t]
+		if !ok {
+			list = make(map[domain.BookID]*termFreq)
+			ix.postings[t] = list
+		}
+		list[id] = tf
+	}
+
+	doc.features = bookFeatures(book)
+	ix.addFeaturesLocked(id, doc.features)
+	ix.docs[id] = doc
+}
+
+// Remove quita un libro del índice.
+func (ix *Index) Remove(id domain.BookID) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.removeLocked(id)
+}
+
+func (ix *Index) removeLocked(id domain.BookID) {
+	doc, ok := ix.docs[id]
+	if !ok {
+		return
+	}
+	for _, t := range doc.terms {
+		delete(ix.postings[t], id)
+		if len(ix.postings[t]) == 0 {
+			delete(ix.postings, t)
+		}
+	}
+	for f := 0; f < fieldCount; f++ {
+		ix.totalLen[f] -= doc.lengths[f]
+	}
+	ix.removeFeaturesLocked(id, doc.features)
+	delete(ix.docs, id)
+}
+
+// Search devuelve los `limit` libros más relevantes para la consulta,
+// de mayor a menor puntaje (a igual puntaje, por ID).
+func (ix *Index) Search(query string, limit int) []domain.SearchHit {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	terms := uniqueTerms(tokenize(query))
+	if len(terms) == 0 || len(ix.docs) == 0 {
+		return []domain.SearchHit{}
+	}
+
+	n := float64(len(ix.docs))
+	var avgLen [fieldCount]float64
+	for f := 0; f < fieldCount; f++ {
+		avgLen[f] = math.Max(float64(ix.totalLen[f])/n, 1)
+	}
+
+	scores := make(map[domain.BookID]float64)
+	for _, t := range terms {
+		list := ix.postings[t]
+		if len(list) == 0 {
+			continue
+		}
+		df := float64(len(list))
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+
+		for id, tf := range list {
+			doc := ix.docs[id]
+			for f := 0; f < fieldCount; f++ {
+				if tf[f] == 0 {
+					continue
+				}
+				freq := float64(tf[f])
+				norm := 1 - bm25B + bm25B*float64(doc.lengths[f])/avgLen[f]
+				scores[id] += fieldBoosts[f] * idf * freq * (bm25K1 + 1) / (freq + bm25K1*norm)
+			}
+		}
+	}
+
+	hits := make([]domain.SearchHit, 0, len(scores))
+	for id, score := range scores {
+		hits = append(hits, domain.SearchHit{BookID: id, Score: score})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].BookID < hits[j].BookID
+	})
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+
+	// Resaltados solo para los resultados que se devuelven.
+	termSet := make(map[string]bool, len(terms))
+	for _, t := range terms {
+		termSet[t] = true
+	}
+	for i := range hits {
+		doc := ix.docs[hits[i].BookID]
+		hits[i].Highlights = make(map[string]string)
+		for f := 0; f < fieldCount; f++ {
+			if snippet, ok := highlight(doc.texts[f], termSet); ok {
+				hits[i].Highlights[fieldNames[f]] = snippet
+			}
+		}
+	}
+	return hits
+}
+
+// uniqueTerms quita términos repetidos conservando el orden.
+func uniqueTerms(terms []string) []string {
+	seen := make(map[string]bool, len(terms))
+	result := make([]string, 0, len(terms))
+	for _, t := range terms {
+		if !seen[t] {
+			seen[t] = true
+			result = append(result, t)
+		}
+	}
+	return result
+}
@@ -0,0 +1,231 @@
+package search
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/domain"
+)
+
+// testBook arma un libro activo ya guardado.
+func testBook(id domain.BookID, title, author, category string, year int, tags ...string) *domain.Book {
+	return domain.RestoreBook(id, title, author, year, "isbn", category, tags, true,
+		time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), 1)
+}
+
+func hitIDs(hits []domain.SearchHit) []domain.BookID {
+	ids := make([]domain.BookID, 0, len(hits))
+	for _, h := range hits {
+		ids = append(ids, h.BookID)
+	}
+	return ids
+}
+
+// El mismo término, una vez, en campos del mismo largo: el orden lo
+// deciden los boosts (título > tags > autor) y el puntaje es BM25.
+func TestSearchFieldBoosts(t *testing.T) {
+	ix := NewIndex()
+	ix.Index(testBook(3, "Redes", "Kernel", "X", 2020))
+	ix.Index(testBook(1, "Kernel", "Ana", "X", 2020))
+	ix.Index(testBook(2, "Redes", "Ana", "X", 2020, "kernel"))
+
+	hits := ix.Search("kernel", 0)
+	if got := hitIDs(hits); !slices.Equal(got, []domain.BookID{1, 2, 3}) {
+		t.Fatalf("orden = %v, se esperaba título, tags, autor", got)
+	}
+
+	// N = 3, df = 3, tf = 1 y todos los campos miden el promedio:
+	// score = boost · idf · (k1+1) / (1+k1) = boost · idf.
+	idf := math.Log(1 + 0.5/3.5)
+	for i, boost := range fieldBoosts {
+		if want := boost * idf; math.Abs(hits[i].Score-want) > 1e-9 {
+			t.Errorf("puntaje de %d = %v, se esperaba %v", hits[i].BookID, hits[i].Score, want)
+		}
+	}
+}
+
+func TestSearchRanking(t *testing.T) {
+	ix := NewIndex()
+	ix.Index(testBook(1, "Go", "Pike", "Programación", 2015))
+	ix.Index(testBook(2, "Go en la práctica de sistemas distribuidos modernos", "Pike", "Programación", 2015))
+	ix.Index(testBook(3, "Redes de Computadoras", "Tanenbaum", "Redes", 2011))
+	ix.Index(testBook(4, "Redes y Go", "Tanenbaum", "Redes", 2011))
+	ix.Index(testBook(5, "Redes", "Tanenbaum", "Redes", 2011))
+
+	tests := []struct {
+		name  string
+		query string
+		limit int
+		want  []domain.BookID
+	}{
+		// Un campo corto con el término pesa más que uno largo.
+		{"título corto primero", "go", 0, []domain.BookID{1, 4, 2}},
+		// "go" es más raro que "redes": el libro con los dos gana, y
+		// después el de "go" pesa más que los de "redes".
+		{"término raro pesa más", "redes go", 0, []domain.BookID{4, 1, 5, 3, 2}},
+		// Mismo pipeline que el índice: acentos, mayúsculas, plurales.
+		{"plegado y raíz", "RED computadora", 0, []domain.BookID{3, 5, 4}},
+		{"a igual puntaje, por ID", "tanenbaum", 0, []domain.BookID{3, 4, 5}},
+		{"límite", "redes", 2, []domain.BookID{5, 3}},
+		{"solo palabras vacías", "de la y", 0, []domain.BookID{}},
+		{"sin coincidencias", "cocina", 0, []domain.BookID{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hits := ix.Search(tt.query, tt.limit)
+			if got := hitIDs(hits); !slices.Equal(got, tt.want) {
+				t.Fatalf("Search(%q) = %v, se esperaba %v", tt.query, got, tt.want)
+			}
+			for i := 1; i < len(hits); i++ {
+				if hits[i].Score > hits[i-1].Score {
+					t.Fatalf("puntajes fuera de orden: %v", hits)
+				}
+			}
+		})
+	}
+}
+
+// checkTotals verifica que totalLen y las postings coincidan con los
+// documentos indexados.
+func checkTotals(t *testing.T, ix *Index) {
+	t.Helper()
+	var want [fieldCount]int
+	for _, doc := range ix.docs {
+		for f := range want {
+			want[f] += doc.lengths[f]
+		}
+	}
+	if ix.totalLen != want {
+		t.Fatalf("totalLen = %v, suma de los documentos %v", ix.totalLen, want)
+	}
+	for term, list := range ix.postings {
+		if len(list) == 0 {
+			t.Fatalf("quedó una posting vacía para %q", term)
+		}
+		for id := range list {
+			if _, ok := ix.docs[id]; !ok {
+				t.Fatalf("la posting de %q apunta al libro %d, que no está indexado", term, id)
+			}
+		}
+	}
+	for feature, list := range ix.features {
+		if len(list) == 0 {
+			t.Fatalf("quedó un rasgo vacío: %q", feature)
+		}
+	}
+}
+
+func TestIndexReindexAndRemove(t *testing.T) {
+	ix := NewIndex()
+	book := testBook(1, "Sistemas Operativos", "Tanenbaum", "Sistemas", 2008, "kernel")
+	ix.Index(testBook(2, "Redes", "Kurose", "Redes", 2012))
+	ix.Index(book)
+	checkTotals(t, ix)
+
+	// Volver a indexar el mismo libro no duplica largos ni postings.
+	ix.Index(book)
+	checkTotals(t, ix)
+	if len(ix.postings["kernel"]) != 1 {
+		t.Fatalf("postings de kernel = %v", ix.postings["kernel"])
+	}
+
+	// Editado: los términos viejos dejan de encontrarlo.
+	if err := book.UpdateDetails("Compiladores", "Aho", 2006, "isbn", "Programación", nil); err != nil {
+		t.Fatal(err)
+	}
+	ix.Index(book)
+	checkTotals(t, ix)
+	if hits := ix.Search("kernel sistemas tanenbaum", 0); len(hits) != 0 {
+		t.Fatalf("el libro editado se encuentra por sus datos viejos: %v", hitIDs(hits))
+	}
+	for _, term := range []string{"kernel", "sistem", "tanenbaum"} {
+		if _, ok := ix.postings[term]; ok {
+			t.Fatalf("quedó la posting de %q", term)
+		}
+	}
+	if got := hitIDs(ix.Search("compiladores", 0)); !slices.Equal(got, []domain.BookID{1}) {
+		t.Fatalf("búsqueda por el título nuevo = %v", got)
+	}
+
+	// Archivado: sale del índice.
+	book.Archive()
+	ix.Index(book)
+	checkTotals(t, ix)
+	if len(ix.Search("compiladores", 0)) != 0 {
+		t.Fatal("un libro archivado sigue apareciendo")
+	}
+	if _, ok := ix.docs[1]; ok {
+		t.Fatal("el libro archivado sigue en docs")
+	}
+
+	ix.Remove(2)
+	ix.Remove(99) // quitar un libro que no está no hace nada
+	checkTotals(t, ix)
+	if len(ix.docs) != 0 || len(ix.postings) != 0 || len(ix.features) != 0 || ix.totalLen != [fieldCount]int{} {
+		t.Fatalf("el índice vacío quedó con restos: %d docs, %d postings, %d rasgos, largos %v",
+			len(ix.docs), len(ix.postings), len(ix.features), ix.totalLen)
+	}
+	if hits := ix.Search("redes", 0); len(hits) != 0 {
+		t.Fatalf("búsqueda en índice vacío = %v", hitIDs(hits))
+	}
+}
+
+func TestSearchHighlights(t *testing.T) {
+	ix := NewIndex()
+	ix.Index(testBook(1, "Redes <TCP/IP> & Go", "Donovan", "Redes", 2016, "go", "redes"))
+
+	hits := ix.Search("red go", 0)
+	if len(hits) != 1 {
+		t.Fatalf("hits = %v", hits)
+	}
+	want := map[string]string{
+		"title": "<mark>Redes</mark> &lt;TCP/IP&gt; &amp; <mark>Go</mark>",
+		"tags":  "<mark>go</mark>, <mark>redes</mark>",
+	}
+	got := hits[0].Highlights
+	if len(got) != len(want) {
+		t.Fatalf("resaltados = %v, se esperaba %v", got, want)
+	}
+	for field, snippet := range want {
+		if got[field] != snippet {
+			t.Errorf("resaltado de %s = %q, se esperaba %q", field, got[field], snippet)
+		}
+	}
+}
+
+func TestHighlightLongText(t *testing.T) {
+	filler := strings.Repeat("palabra ", 40) // 320 runas
+	terms := map[string]bool{"kernel": true}
+
+	tests := []struct {
+		name         string
+		text         string
+		prefix, tail bool // "…" al principio / al final
+	}{
+		{"coincidencia al principio", "Kernel " + filler, false, true},
+		{"coincidencia al final", filler + "kernel", true, false},
+		{"coincidencia en el medio", filler + "kernel " + filler, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snippet, ok := highlight(tt.text, terms)
+			if !ok || !strings.Contains(strings.ToLower(snippet), "<mark>kernel</mark>") {
+				t.Fatalf("fragmento sin la coincidencia: %q", snippet)
+			}
+			if strings.HasPrefix(snippet, "…") != tt.prefix || strings.HasSuffix(snippet, "…") != tt.tail {
+				t.Fatalf("recorte incorrecto: %q", snippet)
+			}
+			plain := strings.NewReplacer(markOpen, "", markClose, "", "…", "").Replace(snippet)
+			if n := len([]rune(plain)); n > snippetMaxRunes {
+				t.Fatalf("fragmento de %d runas, máximo %d", n, snippetMaxRunes)
+			}
+		})
+	}
+
+	if _, ok := highlight("Redes de computadoras", terms); ok {
+		t.Fatal("sin coincidencias no debería haber fragmento")
+	}
+}
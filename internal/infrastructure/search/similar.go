@@ -0,0 +1,179 @@
+package search
+
+import (
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/domain"
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/textnorm"
+)
+
+/*
+   ==========================================================
+   "MÁS COMO ESTE" (similitud por metadatos)
+   ==========================================================
+
+   Un libro recién cargado no tiene accesos, así que el
+   filtrado colaborativo (internal/infrastructure/recommend) no
+   sabe nada de él. Aquí se compara su CONTENIDO.
+
+   Cada libro es un vector de RASGOS con prefijo por campo:
+
+	"t:red"        término del título
+	"g:redes"      tag completo
+	"c:redes"      categoría
+	"a:tanenbaum"  autor completo
+	"y:2010"       década del año
+
+   PESO de un rasgo en un libro (TF-IDF):
+
+	w = peso(campo) · tf · idf      idf = ln(N / df)
+
+   - N: libros indexados; df: libros con ese rasgo.
+   - Un rasgo que tienen casi todos (la década más común) pesa
+     poco, y uno que tienen TODOS no pesa nada; uno raro (un
+     tag poco usado) pesa mucho.
+
+   SIMILITUD COSENO entre los vectores de dos libros. Solo se
+   comparan los libros que comparten algún rasgo con el pedido.
+   El idf se calcula al consultar, así agregar un libro no
+   obliga a recalcular los demás.
+*/
+
+// Prefijos de los rasgos.
+const (
+	featureTitle    = "t:"
+	featureTag      = "g:"
+	featureCategory = "c:"
+	featureAuthor   = "a:"
+	featureDecade   = "y:"
+)
+
+// featureWeights es el peso de cada campo en la similitud: compartir
+// tags o autor dice más que compartir una palabra del título o la década.
+var featureWeights = map[string]float64{
+	featureTitle:    1.0,
+	featureTag:      1.5,
+	featureCategory: 1.0,
+	featureAuthor:   1.5,
+	featureDecade:   0.5,
+}
+
+// bookFeatures devuelve los rasgos de un libro con su peso de campo
+// multiplicado por la cantidad de veces que aparecen (sin idf).
+func bookFeatures(book *domain.Book) map[string]float64 {
+	features := make(map[string]float64)
+	add := func(prefix, value string) {
+		if value != "" {
+			features[prefix+value] += featureWeights[prefix]
+		}
+	}
+
+	for _, t := range tokenize(book.Title()) {
+		add(featureTitle, t)
+	}
+	for _, tag := range book.Tags() {
+		add(featureTag, textnorm.FoldKey(tag))
+	}
+	add(featureCategory, textnorm.FoldKey(book.CategoryTI()))
+	add(featureAuthor, textnorm.FoldKey(book.Author()))
+	if book.Year() > 0 {
+		add(featureDecade, strconv.Itoa(book.Year()/10*10))
+	}
+	return features
+}
+
+// addFeaturesLocked registra los rasgos de un libro ya indexado.
+func (ix *Index) addFeaturesLocked(id domain.BookID, features map[string]float64) {
+	for f, w := range features {
+		list, ok := ix.features[f]
+		if !ok {
+			list = make(map[domain.BookID]float64)
+			ix.features[f] = list
+		}
+		list[id] = w
+	}
+}
+
+// removeFeaturesLocked quita los rasgos de un libro.
+func (ix *Index) removeFeaturesLocked(id domain.BookID, features map[string]float64) {
+	for f := range features {
+		delete(ix.features[f], id)
+		if len(ix.features[f]) == 0 {
+			delete(ix.features, f)
+		}
+	}
+}
+
+// featureIDF es el idf de un rasgo con los libros indexados ahora.
+func (ix *Index) featureIDF(f string) float64 {
+	df := float64(len(ix.features[f]))
+	if df == 0 {
+		return 0
+	}
+	return math.Log(float64(len(ix.docs)) / df)
+}
+
+// vectorNorm es el largo del vector TF-IDF de un libro.
+func (ix *Index) vectorNorm(features map[string]float64, idf map[string]float64) float64 {
+	sum := 0.0
+	for f, w := range features {
+		v, ok := idf[f]
+		if !ok {
+			v = ix.featureIDF(f)
+			idf[f] = v
+		}
+		sum += (w * v) * (w * v)
+	}
+	return math.Sqrt(sum)
+}
+
+// Similar devuelve los `limit` libros activos más parecidos al indicado
+// por sus metadatos, de mayor a menor similitud (a igual, por ID).
+// Si el libro no está indexado (no existe o está archivado) no hay
+// resultados.
+func (ix *Index) Similar(id domain.BookID, limit int) []domain.ScoredBook {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	doc, ok := ix.docs[id]
+	if !ok {
+		return []domain.ScoredBook{}
+	}
+
+	// idf de cada rasgo, calculado una sola vez por consulta.
+	idf := make(map[string]float64)
+
+	// Producto escalar con cada libro que comparte algún rasgo.
+	dots := make(map[domain.BookID]float64)
+	for f, w := range doc.features {
+		v := ix.featureIDF(f)
+		idf[f] = v
+		for other, ow := range ix.features[f] {
+			if other != id {
+				dots[other] += (w * v) * (ow * v)
+			}
+		}
+	}
+
+	norm := ix.vectorNorm(doc.features, idf)
+	result := make([]domain.ScoredBook, 0, len(dots))
+	for other, dot := range dots {
+		den := norm * ix.vectorNorm(ix.docs[other].features, idf)
+		if den == 0 || dot <= 0 {
+			continue
+		}
+		result = append(result, domain.ScoredBook{BookID: other, Score: dot / den})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Score != result[j].Score {
+			return result[i].Score > result[j].Score
+		}
+		return result[i].BookID < result[j].BookID
+	})
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result
+}
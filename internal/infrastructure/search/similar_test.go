@@ -0,0 +1,120 @@
+package search
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/domain"
+)
+
+// bruteCosine es la similitud coseno con los vectores TF-IDF completos.
+func bruteCosine(ix *Index, a, b domain.BookID) float64 {
+	fa, fb := ix.docs[a].features, ix.docs[b].features
+	dot, na, nb := 0.0, 0.0, 0.0
+	for f, w := range fa {
+		v := w * ix.featureIDF(f)
+		na += v * v
+		if ow, ok := fb[f]; ok {
+			dot += v * ow * ix.featureIDF(f)
+		}
+	}
+	for f, w := range fb {
+		v := w * ix.featureIDF(f)
+		nb += v * v
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(na*nb)
+}
+
+func similarCatalog() *Index {
+	ix := NewIndex()
+	for _, b := range []*domain.Book{
+		testBook(1, "Redes de Computadoras", "Tanenbaum", "Redes", 2011, "tcp", "ip"),
+		testBook(2, "Redes Modernas", "Kurose", "Redes", 2012, "tcp", "ip"),
+		testBook(3, "Sistemas Operativos Modernos", "Tanenbaum", "Sistemas", 2008, "kernel"),
+		testBook(4, "Cocina Italiana", "Rossi", "Gastronomía", 1995, "recetas"),
+		testBook(5, "Redes Inalámbricas", "Gast", "Redes", 2015, "wifi"),
+		testBook(6, "Compiladores", "Aho", "Programación", 2006, "parser"),
+	} {
+		ix.Index(b)
+	}
+	return ix
+}
+
+func TestSimilarCosineOrder(t *testing.T) {
+	ix := similarCatalog()
+
+	got := ix.Similar(1, 0)
+	ids := make([]domain.BookID, 0, len(got))
+	for _, s := range got {
+		ids = append(ids, s.BookID)
+	}
+	// 2 comparte tags, categoría, década y "redes"; 3 el autor, que
+	// pesa más y es más raro que lo que comparte 5 (categoría, década
+	// y "redes", cada uno en la mitad del catálogo). 4 y 6 no
+	// comparten nada.
+	if want := []domain.BookID{2, 3, 5}; !slices.Equal(ids, want) {
+		t.Fatalf("Similar(1) = %v, se esperaba %v", ids, want)
+	}
+	for _, s := range got {
+		if s.BookID == 1 {
+			t.Fatal("el libro aparece como parecido a sí mismo")
+		}
+		if want := bruteCosine(ix, 1, s.BookID); math.Abs(s.Score-want) > 1e-9 {
+			t.Errorf("similitud con %d = %v, el coseno completo da %v", s.BookID, s.Score, want)
+		}
+		if s.Score <= 0 || s.Score > 1+1e-9 {
+			t.Errorf("similitud con %d fuera de (0, 1]: %v", s.BookID, s.Score)
+		}
+	}
+
+	if top := ix.Similar(1, 2); len(top) != 2 || top[0].BookID != 2 || top[1].BookID != 3 {
+		t.Fatalf("Similar(1, 2) = %v", top)
+	}
+	// La similitud coseno es simétrica.
+	for _, s := range ix.Similar(2, 0) {
+		if s.BookID == 1 && math.Abs(s.Score-got[0].Score) > 1e-9 {
+			t.Fatalf("sim(2,1) = %v, sim(1,2) = %v", s.Score, got[0].Score)
+		}
+	}
+}
+
+func TestSimilarEdgeCases(t *testing.T) {
+	ix := similarCatalog()
+
+	// Dos libros con los mismos metadatos: coseno 1, a igual puntaje por ID.
+	ix.Index(testBook(7, "Cocina Italiana", "Rossi", "Gastronomía", 1995, "recetas"))
+	ix.Index(testBook(8, "Cocina Italiana", "Rossi", "Gastronomía", 1995, "recetas"))
+	got := ix.Similar(4, 0)
+	if len(got) != 2 || got[0].BookID != 7 || got[1].BookID != 8 || math.Abs(got[0].Score-1) > 1e-9 {
+		t.Fatalf("Similar(4) = %v, se esperaban 7 y 8 con similitud 1", got)
+	}
+
+	// Archivado o inexistente: sin resultados, y deja de aparecer en los demás.
+	archived := testBook(8, "Cocina Italiana", "Rossi", "Gastronomía", 1995, "recetas")
+	archived.Archive()
+	ix.Index(archived)
+	if s := ix.Similar(8, 0); len(s) != 0 {
+		t.Fatalf("Similar de un libro archivado = %v", s)
+	}
+	if s := ix.Similar(99, 0); len(s) != 0 {
+		t.Fatalf("Similar de un libro inexistente = %v", s)
+	}
+	for _, s := range ix.Similar(4, 0) {
+		if s.BookID == 8 {
+			t.Fatal("el libro archivado sigue apareciendo como parecido")
+		}
+	}
+
+	// Un rasgo que tienen TODOS los libros pesa cero: compartir solo
+	// la categoría no los hace parecidos.
+	small := NewIndex()
+	small.Index(testBook(1, "Redes", "Tanenbaum", "Redes", 2011))
+	small.Index(testBook(2, "Protocolos", "Kurose", "Redes", 1990))
+	if s := small.Similar(1, 0); len(s) != 0 {
+		t.Fatalf("Similar con solo un rasgo común a todos = %v", s)
+	}
+}
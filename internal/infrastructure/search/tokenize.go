@@ -0,0 +1,127 @@
+package search
+
+import (
+	"html"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/textnorm"
+)
+
+/*
+   ==========================================================
+   TOKENIZACIÓN Y RESALTADO
+   ==========================================================
+
+   Los textos pasan por el pipeline de internal/textnorm:
+   palabras → sin acentos ni mayúsculas → sin palabras vacías
+   → raíz liviana. Ese resultado es el TÉRMINO que va al índice:
+
+	"Programación de Redes" → ["programacion", "red"]
+
+   Las consultas pasan por el MISMO pipeline, así "redes",
+   "Red" y "REDES" encuentran lo mismo.
+*/
+
+// span es un token dentro del texto original (posiciones en bytes).
+type span struct {
+	start int
+	end   int
+	term  string
+}
+
+// tokenSpans recorre el texto y devuelve sus términos con su posición.
+// Las palabras vacías no generan término.
+func tokenSpans(text string) []span {
+	words := textnorm.Words(text)
+	spans := make([]span, 0, len(words))
+	for _, w := range words {
+		if term, ok := textnorm.Term(w.Text); ok {
+			spans = append(spans, span{start: w.Start, end: w.End, term: term})
+		}
+	}
+	return spans
+}
+
+// tokenize devuelve solo los términos del texto.
+func tokenize(text string) []string {
+	spans := tokenSpans(text)
+	terms := make([]string, 0, len(spans))
+	for _, s := range spans {
+		terms = append(terms, s.term)
+	}
+	return terms
+}
+
+// Marcas que rodean cada coincidencia en los fragmentos.
+const (
+	markOpen  = "<mark>"
+	markClose = "</mark>"
+)
+
+// snippetMaxRunes es el largo máximo aproximado de un fragmento.
+const snippetMaxRunes = 160
+
+/*
+highlight devuelve un fragmento del texto con los términos buscados
+marcados con <mark>...</mark>, y false si no hubo coincidencias.
+
+El resto del texto se escapa para HTML, así el fragmento se puede
+mostrar tal cual en una página. Si el texto es largo, el fragmento
+se recorta alrededor de la primera coincidencia.
+*/
+func highlight(text string, terms map[string]bool) (string, bool) {
+	var matches []span
+	for _, s := range tokenSpans(text) {
+		if terms[s.term] {
+			matches = append(matches, s)
+		}
+	}
+	if len(matches) == 0 {
+		return "", false
+	}
+
+	from, to := snippetWindow(text, matches[0].start)
+
+	var b strings.Builder
+	if from > 0 {
+		b.WriteString("…")
+	}
+	pos := from
+	for _, m := range matches {
+		if m.start < from || m.end > to {
+			continue
+		}
+		b.WriteString(html.EscapeString(text[pos:m.start]))
+		b.WriteString(markOpen)
+		b.WriteString(html.EscapeString(text[m.start:m.end]))
+		b.WriteString(markClose)
+		pos = m.end
+	}
+	b.WriteString(html.EscapeString(text[pos:to]))
+	if to < len(text) {
+		b.WriteString("…")
+	}
+	return b.String(), true
+}
+
+// snippetWindow elige el tramo [from, to) del texto (en bytes) que se
+// muestra, empezando un poco antes de la posición `at`.
+func snippetWindow(text string, at int) (int, int) {
+	if utf8.RuneCountInString(text) <= snippetMaxRunes {
+		return 0, len(text)
+	}
+
+	// Retroceder hasta ~1/4 del fragmento antes de la coincidencia.
+	from := at
+	for back := 0; from > 0 && back < snippetMaxRunes/4; back++ {
+		_, size := utf8.DecodeLastRuneInString(text[:from])
+		from -= size
+	}
+	to := from
+	for n := 0; to < len(text) && n < snippetMaxRunes; n++ {
+		_, size := utf8.DecodeRuneInString(text[to:])
+		to += size
+	}
+	return from, to
+}
@@ -0,0 +1,91 @@
+package textnorm
+
+import (
+	"strings"
+	"unicode"
+)
+
+/*
+   ==========================================================
+   PLEGADO DE ACENTOS (accent folding)
+   ==========================================================
+
+   Fold deja un texto listo para COMPARAR sin importar
+   mayúsculas ni acentos:
+
+	"Programación en ESPAÑOL" → "programacion en espanol"
+
+   Es el mismo resultado que aplicar la forma Unicode NFD
+   (cada letra acentuada se separa en letra base + marca
+   combinante) y luego borrar las marcas combinantes:
+
+	"ó" = "o" + U+0301 (acento agudo) → "o"
+
+   Para no depender de paquetes externos, las letras latinas
+   precompuestas (Latin-1 y Latin Extended-A, que cubren el
+   español y los idiomas europeos habituales) se descomponen
+   con una tabla; las marcas combinantes sueltas (texto que ya
+   venía en NFD) se eliminan directamente.
+
+   La Ñ se pliega a N, igual que en la mayoría de los
+   buscadores: "espanol" encuentra "español".
+*/
+
+// foldPairs son pares "letra base" de la descomposición NFD
+// (solo minúsculas: Fold pasa a minúsculas antes de buscar aquí).
+const foldPairs = "" +
+	"àa áa âa ãa äa åa çc èe ée êe ëe ìi íi îi ïi ñn " +
+	"òo óo ôo õo öo ùu úu ûu üu ýy ÿy āa ăa ąa ćc ĉc " +
+	"ċc čc ďd ēe ĕe ėe ęe ěe ĝg ğg ġg ģg ĥh ĩi īi ĭi " +
+	"įi ĵj ķk ĺl ļl ľl ńn ņn ňn ōo ŏo őo ŕr ŗr řr śs " +
+	"ŝs şs šs ţt ťt ũu ūu ŭu ůu űu ųu ŵw ŷy źz żz žz"
+
+// foldExtra son letras que NFD no descompone pero que se
+// escriben sin el signo al buscar (ß → ss, æ → ae, ...).
+var foldExtra = map[rune]string{
+	'ß': "ss",
+	'æ': "ae",
+	'œ': "oe",
+	'ø': "o",
+	'đ': "d",
+	'ł': "l",
+}
+
+// foldTable es el MAP letra → base, armado a partir de foldPairs.
+var foldTable = buildFoldTable()
+
+func buildFoldTable() map[rune]string {
+	table := make(map[rune]string, len(foldExtra)+128)
+	for _, pair := range strings.Fields(foldPairs) {
+		runes := []rune(pair)
+		table[runes[0]] = string(runes[1:])
+	}
+	for r, base := range foldExtra {
+		table[r] = base
+	}
+	return table
+}
+
+// Fold pasa el texto a minúsculas y le quita acentos y diacríticos.
+func Fold(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		r = unicode.ToLower(r)
+		if unicode.Is(unicode.Mn, r) {
+			continue // marca combinante (texto en NFD)
+		}
+		if base, ok := foldTable[r]; ok {
+			b.WriteString(base)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// FoldKey es Fold más recorte de espacios en los extremos; se usa
+// para claves exactas (categorías, tags).
+func FoldKey(s string) string {
+	return strings.TrimSpace(Fold(s))
+}
@@ -0,0 +1,149 @@
+package textnorm
+
+import (
+	"strings"
+	"unicode"
+)
+
+/*
+   ==========================================================
+   NORMALIZACIÓN DE TEXTO PARA BÚSQUEDAS (español)
+   ==========================================================
+
+   Pipeline que usan los filtros de libros, el índice de texto
+   completo y la CLI, para que TODOS comparen igual:
+
+   1. Words:  separar en palabras (letras y dígitos).
+   2. Fold:   minúsculas, sin acentos, ñ → n   (ver fold.go).
+   3. Stopwords: descartar palabras vacías ("de", "la", "y"...).
+   4. Stem:   raíz liviana ("programaciones" → "programacion").
+
+   Fold solo (pasos 2) se usa para "contiene" y comparaciones
+   exactas; el pipeline completo (Terms) para el índice de
+   texto completo.
+
+	Terms("Las Redes de Computadoras") → ["red", "computador"]
+*/
+
+// Word es una palabra dentro de un texto (posiciones en bytes).
+type Word struct {
+	Start int
+	End   int
+	Text  string
+}
+
+// Words separa el texto en palabras: secuencias de letras, dígitos
+// o marcas combinantes (para no cortar palabras escritas en NFD).
+func Words(s string) []Word {
+	var (
+		words []Word
+		start = -1
+	)
+	for i, r := range s {
+		inWord := unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r)
+		if inWord && start < 0 {
+			start = i
+		}
+		if !inWord && start >= 0 {
+			words = append(words, Word{Start: start, End: i, Text: s[start:i]})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		words = append(words, Word{Start: start, End: len(s), Text: s[start:]})
+	}
+	return words
+}
+
+// Term convierte una palabra en el término que se indexa.
+// Devuelve false si es una palabra vacía (stopword).
+func Term(word string) (string, bool) {
+	folded := Fold(word)
+	if folded == "" || IsStopword(folded) {
+		return "", false
+	}
+	return Stem(folded), true
+}
+
+// Terms aplica el pipeline completo a un texto.
+func Terms(s string) []string {
+	words := Words(s)
+	terms := make([]string, 0, len(words))
+	for _, w := range words {
+		if t, ok := Term(w.Text); ok {
+			terms = append(terms, t)
+		}
+	}
+	return terms
+}
+
+/*
+   ==========================================================
+   PALABRAS VACÍAS (stopwords)
+   ==========================================================
+
+   Artículos, preposiciones, conjunciones y pronombres muy
+   frecuentes: no ayudan a distinguir un libro de otro. Están
+   escritas ya plegadas (sin acentos).
+*/
+
+var stopwords = map[string]bool{}
+
+func init() {
+	for _, w := range strings.Fields(`
+		a al algo algun alguna algunas alguno algunos ante antes como con contra cual
+		cuando de del desde donde durante e el ella ellas ellos en entre era es esa
+		esas ese eso esos esta estas este esto estos fue ha hasta hay la las le les
+		lo los mas me mi mis muy ni no nos o otra otras otro otros para pero por
+		porque que se sea segun ser si sin sobre son su sus tambien te tu tus u un
+		una unas uno unos y ya yo
+	`) {
+		stopwords[w] = true
+	}
+}
+
+// IsStopword indica si una palabra (ya plegada) es vacía.
+func IsStopword(folded string) bool {
+	return stopwords[folded]
+}
+
+/*
+   ==========================================================
+   STEMMER LIVIANO PARA ESPAÑOL
+   ==========================================================
+
+   Reduce plurales y género con pocas reglas (basado en el
+   "light stemmer" de J. Savoy). Trabaja sobre texto ya
+   plegado y no toca palabras de menos de 5 letras:
+
+   - termina en "eses"        → quitar "es"  (meses → mes)
+   - termina en "ces"         → "z"          (luces → luz)
+   - termina en "os/as/es"    → quitar 2     (libros → libr)
+   - termina en "o/a/e"       → quitar 1     (libro → libr)
+
+   Así "libro", "libros", "libra" y "libras" comparten raíz.
+*/
+
+// Stem devuelve la raíz liviana de una palabra ya plegada.
+func Stem(word string) string {
+	r := []rune(word)
+	n := len(r)
+	if n < 5 {
+		return word
+	}
+
+	switch r[n-1] {
+	case 'o', 'a', 'e':
+		return string(r[:n-1])
+	case 's':
+		switch {
+		case r[n-2] == 'e' && r[n-3] == 's' && r[n-4] == 'e':
+			return string(r[:n-2])
+		case r[n-2] == 'e' && r[n-3] == 'c':
+			return string(r[:n-3]) + "z"
+		case r[n-2] == 'o' || r[n-2] == 'a' || r[n-2] == 'e':
+			return string(r[:n-2])
+		}
+	}
+	return word
+}
@@ -0,0 +1,159 @@
+package textnorm
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestFold(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Programación en ESPAÑOL", "programacion en espanol"},
+		{"ñandú", "nandu"},
+		{"ÑOÑO", "nono"},
+		{"Pingüino", "pinguino"},
+		{"Ça va, Élodie?", "ca va, elodie?"},
+		{"Straße", "strasse"},
+		{"Œuvre Æsop", "oeuvre aesop"},
+		{"Łódź", "lodz"},
+		// Texto en NFD: letra base + marca combinante suelta.
+		{"Programacio\u0301n", "programacion"},
+		{"espan\u0303ol", "espanol"},
+		{"Go 1.22 — ¿qué?", "go 1.22 — ¿que?"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := Fold(tt.in); got != tt.want {
+			t.Errorf("Fold(%q) = %q, se esperaba %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+// Plegar dos veces da lo mismo que plegar una: se prueba cada letra
+// de Latin-1, Latin Extended-A y las marcas combinantes.
+func TestFoldIdempotent(t *testing.T) {
+	var all []rune
+	for r := rune(0x20); r <= 0x17F; r++ {
+		all = append(all, r)
+	}
+	for r := rune(0x300); r <= 0x36F; r++ {
+		all = append(all, r)
+	}
+	for _, r := range all {
+		s := "x" + string(r) + "Y"
+		once := Fold(s)
+		if twice := Fold(once); twice != once {
+			t.Errorf("Fold(Fold(%q)) = %q, Fold = %q", s, twice, once)
+		}
+	}
+	for _, s := range []string{"Programación en ESPAÑOL", "STRAßE", "Ñandú", "Programación"} {
+		if once := Fold(s); Fold(once) != once {
+			t.Errorf("Fold no es idempotente para %q", s)
+		}
+	}
+}
+
+func TestFoldKey(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"  Bases de Datos ", "bases de datos"},
+		{"\tCIENCIA\n", "ciencia"},
+		{"Programación", "programacion"},
+		{"   ", ""},
+	}
+	for _, tt := range tests {
+		if got := FoldKey(tt.in); got != tt.want {
+			t.Errorf("FoldKey(%q) = %q, se esperaba %q", tt.in, got, tt.want)
+		}
+	}
+	if FoldKey("REDES") != FoldKey(" redes ") {
+		t.Error("dos categorías iguales salvo mayúsculas y espacios dan claves distintas")
+	}
+}
+
+func TestStem(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		// Plurales y género comparten raíz.
+		{"libro", "libr"},
+		{"libros", "libr"},
+		{"libra", "libr"},
+		{"libras", "libr"},
+		{"redes", "red"},
+		{"computadoras", "computador"},
+		{"programaciones", "programacion"},
+		// -eses → quitar "es"; -ces → "z".
+		{"meses", "mes"},
+		{"procesos", "proces"},
+		{"luces", "luz"},
+		{"lapices", "lapiz"},
+		// Palabras cortas (menos de 5 letras) no se tocan.
+		{"red", "red"},
+		{"casa", "casa"},
+		{"mes", "mes"},
+		// Sin sufijo conocido.
+		{"kernel", "kernel"},
+		{"algoritmos", "algoritm"},
+		{"tcpip", "tcpip"},
+	}
+	for _, tt := range tests {
+		if got := Stem(tt.in); got != tt.want {
+			t.Errorf("Stem(%q) = %q, se esperaba %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestStopwords(t *testing.T) {
+	for _, w := range []string{"de", "la", "y", "segun", "tambien", "mas"} {
+		if !IsStopword(w) {
+			t.Errorf("%q debería ser stopword", w)
+		}
+	}
+	// La lista está plegada: la forma con acento no se reconoce sola,
+	// por eso Term pliega antes de preguntar.
+	if IsStopword("según") {
+		t.Error("IsStopword espera texto ya plegado")
+	}
+	for _, w := range []string{"redes", "go", "datos"} {
+		if IsStopword(w) {
+			t.Errorf("%q no debería ser stopword", w)
+		}
+	}
+	if _, ok := Term("SEGÚN"); ok {
+		t.Error("Term debería descartar una stopword con acento y mayúsculas")
+	}
+}
+
+func TestTerms(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"Las Redes de Computadoras", []string{"red", "computador"}},
+		{"Programación en ESPAÑOL, 2ª edición", []string{"programacion", "espanol", "2ª", "edicion"}},
+		{"el la de y", []string{}},
+		{"Sistemas-Operativos/Linux", []string{"sistem", "operativ", "linux"}},
+	}
+	for _, tt := range tests {
+		if got := Terms(tt.in); !slices.Equal(got, tt.want) {
+			t.Errorf("Terms(%q) = %q, se esperaba %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestWords(t *testing.T) {
+	s := "¡Hola, año 2024!"
+	words := Words(s)
+	want := []string{"Hola", "año", "2024"}
+	if len(words) != len(want) {
+		t.Fatalf("Words(%q) = %v", s, words)
+	}
+	for i, w := range words {
+		if w.Text != want[i] || s[w.Start:w.End] != w.Text {
+			t.Errorf("palabra %d = %+v, se esperaba %q", i, w, want[i])
+		}
+	}
+}
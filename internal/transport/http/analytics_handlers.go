@@ -0,0 +1,48 @@
+package http
+
+import (
+	nethttp "net/http"
+	"time"
+)
+
+/*
+==========================================================
+ENDPOINT GET /analytics/overview
+==========================================================
+
+Tablero del catálogo, armado con agregados en memoria (no recorre
+el registro de accesos). Parámetros:
+- limit: largo de cada ranking (10 por defecto, máximo 20).
+
+Respuesta:
+
+	{
+	  "generated_at": "2024-05-20T18:30:00Z",
+	  "users": {"total": 120, "dau": 14, "wau": 45, "mau": 80},
+	  "by_access_type": {
+	    "LECTURA": {
+	      "books":      [{"book_id": 3, "title": "Redes de Computadoras", "author": "Andrew Tanenbaum", "count": 42}],
+	      "categories": [{"value": "Redes", "count": 60}],
+	      "authors":    [{"value": "Andrew Tanenbaum", "count": 51}],
+	      "tags":       [{"value": "tcp", "count": 38}]
+	    },
+	    "APERTURA": { ... },
+	    "DESCARGA": { ... }
+	  },
+	  "new_books_per_month": [{"month": "2024-05", "count": 7}]
+	}
+
+new_books_per_month trae los últimos 12 meses (UTC), también los
+que no tuvieron libros nuevos.
+*/
+func (h *HTTPHandler) handleAnalyticsOverview(w nethttp.ResponseWriter, r *nethttp.Request) {
+	limit, _, err := parsePageParams(r)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	now := time.Now()
+	overview := h.analyticsService.Overview(limit, now)
+	writeJSON(w, nethttp.StatusOK, toAnalyticsOverviewResponse(overview, now))
+}
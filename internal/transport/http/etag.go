@@ -0,0 +1,58 @@
+package http
+
+import (
+	"errors"
+	nethttp "net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/usecase"
+)
+
+/*
+   ==========================================================
+   ETag / If-Match (concurrencia optimista)
+   ==========================================================
+
+   Cada usuario y cada libro tiene una VERSIÓN que aumenta con
+   cada cambio. La API la publica como ETag:
+
+	ETag: "3"
+
+   Para no pisar cambios ajenos, el cliente reenvía ese valor
+   al modificar (PATCH /users/{id}, PUT y DELETE /books/{id}):
+
+	If-Match: "3"
+
+   Si el recurso ya cambió, la respuesta es 412 Precondition
+   Failed. Sin If-Match (o con If-Match: *) no se verifica.
+*/
+
+// setETag escribe el header ETag con la versión de la entidad.
+func setETag(w nethttp.ResponseWriter, version int64) {
+	w.Header().Set("ETag", strconv.Quote(strconv.FormatInt(version, 10)))
+}
+
+// parseIfMatch lee el header If-Match y devuelve la versión esperada.
+// Devuelve usecase.AnyVersion si no hay header o si vale "*".
+func parseIfMatch(r *nethttp.Request) (int64, error) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" || value == "*" {
+		return usecase.AnyVersion, nil
+	}
+
+	// If-Match usa comparación FUERTE: un ETag débil (W/"3") nunca coincide.
+	if strings.HasPrefix(value, "W/") {
+		return 0, errors.New("If-Match requiere un ETag fuerte, por ejemplo \"3\"")
+	}
+
+	unquoted, err := strconv.Unquote(value)
+	if err != nil {
+		return 0, errors.New("If-Match debe ser un único ETag entre comillas, por ejemplo \"3\"")
+	}
+	version, err := strconv.ParseInt(unquoted, 10, 64)
+	if err != nil || version <= 0 {
+		return 0, errors.New("If-Match no corresponde a una versión válida")
+	}
+	return version, nil
+}
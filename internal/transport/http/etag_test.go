@@ -0,0 +1,105 @@
+package http
+
+import (
+	nethttp "net/http"
+	"testing"
+)
+
+func TestETagHeader(t *testing.T) {
+	mux := newTestMux(t)
+
+	rec := serve(mux, "POST", "/users", `{"name":"Ana","email":"ana@example.com","role":"READER"}`)
+	if etag := rec.Header().Get("ETag"); etag != `"1"` {
+		t.Fatalf("ETag de POST /users = %s, se esperaba \"1\"", etag)
+	}
+	rec = serve(mux, "POST", "/books", `{"title":"Redes","author":"Tanenbaum","year":2010,"isbn":"978-1","category_ti":"Redes"}`)
+	if etag := rec.Header().Get("ETag"); etag != `"1"` {
+		t.Fatalf("ETag de POST /books = %s, se esperaba \"1\"", etag)
+	}
+
+	serve(mux, "PATCH", "/users/1", `{"name":"Ana María"}`)
+	if etag := serve(mux, "GET", "/users/1", "").Header().Get("ETag"); etag != `"2"` {
+		t.Fatalf("ETag después de PATCH = %s, se esperaba \"2\"", etag)
+	}
+	if etag := serve(mux, "DELETE", "/books/1", "").Header().Get("ETag"); etag != `"2"` {
+		t.Fatalf("ETag de DELETE = %s, se esperaba \"2\"", etag)
+	}
+}
+
+// Con If-Match la modificación solo se aplica si la versión coincide;
+// si no, 412 y el recurso queda como estaba.
+func TestIfMatch(t *testing.T) {
+	const putBody = `{"title":"Redes","author":"Kurose","year":2017,"isbn":"978-1","category_ti":"Redes"}`
+
+	tests := []struct {
+		name, method, target, body, ifMatch string
+		status                              int
+		typ                                 string // vacío si la respuesta no es un error
+	}{
+		{"PUT con la versión actual", "PUT", "/books/1", putBody, `"1"`, 200, ""},
+		{"PUT con versión vieja", "PUT", "/books/1", putBody, `"2"`, 412, "/problems/version-conflict"},
+		{"DELETE con versión vieja", "DELETE", "/books/1", "", `"7"`, 412, "/problems/version-conflict"},
+		{"PATCH con versión vieja", "PATCH", "/users/1", `{"name":"Beto"}`, `"3"`, 412, "/problems/version-conflict"},
+		{"PATCH con la versión actual", "PATCH", "/users/1", `{"name":"Beto"}`, `"1"`, 200, ""},
+		{"sin If-Match no se verifica", "PUT", "/books/1", putBody, "", 200, ""},
+		{"If-Match: * no se verifica", "DELETE", "/books/1", "", "*", 200, ""},
+		{"ETag débil", "PUT", "/books/1", putBody, `W/"1"`, 400, "about:blank"},
+		{"sin comillas", "PUT", "/books/1", putBody, "1", 400, "about:blank"},
+		{"varios ETags", "PATCH", "/users/1", `{"name":"Beto"}`, `"1", "2"`, 400, "about:blank"},
+		{"versión cero", "DELETE", "/books/1", "", `"0"`, 400, "about:blank"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := newTestMux(t)
+			mustPostUser(t, mux, "Ana", "ana@example.com")
+			mustPostBook(t, mux, `{"title":"Redes","author":"Tanenbaum","year":2010,"isbn":"978-1","category_ti":"Redes"}`)
+
+			var headers []string
+			if tt.ifMatch != "" {
+				headers = []string{"If-Match", tt.ifMatch}
+			}
+			rec := serve(mux, tt.method, tt.target, tt.body, headers...)
+
+			if tt.typ == "" {
+				if rec.Code != tt.status || rec.Header().Get("ETag") != `"2"` {
+					t.Fatalf("status %d ETag %s, se esperaba %d \"2\" (cuerpo: %s)",
+						rec.Code, rec.Header().Get("ETag"), tt.status, rec.Body.String())
+				}
+				return
+			}
+			p := decodeProblem(t, rec)
+			if p.Status != tt.status || p.Type != tt.typ {
+				t.Fatalf("%d %s, se esperaba %d %s", p.Status, p.Type, tt.status, tt.typ)
+			}
+
+			// El rechazo no cambia nada: la versión sigue siendo 1.
+			if etag := serve(mux, "GET", tt.target, "").Header().Get("ETag"); etag != `"1"` {
+				t.Fatalf("ETag después del rechazo = %s, se esperaba \"1\"", etag)
+			}
+		})
+	}
+}
+
+// Dos clientes leen la misma versión: el segundo en guardar pierde.
+func TestIfMatchLostUpdate(t *testing.T) {
+	mux := newTestMux(t)
+	mustPostBook(t, mux, `{"title":"Redes","author":"Tanenbaum","year":2010,"isbn":"978-1","category_ti":"Redes"}`)
+	etag := serve(mux, "GET", "/books/1", "").Header().Get("ETag")
+
+	first := serve(mux, "PUT", "/books/1",
+		`{"title":"Redes I","author":"Tanenbaum","year":2010,"isbn":"978-1","category_ti":"Redes"}`, "If-Match", etag)
+	if first.Code != nethttp.StatusOK {
+		t.Fatalf("primer PUT = %d", first.Code)
+	}
+	second := serve(mux, "PUT", "/books/1",
+		`{"title":"Redes II","author":"Tanenbaum","year":2010,"isbn":"978-1","category_ti":"Redes"}`, "If-Match", etag)
+	if p := decodeProblem(t, second); p.Status != nethttp.StatusPreconditionFailed {
+		t.Fatalf("segundo PUT = %d, se esperaba 412", p.Status)
+	}
+
+	var got bookResponse
+	decodeBody(t, serve(mux, "GET", "/books/1", ""), nethttp.StatusOK, &got)
+	if got.Title != "Redes I" || got.Version != 2 {
+		t.Fatalf("libro = %+v, se esperaba el del primer PUT", got)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	nethttp "net/http"
 	"strconv"
 
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/bookquery"
 	"github.com/jfmg0509/sistema_libros_funcional_go/internal/domain"
 	"github.com/jfmg0509/sistema_libros_funcional_go/internal/usecase"
 )
@@ -22,15 +23,24 @@ import (
 */
 
 type HTTPHandler struct {
-	userService *usecase.UserService
-	bookService *usecase.BookService
+	userService      *usecase.UserService
+	bookService      *usecase.BookService
+	historyService   *usecase.HistoryService
+	analyticsService *usecase.AnalyticsService
 }
 
 // NewHTTPHandler es el CONSTRUCTOR del handler HTTP.
-func NewHTTPHandler(userSvc *usecase.UserService, bookSvc *usecase.BookService) *HTTPHandler {
+func NewHTTPHandler(
+	userSvc *usecase.UserService,
+	bookSvc *usecase.BookService,
+	historySvc *usecase.HistoryService,
+	analyticsSvc *usecase.AnalyticsService,
+) *HTTPHandler {
 	return &HTTPHandler{
-		userService: userSvc,
-		bookService: bookSvc,
+		userService:      userSvc,
+		bookService:      bookSvc,
+		historyService:   historySvc,
+		analyticsService: analyticsSvc,
 	}
 }
 
@@ -41,16 +51,47 @@ las rutas que soporta nuestra API.
 Aquí definimos:
 - /health
 - /users
+- /users/{id}   (GET, PATCH)
+- /users/{id}/recommendations (GET)
+- /users/{id}/history (GET, accesos paginados)
+- /users/{id}/stats (GET, resumen de actividad)
 - /books
+- /books/{id}   (GET, PUT, DELETE)
+- /books/search (GET, texto completo)
+- /books/suggest (GET, autocompletado)
+- /books/trending (GET, más consultados en 1h / 24h / 7d)
+- /books/{id}/related (GET)
+- /books/{id}/similar (GET)
 - /access
 - /access/stats
+- /access/stats/timeseries (GET, accesos por hora / día / semana / mes)
+- /access/stats/readers (GET, lectores distintos por día)
+- /access/stats/funnel (GET, embudo APERTURA → LECTURA → DESCARGA)
+- /analytics/overview (GET, tablero del catálogo)
 */
 func (h *HTTPHandler) RegisterRoutes(mux *nethttp.ServeMux) {
 	mux.HandleFunc("/health", h.handleHealth)
 	mux.HandleFunc("/users", h.handleUsers)
+	mux.HandleFunc("GET /users/{id}", h.handleGetUser)
+	mux.HandleFunc("PATCH /users/{id}", h.handlePatchUser)
+	mux.HandleFunc("GET /users/{id}/recommendations", h.handleUserRecommendations)
+	mux.HandleFunc("GET /users/{id}/history", h.handleUserHistory)
+	mux.HandleFunc("GET /users/{id}/stats", h.handleUserStats)
 	mux.HandleFunc("/books", h.handleBooks)
+	mux.HandleFunc("GET /books/search", h.handleSearchBooks)
+	mux.HandleFunc("GET /books/suggest", h.handleSuggestBooks)
+	mux.HandleFunc("GET /books/trending", h.handleTrendingBooks)
+	mux.HandleFunc("GET /books/{id}", h.handleGetBook)
+	mux.HandleFunc("PUT /books/{id}", h.handlePutBook)
+	mux.HandleFunc("DELETE /books/{id}", h.handleDeleteBook)
+	mux.HandleFunc("GET /books/{id}/related", h.handleRelatedBooks)
+	mux.HandleFunc("GET /books/{id}/similar", h.handleSimilarBooks)
 	mux.HandleFunc("/access", h.handleAccess)
 	mux.HandleFunc("/access/stats", h.handleAccessStats)
+	mux.HandleFunc("GET /access/stats/timeseries", h.handleAccessTimeSeries)
+	mux.HandleFunc("GET /access/stats/readers", h.handleUniqueReaders)
+	mux.HandleFunc("GET /access/stats/funnel", h.handleAccessFunnel)
+	mux.HandleFunc("GET /analytics/overview", h.handleAnalyticsOverview)
 }
 
 /*
@@ -75,7 +116,7 @@ ENDPOINT /users
 ==========================================================
 
 Métodos soportados:
-- GET  /users  → Lista todos los usuarios
+- GET  /users  → Lista los usuarios por páginas (sort, limit, cursor; ver pagination.go)
 - POST /users  → Crea un nuevo usuario
 
 Formato JSON para crear usuario:
@@ -89,13 +130,30 @@ Formato JSON para crear usuario:
 func (h *HTTPHandler) handleUsers(w nethttp.ResponseWriter, r *nethttp.Request) {
 	switch r.Method {
 	case nethttp.MethodGet:
-		// Obtener lista de usuarios desde la capa de negocio.
-		users, err := h.userService.ListUsers()
+		// Orden y página pedidos en la URL.
+		sortBy, desc, err := domain.ParseUserSort(r.URL.Query().Get("sort"))
 		if err != nil {
-			writeError(w, nethttp.StatusInternalServerError, err.Error())
+			writeServiceError(w, r, err)
+			return
+		}
+		limit, after, err := parsePageParams(r)
+		if err != nil {
+			writeServiceError(w, r, err)
 			return
 		}
-		writeJSON(w, nethttp.StatusOK, users)
+
+		// Obtener la página de usuarios desde la capa de negocio.
+		page, err := h.userService.SearchUsers(domain.UserQuery{
+			SortBy:   sortBy,
+			SortDesc: desc,
+			Limit:    limit,
+			After:    after,
+		})
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+		writePage(w, r, toUserResponses(page.Users), page.NextCursor)
 
 	case nethttp.MethodPost:
 		// Estructura auxiliar para leer el JSON de entrada.
@@ -114,12 +172,13 @@ func (h *HTTPHandler) handleUsers(w nethttp.ResponseWriter, r *nethttp.Request)
 		// Llamar al caso de uso para registrar el usuario.
 		user, err := h.userService.RegisterUser(payload.Name, payload.Email, payload.Role)
 		if err != nil {
-			writeError(w, nethttp.StatusBadRequest, err.Error())
+			writeServiceError(w, r, err)
 			return
 		}
 
-		// Responder con el usuario creado (aunque por encapsulación se ve como {} en JSON).
-		writeJSON(w, nethttp.StatusCreated, user)
+		// Responder con el usuario creado usando su DTO (ver presenters.go).
+		setETag(w, user.Version())
+		writeJSON(w, nethttp.StatusCreated, toUserResponse(user))
 
 	default:
 		writeError(w, nethttp.StatusMethodNotAllowed, "método no permitido en /users")
@@ -132,7 +191,7 @@ ENDPOINT /books
 ==========================================================
 
 Métodos soportados:
-- GET  /books         → Lista o busca libros por filtros.
+- GET  /books         → Lista o busca libros por filtros, paginado (ver pagination.go).
 - POST /books         → Crea un nuevo libro.
 
 Ejemplo JSON para crear libro:
@@ -155,6 +214,47 @@ func (h *HTTPHandler) handleBooks(w nethttp.ResponseWriter, r *nethttp.Request)
 			TitleContains:  query.Get("title"),
 			AuthorContains: query.Get("author"),
 			CategoryTI:     query.Get("category"),
+			ISBN:           query.Get("isbn"),
+			Tags:           query["tag"], // ?tag=go&tag=concurrency
+		}
+
+		// Modo de tags: all (por defecto), any o none.
+		tagMode, err := domain.ParseTagMatchMode(query.Get("tag_mode"))
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+		filter.TagMode = tagMode
+
+		// q: consulta del lenguaje de internal/bookquery, ej.
+		// q=author:"Baca Urbina" AND (tag:redes OR tag:seguridad) year>=2015
+		if q := query.Get("q"); q != "" {
+			filter.Query, err = bookquery.Parse(q)
+			if err != nil {
+				writeServiceError(w, r, err)
+				return
+			}
+		}
+
+		// fuzzy=true: título y autor toleran errores de tipeo.
+		if fuzzyStr := query.Get("fuzzy"); fuzzyStr != "" {
+			filter.Fuzzy, err = strconv.ParseBool(fuzzyStr)
+			if err != nil {
+				writeServiceError(w, r, domain.NewValidationError("fuzzy", "fuzzy debe ser true o false"))
+				return
+			}
+		}
+
+		// Orden y página.
+		filter.SortBy, filter.SortDesc, err = domain.ParseBookSort(query.Get("sort"))
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+		filter.Limit, filter.After, err = parsePageParams(r)
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
 		}
 
 		// Convertir year_from y year_to si vienen en la URL.
@@ -169,12 +269,24 @@ func (h *HTTPHandler) handleBooks(w nethttp.ResponseWriter, r *nethttp.Request)
 			}
 		}
 
-		books, err := h.bookService.SearchBooks(filter)
+		page, err := h.bookService.SearchBooks(filter)
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+
+		// Facetas de todos los resultados, para afinar la búsqueda.
+		facets, err := h.bookService.BookFacets(filter)
 		if err != nil {
-			writeError(w, nethttp.StatusInternalServerError, err.Error())
+			writeServiceError(w, r, err)
 			return
 		}
-		writeJSON(w, nethttp.StatusOK, books)
+
+		writePageResponse(w, r, pageResponse{
+			Items:      toBookResponses(page.Books),
+			DidYouMean: toSpellingSuggestionResponse(page.DidYouMean),
+			Facets:     toFacetsResponse(facets),
+		}, page.NextCursor)
 
 	case nethttp.MethodPost:
 		// Estructura auxiliar para el JSON de entrada.
@@ -201,11 +313,12 @@ func (h *HTTPHandler) handleBooks(w nethttp.ResponseWriter, r *nethttp.Request)
 			payload.Tags,
 		)
 		if err != nil {
-			writeError(w, nethttp.StatusBadRequest, err.Error())
+			writeServiceError(w, r, err)
 			return
 		}
 
-		writeJSON(w, nethttp.StatusCreated, book)
+		setETag(w, book.Version())
+		writeJSON(w, nethttp.StatusCreated, toBookResponse(book))
 
 	default:
 		writeError(w, nethttp.StatusMethodNotAllowed, "método no permitido en /books")
@@ -218,7 +331,7 @@ ENDPOINT /access
 ==========================================================
 
 Método soportado:
-- POST /access   → registra un acceso de un usuario a un libro.
+- POST /access   → registra un acceso y responde con el evento creado.
 
 Ejemplo JSON:
 
@@ -245,29 +358,19 @@ func (h *HTTPHandler) handleAccess(w nethttp.ResponseWriter, r *nethttp.Request)
 		return
 	}
 
-	if payload.BookID <= 0 {
-		writeError(w, nethttp.StatusBadRequest, "book_id debe ser mayor que cero")
-		return
-	}
-	if payload.UserID <= 0 {
-		writeError(w, nethttp.StatusBadRequest, "user_id debe ser mayor que cero")
-		return
-	}
-
 	// Llamar a la lógica de negocio para registrar el acceso.
-	err := h.bookService.RecordAccess(
+	// Los ids y el tipo de acceso los valida el dominio (→ 422 por campo).
+	event, err := h.bookService.RecordAccess(
 		domain.BookID(payload.BookID),
 		domain.UserID(payload.UserID),
 		payload.AccessType,
 	)
 	if err != nil {
-		writeError(w, nethttp.StatusBadRequest, err.Error())
+		writeServiceError(w, r, err)
 		return
 	}
 
-	writeJSON(w, nethttp.StatusCreated, map[string]string{
-		"message": "acceso registrado correctamente",
-	})
+	writeJSON(w, nethttp.StatusCreated, toAccessEventResponse(event))
 }
 
 /*
@@ -283,8 +386,12 @@ Ejemplo de respuesta:
 	{
 	  "LECTURA":  3,
 	  "APERTURA": 5,
-	  "DESCARGA": 1
+	  "DESCARGA": 1,
+	  "unique_readers": 4
 	}
+
+unique_readers es la cantidad ESTIMADA de usuarios distintos que
+accedieron al libro (HyperLogLog; ver /access/stats/readers).
 */
 func (h *HTTPHandler) handleAccessStats(w nethttp.ResponseWriter, r *nethttp.Request) {
 	if r.Method != nethttp.MethodGet {
@@ -307,17 +414,26 @@ func (h *HTTPHandler) handleAccessStats(w nethttp.ResponseWriter, r *nethttp.Req
 
 	stats, err := h.bookService.BuildAccessStatsByBook(domain.BookID(bookIDInt))
 	if err != nil {
-		writeError(w, nethttp.StatusInternalServerError, err.Error())
+		writeServiceError(w, r, err)
+		return
+	}
+
+	readers, err := h.bookService.UniqueReaders(usecase.UniqueReadersQuery{BookID: domain.BookID(bookIDInt)})
+	if err != nil {
+		writeServiceError(w, r, err)
 		return
 	}
 
-	writeJSON(w, nethttp.StatusOK, stats)
+	writeJSON(w, nethttp.StatusOK, toAccessStatsResponse(stats, readers.UniqueReaders))
 }
 
 /*
    ==========================================================
    Funciones auxiliares para respuestas JSON
    ==========================================================
+
+   Los errores se escriben con writeError / writeServiceError
+   (ver problem.go).
 */
 
 // writeJSON escribe una respuesta JSON con el código de estado indicado.
@@ -326,10 +442,3 @@ func writeJSON(w nethttp.ResponseWriter, status int, data any) {
 	w.WriteHeader(status)
 	_ = json.NewEncoder(w).Encode(data)
 }
-
-// writeError simplifica el envío de errores en formato JSON.
-func writeError(w nethttp.ResponseWriter, status int, msg string) {
-	writeJSON(w, status, map[string]string{
-		"error": msg,
-	})
-}
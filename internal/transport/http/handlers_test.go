@@ -0,0 +1,286 @@
+package http
+
+import (
+	"encoding/json"
+	nethttp "net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/domain"
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/infrastructure/db"
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/infrastructure/recommend"
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/infrastructure/search"
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/usecase"
+)
+
+// newTestMux arma la API como cmd/api, sobre repositorios en memoria.
+func newTestMux(t *testing.T) *nethttp.ServeMux {
+	t.Helper()
+	repos := domain.Repositories{
+		Users:  db.NewInMemoryUserRepo(),
+		Books:  db.NewInMemoryBookRepo(),
+		Access: db.NewInMemoryAccessLogRepo(),
+	}
+	uow := db.NewInMemoryUnitOfWork(repos)
+
+	userService := usecase.NewUserService(repos.Users, uow)
+	bookService := usecase.NewBookService(repos.Books, repos.Users, repos.Access, uow, search.NewIndex(), recommend.NewEngine())
+	historyService := usecase.NewHistoryService(repos.Users, repos.Books, repos.Access)
+	analyticsService := usecase.NewAnalyticsService(repos.Users, repos.Books, repos.Access)
+	userService.AddUserObserver(analyticsService)
+	bookService.AddBookObserver(analyticsService)
+	bookService.AddAccessObserver(analyticsService)
+
+	mux := nethttp.NewServeMux()
+	NewHTTPHandler(userService, bookService, historyService, analyticsService).RegisterRoutes(mux)
+	return mux
+}
+
+// serve ejecuta una petición contra el mux. Los headers van de a pares
+// (nombre, valor).
+func serve(mux *nethttp.ServeMux, method, target, body string, headers ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+// decodeBody verifica el código y el Content-Type y decodifica el JSON.
+func decodeBody(t *testing.T, rec *httptest.ResponseRecorder, status int, v any) {
+	t.Helper()
+	if rec.Code != status {
+		t.Fatalf("status = %d, se esperaba %d (cuerpo: %s)", rec.Code, status, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("Content-Type = %q, se esperaba application/json", ct)
+	}
+	if err := json.NewDecoder(rec.Body).Decode(v); err != nil {
+		t.Fatalf("JSON inválido: %v", err)
+	}
+}
+
+// checkKeys verifica que el objeto JSON tenga exactamente esos campos.
+func checkKeys(t *testing.T, obj map[string]any, want ...string) {
+	t.Helper()
+	got := make([]string, 0, len(obj))
+	for k := range obj {
+		got = append(got, k)
+	}
+	slices.Sort(got)
+	slices.Sort(want)
+	if !slices.Equal(got, want) {
+		t.Fatalf("campos = %v, se esperaba %v", got, want)
+	}
+}
+
+func checkRFC3339(t *testing.T, value any) {
+	t.Helper()
+	s, _ := value.(string)
+	ts, err := time.Parse(time.RFC3339, s)
+	if err != nil || ts.Location() != time.UTC {
+		t.Fatalf("%v no es una hora RFC 3339 en UTC", value)
+	}
+}
+
+func mustPostUser(t *testing.T, mux *nethttp.ServeMux, name, email string) int64 {
+	t.Helper()
+	var u userResponse
+	body := `{"name":"` + name + `","email":"` + email + `","role":"READER"}`
+	decodeBody(t, serve(mux, "POST", "/users", body), nethttp.StatusCreated, &u)
+	return u.ID
+}
+
+func mustPostBook(t *testing.T, mux *nethttp.ServeMux, body string) bookResponse {
+	t.Helper()
+	var b bookResponse
+	decodeBody(t, serve(mux, "POST", "/books", body), nethttp.StatusCreated, &b)
+	return b
+}
+
+// Las respuestas exponen los datos de las entidades en snake_case,
+// con horas RFC 3339 y slices vacías como [] (nunca {} ni null).
+func TestEntityResponses(t *testing.T) {
+	mux := newTestMux(t)
+
+	var user map[string]any
+	decodeBody(t, serve(mux, "POST", "/users", `{"name":"Ana","email":"ana@example.com","role":"ADMIN"}`),
+		nethttp.StatusCreated, &user)
+	checkKeys(t, user, "id", "name", "email", "role", "active", "created_at", "version")
+	if user["id"] != 1.0 || user["name"] != "Ana" || user["email"] != "ana@example.com" ||
+		user["role"] != "ADMIN" || user["active"] != true || user["version"] != 1.0 {
+		t.Fatalf("usuario = %v", user)
+	}
+	checkRFC3339(t, user["created_at"])
+
+	var book map[string]any
+	decodeBody(t, serve(mux, "POST", "/books",
+		`{"title":"Redes de Computadoras","author":"Tanenbaum","year":2012,"isbn":"978-1","category_ti":"Redes","tags":["tcp","ip"]}`),
+		nethttp.StatusCreated, &book)
+	checkKeys(t, book, "id", "title", "author", "year", "isbn", "category_ti", "tags", "active", "created_at", "version")
+	if book["title"] != "Redes de Computadoras" || book["author"] != "Tanenbaum" || book["year"] != 2012.0 ||
+		book["isbn"] != "978-1" || book["category_ti"] != "Redes" || book["active"] != true {
+		t.Fatalf("libro = %v", book)
+	}
+	if tags, _ := book["tags"].([]any); len(tags) != 2 {
+		t.Fatalf("tags = %v, se esperaban dos", book["tags"])
+	}
+	checkRFC3339(t, book["created_at"])
+
+	var untagged map[string]any
+	decodeBody(t, serve(mux, "POST", "/books",
+		`{"title":"Sistemas Operativos","author":"Silberschatz","year":2018,"isbn":"978-2","category_ti":"Sistemas"}`),
+		nethttp.StatusCreated, &untagged)
+	if tags, ok := untagged["tags"].([]any); !ok || len(tags) != 0 {
+		t.Fatalf("tags = %#v, se esperaba []", untagged["tags"])
+	}
+
+	var event map[string]any
+	decodeBody(t, serve(mux, "POST", "/access", `{"book_id":1,"user_id":1,"access_type":"LECTURA"}`),
+		nethttp.StatusCreated, &event)
+	checkKeys(t, event, "id", "book_id", "user_id", "access_type", "timestamp")
+	if event["book_id"] != 1.0 || event["user_id"] != 1.0 || event["access_type"] != "LECTURA" {
+		t.Fatalf("evento = %v", event)
+	}
+	checkRFC3339(t, event["timestamp"])
+
+	var users struct {
+		Items []map[string]any `json:"items"`
+	}
+	decodeBody(t, serve(mux, "GET", "/users", ""), nethttp.StatusOK, &users)
+	if len(users.Items) != 1 || users.Items[0]["email"] != "ana@example.com" {
+		t.Fatalf("GET /users = %v", users.Items)
+	}
+}
+
+// seedBooks carga libros por POST /books (ver mustPostBook).
+func seedBooks(t *testing.T, mux *nethttp.ServeMux, bodies ...string) {
+	t.Helper()
+	for _, body := range bodies {
+		mustPostBook(t, mux, body)
+	}
+}
+
+// GET /books sin resultados: items vacío, next_cursor null y
+// did_you_mean con la corrección que sí encuentra libros.
+func TestListBooksDidYouMean(t *testing.T) {
+	mux := newTestMux(t)
+	seedBooks(t, mux,
+		`{"title":"Redes de Computadoras","author":"Andrew Tanenbaum","year":2010,"isbn":"978-1","category_ti":"Redes"}`,
+		`{"title":"Sistemas Operativos Modernos","author":"Andrew Tanenbaum","year":2014,"isbn":"978-2","category_ti":"Sistemas"}`,
+		`{"title":"Compiladores","author":"Alfred Aho","year":2006,"isbn":"978-3","category_ti":"Lenguajes"}`,
+	)
+
+	tests := []struct {
+		name   string
+		target string
+		items  int
+		want   map[string]any // did_you_mean esperado; nil si no debe aparecer
+	}{
+		{"autor mal escrito", "/books?author=tanenbaun", 0, map[string]any{"author": "Tanenbaum"}},
+		{"título mal escrito", "/books?title=compiladres", 0, map[string]any{"title": "Compiladores"}},
+		{"con resultados no hay sugerencia", "/books?author=tanenbaum", 2, nil},
+		{"sin nada parecido", "/books?author=zzzzzz", 0, nil},
+		{"fuzzy encuentra sin sugerir", "/books?author=tanenbaun&fuzzy=true", 2, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var page map[string]any
+			decodeBody(t, serve(mux, "GET", tt.target, ""), nethttp.StatusOK, &page)
+
+			items, ok := page["items"].([]any)
+			if !ok || len(items) != tt.items {
+				t.Fatalf("items = %#v, se esperaban %d", page["items"], tt.items)
+			}
+			if next, ok := page["next_cursor"]; !ok || next != nil {
+				t.Fatalf("next_cursor = %v, se esperaba null", next)
+			}
+			got, present := page["did_you_mean"]
+			if tt.want == nil {
+				if present {
+					t.Fatalf("did_you_mean = %v, no debía aparecer", got)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("did_you_mean = %v, se esperaba %v", got, tt.want)
+			}
+		})
+	}
+
+	p := decodeProblem(t, serve(mux, "GET", "/books?author=x&fuzzy=quizas", ""))
+	if p.Status != nethttp.StatusUnprocessableEntity || len(p.Errors) != 1 || p.Errors[0].Field != "fuzzy" {
+		t.Fatalf("fuzzy inválido = %+v", p)
+	}
+}
+
+// listEnvelope es el sobre de GET /books, con las facetas tipadas.
+type listEnvelope struct {
+	Items      []bookResponse  `json:"items"`
+	NextCursor *string         `json:"next_cursor"`
+	Facets     *facetsResponse `json:"facets"`
+}
+
+// Las facetas cuentan TODOS los resultados del filtro, no solo la
+// página, y no cuentan libros archivados.
+func TestListBooksFacets(t *testing.T) {
+	mux := newTestMux(t)
+	seedBooks(t, mux,
+		`{"title":"Redes I","author":"A","year":2009,"isbn":"978-1","category_ti":"Redes","tags":["tcp","ip"]}`,
+		`{"title":"Redes II","author":"B","year":2012,"isbn":"978-2","category_ti":"Redes","tags":["tcp"]}`,
+		`{"title":"Redes III","author":"C","year":2012,"isbn":"978-3","category_ti":"Seguridad","tags":["tcp","vpn"]}`,
+		`{"title":"Kernel","author":"D","year":1995,"isbn":"978-4","category_ti":"Sistemas","tags":["linux"]}`,
+		`{"title":"Redes IV","author":"E","year":2020,"isbn":"978-5","category_ti":"Redes","tags":["tcp"]}`,
+	)
+	if rec := serve(mux, "DELETE", "/books/5", ""); rec.Code != nethttp.StatusOK {
+		t.Fatalf("DELETE /books/5 = %d", rec.Code)
+	}
+
+	rec := serve(mux, "GET", "/books?tag=tcp&sort=year&limit=1", "")
+	var page listEnvelope
+	decodeBody(t, rec, nethttp.StatusOK, &page)
+
+	if len(page.Items) != 1 || page.Items[0].ID != 1 {
+		t.Fatalf("items = %+v, se esperaba solo el libro 1", page.Items)
+	}
+	if page.NextCursor == nil {
+		t.Fatal("next_cursor = null, se esperaba otra página")
+	}
+	if link := rec.Header().Get("Link"); !strings.Contains(link, "cursor=") || !strings.HasSuffix(link, `>; rel="next"`) {
+		t.Fatalf("Link = %q", link)
+	}
+
+	want := &facetsResponse{
+		Categories: []facetCountResponse{{"Redes", 2}, {"Seguridad", 1}},
+		Decades:    []yearFacetCountResponse{{2000, 2009, 1}, {2010, 2019, 2}},
+		Years:      []yearFacetCountResponse{{2009, 2009, 1}, {2012, 2012, 2}},
+		Tags:       []facetCountResponse{{"tcp", 3}, {"ip", 1}, {"vpn", 1}},
+	}
+	if !reflect.DeepEqual(page.Facets, want) {
+		t.Fatalf("facets = %+v, se esperaba %+v", page.Facets, want)
+	}
+
+	// La página siguiente trae las mismas facetas.
+	var next listEnvelope
+	decodeBody(t, serve(mux, "GET", "/books?tag=tcp&sort=year&limit=1&cursor="+*page.NextCursor, ""), nethttp.StatusOK, &next)
+	if len(next.Items) != 1 || next.Items[0].ID != 2 || !reflect.DeepEqual(next.Facets, want) {
+		t.Fatalf("segunda página = %+v", next)
+	}
+
+	// Sin resultados: facetas con listas vacías, nunca null.
+	var raw map[string]any
+	decodeBody(t, serve(mux, "GET", "/books?category=Historia", ""), nethttp.StatusOK, &raw)
+	facets, _ := raw["facets"].(map[string]any)
+	checkKeys(t, facets, "categories", "decades", "years", "tags")
+	for key, value := range facets {
+		if list, ok := value.([]any); !ok || len(list) != 0 {
+			t.Fatalf("facets.%s = %#v, se esperaba []", key, value)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package http
+
+import (
+	nethttp "net/http"
+
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/domain"
+)
+
+/*
+==========================================================
+ENDPOINT GET /users/{id}/history
+==========================================================
+
+Accesos del usuario con los datos de cada libro, en orden
+cronológico y por páginas (mismo sobre que GET /books).
+Parámetros:
+- sort:   timestamp (por defecto) o -timestamp (más nuevo primero).
+- limit:  tamaño de página (por defecto 50, máximo 200).
+- cursor: el next_cursor de la página anterior.
+
+Respuesta:
+
+	{
+	  "items": [
+	    {"id": 7, "access_type": "LECTURA", "timestamp": "...", "book_id": 3, "book": { ... }}
+	  ],
+	  "next_cursor": null
+	}
+*/
+func (h *HTTPHandler) handleUserHistory(w nethttp.ResponseWriter, r *nethttp.Request) {
+	id, err := parseIDParam(r)
+	if err != nil {
+		writeError(w, nethttp.StatusBadRequest, err.Error())
+		return
+	}
+
+	query := domain.HistoryQuery{}
+	if query.SortDesc, err = domain.ParseHistorySort(r.URL.Query().Get("sort")); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	if query.Limit, query.After, err = parsePageParams(r); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	page, err := h.historyService.UserHistory(domain.UserID(id), query)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writePage(w, r, toHistoryEntryResponses(page.Entries), page.NextCursor)
+}
+
+/*
+==========================================================
+ENDPOINT GET /users/{id}/stats
+==========================================================
+
+Resumen de la actividad del usuario. Parámetros:
+- tz: zona horaria IANA para contar los días activos (UTC por defecto).
+
+Respuesta:
+
+	{
+	  "user_id": 1,
+	  "accesses": 12,
+	  "books": 4,
+	  "books_by_type": {"APERTURA": 4, "LECTURA": 3, "DESCARGA": 1},
+	  "favorite_categories": [{"value": "Redes", "count": 8}],
+	  "favorite_authors": [{"value": "Andrew Tanenbaum", "count": 6}],
+	  "active_days": 5,
+	  "first_access": "2024-05-01T13:00:00Z",
+	  "last_access": "2024-05-20T18:30:00Z"
+	}
+
+books_by_type cuenta libros DISTINTOS (abrir diez veces el mismo
+libro es un libro abierto).
+*/
+func (h *HTTPHandler) handleUserStats(w nethttp.ResponseWriter, r *nethttp.Request) {
+	id, err := parseIDParam(r)
+	if err != nil {
+		writeError(w, nethttp.StatusBadRequest, err.Error())
+		return
+	}
+
+	loc, err := parseTZParam(r.URL.Query())
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	stats, err := h.historyService.BuildUserStats(domain.UserID(id), loc)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, nethttp.StatusOK, toUserStatsResponse(id, stats))
+}
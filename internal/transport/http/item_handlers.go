@@ -0,0 +1,229 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	nethttp "net/http"
+	"strconv"
+
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/domain"
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/usecase"
+)
+
+/*
+   ==========================================================
+   RUTAS DE RECURSOS INDIVIDUALES
+   ==========================================================
+
+   Estas rutas usan los patrones del ServeMux de Go 1.22+:
+   el método va en el patrón ("GET /users/{id}") y el valor
+   del comodín se lee con r.PathValue("id").
+*/
+
+/*
+==========================================================
+ENDPOINT GET /users/{id}
+==========================================================
+
+Devuelve un usuario por su ID o 404 si no existe.
+El header ETag lleva la versión del usuario.
+*/
+func (h *HTTPHandler) handleGetUser(w nethttp.ResponseWriter, r *nethttp.Request) {
+	id, err := parseIDParam(r)
+	if err != nil {
+		writeError(w, nethttp.StatusBadRequest, err.Error())
+		return
+	}
+
+	user, err := h.userService.FindUserByID(domain.UserID(id))
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	if user == nil {
+		writeServiceError(w, r, domain.NotFound("usuario no encontrado"))
+		return
+	}
+
+	setETag(w, user.Version())
+	writeJSON(w, nethttp.StatusOK, toUserResponse(user))
+}
+
+/*
+==========================================================
+ENDPOINT PATCH /users/{id}
+==========================================================
+
+Actualiza solo los campos enviados. Ejemplo:
+
+	{
+	  "role": "READER",
+	  "active": false
+	}
+
+Con el header If-Match: "<versión>" la actualización solo se
+aplica si nadie modificó el usuario antes (si no, 412).
+*/
+func (h *HTTPHandler) handlePatchUser(w nethttp.ResponseWriter, r *nethttp.Request) {
+	id, err := parseIDParam(r)
+	if err != nil {
+		writeError(w, nethttp.StatusBadRequest, err.Error())
+		return
+	}
+
+	expectedVersion, err := parseIfMatch(r)
+	if err != nil {
+		writeError(w, nethttp.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Punteros para distinguir "no enviado" de "valor vacío".
+	var payload struct {
+		Name   *string      `json:"name"`
+		Email  *string      `json:"email"`
+		Role   *domain.Role `json:"role"`
+		Active *bool        `json:"active"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, nethttp.StatusBadRequest, "JSON inválido en actualización de usuario")
+		return
+	}
+
+	user, err := h.userService.UpdateUser(domain.UserID(id), expectedVersion, usecase.UserPatch{
+		Name:   payload.Name,
+		Email:  payload.Email,
+		Role:   payload.Role,
+		Active: payload.Active,
+	})
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	setETag(w, user.Version())
+	writeJSON(w, nethttp.StatusOK, toUserResponse(user))
+}
+
+/*
+==========================================================
+ENDPOINT GET /books/{id}
+==========================================================
+
+Devuelve un libro por su ID (también si está archivado).
+*/
+func (h *HTTPHandler) handleGetBook(w nethttp.ResponseWriter, r *nethttp.Request) {
+	id, err := parseIDParam(r)
+	if err != nil {
+		writeError(w, nethttp.StatusBadRequest, err.Error())
+		return
+	}
+
+	book, err := h.bookService.FindBookByID(domain.BookID(id))
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	if book == nil {
+		writeServiceError(w, r, domain.NotFound("libro no encontrado"))
+		return
+	}
+
+	setETag(w, book.Version())
+	writeJSON(w, nethttp.StatusOK, toBookResponse(book))
+}
+
+/*
+==========================================================
+ENDPOINT PUT /books/{id}
+==========================================================
+
+Reemplaza todos los datos editables del libro.
+Usa el mismo JSON que POST /books. Admite If-Match
+igual que PATCH /users/{id}.
+*/
+func (h *HTTPHandler) handlePutBook(w nethttp.ResponseWriter, r *nethttp.Request) {
+	id, err := parseIDParam(r)
+	if err != nil {
+		writeError(w, nethttp.StatusBadRequest, err.Error())
+		return
+	}
+
+	expectedVersion, err := parseIfMatch(r)
+	if err != nil {
+		writeError(w, nethttp.StatusBadRequest, err.Error())
+		return
+	}
+
+	var payload struct {
+		Title      string   `json:"title"`
+		Author     string   `json:"author"`
+		Year       int      `json:"year"`
+		ISBN       string   `json:"isbn"`
+		CategoryTI string   `json:"category_ti"`
+		Tags       []string `json:"tags"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, nethttp.StatusBadRequest, "JSON inválido en actualización de libro")
+		return
+	}
+
+	book, err := h.bookService.UpdateBook(
+		domain.BookID(id),
+		expectedVersion,
+		payload.Title,
+		payload.Author,
+		payload.Year,
+		payload.ISBN,
+		payload.CategoryTI,
+		payload.Tags,
+	)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	setETag(w, book.Version())
+	writeJSON(w, nethttp.StatusOK, toBookResponse(book))
+}
+
+/*
+==========================================================
+ENDPOINT DELETE /books/{id}
+==========================================================
+
+No borra el libro: lo archiva (Book.Archive) y lo devuelve
+con "active": false. Admite If-Match.
+*/
+func (h *HTTPHandler) handleDeleteBook(w nethttp.ResponseWriter, r *nethttp.Request) {
+	id, err := parseIDParam(r)
+	if err != nil {
+		writeError(w, nethttp.StatusBadRequest, err.Error())
+		return
+	}
+
+	expectedVersion, err := parseIfMatch(r)
+	if err != nil {
+		writeError(w, nethttp.StatusBadRequest, err.Error())
+		return
+	}
+
+	book, err := h.bookService.ArchiveBook(domain.BookID(id), expectedVersion)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	setETag(w, book.Version())
+	writeJSON(w, nethttp.StatusOK, toBookResponse(book))
+}
+
+// parseIDParam lee el comodín {id} de la ruta y valida que sea un número positivo.
+func parseIDParam(r *nethttp.Request) (int64, error) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, errors.New("el id de la ruta debe ser un número válido mayor que cero")
+	}
+	return id, nil
+}
@@ -0,0 +1,99 @@
+package http
+
+import (
+	nethttp "net/http"
+	"slices"
+	"testing"
+)
+
+func TestUserItemRoutes(t *testing.T) {
+	mux := newTestMux(t)
+	id := mustPostUser(t, mux, "Ana", "ana@example.com")
+
+	var got userResponse
+	rec := serve(mux, "GET", "/users/1", "")
+	decodeBody(t, rec, nethttp.StatusOK, &got)
+	if got.ID != id || got.Name != "Ana" {
+		t.Fatalf("GET /users/1 = %+v", got)
+	}
+
+	// PATCH solo cambia los campos enviados.
+	decodeBody(t, serve(mux, "PATCH", "/users/1", `{"role":"ADMIN","active":false}`), nethttp.StatusOK, &got)
+	if got.Name != "Ana" || got.Email != "ana@example.com" || got.Role != "ADMIN" || got.Active || got.Version != 2 {
+		t.Fatalf("PATCH /users/1 = %+v", got)
+	}
+	decodeBody(t, serve(mux, "GET", "/users/1", ""), nethttp.StatusOK, &got)
+	if got.Role != "ADMIN" || got.Active {
+		t.Fatalf("el cambio no quedó guardado: %+v", got)
+	}
+
+	p := decodeProblem(t, serve(mux, "PATCH", "/users/1", `{"email":"  "}`))
+	if p.Status != nethttp.StatusUnprocessableEntity || p.Type != "/problems/validation" {
+		t.Fatalf("email vacío = %+v", p)
+	}
+}
+
+func TestBookItemRoutes(t *testing.T) {
+	mux := newTestMux(t)
+	created := mustPostBook(t, mux,
+		`{"title":"Redes","author":"Tanenbaum","year":2010,"isbn":"978-1","category_ti":"Redes","tags":["tcp"]}`)
+
+	var got bookResponse
+	decodeBody(t, serve(mux, "GET", "/books/1", ""), nethttp.StatusOK, &got)
+	if got.ID != created.ID || got.Title != "Redes" {
+		t.Fatalf("GET /books/1 = %+v", got)
+	}
+
+	// PUT reemplaza todos los datos editables.
+	decodeBody(t, serve(mux, "PUT", "/books/1",
+		`{"title":"Redes de Computadoras","author":"Kurose","year":2017,"isbn":"978-1","category_ti":"Redes","tags":["ip","web"]}`),
+		nethttp.StatusOK, &got)
+	if got.Title != "Redes de Computadoras" || got.Author != "Kurose" || got.Year != 2017 ||
+		!slices.Equal(got.Tags, []string{"ip", "web"}) || got.Version != 2 {
+		t.Fatalf("PUT /books/1 = %+v", got)
+	}
+
+	// DELETE archiva: el libro sigue existiendo con active false.
+	decodeBody(t, serve(mux, "DELETE", "/books/1", ""), nethttp.StatusOK, &got)
+	if got.Active || got.Version != 3 {
+		t.Fatalf("DELETE /books/1 = %+v", got)
+	}
+	decodeBody(t, serve(mux, "GET", "/books/1", ""), nethttp.StatusOK, &got)
+	if got.Active || got.Title != "Redes de Computadoras" {
+		t.Fatalf("GET del libro archivado = %+v", got)
+	}
+}
+
+// Errores de las rutas de recursos: siempre problem+json.
+func TestItemRouteErrors(t *testing.T) {
+	mux := newTestMux(t)
+	mustPostUser(t, mux, "Ana", "ana@example.com")
+	mustPostBook(t, mux, `{"title":"Redes","author":"Tanenbaum","year":2010,"isbn":"978-1","category_ti":"Redes"}`)
+
+	tests := []struct {
+		name, method, target, body string
+		status                     int
+		typ                        string
+	}{
+		{"usuario inexistente", "GET", "/users/99", "", 404, "/problems/not-found"},
+		{"libro inexistente", "GET", "/books/99", "", 404, "/problems/not-found"},
+		{"PATCH de usuario inexistente", "PATCH", "/users/99", `{"name":"X"}`, 404, "/problems/not-found"},
+		{"PUT de libro inexistente", "PUT", "/books/99",
+			`{"title":"X","author":"Y","year":2000,"isbn":"978-9","category_ti":"Redes"}`, 404, "/problems/not-found"},
+		{"DELETE de libro inexistente", "DELETE", "/books/99", "", 404, "/problems/not-found"},
+		{"id no numérico", "GET", "/books/abc", "", 400, "about:blank"},
+		{"id cero", "GET", "/users/0", "", 400, "about:blank"},
+		{"JSON inválido", "PATCH", "/users/1", "{", 400, "about:blank"},
+		{"PUT sin título", "PUT", "/books/1",
+			`{"author":"Y","year":2000,"isbn":"978-1","category_ti":"Redes"}`, 422, "/problems/validation"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(mux, tt.method, tt.target, tt.body)
+			p := decodeProblem(t, rec)
+			if p.Status != tt.status || p.Type != tt.typ {
+				t.Fatalf("%s %s = %d %s, se esperaba %d %s", tt.method, tt.target, p.Status, p.Type, tt.status, tt.typ)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package http
+
+import (
+	nethttp "net/http"
+	"strconv"
+
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/domain"
+)
+
+/*
+   ==========================================================
+   RESPUESTAS PAGINADAS (GET /books, GET /users)
+   ==========================================================
+
+   Parámetros de consulta:
+   - sort:   campo de orden; con "-" delante es descendente
+             (ej. sort=-year).
+   - limit:  tamaño de página (por defecto 50, máximo 200).
+   - cursor: el next_cursor de la página anterior.
+
+   Respuesta:
+
+	{
+	  "items": [ ... ],
+	  "next_cursor": "eyJzIjoi..."   (null en la última página)
+	}
+
+   GET /books agrega "did_you_mean" cuando no encontró nada y
+   hay una corrección del título o del autor que sí encuentra:
+
+	"did_you_mean": {"author": "Tanenbaum"}
+
+   y "facets": cantidades por categoría, década, año y tag de
+   TODOS los resultados del filtro (ver toFacetsResponse).
+
+   Además, si hay otra página se agrega el header
+   Link: </books?...&cursor=...>; rel="next"
+*/
+
+// pageResponse es el sobre JSON de un listado paginado.
+type pageResponse struct {
+	Items      any                         `json:"items"`
+	NextCursor *string                     `json:"next_cursor"`
+	DidYouMean *spellingSuggestionResponse `json:"did_you_mean,omitempty"`
+	Facets     *facetsResponse             `json:"facets,omitempty"`
+}
+
+// parsePageParams lee limit y cursor de la URL.
+func parsePageParams(r *nethttp.Request) (int, *domain.Cursor, error) {
+	query := r.URL.Query()
+
+	limit := 0
+	if limitStr := query.Get("limit"); limitStr != "" {
+		n, err := strconv.Atoi(limitStr)
+		if err != nil || n <= 0 {
+			return 0, nil, domain.NewValidationError("limit", "limit debe ser un número mayor que cero")
+		}
+		limit = n
+	}
+
+	var after *domain.Cursor
+	if cursorStr := query.Get("cursor"); cursorStr != "" {
+		c, err := domain.DecodeCursor(cursorStr)
+		if err != nil {
+			return 0, nil, err
+		}
+		after = c
+	}
+	return limit, after, nil
+}
+
+// writePage escribe una página con su header Link (si hay siguiente).
+func writePage(w nethttp.ResponseWriter, r *nethttp.Request, items any, nextCursor string) {
+	writePageResponse(w, r, pageResponse{Items: items}, nextCursor)
+}
+
+// writePageResponse es writePage para sobres con campos extra (did_you_mean, facets).
+func writePageResponse(w nethttp.ResponseWriter, r *nethttp.Request, resp pageResponse, nextCursor string) {
+	if nextCursor != "" {
+		resp.NextCursor = &nextCursor
+
+		// Misma URL (mismos filtros y orden) con el cursor nuevo.
+		next := *r.URL
+		query := next.Query()
+		query.Set("cursor", nextCursor)
+		next.RawQuery = query.Encode()
+		w.Header().Set("Link", "<"+next.RequestURI()+`>; rel="next"`)
+	}
+	writeJSON(w, nethttp.StatusOK, resp)
+}
@@ -0,0 +1,467 @@
+package http
+
+import (
+	"time"
+
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/domain"
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/usecase"
+)
+
+/*
+   ==========================================================
+   PRESENTERS (DTOs de respuesta)
+   ==========================================================
+
+   Las entidades del dominio tienen todos sus campos privados,
+   por eso json.Encoder las convierte en "{}".
+
+   Aquí definimos estructuras de RESPUESTA con campos públicos
+   y etiquetas JSON en snake_case, y funciones que copian los
+   datos desde las entidades usando sus getters.
+*/
+
+// userResponse es la representación JSON de un usuario.
+type userResponse struct {
+	ID        int64  `json:"id"`
+	Name      string `json:"name"`
+	Email     string `json:"email"`
+	Role      string `json:"role"`
+	Active    bool   `json:"active"`
+	CreatedAt string `json:"created_at"`
+	Version   int64  `json:"version"`
+}
+
+// bookResponse es la representación JSON de un libro.
+type bookResponse struct {
+	ID         int64    `json:"id"`
+	Title      string   `json:"title"`
+	Author     string   `json:"author"`
+	Year       int      `json:"year"`
+	ISBN       string   `json:"isbn"`
+	CategoryTI string   `json:"category_ti"`
+	Tags       []string `json:"tags"`
+	Active     bool     `json:"active"`
+	CreatedAt  string   `json:"created_at"`
+	Version    int64    `json:"version"`
+}
+
+// accessEventResponse es la representación JSON de un evento de acceso.
+type accessEventResponse struct {
+	ID         int64  `json:"id"`
+	BookID     int64  `json:"book_id"`
+	UserID     int64  `json:"user_id"`
+	AccessType string `json:"access_type"`
+	Timestamp  string `json:"timestamp"`
+}
+
+// toUserResponse convierte un *domain.User en su DTO de respuesta.
+func toUserResponse(u *domain.User) userResponse {
+	return userResponse{
+		ID:        int64(u.ID()),
+		Name:      u.Name(),
+		Email:     u.Email(),
+		Role:      string(u.Role()),
+		Active:    u.Active(),
+		CreatedAt: formatTime(u.CreatedAt()),
+		Version:   u.Version(),
+	}
+}
+
+// toUserResponses convierte una slice de usuarios (nunca devuelve nil,
+// para que el JSON sea [] y no null).
+func toUserResponses(users []*domain.User) []userResponse {
+	result := make([]userResponse, 0, len(users))
+	for _, u := range users {
+		result = append(result, toUserResponse(u))
+	}
+	return result
+}
+
+// toBookResponse convierte un *domain.Book en su DTO de respuesta.
+func toBookResponse(b *domain.Book) bookResponse {
+	// Copiamos los tags para no exponer la slice interna del dominio.
+	tags := make([]string, len(b.Tags()))
+	copy(tags, b.Tags())
+
+	return bookResponse{
+		ID:         int64(b.ID()),
+		Title:      b.Title(),
+		Author:     b.Author(),
+		Year:       b.Year(),
+		ISBN:       b.ISBN(),
+		CategoryTI: b.CategoryTI(),
+		Tags:       tags,
+		Active:     b.Active(),
+		CreatedAt:  formatTime(b.CreatedAt()),
+		Version:    b.Version(),
+	}
+}
+
+// toBookResponses convierte una slice de libros.
+func toBookResponses(books []*domain.Book) []bookResponse {
+	result := make([]bookResponse, 0, len(books))
+	for _, b := range books {
+		result = append(result, toBookResponse(b))
+	}
+	return result
+}
+
+// toAccessEventResponse convierte un *domain.AccessEvent en su DTO de respuesta.
+func toAccessEventResponse(e *domain.AccessEvent) accessEventResponse {
+	return accessEventResponse{
+		ID:         int64(e.ID()),
+		BookID:     int64(e.BookID()),
+		UserID:     int64(e.UserID()),
+		AccessType: string(e.AccessType()),
+		Timestamp:  formatTime(e.Timestamp()),
+	}
+}
+
+// searchResultResponse es un resultado de GET /books/search.
+type searchResultResponse struct {
+	Book       bookResponse      `json:"book"`
+	Score      float64           `json:"score"`
+	Highlights map[string]string `json:"highlights"`
+}
+
+// toSearchResultResponses convierte los resultados de la búsqueda de texto completo.
+func toSearchResultResponses(results []usecase.BookSearchResult) []searchResultResponse {
+	out := make([]searchResultResponse, 0, len(results))
+	for _, res := range results {
+		out = append(out, searchResultResponse{
+			Book:       toBookResponse(res.Book),
+			Score:      res.Score,
+			Highlights: res.Highlights,
+		})
+	}
+	return out
+}
+
+// suggestionResponse es una sugerencia de GET /books/suggest.
+type suggestionResponse struct {
+	Kind       string `json:"kind"`
+	Text       string `json:"text"`
+	Popularity int    `json:"popularity"`
+	Books      int    `json:"books"`
+}
+
+// toSuggestionResponses convierte las sugerencias del autocompletado.
+func toSuggestionResponses(suggestions []usecase.BookSuggestion) []suggestionResponse {
+	out := make([]suggestionResponse, 0, len(suggestions))
+	for _, s := range suggestions {
+		out = append(out, suggestionResponse{
+			Kind:       string(s.Kind),
+			Text:       s.Text,
+			Popularity: s.Popularity,
+			Books:      s.Books,
+		})
+	}
+	return out
+}
+
+// recommendationResponse es un libro de GET /books/{id}/related,
+// /books/{id}/similar o /users/{id}/recommendations.
+type recommendationResponse struct {
+	Book  bookResponse `json:"book"`
+	Score float64      `json:"score"`
+}
+
+// toRecommendationResponses convierte las recomendaciones.
+func toRecommendationResponses(recs []usecase.BookRecommendation) []recommendationResponse {
+	out := make([]recommendationResponse, 0, len(recs))
+	for _, rec := range recs {
+		out = append(out, recommendationResponse{
+			Book:  toBookResponse(rec.Book),
+			Score: rec.Score,
+		})
+	}
+	return out
+}
+
+// trendingBookResponse es un libro de GET /books/trending.
+type trendingBookResponse struct {
+	Book     bookResponse `json:"book"`
+	Accesses int          `json:"accesses"`
+}
+
+// toTrendingBookResponses convierte los libros en tendencia.
+func toTrendingBookResponses(books []usecase.TrendingBook) []trendingBookResponse {
+	out := make([]trendingBookResponse, 0, len(books))
+	for _, tb := range books {
+		out = append(out, trendingBookResponse{
+			Book:     toBookResponse(tb.Book),
+			Accesses: tb.Accesses,
+		})
+	}
+	return out
+}
+
+// seriesBucketResponse es un período de GET /access/stats/timeseries.
+type seriesBucketResponse struct {
+	Start  string                    `json:"start"`
+	Counts map[domain.AccessType]int `json:"counts"`
+	Total  int                       `json:"total"`
+}
+
+// toSeriesBucketResponses convierte las cubetas de una serie de tiempo.
+// El inicio se muestra en la zona horaria pedida, no en UTC.
+func toSeriesBucketResponses(buckets []usecase.AccessSeriesBucket) []seriesBucketResponse {
+	out := make([]seriesBucketResponse, 0, len(buckets))
+	for _, b := range buckets {
+		out = append(out, seriesBucketResponse{
+			Start:  b.Start.Format(time.RFC3339),
+			Counts: b.Counts,
+			Total:  b.Total,
+		})
+	}
+	return out
+}
+
+// toAccessStatsResponse arma la respuesta de GET /access/stats: los
+// accesos por tipo más "unique_readers" en el mismo objeto.
+func toAccessStatsResponse(stats map[domain.AccessType]int, uniqueReaders uint64) map[string]any {
+	out := make(map[string]any, len(stats)+1)
+	for t, n := range stats {
+		out[string(t)] = n
+	}
+	out["unique_readers"] = uniqueReaders
+	return out
+}
+
+// dailyReadersResponse es un día de GET /access/stats/readers.
+type dailyReadersResponse struct {
+	Day           string `json:"day"`
+	UniqueReaders uint64 `json:"unique_readers"`
+}
+
+// toDailyReadersResponses convierte los lectores distintos por día.
+func toDailyReadersResponses(days []usecase.DailyReaders) []dailyReadersResponse {
+	out := make([]dailyReadersResponse, 0, len(days))
+	for _, d := range days {
+		out = append(out, dailyReadersResponse{Day: d.Day, UniqueReaders: d.UniqueReaders})
+	}
+	return out
+}
+
+// funnelStepResponse es un paso de GET /access/stats/funnel.
+// Los tiempos van en segundos; null en el primer paso o sin usuarios.
+type funnelStepResponse struct {
+	AccessType    domain.AccessType `json:"access_type"`
+	Users         int               `json:"users"`
+	Conversion    float64           `json:"conversion"`
+	MedianSeconds *float64          `json:"median_seconds"`
+}
+
+// toFunnelStepResponses convierte los pasos del embudo.
+func toFunnelStepResponses(steps [3]usecase.FunnelStep) []funnelStepResponse {
+	out := make([]funnelStepResponse, 0, len(steps))
+	for _, st := range steps {
+		item := funnelStepResponse{
+			AccessType: st.AccessType,
+			Users:      st.Users,
+			Conversion: st.Conversion,
+		}
+		if st.MedianTime != nil {
+			seconds := st.MedianTime.Seconds()
+			item.MedianSeconds = &seconds
+		}
+		out = append(out, item)
+	}
+	return out
+}
+
+// historyEntryResponse es un acceso de GET /users/{id}/history.
+// Book es null si el libro ya no existe.
+type historyEntryResponse struct {
+	ID         int64         `json:"id"`
+	AccessType string        `json:"access_type"`
+	Timestamp  string        `json:"timestamp"`
+	BookID     int64         `json:"book_id"`
+	Book       *bookResponse `json:"book"`
+}
+
+// toHistoryEntryResponses convierte una página del historial.
+func toHistoryEntryResponses(entries []usecase.HistoryEntry) []historyEntryResponse {
+	out := make([]historyEntryResponse, 0, len(entries))
+	for _, e := range entries {
+		item := historyEntryResponse{
+			ID:         int64(e.Event.ID()),
+			AccessType: string(e.Event.AccessType()),
+			Timestamp:  formatTime(e.Event.Timestamp()),
+			BookID:     int64(e.Event.BookID()),
+		}
+		if e.Book != nil {
+			book := toBookResponse(e.Book)
+			item.Book = &book
+		}
+		out = append(out, item)
+	}
+	return out
+}
+
+// userStatsResponse es la respuesta de GET /users/{id}/stats.
+type userStatsResponse struct {
+	UserID             int64                     `json:"user_id"`
+	Accesses           int                       `json:"accesses"`
+	Books              int                       `json:"books"`
+	BooksByType        map[domain.AccessType]int `json:"books_by_type"`
+	FavoriteCategories []facetCountResponse      `json:"favorite_categories"`
+	FavoriteAuthors    []facetCountResponse      `json:"favorite_authors"`
+	ActiveDays         int                       `json:"active_days"`
+	FirstAccess        *string                   `json:"first_access"`
+	LastAccess         *string                   `json:"last_access"`
+}
+
+// toUserStatsResponse convierte el resumen de un usuario.
+// Sin accesos, first_access y last_access son null.
+func toUserStatsResponse(userID int64, s usecase.UserStats) userStatsResponse {
+	resp := userStatsResponse{
+		UserID:             userID,
+		Accesses:           s.Accesses,
+		Books:              s.Books,
+		BooksByType:        s.BooksByType,
+		FavoriteCategories: toFacetCountResponses(s.FavoriteCategories),
+		FavoriteAuthors:    toFacetCountResponses(s.FavoriteAuthors),
+		ActiveDays:         s.ActiveDays,
+	}
+	if !s.FirstAccess.IsZero() {
+		first, last := formatTime(s.FirstAccess), formatTime(s.LastAccess)
+		resp.FirstAccess, resp.LastAccess = &first, &last
+	}
+	return resp
+}
+
+// bookCountResponse es un libro de un ranking de GET /analytics/overview.
+type bookCountResponse struct {
+	BookID int64  `json:"book_id"`
+	Title  string `json:"title"`
+	Author string `json:"author"`
+	Count  int    `json:"count"`
+}
+
+// accessTypeRankingResponse son los rankings de un tipo de acceso.
+type accessTypeRankingResponse struct {
+	Books      []bookCountResponse  `json:"books"`
+	Categories []facetCountResponse `json:"categories"`
+	Authors    []facetCountResponse `json:"authors"`
+	Tags       []facetCountResponse `json:"tags"`
+}
+
+// monthCountResponse es una cantidad de un mes.
+type monthCountResponse struct {
+	Month string `json:"month"`
+	Count int    `json:"count"`
+}
+
+// analyticsOverviewResponse es la respuesta de GET /analytics/overview.
+type analyticsOverviewResponse struct {
+	GeneratedAt      string                                          `json:"generated_at"`
+	Users            analyticsUsersResponse                          `json:"users"`
+	ByAccessType     map[domain.AccessType]accessTypeRankingResponse `json:"by_access_type"`
+	NewBooksPerMonth []monthCountResponse                            `json:"new_books_per_month"`
+}
+
+// analyticsUsersResponse son los usuarios registrados y los activos.
+type analyticsUsersResponse struct {
+	Total int `json:"total"`
+	DAU   int `json:"dau"`
+	WAU   int `json:"wau"`
+	MAU   int `json:"mau"`
+}
+
+// toAnalyticsOverviewResponse convierte el tablero de analítica.
+func toAnalyticsOverviewResponse(o usecase.AnalyticsOverview, generatedAt time.Time) analyticsOverviewResponse {
+	resp := analyticsOverviewResponse{
+		GeneratedAt: formatTime(generatedAt),
+		Users: analyticsUsersResponse{
+			Total: o.Users,
+			DAU:   o.ActiveUsers.Daily,
+			WAU:   o.ActiveUsers.Weekly,
+			MAU:   o.ActiveUsers.Monthly,
+		},
+		ByAccessType:     make(map[domain.AccessType]accessTypeRankingResponse),
+		NewBooksPerMonth: make([]monthCountResponse, 0, len(o.NewBooksPerMonth)),
+	}
+	for t, ranking := range o.ByType {
+		books := make([]bookCountResponse, 0, len(ranking.Books))
+		for _, b := range ranking.Books {
+			books = append(books, bookCountResponse{BookID: int64(b.BookID), Title: b.Title, Author: b.Author, Count: b.Count})
+		}
+		resp.ByAccessType[t] = accessTypeRankingResponse{
+			Books:      books,
+			Categories: toFacetCountResponses(ranking.Categories),
+			Authors:    toFacetCountResponses(ranking.Authors),
+			Tags:       toFacetCountResponses(ranking.Tags),
+		}
+	}
+	for _, m := range o.NewBooksPerMonth {
+		resp.NewBooksPerMonth = append(resp.NewBooksPerMonth, monthCountResponse{Month: m.Month, Count: m.Count})
+	}
+	return resp
+}
+
+// spellingSuggestionResponse es el "did_you_mean" de GET /books.
+type spellingSuggestionResponse struct {
+	Title  string `json:"title,omitempty"`
+	Author string `json:"author,omitempty"`
+}
+
+// toSpellingSuggestionResponse convierte la sugerencia (nil si no hay).
+func toSpellingSuggestionResponse(s *domain.SpellingSuggestion) *spellingSuggestionResponse {
+	if s == nil {
+		return nil
+	}
+	return &spellingSuggestionResponse{Title: s.Title, Author: s.Author}
+}
+
+// facetCountResponse es un valor de faceta con su cantidad.
+type facetCountResponse struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// toFacetCountResponses convierte valores con cantidad (slice vacía, nunca null).
+func toFacetCountResponses(counts []domain.FacetCount) []facetCountResponse {
+	out := make([]facetCountResponse, 0, len(counts))
+	for _, c := range counts {
+		out = append(out, facetCountResponse{Value: c.Value, Count: c.Count})
+	}
+	return out
+}
+
+// yearFacetCountResponse es un rango de años con su cantidad.
+type yearFacetCountResponse struct {
+	From  int `json:"from"`
+	To    int `json:"to"`
+	Count int `json:"count"`
+}
+
+// facetsResponse son las facetas de GET /books.
+type facetsResponse struct {
+	Categories []facetCountResponse     `json:"categories"`
+	Decades    []yearFacetCountResponse `json:"decades"`
+	Years      []yearFacetCountResponse `json:"years"`
+	Tags       []facetCountResponse     `json:"tags"`
+}
+
+// toFacetsResponse convierte las facetas del dominio (slices vacías, nunca null).
+func toFacetsResponse(f domain.BookFacets) *facetsResponse {
+	resp := &facetsResponse{
+		Categories: toFacetCountResponses(f.Categories),
+		Decades:    make([]yearFacetCountResponse, 0, len(f.Decades)),
+		Years:      make([]yearFacetCountResponse, 0, len(f.Years)),
+		Tags:       toFacetCountResponses(f.Tags),
+	}
+	for _, d := range f.Decades {
+		resp.Decades = append(resp.Decades, yearFacetCountResponse{From: d.From, To: d.To, Count: d.Count})
+	}
+	for _, y := range f.Years {
+		resp.Years = append(resp.Years, yearFacetCountResponse{From: y.From, To: y.To, Count: y.Count})
+	}
+	return resp
+}
+
+// formatTime formatea las fechas en RFC 3339 (UTC) para todas las respuestas.
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
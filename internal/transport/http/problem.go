@@ -0,0 +1,127 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	nethttp "net/http"
+
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/bookquery"
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/domain"
+)
+
+/*
+   ==========================================================
+   ERRORES HTTP (RFC 7807 - application/problem+json)
+   ==========================================================
+
+   Todas las respuestas de error pasan por writeProblem, que
+   escribe un cuerpo con el formato estándar "problem details":
+
+	{
+	  "type": "/problems/validation",
+	  "title": "Unprocessable Entity",
+	  "status": 422,
+	  "detail": "el nombre no puede estar vacío",
+	  "instance": "/users",
+	  "errors": [{"field": "name", "message": "el nombre no puede estar vacío"}]
+	}
+
+   writeServiceError traduce los errores del dominio a códigos HTTP:
+   - domain.ErrNotFound   → 404
+   - domain.ErrVersionConflict → 412 (If-Match desactualizado)
+   - domain.ErrConflict   → 409
+   - domain.ErrValidation → 422 (con el detalle de cada campo)
+   - bookquery.SyntaxError → 422 (campo "q", con la columna del error)
+   - domain.ErrForbidden  → 403
+   - cualquier otro       → 500
+*/
+
+// problemDetails es el cuerpo de error definido por RFC 7807.
+type problemDetails struct {
+	Type     string         `json:"type"`
+	Title    string         `json:"title"`
+	Status   int            `json:"status"`
+	Detail   string         `json:"detail,omitempty"`
+	Instance string         `json:"instance,omitempty"`
+	Errors   []fieldProblem `json:"errors,omitempty"`
+}
+
+// fieldProblem describe un error de validación de un campo.
+// Position es la columna del error en consultas (?q=...).
+type fieldProblem struct {
+	Field    string `json:"field"`
+	Message  string `json:"message"`
+	Position int    `json:"position,omitempty"`
+}
+
+// writeProblem es el ÚNICO lugar que escribe respuestas de error.
+func writeProblem(w nethttp.ResponseWriter, p problemDetails) {
+	if p.Type == "" {
+		p.Type = "about:blank"
+	}
+	if p.Title == "" {
+		p.Title = nethttp.StatusText(p.Status)
+	}
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(p.Status)
+	_ = json.NewEncoder(w).Encode(p)
+}
+
+// writeError envía un error simple (ej. JSON mal formado, método no permitido).
+func writeError(w nethttp.ResponseWriter, status int, msg string) {
+	writeProblem(w, problemDetails{
+		Status: status,
+		Detail: msg,
+	})
+}
+
+// writeServiceError traduce un error de la capa de negocio a su código HTTP.
+func writeServiceError(w nethttp.ResponseWriter, r *nethttp.Request, err error) {
+	p := problemDetails{
+		Detail:   err.Error(),
+		Instance: r.URL.Path,
+	}
+
+	var (
+		validation *domain.ValidationError
+		syntax     *bookquery.SyntaxError
+	)
+
+	switch {
+	case errors.As(err, &syntax):
+		p.Type = "/problems/query-syntax"
+		p.Status = nethttp.StatusUnprocessableEntity
+		p.Errors = []fieldProblem{{Field: "q", Message: syntax.Msg, Position: syntax.Pos}}
+	case errors.As(err, &validation):
+		p.Type = "/problems/validation"
+		p.Status = nethttp.StatusUnprocessableEntity
+		for _, f := range validation.Fields {
+			p.Errors = append(p.Errors, fieldProblem{Field: f.Field, Message: f.Message})
+		}
+	case errors.Is(err, domain.ErrValidation):
+		p.Type = "/problems/validation"
+		p.Status = nethttp.StatusUnprocessableEntity
+	case errors.Is(err, domain.ErrNotFound):
+		p.Type = "/problems/not-found"
+		p.Status = nethttp.StatusNotFound
+	case errors.Is(err, domain.ErrVersionConflict):
+		// Va antes que ErrConflict porque también es un conflicto.
+		p.Type = "/problems/version-conflict"
+		p.Status = nethttp.StatusPreconditionFailed
+	case errors.Is(err, domain.ErrConflict):
+		p.Type = "/problems/conflict"
+		p.Status = nethttp.StatusConflict
+	case errors.Is(err, domain.ErrForbidden):
+		p.Type = "/problems/forbidden"
+		p.Status = nethttp.StatusForbidden
+	default:
+		// Error inesperado: lo registramos y no exponemos detalles internos.
+		log.Printf("error interno en %s %s: %v", r.Method, r.URL.Path, err)
+		p.Status = nethttp.StatusInternalServerError
+		p.Detail = "error interno del servidor"
+	}
+
+	writeProblem(w, p)
+}
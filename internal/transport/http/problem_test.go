@@ -0,0 +1,109 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	nethttp "net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/bookquery"
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/domain"
+)
+
+// decodeProblem verifica el Content-Type y devuelve el cuerpo problem+json.
+func decodeProblem(t *testing.T, rec *httptest.ResponseRecorder) problemDetails {
+	t.Helper()
+	if ct := rec.Header().Get("Content-Type"); ct != "application/problem+json" {
+		t.Fatalf("Content-Type = %q, se esperaba application/problem+json", ct)
+	}
+	var p problemDetails
+	if err := json.NewDecoder(rec.Body).Decode(&p); err != nil {
+		t.Fatalf("cuerpo problem+json inválido: %v", err)
+	}
+	if p.Status != rec.Code {
+		t.Fatalf("status del cuerpo %d, de la respuesta %d", p.Status, rec.Code)
+	}
+	return p
+}
+
+func TestWriteServiceError(t *testing.T) {
+	validation := &domain.ValidationError{}
+	validation.Add("name", "el nombre no puede estar vacío")
+	validation.Add("email", "el email no es válido")
+	_, syntaxErr := bookquery.Parse("title:")
+
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		typ    string
+		detail string
+		errors []fieldProblem
+	}{
+		{"no encontrado", domain.NotFound("libro no encontrado"),
+			404, "/problems/not-found", "libro no encontrado", nil},
+		{"conflicto", domain.Conflict("ya existe un usuario con ese email"),
+			409, "/problems/conflict", "ya existe un usuario con ese email", nil},
+		{"versión desactualizada", domain.VersionConflict("el libro fue modificado por otra operación"),
+			412, "/problems/version-conflict", "el libro fue modificado por otra operación", nil},
+		{"prohibido", domain.Forbidden("el libro está archivado"),
+			403, "/problems/forbidden", "el libro está archivado", nil},
+		{"validación por campo", validation,
+			422, "/problems/validation", validation.Error(), []fieldProblem{
+				{Field: "name", Message: "el nombre no puede estar vacío"},
+				{Field: "email", Message: "el email no es válido"},
+			}},
+		{"validación sin campos", fmt.Errorf("filtro: %w", domain.ErrValidation),
+			422, "/problems/validation", "filtro: datos no válidos", nil},
+		{"envuelto con %w", fmt.Errorf("registrar acceso: %w", domain.NotFound("usuario no encontrado")),
+			404, "/problems/not-found", "registrar acceso: usuario no encontrado", nil},
+		{"inesperado", errors.New("disk full"),
+			500, "about:blank", "error interno del servidor", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(nethttp.MethodPost, "/access", nil)
+			writeServiceError(rec, req, tt.err)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, se esperaba %d", rec.Code, tt.status)
+			}
+			p := decodeProblem(t, rec)
+			if p.Type != tt.typ || p.Detail != tt.detail || p.Instance != "/access" {
+				t.Fatalf("problema = %+v", p)
+			}
+			if p.Title != nethttp.StatusText(tt.status) {
+				t.Fatalf("title = %q", p.Title)
+			}
+			if !slices.Equal(p.Errors, tt.errors) {
+				t.Fatalf("errors = %+v, se esperaba %+v", p.Errors, tt.errors)
+			}
+		})
+	}
+
+	t.Run("sintaxis de la consulta", func(t *testing.T) {
+		if syntaxErr == nil {
+			t.Fatal("la consulta debería tener un error de sintaxis")
+		}
+		rec := httptest.NewRecorder()
+		writeServiceError(rec, httptest.NewRequest(nethttp.MethodGet, "/books", nil), syntaxErr)
+		p := decodeProblem(t, rec)
+		if rec.Code != 422 || p.Type != "/problems/query-syntax" || len(p.Errors) != 1 ||
+			p.Errors[0].Field != "q" || p.Errors[0].Position == 0 {
+			t.Fatalf("problema = %+v", p)
+		}
+	})
+}
+
+func TestWriteError(t *testing.T) {
+	rec := httptest.NewRecorder()
+	writeError(rec, nethttp.StatusMethodNotAllowed, "método no permitido")
+	p := decodeProblem(t, rec)
+	if p.Type != "about:blank" || p.Title != "Method Not Allowed" || p.Detail != "método no permitido" {
+		t.Fatalf("problema = %+v", p)
+	}
+}
@@ -0,0 +1,120 @@
+package http
+
+import (
+	nethttp "net/http"
+
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/domain"
+)
+
+/*
+==========================================================
+ENDPOINT GET /books/{id}/related
+==========================================================
+
+Libros que abrieron, leyeron o descargaron los mismos lectores
+que el libro indicado, del más parecido al menos. Si el libro
+todavía no tiene accesos, los parecidos por metadatos (igual que
+/books/{id}/similar). Parámetros:
+- limit: cantidad de libros (10 por defecto, máximo 50).
+
+Respuesta:
+
+	{
+	  "book_id": 3,
+	  "items": [
+	    {"book": { ... }, "score": 0.82}
+	  ]
+	}
+*/
+func (h *HTTPHandler) handleRelatedBooks(w nethttp.ResponseWriter, r *nethttp.Request) {
+	id, err := parseIDParam(r)
+	if err != nil {
+		writeError(w, nethttp.StatusBadRequest, err.Error())
+		return
+	}
+
+	limit, _, err := parsePageParams(r)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	recs, err := h.bookService.RelatedBooks(domain.BookID(id), limit)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, nethttp.StatusOK, map[string]any{
+		"book_id": id,
+		"items":   toRecommendationResponses(recs),
+	})
+}
+
+/*
+==========================================================
+ENDPOINT GET /books/{id}/similar
+==========================================================
+
+Libros activos parecidos por título, tags, categoría, autor y
+año, sin mirar los accesos. Mismos parámetros y forma de
+respuesta que /books/{id}/related.
+*/
+func (h *HTTPHandler) handleSimilarBooks(w nethttp.ResponseWriter, r *nethttp.Request) {
+	id, err := parseIDParam(r)
+	if err != nil {
+		writeError(w, nethttp.StatusBadRequest, err.Error())
+		return
+	}
+
+	limit, _, err := parsePageParams(r)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	recs, err := h.bookService.SimilarBooks(domain.BookID(id), limit)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, nethttp.StatusOK, map[string]any{
+		"book_id": id,
+		"items":   toRecommendationResponses(recs),
+	})
+}
+
+/*
+==========================================================
+ENDPOINT GET /users/{id}/recommendations
+==========================================================
+
+Libros que el usuario todavía no consultó, parecidos a los que
+sí consultó. Mismos parámetros y forma de respuesta que
+/books/{id}/related, con "user_id" en lugar de "book_id".
+*/
+func (h *HTTPHandler) handleUserRecommendations(w nethttp.ResponseWriter, r *nethttp.Request) {
+	id, err := parseIDParam(r)
+	if err != nil {
+		writeError(w, nethttp.StatusBadRequest, err.Error())
+		return
+	}
+
+	limit, _, err := parsePageParams(r)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	recs, err := h.bookService.RecommendBooks(domain.UserID(id), limit)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, nethttp.StatusOK, map[string]any{
+		"user_id": id,
+		"items":   toRecommendationResponses(recs),
+	})
+}
@@ -0,0 +1,136 @@
+package http
+
+import (
+	nethttp "net/http"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+// recommendEnvelope es la respuesta de /books/{id}/related,
+// /books/{id}/similar y /users/{id}/recommendations.
+type recommendEnvelope struct {
+	BookID int64                    `json:"book_id"`
+	UserID int64                    `json:"user_id"`
+	Items  []recommendationResponse `json:"items"`
+}
+
+func recommendedIDs(t *testing.T, mux *nethttp.ServeMux, target string) recommendEnvelope {
+	t.Helper()
+	var env recommendEnvelope
+	decodeBody(t, serve(mux, "GET", target, ""), nethttp.StatusOK, &env)
+	for i, item := range env.Items {
+		if item.Score <= 0 || (i > 0 && item.Score > env.Items[i-1].Score) {
+			t.Fatalf("GET %s: puntajes fuera de orden: %+v", target, env.Items)
+		}
+	}
+	return env
+}
+
+func ids(env recommendEnvelope) []int64 {
+	out := make([]int64, 0, len(env.Items))
+	for _, item := range env.Items {
+		out = append(out, item.Book.ID)
+	}
+	return out
+}
+
+// recommendFixture: Ana y Beto leen los libros 1 y 2; Carla abre
+// el 1 y el 3. El libro 4 no tiene accesos.
+func recommendFixture(t *testing.T) *nethttp.ServeMux {
+	t.Helper()
+	mux := newTestMux(t)
+	for _, name := range []string{"Ana", "Beto", "Carla"} {
+		mustPostUser(t, mux, name, name+"@example.com")
+	}
+	seedBooks(t, mux,
+		`{"title":"Redes de Computadoras","author":"Tanenbaum","year":2010,"isbn":"978-1","category_ti":"Redes","tags":["tcp"]}`,
+		`{"title":"Seguridad en Redes","author":"Stallings","year":2012,"isbn":"978-2","category_ti":"Seguridad","tags":["vpn"]}`,
+		`{"title":"Compiladores","author":"Aho","year":2006,"isbn":"978-3","category_ti":"Lenguajes","tags":["parser"]}`,
+		`{"title":"Redes Inalámbricas","author":"Gast","year":2013,"isbn":"978-4","category_ti":"Redes","tags":["tcp","wifi"]}`,
+	)
+	accesses := []struct {
+		book, user int
+		typ        string
+	}{
+		{1, 1, "LECTURA"}, {2, 1, "DESCARGA"},
+		{1, 2, "LECTURA"}, {2, 2, "LECTURA"},
+		{1, 3, "APERTURA"}, {3, 3, "APERTURA"},
+	}
+	for _, a := range accesses {
+		body := `{"book_id":` + strconv.Itoa(a.book) + `,"user_id":` + strconv.Itoa(a.user) + `,"access_type":"` + a.typ + `"}`
+		if rec := serve(mux, "POST", "/access", body); rec.Code != nethttp.StatusCreated {
+			t.Fatalf("POST /access %s = %d", body, rec.Code)
+		}
+	}
+	return mux
+}
+
+func TestRelatedBooks(t *testing.T) {
+	mux := recommendFixture(t)
+
+	env := recommendedIDs(t, mux, "/books/1/related")
+	if env.BookID != 1 || !slices.Equal(ids(env), []int64{2, 3}) {
+		t.Fatalf("related de 1 = %+v", env)
+	}
+	env = recommendedIDs(t, mux, "/books/1/related?limit=1")
+	if !slices.Equal(ids(env), []int64{2}) {
+		t.Fatalf("related de 1 con limit=1 = %v", ids(env))
+	}
+
+	// Sin accesos, los parecidos por metadatos (igual que /similar).
+	related := recommendedIDs(t, mux, "/books/4/related")
+	similar := recommendedIDs(t, mux, "/books/4/similar")
+	if len(related.Items) == 0 || related.Items[0].Book.ID != 1 || !slices.Equal(ids(related), ids(similar)) {
+		t.Fatalf("related de 4 = %v, similar = %v", ids(related), ids(similar))
+	}
+
+	// Un libro archivado deja de recomendarse.
+	if rec := serve(mux, "DELETE", "/books/2", ""); rec.Code != nethttp.StatusOK {
+		t.Fatalf("DELETE /books/2 = %d", rec.Code)
+	}
+	if got := ids(recommendedIDs(t, mux, "/books/1/related")); !slices.Equal(got, []int64{3}) {
+		t.Fatalf("related de 1 con el 2 archivado = %v", got)
+	}
+}
+
+func TestUserRecommendations(t *testing.T) {
+	mux := recommendFixture(t)
+
+	// Carla no leyó el 2, que comparte lectores con el 1.
+	env := recommendedIDs(t, mux, "/users/3/recommendations")
+	if env.UserID != 3 || !slices.Equal(ids(env), []int64{2}) {
+		t.Fatalf("recomendaciones de Carla = %+v", env)
+	}
+	// A Ana le falta el 3, que Carla abrió junto con el 1.
+	if got := ids(recommendedIDs(t, mux, "/users/1/recommendations")); !slices.Equal(got, []int64{3}) {
+		t.Fatalf("recomendaciones de Ana = %v", got)
+	}
+
+	// Un usuario sin accesos recibe una lista vacía, no null.
+	mustPostUser(t, mux, "Diego", "diego@example.com")
+	var raw map[string]any
+	decodeBody(t, serve(mux, "GET", "/users/4/recommendations", ""), nethttp.StatusOK, &raw)
+	if items, ok := raw["items"].([]any); !ok || len(items) != 0 {
+		t.Fatalf("items = %#v, se esperaba []", raw["items"])
+	}
+}
+
+func TestRecommendationErrors(t *testing.T) {
+	mux := recommendFixture(t)
+	tests := []struct {
+		target string
+		status int
+	}{
+		{"/books/99/related", 404},
+		{"/books/99/similar", 404},
+		{"/users/99/recommendations", 404},
+		{"/books/0/related", 400},
+		{"/books/1/similar?limit=-1", 422},
+	}
+	for _, tt := range tests {
+		if p := decodeProblem(t, serve(mux, "GET", tt.target, "")); p.Status != tt.status {
+			t.Fatalf("GET %s = %d, se esperaba %d", tt.target, p.Status, tt.status)
+		}
+	}
+}
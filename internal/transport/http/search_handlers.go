@@ -0,0 +1,90 @@
+package http
+
+import (
+	nethttp "net/http"
+)
+
+/*
+==========================================================
+ENDPOINT GET /books/search?q=...
+==========================================================
+
+Búsqueda de texto completo en título, tags y autor, ordenada
+por relevancia. Parámetros:
+- q:     texto a buscar (obligatorio).
+- limit: cantidad máxima de resultados (50 por defecto, máximo 200).
+
+Respuesta:
+
+	{
+	  "query": "go concurrencia",
+	  "items": [
+	    {
+	      "book": { ... },
+	      "score": 4.21,
+	      "highlights": {"title": "Programación <mark>Go</mark>"}
+	    }
+	  ]
+	}
+*/
+func (h *HTTPHandler) handleSearchBooks(w nethttp.ResponseWriter, r *nethttp.Request) {
+	q := r.URL.Query().Get("q")
+
+	limit, _, err := parsePageParams(r)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	results, err := h.bookService.FullTextSearch(q, limit)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, nethttp.StatusOK, map[string]any{
+		"query": q,
+		"items": toSearchResultResponses(results),
+	})
+}
+
+/*
+==========================================================
+ENDPOINT GET /books/suggest?prefix=...
+==========================================================
+
+Autocompletado del cuadro de búsqueda: títulos, autores y tags
+que empiezan con el prefijo (o alguna de sus palabras), de los
+más consultados a los menos. Parámetros:
+- prefix: lo que lleva escrito el lector (obligatorio).
+- limit:  cantidad de sugerencias (10 por defecto, máximo 50).
+
+Respuesta:
+
+	{
+	  "prefix": "tanen",
+	  "items": [
+	    {"kind": "author", "text": "Andrew Tanenbaum", "popularity": 12, "books": 3}
+	  ]
+	}
+*/
+func (h *HTTPHandler) handleSuggestBooks(w nethttp.ResponseWriter, r *nethttp.Request) {
+	prefix := r.URL.Query().Get("prefix")
+
+	limit, _, err := parsePageParams(r)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	suggestions, err := h.bookService.SuggestBooks(prefix, limit)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, nethttp.StatusOK, map[string]any{
+		"prefix": prefix,
+		"items":  toSuggestionResponses(suggestions),
+	})
+}
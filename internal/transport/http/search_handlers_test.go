@@ -0,0 +1,82 @@
+package http
+
+import (
+	nethttp "net/http"
+	"reflect"
+	"testing"
+)
+
+// suggestEnvelope es la respuesta de GET /books/suggest.
+type suggestEnvelope struct {
+	Prefix string               `json:"prefix"`
+	Items  []suggestionResponse `json:"items"`
+}
+
+// Las sugerencias mezclan títulos, autores y tags y se ordenan por
+// los accesos registrados con POST /access.
+func TestSuggestBooks(t *testing.T) {
+	mux := newTestMux(t)
+	mustPostUser(t, mux, "Ana", "ana@example.com")
+	seedBooks(t, mux,
+		`{"title":"Redes de Computadoras","author":"Andrew Tanenbaum","year":2010,"isbn":"978-1","category_ti":"Redes","tags":["redes"]}`,
+		`{"title":"Redes Neuronales","author":"Simon Haykin","year":2009,"isbn":"978-2","category_ti":"IA","tags":["redes"]}`,
+		`{"title":"Sistemas Operativos","author":"Andrew Tanenbaum","year":2014,"isbn":"978-3","category_ti":"Sistemas"}`,
+	)
+
+	var got suggestEnvelope
+	decodeBody(t, serve(mux, "GET", "/books/suggest?prefix=red", ""), nethttp.StatusOK, &got)
+	want := suggestEnvelope{Prefix: "red", Items: []suggestionResponse{
+		{Kind: "tag", Text: "redes", Popularity: 0, Books: 2},
+		{Kind: "title", Text: "Redes Neuronales", Popularity: 0, Books: 1},
+		{Kind: "title", Text: "Redes de Computadoras", Popularity: 0, Books: 1},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("sin accesos = %+v, se esperaba %+v", got, want)
+	}
+
+	// Tres accesos al libro 1 lo suben sobre "Redes Neuronales".
+	for _, typ := range []string{"APERTURA", "LECTURA", "DESCARGA"} {
+		rec := serve(mux, "POST", "/access", `{"book_id":1,"user_id":1,"access_type":"`+typ+`"}`)
+		if rec.Code != nethttp.StatusCreated {
+			t.Fatalf("POST /access = %d", rec.Code)
+		}
+	}
+	decodeBody(t, serve(mux, "GET", "/books/suggest?prefix=RED", ""), nethttp.StatusOK, &got)
+	want = suggestEnvelope{Prefix: "RED", Items: []suggestionResponse{
+		{Kind: "tag", Text: "redes", Popularity: 3, Books: 2},
+		{Kind: "title", Text: "Redes de Computadoras", Popularity: 3, Books: 1},
+		{Kind: "title", Text: "Redes Neuronales", Popularity: 0, Books: 1},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("con accesos = %+v, se esperaba %+v", got, want)
+	}
+
+	// El prefijo puede ser el comienzo de otra palabra; limit recorta.
+	decodeBody(t, serve(mux, "GET", "/books/suggest?prefix=tanen&limit=1", ""), nethttp.StatusOK, &got)
+	want = suggestEnvelope{Prefix: "tanen", Items: []suggestionResponse{
+		{Kind: "author", Text: "Andrew Tanenbaum", Popularity: 3, Books: 2},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("por autor = %+v, se esperaba %+v", got, want)
+	}
+
+	var none suggestEnvelope
+	decodeBody(t, serve(mux, "GET", "/books/suggest?prefix=xyz", ""), nethttp.StatusOK, &none)
+	if none.Items == nil || len(none.Items) != 0 {
+		t.Fatalf("sin completaciones = %#v, se esperaba []", none.Items)
+	}
+}
+
+func TestSuggestBooksErrors(t *testing.T) {
+	mux := newTestMux(t)
+	for target, field := range map[string]string{
+		"/books/suggest":                  "prefix",
+		"/books/suggest?prefix=%20":       "prefix",
+		"/books/suggest?prefix=a&limit=0": "limit",
+	} {
+		p := decodeProblem(t, serve(mux, "GET", target, ""))
+		if p.Status != nethttp.StatusUnprocessableEntity || len(p.Errors) != 1 || p.Errors[0].Field != field {
+			t.Fatalf("GET %s = %+v, se esperaba 422 en %s", target, p, field)
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package http
+
+import (
+	nethttp "net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/domain"
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/usecase"
+)
+
+/*
+==========================================================
+ENDPOINT GET /access/stats/timeseries
+==========================================================
+
+Accesos por tipo en cada hora, día, semana o mes de un rango.
+Parámetros:
+- from, to: rango [from, to) (obligatorios).
+- interval: hour, day (por defecto), week o month.
+- tz:       zona horaria IANA ("America/Guayaquil"); UTC por defecto.
+- book_id o category: un libro o una categoría (opcional).
+
+Las fechas van en RFC 3339 ("2024-05-01T08:00:00-05:00") o solo
+fecha ("2024-05-01") en la zona tz; una fecha sola en to INCLUYE
+ese día.
+
+Respuesta:
+
+	{
+	  "interval": "day",
+	  "tz": "America/Guayaquil",
+	  "from": "2024-05-01T00:00:00-05:00",
+	  "to": "2024-05-03T00:00:00-05:00",
+	  "buckets": [
+	    {"start": "2024-05-01T00:00:00-05:00", "counts": {"APERTURA": 3, "LECTURA": 1, "DESCARGA": 0}, "total": 4},
+	    {"start": "2024-05-02T00:00:00-05:00", "counts": {"APERTURA": 0, "LECTURA": 0, "DESCARGA": 0}, "total": 0}
+	  ]
+	}
+*/
+func (h *HTTPHandler) handleAccessTimeSeries(w nethttp.ResponseWriter, r *nethttp.Request) {
+	q := r.URL.Query()
+
+	interval, err := domain.ParseStatsInterval(q.Get("interval"))
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	loc, err := parseTZParam(q)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	query := usecase.AccessSeriesQuery{
+		CategoryTI: q.Get("category"),
+		Interval:   interval,
+		Location:   loc,
+	}
+	if query.From, err = parseTimeParam(q, "from", loc, false); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	if query.To, err = parseTimeParam(q, "to", loc, true); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	if s := q.Get("book_id"); s != "" {
+		id, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || id <= 0 {
+			writeServiceError(w, r, domain.NewValidationError("book_id", "book_id debe ser un número mayor que cero"))
+			return
+		}
+		query.BookID = domain.BookID(id)
+	}
+
+	buckets, err := h.bookService.AccessTimeSeries(query)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	resp := map[string]any{
+		"interval": interval,
+		"tz":       loc.String(),
+		"from":     query.From.In(loc).Format(time.RFC3339),
+		"to":       query.To.In(loc).Format(time.RFC3339),
+		"buckets":  toSeriesBucketResponses(buckets),
+	}
+	if query.BookID != 0 {
+		resp["book_id"] = query.BookID
+	}
+	if query.CategoryTI != "" {
+		resp["category"] = query.CategoryTI
+	}
+	writeJSON(w, nethttp.StatusOK, resp)
+}
+
+/*
+==========================================================
+ENDPOINT GET /access/stats/readers
+==========================================================
+
+Lectores DISTINTOS (estimados con HyperLogLog) en total y por día.
+Parámetros (todos opcionales):
+- from, to: rango [from, to) en días UTC completos.
+- book_id o category: un libro o una categoría.
+
+Los sketches son por día: con fecha y hora, entra todo día que el rango
+toque (to=2024-05-02T10:00:00Z incluye el 2 de mayo).
+El total no es la suma de los días: quien leyó dos días cuenta una vez.
+
+Respuesta:
+
+	{
+	  "book_id": 1,
+	  "unique_readers": 3,
+	  "days": [
+	    {"day": "2024-05-01", "unique_readers": 2},
+	    {"day": "2024-05-02", "unique_readers": 2}
+	  ]
+	}
+*/
+func (h *HTTPHandler) handleUniqueReaders(w nethttp.ResponseWriter, r *nethttp.Request) {
+	q := r.URL.Query()
+
+	query := usecase.UniqueReadersQuery{CategoryTI: q.Get("category")}
+	var err error
+	if query.From, err = parseTimeParam(q, "from", time.UTC, false); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	if query.To, err = parseTimeParam(q, "to", time.UTC, true); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	if s := q.Get("book_id"); s != "" {
+		id, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || id <= 0 {
+			writeServiceError(w, r, domain.NewValidationError("book_id", "book_id debe ser un número mayor que cero"))
+			return
+		}
+		query.BookID = domain.BookID(id)
+	}
+
+	stats, err := h.bookService.UniqueReaders(query)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	resp := map[string]any{
+		"unique_readers": stats.UniqueReaders,
+		"days":           toDailyReadersResponses(stats.Days),
+	}
+	if query.BookID != 0 {
+		resp["book_id"] = query.BookID
+	}
+	if query.CategoryTI != "" {
+		resp["category"] = query.CategoryTI
+	}
+	writeJSON(w, nethttp.StatusOK, resp)
+}
+
+/*
+==========================================================
+ENDPOINT GET /access/stats/funnel
+==========================================================
+
+Embudo APERTURA → LECTURA → DESCARGA: de los usuarios que abrieron
+un libro, cuántos lo leyeron y lo descargaron después.
+Parámetros (todos opcionales):
+- window: plazo desde la apertura ("30m", "24h", "7d"...; 7d por defecto).
+- book_id o category: un libro o una categoría.
+
+conversion de cada paso es respecto del anterior; la de afuera, de
+APERTURA a DESCARGA. median_seconds es la mediana del tiempo desde
+el paso anterior.
+
+Respuesta:
+
+	{
+	  "book_id": 1,
+	  "window": "168h0m0s",
+	  "conversion": 0.25,
+	  "steps": [
+	    {"access_type": "APERTURA", "users": 8, "conversion": 1, "median_seconds": null},
+	    {"access_type": "LECTURA", "users": 4, "conversion": 0.5, "median_seconds": 540},
+	    {"access_type": "DESCARGA", "users": 2, "conversion": 0.5, "median_seconds": 3600}
+	  ]
+	}
+*/
+func (h *HTTPHandler) handleAccessFunnel(w nethttp.ResponseWriter, r *nethttp.Request) {
+	q := r.URL.Query()
+
+	window, err := domain.ParseFunnelWindow(q.Get("window"))
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	query := usecase.FunnelQuery{CategoryTI: q.Get("category"), Window: window}
+	if s := q.Get("book_id"); s != "" {
+		id, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || id <= 0 {
+			writeServiceError(w, r, domain.NewValidationError("book_id", "book_id debe ser un número mayor que cero"))
+			return
+		}
+		query.BookID = domain.BookID(id)
+	}
+
+	funnel, err := h.bookService.AccessFunnel(query)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	resp := map[string]any{
+		"window":     funnel.Window.String(),
+		"conversion": funnel.Conversion,
+		"steps":      toFunnelStepResponses(funnel.Steps),
+	}
+	if query.BookID != 0 {
+		resp["book_id"] = query.BookID
+	}
+	if query.CategoryTI != "" {
+		resp["category"] = query.CategoryTI
+	}
+	writeJSON(w, nethttp.StatusOK, resp)
+}
+
+// parseTZParam lee la zona horaria IANA del parámetro "tz" (vacío = UTC).
+func parseTZParam(q url.Values) (*time.Location, error) {
+	tz := q.Get("tz")
+	if tz == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, domain.NewValidationError("tz", "zona horaria desconocida")
+	}
+	return loc, nil
+}
+
+// parseTimeParam lee una fecha RFC 3339 o solo fecha (en loc).
+// Con endOfDay, una fecha sola se toma como el final de ese día
+// (el inicio del día siguiente). Vacío = instante cero.
+func parseTimeParam(q url.Values, name string, loc *time.Location, endOfDay bool) (time.Time, error) {
+	s := q.Get(name)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation(time.DateOnly, s, loc)
+	if err != nil {
+		return time.Time{}, domain.NewValidationError(name, name+" debe ser una fecha (2024-05-01) o fecha y hora RFC 3339")
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
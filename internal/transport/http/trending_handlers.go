@@ -0,0 +1,57 @@
+package http
+
+import (
+	nethttp "net/http"
+
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/domain"
+)
+
+/*
+==========================================================
+ENDPOINT GET /books/trending?window=7d&category=Redes
+==========================================================
+
+Libros activos con más accesos (de cualquier tipo) en la última
+hora, día o semana, del más consultado al menos. Parámetros:
+- window:   1h, 24h (por defecto) o 7d.
+- category: solo libros de esa categoría (opcional).
+- limit:    cantidad de libros (10 por defecto, máximo 50).
+
+Respuesta:
+
+	{
+	  "window": "7d",
+	  "category": "Redes",
+	  "items": [
+	    {"book": { ... }, "accesses": 42}
+	  ]
+	}
+*/
+func (h *HTTPHandler) handleTrendingBooks(w nethttp.ResponseWriter, r *nethttp.Request) {
+	q := r.URL.Query()
+
+	window, err := domain.ParseTrendWindow(q.Get("window"))
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	limit, _, err := parsePageParams(r)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	category := q.Get("category")
+	books, err := h.bookService.TrendingBooks(window, category, limit)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, nethttp.StatusOK, map[string]any{
+		"window":   window,
+		"category": category,
+		"items":    toTrendingBookResponses(books),
+	})
+}
@@ -0,0 +1,320 @@
+package usecase
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/domain"
+)
+
+/*
+   ==========================================================
+   AnalyticsService
+   ==========================================================
+
+   Tablero general del catálogo:
+   - los libros, categorías, autores y tags más consultados por
+     cada tipo de acceso;
+   - usuarios activos en las últimas 24 horas, 7 días y 30 días
+     (DAU / WAU / MAU);
+   - libros nuevos por mes.
+
+   Los AGREGADOS se arman una vez al arrancar (Rebuild, con los
+   tres repositorios) y después se actualizan con cada cambio:
+   es observador de accesos, de libros y de usuarios (ver
+   domain/observers.go). Pedir el tablero no recorre el registro
+   de accesos.
+
+   Los accesos de un libro se cuentan en su categoría, su autor
+   y sus tags ACTUALES: si se edita el libro, sus accesos se
+   mueven de la categoría vieja a la nueva.
+*/
+
+// Límites de los rankings del tablero.
+const (
+	defaultAnalyticsLimit = 10
+	maxAnalyticsLimit     = domain.MaxFacetValues
+)
+
+// newBooksMonths es cuántos meses (hasta el actual) muestra el tablero.
+const newBooksMonths = 12
+
+// AnalyticsService mantiene los agregados del tablero.
+type AnalyticsService struct {
+	userRepo      domain.UserRepository
+	bookRepo      domain.BookRepository
+	accessLogRepo domain.AccessLogRepository
+
+	mu       sync.RWMutex
+	books    map[domain.BookID]bookMeta
+	byType   map[domain.AccessType]*typeAggregates
+	lastSeen map[domain.UserID]time.Time // último acceso de cada usuario
+	users    int                         // usuarios registrados
+	newBooks map[string]int              // "2024-05" → libros creados ese mes (UTC)
+}
+
+// bookMeta son los datos de un libro que usan los rankings.
+type bookMeta struct {
+	title    string
+	author   string
+	category string
+	tags     []string
+	active   bool
+}
+
+// typeAggregates son los conteos de un tipo de acceso.
+type typeAggregates struct {
+	books      map[domain.BookID]int
+	categories *textCounter
+	authors    *textCounter
+	tags       *textCounter
+}
+
+func newTypeAggregates() *typeAggregates {
+	return &typeAggregates{
+		books:      make(map[domain.BookID]int),
+		categories: newTextCounter(),
+		authors:    newTextCounter(),
+		tags:       newTextCounter(),
+	}
+}
+
+// addMeta suma n accesos a la categoría, el autor y los tags de un libro
+// (o los resta, si n es negativo).
+func (a *typeAggregates) addMeta(meta bookMeta, n int) {
+	a.categories.add(meta.category, n)
+	a.authors.add(meta.author, n)
+	for _, tag := range meta.tags {
+		a.tags.add(tag, n)
+	}
+}
+
+// NewAnalyticsService es el CONSTRUCTOR del servicio de analítica.
+func NewAnalyticsService(
+	userRepo domain.UserRepository,
+	bookRepo domain.BookRepository,
+	accessLogRepo domain.AccessLogRepository,
+) *AnalyticsService {
+	s := &AnalyticsService{
+		userRepo:      userRepo,
+		bookRepo:      bookRepo,
+		accessLogRepo: accessLogRepo,
+	}
+	s.reset()
+	return s
+}
+
+// reset vacía los agregados.
+func (s *AnalyticsService) reset() {
+	s.books = make(map[domain.BookID]bookMeta)
+	s.byType = make(map[domain.AccessType]*typeAggregates)
+	for _, t := range domain.AccessTypes() {
+		s.byType[t] = newTypeAggregates()
+	}
+	s.lastSeen = make(map[domain.UserID]time.Time)
+	s.users = 0
+	s.newBooks = make(map[string]int)
+}
+
+/*
+Rebuild arma los agregados desde los repositorios. Se llama al
+arrancar, antes de atender peticiones:
+1. Libros (para conocer categoría, autor y tags).
+2. Usuarios.
+3. Accesos, en orden.
+*/
+func (s *AnalyticsService) Rebuild() error {
+	books, err := s.bookRepo.ListAll()
+	if err != nil {
+		return err
+	}
+	users, err := s.userRepo.ListAll()
+	if err != nil {
+		return err
+	}
+	events, err := s.accessLogRepo.ListAll()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.reset()
+	s.mu.Unlock()
+
+	for _, b := range books {
+		s.ObserveBook(b)
+	}
+	for _, u := range users {
+		s.ObserveUser(u)
+	}
+	for _, ev := range events {
+		s.ObserveAccess(ev)
+	}
+	return nil
+}
+
+// ObserveBook registra un libro nuevo o mueve los accesos de un libro
+// editado a su nueva categoría, autor y tags (implementa domain.BookObserver).
+func (s *AnalyticsService) ObserveBook(book *domain.Book) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := book.ID()
+	old, known := s.books[id]
+	meta := bookMeta{
+		title:    book.Title(),
+		author:   book.Author(),
+		category: book.CategoryTI(),
+		tags:     append([]string(nil), book.Tags()...),
+		active:   book.Active(),
+	}
+	if !known {
+		s.newBooks[book.CreatedAt().UTC().Format("2006-01")]++
+	}
+	for _, agg := range s.byType {
+		n := agg.books[id]
+		if n == 0 {
+			continue
+		}
+		if known {
+			agg.addMeta(old, -n)
+		}
+		agg.addMeta(meta, n)
+	}
+	s.books[id] = meta
+}
+
+// ObserveUser cuenta un usuario registrado (implementa domain.UserObserver).
+func (s *AnalyticsService) ObserveUser(user *domain.User) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users++
+}
+
+// ObserveAccess suma un acceso a los rankings de su tipo y actualiza
+// el último acceso del usuario (implementa domain.AccessObserver).
+func (s *AnalyticsService) ObserveAccess(event *domain.AccessEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	agg, ok := s.byType[event.AccessType()]
+	if !ok {
+		return
+	}
+	agg.books[event.BookID()]++
+	if meta, ok := s.books[event.BookID()]; ok {
+		agg.addMeta(meta, 1)
+	}
+
+	if ts := event.Timestamp(); ts.After(s.lastSeen[event.UserID()]) {
+		s.lastSeen[event.UserID()] = ts
+	}
+}
+
+// BookCount es un libro con su cantidad de accesos.
+type BookCount struct {
+	BookID domain.BookID
+	Title  string
+	Author string
+	Count  int
+}
+
+// AccessTypeRanking son los más consultados de un tipo de acceso.
+type AccessTypeRanking struct {
+	Books      []BookCount
+	Categories []domain.FacetCount
+	Authors    []domain.FacetCount
+	Tags       []domain.FacetCount
+}
+
+// ActiveUsers son los usuarios con algún acceso en cada ventana.
+type ActiveUsers struct {
+	Daily   int // últimas 24 horas (DAU)
+	Weekly  int // últimos 7 días (WAU)
+	Monthly int // últimos 30 días (MAU)
+}
+
+// MonthCount es una cantidad de un mes ("2024-05").
+type MonthCount struct {
+	Month string
+	Count int
+}
+
+// AnalyticsOverview es el tablero completo.
+type AnalyticsOverview struct {
+	ByType           map[domain.AccessType]AccessTypeRanking
+	Users            int
+	ActiveUsers      ActiveUsers
+	NewBooksPerMonth []MonthCount // los últimos 12 meses, del más viejo al actual
+}
+
+// Overview devuelve el tablero con los limit primeros de cada ranking.
+// Los libros archivados no aparecen en el ranking de libros, pero sus
+// accesos siguen contando en categorías, autores y tags.
+func (s *AnalyticsService) Overview(limit int, now time.Time) AnalyticsOverview {
+	if limit <= 0 {
+		limit = defaultAnalyticsLimit
+	}
+	if limit > maxAnalyticsLimit {
+		limit = maxAnalyticsLimit
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	overview := AnalyticsOverview{
+		ByType: make(map[domain.AccessType]AccessTypeRanking),
+		Users:  s.users,
+	}
+	for t, agg := range s.byType {
+		overview.ByType[t] = AccessTypeRanking{
+			Books:      s.topBooks(agg, limit),
+			Categories: agg.categories.top(limit),
+			Authors:    agg.authors.top(limit),
+			Tags:       agg.tags.top(limit),
+		}
+	}
+
+	day, week, month := now.Add(-24*time.Hour), now.AddDate(0, 0, -7), now.AddDate(0, 0, -30)
+	for _, seen := range s.lastSeen {
+		if seen.After(month) {
+			overview.ActiveUsers.Monthly++
+		}
+		if seen.After(week) {
+			overview.ActiveUsers.Weekly++
+		}
+		if seen.After(day) {
+			overview.ActiveUsers.Daily++
+		}
+	}
+
+	utc := now.UTC()
+	for i := newBooksMonths - 1; i >= 0; i-- {
+		key := time.Date(utc.Year(), utc.Month()-time.Month(i), 1, 0, 0, 0, 0, time.UTC).Format("2006-01")
+		overview.NewBooksPerMonth = append(overview.NewBooksPerMonth, MonthCount{Month: key, Count: s.newBooks[key]})
+	}
+	return overview
+}
+
+// topBooks devuelve los limit libros activos con más accesos (el ID desempata).
+func (s *AnalyticsService) topBooks(agg *typeAggregates, limit int) []BookCount {
+	result := make([]BookCount, 0, len(agg.books))
+	for id, n := range agg.books {
+		meta, ok := s.books[id]
+		if !ok || !meta.active {
+			continue
+		}
+		result = append(result, BookCount{BookID: id, Title: meta.title, Author: meta.author, Count: n})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].BookID < result[j].BookID
+	})
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result
+}
@@ -0,0 +1,183 @@
+package usecase
+
+import (
+	"reflect"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/domain"
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/infrastructure/db"
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/infrastructure/recommend"
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/infrastructure/search"
+)
+
+// analyticsFixture arma los servicios como cmd/api: la analítica
+// observa usuarios, libros y accesos.
+type analyticsFixture struct {
+	repos     domain.Repositories
+	users     *UserService
+	books     *BookService
+	analytics *AnalyticsService
+}
+
+func newAnalyticsFixture() analyticsFixture {
+	repos := domain.Repositories{
+		Users:  db.NewInMemoryUserRepo(),
+		Books:  db.NewInMemoryBookRepo(),
+		Access: db.NewInMemoryAccessLogRepo(),
+	}
+	uow := db.NewInMemoryUnitOfWork(repos)
+	f := analyticsFixture{
+		repos:     repos,
+		users:     NewUserService(repos.Users, uow),
+		books:     NewBookService(repos.Books, repos.Users, repos.Access, uow, search.NewIndex(), recommend.NewEngine()),
+		analytics: NewAnalyticsService(repos.Users, repos.Books, repos.Access),
+	}
+	f.users.AddUserObserver(f.analytics)
+	f.books.AddBookObserver(f.analytics)
+	f.books.AddAccessObserver(f.analytics)
+	return f
+}
+
+// access guarda un acceso con la hora indicada y lo avisa, como hace
+// RecordAccess después de confirmar.
+func (f analyticsFixture) access(t *testing.T, bookID domain.BookID, userID domain.UserID, typ domain.AccessType, ts time.Time) {
+	t.Helper()
+	ev := domain.RestoreAccessEvent(0, bookID, userID, typ, ts)
+	if err := f.repos.Access.Store(ev); err != nil {
+		t.Fatal(err)
+	}
+	f.analytics.ObserveAccess(ev)
+}
+
+// checkMatchesRebuild compara el tablero incremental con el de un
+// servicio nuevo armado con Rebuild sobre los mismos repositorios.
+func (f analyticsFixture) checkMatchesRebuild(t *testing.T, now time.Time) AnalyticsOverview {
+	t.Helper()
+	fresh := NewAnalyticsService(f.repos.Users, f.repos.Books, f.repos.Access)
+	if err := fresh.Rebuild(); err != nil {
+		t.Fatal(err)
+	}
+	got := f.analytics.Overview(maxAnalyticsLimit, now)
+	want := fresh.Overview(maxAnalyticsLimit, now)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("el tablero incremental no coincide con Rebuild:\n%+v\n%+v", got, want)
+	}
+	return got
+}
+
+func facetValues(counts []domain.FacetCount) []string {
+	out := make([]string, 0, len(counts))
+	for _, c := range counts {
+		out = append(out, c.Value)
+	}
+	return out
+}
+
+func TestAnalyticsMovesCountsWhenBooksChange(t *testing.T) {
+	f := newAnalyticsFixture()
+	now := time.Now()
+
+	ana, err := f.users.RegisterUser("Ana", "ana@example.com", domain.RoleReader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	redes, _ := f.books.RegisterBook("Redes", "Tanenbaum", 2011, "978-1", "Redes", []string{"tcp", "ip"})
+	so, _ := f.books.RegisterBook("Sistemas Operativos", "Tanenbaum", 2008, "978-2", "Sistemas", []string{"kernel"})
+
+	for i := 0; i < 3; i++ {
+		f.access(t, redes.ID(), ana.ID(), domain.AccessTypeLectura, now.Add(-time.Duration(i)*time.Hour))
+	}
+	f.access(t, so.ID(), ana.ID(), domain.AccessTypeLectura, now)
+	f.access(t, so.ID(), ana.ID(), domain.AccessTypeDescarga, now)
+	f.access(t, 99, ana.ID(), domain.AccessTypeLectura, now) // libro que no existe
+	overview := f.checkMatchesRebuild(t, now)
+	lectura := overview.ByType[domain.AccessTypeLectura]
+	if got := lectura.Categories; !slices.Equal(got, []domain.FacetCount{{Value: "Redes", Count: 3}, {Value: "Sistemas", Count: 1}}) {
+		t.Fatalf("categorías antes de editar = %v", got)
+	}
+
+	// Editar categoría, autor y tags mueve los tres accesos de lectura.
+	if _, err := f.books.UpdateBook(redes.ID(), AnyVersion, "Redes", "Kurose", 2012, "978-1", "Sistemas", []string{"TCP", "udp"}); err != nil {
+		t.Fatal(err)
+	}
+	overview = f.checkMatchesRebuild(t, now)
+	lectura = overview.ByType[domain.AccessTypeLectura]
+	if got := lectura.Categories; !slices.Equal(got, []domain.FacetCount{{Value: "Sistemas", Count: 4}}) {
+		t.Fatalf("categorías después de editar = %v", got)
+	}
+	if got := lectura.Authors; !slices.Equal(got, []domain.FacetCount{{Value: "Kurose", Count: 3}, {Value: "Tanenbaum", Count: 1}}) {
+		t.Fatalf("autores después de editar = %v", got)
+	}
+	// "tcp" se vacía y vuelve a contar con la forma del libro editado.
+	if got := facetValues(lectura.Tags); !slices.Equal(got, []string{"TCP", "udp", "kernel"}) {
+		t.Fatalf("tags después de editar = %v", got)
+	}
+	if got := overview.ByType[domain.AccessTypeDescarga].Categories; !slices.Equal(got, []domain.FacetCount{{Value: "Sistemas", Count: 1}}) {
+		t.Fatalf("la descarga de otro libro cambió: %v", got)
+	}
+
+	// Archivado: sale del ranking de libros, sus accesos siguen contando.
+	if _, err := f.books.ArchiveBook(redes.ID(), AnyVersion); err != nil {
+		t.Fatal(err)
+	}
+	overview = f.checkMatchesRebuild(t, now)
+	lectura = overview.ByType[domain.AccessTypeLectura]
+	if len(lectura.Books) != 1 || lectura.Books[0].BookID != so.ID() {
+		t.Fatalf("ranking de libros con uno archivado = %v", lectura.Books)
+	}
+	if lectura.Categories[0].Count != 4 {
+		t.Fatalf("los accesos del libro archivado dejaron de contar: %v", lectura.Categories)
+	}
+
+	// Accesos registrados por el caso de uso también llegan.
+	if _, err := f.books.RecordAccess(so.ID(), ana.ID(), domain.AccessTypeApertura); err != nil {
+		t.Fatal(err)
+	}
+	overview = f.checkMatchesRebuild(t, time.Now())
+	if got := overview.ByType[domain.AccessTypeApertura].Books; len(got) != 1 || got[0].Count != 1 {
+		t.Fatalf("ranking de aperturas = %v", got)
+	}
+}
+
+func TestAnalyticsActiveUsers(t *testing.T) {
+	f := newAnalyticsFixture()
+	now := time.Date(2026, 3, 31, 12, 0, 0, 0, time.UTC)
+	book, _ := f.books.RegisterBook("Redes", "Tanenbaum", 2011, "978-1", "Redes", nil)
+
+	lastSeen := []time.Duration{
+		time.Hour,                  // DAU, WAU, MAU
+		24*time.Hour - time.Second, // DAU, WAU, MAU
+		24 * time.Hour,             // justo en el borde: WAU y MAU
+		6 * 24 * time.Hour,         // WAU, MAU
+		7 * 24 * time.Hour,         // MAU
+		29 * 24 * time.Hour,        // MAU
+		30 * 24 * time.Hour,        // ninguno
+		40 * 24 * time.Hour,        // ninguno
+	}
+	for i, ago := range lastSeen {
+		u, err := f.users.RegisterUser("Usuario", "u"+strconv.Itoa(i)+"@example.com", domain.RoleReader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Un acceso más viejo después del último no lo retrasa.
+		f.access(t, book.ID(), u.ID(), domain.AccessTypeLectura, now.Add(-ago))
+		f.access(t, book.ID(), u.ID(), domain.AccessTypeLectura, now.Add(-ago-48*time.Hour))
+	}
+
+	overview := f.checkMatchesRebuild(t, now)
+	if want := (ActiveUsers{Daily: 2, Weekly: 4, Monthly: 6}); overview.ActiveUsers != want {
+		t.Fatalf("usuarios activos = %+v, se esperaba %+v", overview.ActiveUsers, want)
+	}
+	if overview.Users != len(lastSeen) {
+		t.Fatalf("usuarios registrados = %d", overview.Users)
+	}
+
+	// Una semana después, las ventanas avanzan sin recalcular nada.
+	later := f.analytics.Overview(maxAnalyticsLimit, now.AddDate(0, 0, 7))
+	if want := (ActiveUsers{Daily: 0, Weekly: 0, Monthly: 5}); later.ActiveUsers != want {
+		t.Fatalf("usuarios activos una semana después = %+v, se esperaba %+v", later.ActiveUsers, want)
+	}
+}
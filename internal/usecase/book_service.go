@@ -2,6 +2,7 @@ package usecase
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/jfmg0509/sistema_libros_funcional_go/internal/domain"
 )
@@ -18,6 +19,27 @@ import (
    - BookRepository: para guardar y buscar libros.
    - UserRepository: para verificar que el usuario exista.
    - AccessLogRepository: para guardar eventos de acceso.
+
+   Y de una UnitOfWork: los casos de uso que leen y luego
+   escriben (editar, archivar, registrar acceso) corren dentro
+   de ella, para que un archivado o una desactivación concurrente
+   no se cuele entre la verificación y la escritura.
+
+   Además mantiene al día un BookSearchIndex (búsqueda de texto
+   completo): cada libro creado, editado o archivado se vuelve a
+   indexar después de guardarse.
+
+   Reindexar y avisar a los observadores se agenda con
+   Repositories.AfterCommit: corre solo si el cambio se confirmó
+   y en el orden de confirmación, así dos ediciones simultáneas
+   del mismo libro no dejan el índice con la más vieja.
+
+   Cada acceso guardado se avisa a los AccessObserver: el
+   contador de popularidad (autocompletado, ver suggest.go), los
+   contadores de tendencias (ver trending.go) y el Recommender
+   (libros relacionados, ver recommend.go). Se pueden sumar más
+   observadores de accesos y de libros (AddAccessObserver,
+   AddBookObserver), por ejemplo la analítica.
 */
 
 // BookService representa los casos de uso relacionados con libros.
@@ -25,6 +47,13 @@ type BookService struct {
 	bookRepo      domain.BookRepository
 	userRepo      domain.UserRepository
 	accessLogRepo domain.AccessLogRepository
+	uow           domain.UnitOfWork
+	searchIndex   domain.BookSearchIndex
+	recommender   domain.Recommender
+	popularity    *popularity
+	trending      *trending
+	observers     []domain.AccessObserver
+	bookObservers []domain.BookObserver
 }
 
 // NewBookService es el CONSTRUCTOR de BookService.
@@ -32,20 +61,65 @@ func NewBookService(
 	bookRepo domain.BookRepository,
 	userRepo domain.UserRepository,
 	accessLogRepo domain.AccessLogRepository,
+	uow domain.UnitOfWork,
+	searchIndex domain.BookSearchIndex,
+	recommender domain.Recommender,
 ) *BookService {
+	popularity := newPopularity()
+	trending := newTrending()
 	return &BookService{
 		bookRepo:      bookRepo,
 		userRepo:      userRepo,
 		accessLogRepo: accessLogRepo,
+		uow:           uow,
+		searchIndex:   searchIndex,
+		recommender:   recommender,
+		popularity:    popularity,
+		trending:      trending,
+		observers:     []domain.AccessObserver{popularity, trending, recommender},
 	}
 }
 
+// AddAccessObserver suma un observador que recibirá cada acceso guardado.
+// Se llama al armar los servicios, antes de atender peticiones.
+func (s *BookService) AddAccessObserver(o domain.AccessObserver) {
+	s.observers = append(s.observers, o)
+}
+
+// AddBookObserver suma un observador que recibirá cada libro creado,
+// editado o archivado. Se llama al armar los servicios.
+func (s *BookService) AddBookObserver(o domain.BookObserver) {
+	s.bookObservers = append(s.bookObservers, o)
+}
+
+// bookChanged reindexa un libro recién guardado y avisa a los observadores.
+// Se agenda con AfterCommit dentro de la unidad de trabajo.
+func (s *BookService) bookChanged(book *domain.Book) {
+	s.searchIndex.Index(book)
+	for _, o := range s.bookObservers {
+		o.ObserveBook(book)
+	}
+}
+
+// RebuildSearchIndex indexa todos los libros guardados.
+// Se llama al arrancar, con el índice todavía vacío.
+func (s *BookService) RebuildSearchIndex() error {
+	books, err := s.bookRepo.ListAll()
+	if err != nil {
+		return err
+	}
+	for _, b := range books {
+		s.searchIndex.Index(b)
+	}
+	return nil
+}
+
 /*
 RegisterBook registra un nuevo libro en el sistema.
 
 Pasos:
 1. Usa el constructor de dominio (NewBook) para validar los datos.
-2. Pide al repositorio que cree el libro.
+2. Pide al repositorio que cree el libro (en una unidad de trabajo).
 3. Devuelve el libro creado.
 */
 func (s *BookService) RegisterBook(
@@ -60,7 +134,115 @@ func (s *BookService) RegisterBook(
 		return nil, err
 	}
 
-	if err := s.bookRepo.Create(book); err != nil {
+	err = s.uow.Do(func(repos domain.Repositories) error {
+		if err := repos.Books.Create(book); err != nil {
+			return err
+		}
+		repos.AfterCommit(func() { s.bookChanged(book) })
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return book, nil
+}
+
+/*
+FindBookByID busca un libro por su ID (incluye libros archivados).
+
+Devuelve (nil, nil) si el libro no existe.
+*/
+func (s *BookService) FindBookByID(id domain.BookID) (*domain.Book, error) {
+	return s.bookRepo.FindByID(id)
+}
+
+/*
+UpdateBook reemplaza los datos de un libro existente (PUT).
+
+Si expectedVersion no es AnyVersion, el libro debe seguir en esa
+versión; si no, se devuelve un error ErrVersionConflict.
+
+Pasos:
+1. Buscar el libro (y verificar su versión).
+2. Aplicar los nuevos datos (el dominio valida).
+3. Guardar en el repositorio.
+*/
+func (s *BookService) UpdateBook(
+	id domain.BookID,
+	expectedVersion int64,
+	title, author string,
+	year int,
+	isbn, categoryTI string,
+	tags []string,
+) (*domain.Book, error) {
+
+	var book *domain.Book
+
+	err := s.uow.Do(func(repos domain.Repositories) error {
+		var err error
+		book, err = repos.Books.FindByID(id)
+		if err != nil {
+			return err
+		}
+		if book == nil {
+			return domain.NotFound("libro no encontrado")
+		}
+		if err := checkVersion(book.Version(), expectedVersion, "el libro fue modificado por otra operación"); err != nil {
+			return err
+		}
+
+		if err := book.UpdateDetails(title, author, year, isbn, categoryTI, tags); err != nil {
+			return err
+		}
+
+		if err := repos.Books.Update(book); err != nil {
+			return err
+		}
+		repos.AfterCommit(func() { s.bookChanged(book) })
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return book, nil
+}
+
+/*
+ArchiveBook "retira" un libro del catálogo (DELETE).
+
+No se borra: se marca como archivado con Book.Archive(),
+así deja de aparecer en las búsquedas pero se conservan
+sus estadísticas de acceso.
+
+expectedVersion funciona igual que en UpdateBook.
+*/
+func (s *BookService) ArchiveBook(id domain.BookID, expectedVersion int64) (*domain.Book, error) {
+	var book *domain.Book
+
+	err := s.uow.Do(func(repos domain.Repositories) error {
+		var err error
+		book, err = repos.Books.FindByID(id)
+		if err != nil {
+			return err
+		}
+		if book == nil {
+			return domain.NotFound("libro no encontrado")
+		}
+		if err := checkVersion(book.Version(), expectedVersion, "el libro fue modificado por otra operación"); err != nil {
+			return err
+		}
+
+		book.Archive()
+
+		if err := repos.Books.Update(book); err != nil {
+			return err
+		}
+		repos.AfterCommit(func() { s.bookChanged(book) })
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -78,56 +260,197 @@ Recibe un BookFilter que puede tener:
 - tags
 
 La implementación exacta del filtro se hace en el repositorio.
+
+Los resultados vienen ordenados (filter.SortBy) y paginados:
+se devuelven como máximo filter.Limit libros y, si hay más,
+el cursor para pedir la página siguiente.
+*/
+func (s *BookService) SearchBooks(filter domain.BookFilter) (BookPage, error) {
+	sortSpec := domain.SortSpec(string(filter.SortBy), filter.SortDesc)
+	if err := checkCursorSort(filter.After, sortSpec); err != nil {
+		return BookPage{}, err
+	}
+	if err := checkFilterTags(filter); err != nil {
+		return BookPage{}, err
+	}
+
+	limit := pageLimit(filter.Limit)
+	filter.Limit = limit + 1
+
+	books, err := s.bookRepo.SearchByFilters(filter)
+	if err != nil {
+		return BookPage{}, err
+	}
+
+	page := BookPage{Books: books}
+	if len(books) == 0 && filter.After == nil {
+		if page.DidYouMean, err = s.suggestSpelling(filter); err != nil {
+			return BookPage{}, err
+		}
+	}
+	if len(books) > limit {
+		page.Books = books[:limit]
+		last := page.Books[limit-1]
+		page.NextCursor = domain.EncodeCursor(domain.Cursor{
+			Sort: sortSpec,
+			Key:  domain.BookSortKey(last, filter.SortBy),
+			ID:   int64(last.ID()),
+		})
+	}
+	return page, nil
+}
+
+// checkFilterTags rechaza filtros con más de domain.MaxFilterTags tags.
+func checkFilterTags(filter domain.BookFilter) error {
+	if len(filter.NormalizedTags()) > domain.MaxFilterTags {
+		return domain.NewValidationError("tag", fmt.Sprintf("se pueden filtrar a lo sumo %d tags", domain.MaxFilterTags))
+	}
+	return nil
+}
+
+/*
+BookFacets cuenta los libros que cumplen el filtro por categoría,
+década / año y tag, para que la interfaz permita afinar la búsqueda.
+Cuenta TODOS los resultados: se ignoran orden, cursor y límite.
+*/
+func (s *BookService) BookFacets(filter domain.BookFilter) (domain.BookFacets, error) {
+	filter.SortBy, filter.SortDesc = "", false
+	filter.Limit, filter.After = 0, nil
+	if err := checkFilterTags(filter); err != nil {
+		return domain.BookFacets{}, err
+	}
+	return s.bookRepo.Facets(filter)
+}
+
+// suggestSpelling busca un "¿Quisiste decir?" para un filtro sin resultados.
+// Devuelve nil si no hay corrección o si la corrección tampoco encuentra nada.
+func (s *BookService) suggestSpelling(filter domain.BookFilter) (*domain.SpellingSuggestion, error) {
+	if filter.TitleContains == "" && filter.AuthorContains == "" {
+		return nil, nil
+	}
+
+	suggestion, err := s.bookRepo.SuggestSpelling(filter)
+	if err != nil || suggestion.IsEmpty() {
+		return nil, err
+	}
+
+	corrected := suggestion.Apply(filter)
+	corrected.Limit = 1
+	books, err := s.bookRepo.SearchByFilters(corrected)
+	if err != nil || len(books) == 0 {
+		return nil, err
+	}
+	return &suggestion, nil
+}
+
+// BookSearchResult es un libro encontrado por FullTextSearch.
+type BookSearchResult struct {
+	Book       *domain.Book
+	Score      float64
+	Highlights map[string]string
+}
+
+/*
+FullTextSearch busca libros por texto libre en título, tags y autor,
+ordenados por relevancia (BM25, el título pesa más que los tags y
+estos más que el autor).
+
+Cada resultado trae el puntaje y fragmentos con las coincidencias
+marcadas con <mark>...</mark>.
 */
-func (s *BookService) SearchBooks(filter domain.BookFilter) ([]*domain.Book, error) {
-	return s.bookRepo.SearchByFilters(filter)
+func (s *BookService) FullTextSearch(query string, limit int) ([]BookSearchResult, error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, domain.NewValidationError("q", "la búsqueda no puede estar vacía")
+	}
+
+	hits := s.searchIndex.Search(query, pageLimit(limit))
+
+	results := make([]BookSearchResult, 0, len(hits))
+	for _, hit := range hits {
+		book, err := s.bookRepo.FindByID(hit.BookID)
+		if err != nil {
+			return nil, err
+		}
+		if book == nil {
+			continue
+		}
+		results = append(results, BookSearchResult{
+			Book:       book,
+			Score:      hit.Score,
+			Highlights: hit.Highlights,
+		})
+	}
+	return results, nil
 }
 
 /*
 RecordAccess registra un acceso de un usuario a un libro.
 
 Pasos:
-1. Verificar que el libro exista.
-2. Verificar que el usuario exista.
-3. Crear un AccessEvent (dominio).
+1. Crear un AccessEvent (dominio): valida ids y tipo de acceso.
+2. Verificar que el libro exista y no esté archivado.
+3. Verificar que el usuario exista y esté activo.
 4. Guardar el evento en el AccessLogRepository.
+5. Avisar a los observadores (después de confirmar, en orden).
+6. Devolver el evento guardado (ya con ID asignado).
+
+Los pasos 2 a 5 corren en UNA unidad de trabajo.
 */
 func (s *BookService) RecordAccess(
 	bookID domain.BookID,
 	userID domain.UserID,
 	accessType domain.AccessType,
-) error {
+) (*domain.AccessEvent, error) {
 
-	// 1. Verificar libro.
-	book, err := s.bookRepo.FindByID(bookID)
+	// 1. Crear el evento de acceso (si los datos son inválidos → ErrValidation).
+	event, err := domain.NewAccessEvent(bookID, userID, accessType)
 	if err != nil {
-		return err
-	}
-	if book == nil {
-		return fmt.Errorf("libro no encontrado")
+		return nil, err
 	}
 
-	// 2. Verificar usuario.
-	user, err := s.userRepo.FindByID(userID)
-	if err != nil {
-		return err
-	}
-	if user == nil {
-		return fmt.Errorf("usuario no encontrado")
-	}
+	err = s.uow.Do(func(repos domain.Repositories) error {
+		// 2. Verificar libro.
+		book, err := repos.Books.FindByID(bookID)
+		if err != nil {
+			return err
+		}
+		if book == nil {
+			return domain.NotFound("libro no encontrado")
+		}
+		if !book.Active() {
+			return domain.Forbidden("el libro está archivado")
+		}
 
-	// 3. Crear el evento de acceso.
-	event, err := domain.NewAccessEvent(bookID, userID, accessType)
-	if err != nil {
-		return err
-	}
+		// 3. Verificar usuario.
+		user, err := repos.Users.FindByID(userID)
+		if err != nil {
+			return err
+		}
+		if user == nil {
+			return domain.NotFound("usuario no encontrado")
+		}
+		if !user.Active() {
+			return domain.Forbidden("el usuario está inactivo")
+		}
 
-	// 4. Guardar el evento.
-	if err := s.accessLogRepo.Store(event); err != nil {
-		return err
+		// 4. Guardar el evento.
+		if err := repos.Access.Store(event); err != nil {
+			return err
+		}
+
+		// 5. Avisar a los observadores (popularidad, tendencias, recomendaciones...).
+		repos.AfterCommit(func() {
+			for _, o := range s.observers {
+				o.ObserveAccess(event)
+			}
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	return event, nil
 }
 
 /*
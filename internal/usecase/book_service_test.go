@@ -0,0 +1,90 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/domain"
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/infrastructure/db"
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/infrastructure/recommend"
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/infrastructure/search"
+)
+
+// newTestBookService arma un BookService sobre repositorios en memoria.
+func newTestBookService(t *testing.T) (*BookService, domain.Repositories) {
+	t.Helper()
+	repos := domain.Repositories{
+		Users:  db.NewInMemoryUserRepo(),
+		Books:  db.NewInMemoryBookRepo(),
+		Access: db.NewInMemoryAccessLogRepo(),
+	}
+	uow := db.NewInMemoryUnitOfWork(repos)
+	s := NewBookService(repos.Books, repos.Users, repos.Access, uow, search.NewIndex(), recommend.NewEngine())
+	return s, repos
+}
+
+func mustCreateUser(t *testing.T, repos domain.Repositories, name, email string) *domain.User {
+	t.Helper()
+	u, err := domain.NewUser(name, email, domain.RoleReader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repos.Users.Create(u); err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+func mustCreateBook(t *testing.T, s *BookService, title, category string, tags ...string) *domain.Book {
+	t.Helper()
+	b, err := s.RegisterBook(title, "Autor", 2020, "978-"+title, category, tags)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// RecordAccess: 404 si el libro o el usuario no existen, 403 si el
+// libro está archivado o el usuario inactivo, y en esos casos no se
+// guarda nada.
+func TestRecordAccessRules(t *testing.T) {
+	s, repos := newTestBookService(t)
+	active := mustCreateBook(t, s, "Redes", "Redes")
+	archived := mustCreateBook(t, s, "Sistemas", "Sistemas")
+	if _, err := s.ArchiveBook(archived.ID(), AnyVersion); err != nil {
+		t.Fatal(err)
+	}
+	ana := mustCreateUser(t, repos, "Ana", "ana@example.com")
+	beto := mustCreateUser(t, repos, "Beto", "beto@example.com")
+	beto.Deactivate()
+	if err := repos.Users.Update(beto); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		bookID domain.BookID
+		userID domain.UserID
+		want   error
+	}{
+		{"libro inexistente", 99, ana.ID(), domain.ErrNotFound},
+		{"usuario inexistente", active.ID(), 99, domain.ErrNotFound},
+		{"libro archivado", archived.ID(), ana.ID(), domain.ErrForbidden},
+		{"usuario inactivo", active.ID(), beto.ID(), domain.ErrForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.RecordAccess(tt.bookID, tt.userID, domain.AccessTypeLectura); !errors.Is(err, tt.want) {
+				t.Fatalf("RecordAccess = %v, se esperaba %v", err, tt.want)
+			}
+		})
+	}
+	if events, _ := repos.Access.ListAll(); len(events) != 0 {
+		t.Fatalf("se guardaron accesos rechazados: %d", len(events))
+	}
+
+	ev, err := s.RecordAccess(active.ID(), ana.ID(), domain.AccessTypeLectura)
+	if err != nil || ev.ID() == 0 {
+		t.Fatalf("acceso válido: %v, ID %d", err, ev.ID())
+	}
+}
@@ -0,0 +1,235 @@
+package usecase
+
+import (
+	"sort"
+	"time"
+
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/domain"
+)
+
+/*
+   ==========================================================
+   EMBUDO APERTURA → LECTURA → DESCARGA
+   ==========================================================
+
+   De los usuarios que ABRIERON un libro, ¿cuántos lo LEYERON y
+   cuántos lo DESCARGARON después, dentro de la ventana?
+
+   Cada (usuario, libro) es un RECORRIDO:
+   1. Empieza con una APERTURA en el instante t.
+   2. Llega a LECTURA si hay una lectura entre t y t + ventana.
+   3. Llega a DESCARGA si hay una descarga después de esa
+      lectura y antes de t + ventana.
+
+   Si el usuario abrió el libro varias veces, cuenta la apertura
+   que más avanzó (la primera, si hay empate). Una lectura o
+   descarga sin apertura previa no entra en el embudo.
+
+   En una categoría, cada usuario cuenta UNA vez, con el
+   recorrido que más avanzó entre todos sus libros.
+
+   ComputeFunnel es una función PURA sobre una lista de eventos:
+   la usan la API (BookService.AccessFunnel) y la CLI.
+*/
+
+// FunnelQuery indica de qué alcance calcular el embudo.
+type FunnelQuery struct {
+	BookID     domain.BookID // 0 = todos los libros
+	CategoryTI string        // "" = todas las categorías
+	Window     time.Duration // 0 = domain.DefaultFunnelWindow
+}
+
+// FunnelStep es un paso del embudo.
+type FunnelStep struct {
+	AccessType domain.AccessType
+	Users      int     // usuarios que llegaron a este paso
+	Conversion float64 // Users / Users del paso anterior (el primero, 1 si hay usuarios)
+
+	// MedianTime es la mediana del tiempo desde el paso anterior,
+	// entre los que llegaron (nil en el primer paso o sin usuarios).
+	MedianTime *time.Duration
+}
+
+// Funnel es el resultado de ComputeFunnel.
+type Funnel struct {
+	Window     time.Duration
+	Steps      [3]FunnelStep
+	Conversion float64 // de APERTURA a DESCARGA
+}
+
+// funnelJourney es hasta dónde llegó un recorrido y cuánto tardó.
+type funnelJourney struct {
+	depth int              // pasos alcanzados: 1, 2 o 3
+	times [2]time.Duration // apertura → lectura, lectura → descarga
+}
+
+/*
+ComputeFunnel calcula el embudo de una lista de eventos (de un libro,
+de varios o de todos).
+
+Pasos:
+1. Ordenar los eventos y agruparlos por usuario y libro.
+2. Elegir, para cada usuario, el recorrido que más avanzó.
+3. Contar usuarios por paso, tasas de conversión y medianas.
+*/
+func ComputeFunnel(events []*domain.AccessEvent, window time.Duration) Funnel {
+	if window <= 0 {
+		window = domain.DefaultFunnelWindow
+	}
+
+	// 1. Orden cronológico (el ID desempata) y grupos por (usuario, libro).
+	sorted := append([]*domain.AccessEvent(nil), events...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if !sorted[i].Timestamp().Equal(sorted[j].Timestamp()) {
+			return sorted[i].Timestamp().Before(sorted[j].Timestamp())
+		}
+		return sorted[i].ID() < sorted[j].ID()
+	})
+
+	type pairKey struct {
+		user domain.UserID
+		book domain.BookID
+	}
+	groups := make(map[pairKey][]*domain.AccessEvent)
+	var order []pairKey // orden de aparición, para que el resultado sea estable
+	for _, ev := range sorted {
+		key := pairKey{ev.UserID(), ev.BookID()}
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], ev)
+	}
+
+	// 2. El mejor recorrido de cada usuario.
+	best := make(map[domain.UserID]funnelJourney)
+	for _, key := range order {
+		j := bestJourney(groups[key], window)
+		if j.depth > best[key.user].depth {
+			best[key.user] = j
+		}
+	}
+
+	// 3. Contar.
+	funnel := Funnel{Window: window}
+	var durations [2][]time.Duration
+	for i, t := range domain.FunnelSteps() {
+		funnel.Steps[i].AccessType = t
+	}
+	for _, j := range best {
+		for step := 0; step < j.depth; step++ {
+			funnel.Steps[step].Users++
+			if step > 0 {
+				durations[step-1] = append(durations[step-1], j.times[step-1])
+			}
+		}
+	}
+
+	funnel.Steps[0].Conversion = ratio(funnel.Steps[0].Users, funnel.Steps[0].Users)
+	for i := 1; i < len(funnel.Steps); i++ {
+		funnel.Steps[i].Conversion = ratio(funnel.Steps[i].Users, funnel.Steps[i-1].Users)
+		funnel.Steps[i].MedianTime = medianDuration(durations[i-1])
+	}
+	funnel.Conversion = ratio(funnel.Steps[2].Users, funnel.Steps[0].Users)
+	return funnel
+}
+
+// bestJourney prueba cada apertura de un (usuario, libro) como inicio y
+// devuelve el recorrido que más avanzó. Los eventos van en orden.
+func bestJourney(events []*domain.AccessEvent, window time.Duration) funnelJourney {
+	var best funnelJourney
+	for i, start := range events {
+		if start.AccessType() != domain.AccessTypeApertura {
+			continue
+		}
+		deadline := start.Timestamp().Add(window)
+		j := funnelJourney{depth: 1}
+		var readAt time.Time
+		for _, ev := range events[i+1:] {
+			if ev.Timestamp().After(deadline) {
+				break
+			}
+			switch {
+			case j.depth == 1 && ev.AccessType() == domain.AccessTypeLectura:
+				readAt = ev.Timestamp()
+				j.depth = 2
+				j.times[0] = readAt.Sub(start.Timestamp())
+			case j.depth == 2 && ev.AccessType() == domain.AccessTypeDescarga:
+				j.depth = 3
+				j.times[1] = ev.Timestamp().Sub(readAt)
+			}
+		}
+		if j.depth > best.depth {
+			best = j
+		}
+		if best.depth == 3 {
+			break
+		}
+	}
+	return best
+}
+
+// ratio divide dos cantidades (0 si no hay base).
+func ratio(n, base int) float64 {
+	if base == 0 {
+		return 0
+	}
+	return float64(n) / float64(base)
+}
+
+// medianDuration devuelve la mediana (nil si no hay valores).
+func medianDuration(values []time.Duration) *time.Duration {
+	if len(values) == 0 {
+		return nil
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+	mid := len(values) / 2
+	median := values[mid]
+	if len(values)%2 == 0 {
+		median = (values[mid-1] + values[mid]) / 2
+	}
+	return &median
+}
+
+/*
+AccessFunnel calcula el embudo de un libro, de una categoría o de todo
+el catálogo.
+*/
+func (s *BookService) AccessFunnel(q FunnelQuery) (*Funnel, error) {
+	if q.BookID != 0 && q.CategoryTI != "" {
+		return nil, domain.NewValidationError("category", "usar book_id o category, no ambos")
+	}
+
+	var events []*domain.AccessEvent
+	switch {
+	case q.BookID != 0:
+		if err := s.checkBookExists(q.BookID); err != nil {
+			return nil, err
+		}
+		var err error
+		if events, err = s.accessLogRepo.ListByBook(q.BookID); err != nil {
+			return nil, err
+		}
+
+	case q.CategoryTI != "":
+		books, err := s.bookRepo.SearchByFilters(domain.BookFilter{CategoryTI: q.CategoryTI})
+		if err != nil {
+			return nil, err
+		}
+		for _, b := range books {
+			bookEvents, err := s.accessLogRepo.ListByBook(b.ID())
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, bookEvents...)
+		}
+
+	default:
+		var err error
+		if events, err = s.accessLogRepo.ListAll(); err != nil {
+			return nil, err
+		}
+	}
+
+	funnel := ComputeFunnel(events, q.Window)
+	return &funnel, nil
+}
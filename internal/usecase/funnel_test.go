@@ -0,0 +1,215 @@
+package usecase
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/domain"
+)
+
+const (
+	apertura = domain.AccessTypeApertura
+	lectura  = domain.AccessTypeLectura
+	descarga = domain.AccessTypeDescarga
+)
+
+// funnelEvent es un evento de prueba: minutos desde funnelBase.
+type funnelEvent struct {
+	user    domain.UserID
+	book    domain.BookID
+	typ     domain.AccessType
+	minutes int
+}
+
+var funnelBase = time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+
+// funnelEvents arma los eventos con IDs en el orden de la lista.
+func funnelEvents(list []funnelEvent) []*domain.AccessEvent {
+	events := make([]*domain.AccessEvent, 0, len(list))
+	for i, e := range list {
+		ts := funnelBase.Add(time.Duration(e.minutes) * time.Minute)
+		events = append(events, domain.RestoreAccessEvent(domain.AccessEventID(i+1), e.book, e.user, e.typ, ts))
+	}
+	return events
+}
+
+func minutesPtr(m float64) *time.Duration {
+	d := time.Duration(m * float64(time.Minute))
+	return &d
+}
+
+func TestComputeFunnel(t *testing.T) {
+	tests := []struct {
+		name    string
+		events  []funnelEvent
+		window  time.Duration
+		users   [3]int
+		medians [2]*time.Duration // apertura → lectura, lectura → descarga
+	}{
+		{
+			name:    "recorrido completo",
+			events:  []funnelEvent{{1, 1, apertura, 0}, {1, 1, lectura, 10}, {1, 1, descarga, 30}},
+			users:   [3]int{1, 1, 1},
+			medians: [2]*time.Duration{minutesPtr(10), minutesPtr(20)},
+		},
+		{
+			name:   "solo apertura",
+			events: []funnelEvent{{1, 1, apertura, 0}},
+			users:  [3]int{1, 0, 0},
+		},
+		{
+			name:   "sin eventos",
+			events: nil,
+			users:  [3]int{0, 0, 0},
+		},
+		{
+			name:   "lectura fuera de la ventana",
+			events: []funnelEvent{{1, 1, apertura, 0}, {1, 1, lectura, 61}, {1, 1, descarga, 62}},
+			window: time.Hour,
+			users:  [3]int{1, 0, 0},
+		},
+		{
+			name:    "lectura justo al cerrar la ventana",
+			events:  []funnelEvent{{1, 1, apertura, 0}, {1, 1, lectura, 60}, {1, 1, descarga, 61}},
+			window:  time.Hour,
+			users:   [3]int{1, 1, 0},
+			medians: [2]*time.Duration{minutesPtr(60), nil},
+		},
+		{
+			name:    "descarga antes de leer no cuenta",
+			events:  []funnelEvent{{1, 1, apertura, 0}, {1, 1, descarga, 5}, {1, 1, lectura, 10}},
+			users:   [3]int{1, 1, 0},
+			medians: [2]*time.Duration{minutesPtr(10), nil},
+		},
+		{
+			name:   "lectura antes de abrir no cuenta",
+			events: []funnelEvent{{1, 1, lectura, 0}, {1, 1, apertura, 5}, {1, 1, descarga, 10}},
+			users:  [3]int{1, 0, 0},
+		},
+		{
+			name:   "sin apertura no entra en el embudo",
+			events: []funnelEvent{{1, 1, lectura, 0}, {1, 1, descarga, 5}},
+			users:  [3]int{0, 0, 0},
+		},
+		{
+			name:    "eventos desordenados en la lista",
+			events:  []funnelEvent{{1, 1, descarga, 30}, {1, 1, lectura, 10}, {1, 1, apertura, 0}},
+			users:   [3]int{1, 1, 1},
+			medians: [2]*time.Duration{minutesPtr(10), minutesPtr(20)},
+		},
+		{
+			name: "de varias aperturas cuenta la que más avanzó",
+			events: []funnelEvent{
+				{1, 1, apertura, 0}, {1, 1, apertura, 120}, {1, 1, lectura, 130}, {1, 1, descarga, 140},
+			},
+			window:  time.Hour,
+			users:   [3]int{1, 1, 1},
+			medians: [2]*time.Duration{minutesPtr(10), minutesPtr(10)},
+		},
+		{
+			name: "eventos repetidos: el usuario cuenta una vez, con la primera apertura",
+			events: []funnelEvent{
+				{1, 1, apertura, 0}, {1, 1, apertura, 1}, {1, 1, lectura, 5}, {1, 1, lectura, 6},
+				{1, 1, descarga, 10}, {1, 1, descarga, 11},
+			},
+			users:   [3]int{1, 1, 1},
+			medians: [2]*time.Duration{minutesPtr(5), minutesPtr(5)},
+		},
+		{
+			name: "los pasos deben ser del mismo libro",
+			events: []funnelEvent{
+				{1, 1, apertura, 0}, {1, 2, lectura, 5}, {1, 2, descarga, 10},
+			},
+			users: [3]int{1, 0, 0},
+		},
+		{
+			name: "un usuario con dos libros cuenta una vez, con el mejor",
+			events: []funnelEvent{
+				{1, 1, apertura, 0}, {1, 1, lectura, 5},
+				{1, 2, apertura, 0}, {1, 2, lectura, 20}, {1, 2, descarga, 30},
+			},
+			users:   [3]int{1, 1, 1},
+			medians: [2]*time.Duration{minutesPtr(20), minutesPtr(10)},
+		},
+		{
+			name: "varios usuarios: conversiones y medianas",
+			events: []funnelEvent{
+				{1, 1, apertura, 0}, {1, 1, lectura, 10}, {1, 1, descarga, 20},
+				{2, 1, apertura, 0}, {2, 1, lectura, 20},
+				{3, 1, apertura, 0},
+				{4, 1, apertura, 0}, {4, 1, lectura, 30}, {4, 1, descarga, 90},
+			},
+			users:   [3]int{4, 3, 2},
+			medians: [2]*time.Duration{minutesPtr(20), minutesPtr(35)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := funnelEvents(tt.events)
+			f := ComputeFunnel(events, tt.window)
+
+			for i, step := range f.Steps {
+				if step.AccessType != domain.FunnelSteps()[i] {
+					t.Fatalf("paso %d = %s", i, step.AccessType)
+				}
+				if step.Users != tt.users[i] {
+					t.Fatalf("usuarios por paso = %d/%d/%d, se esperaba %v",
+						f.Steps[0].Users, f.Steps[1].Users, f.Steps[2].Users, tt.users)
+				}
+			}
+			if f.Steps[0].MedianTime != nil {
+				t.Fatal("el primer paso no tiene mediana")
+			}
+			for i, want := range tt.medians {
+				got := f.Steps[i+1].MedianTime
+				if (got == nil) != (want == nil) || (got != nil && *got != *want) {
+					t.Fatalf("mediana del paso %d = %v, se esperaba %v", i+1, fmtDuration(got), fmtDuration(want))
+				}
+			}
+
+			// Conversiones: cada paso sobre el anterior, y la total.
+			wantConv := [3]float64{ratio(tt.users[0], tt.users[0]), ratio(tt.users[1], tt.users[0]), ratio(tt.users[2], tt.users[1])}
+			for i, step := range f.Steps {
+				if math.Abs(step.Conversion-wantConv[i]) > 1e-9 {
+					t.Fatalf("conversión del paso %d = %v, se esperaba %v", i, step.Conversion, wantConv[i])
+				}
+			}
+			if want := ratio(tt.users[2], tt.users[0]); math.Abs(f.Conversion-want) > 1e-9 {
+				t.Fatalf("conversión total = %v, se esperaba %v", f.Conversion, want)
+			}
+		})
+	}
+}
+
+func fmtDuration(d *time.Duration) string {
+	if d == nil {
+		return "nil"
+	}
+	return d.String()
+}
+
+// A igual instante, el ID decide qué pasó primero.
+func TestComputeFunnelSameInstant(t *testing.T) {
+	open := domain.RestoreAccessEvent(2, 1, 1, apertura, funnelBase)
+	read := domain.RestoreAccessEvent(1, 1, 1, lectura, funnelBase)
+	if f := ComputeFunnel([]*domain.AccessEvent{open, read}, 0); f.Steps[1].Users != 0 {
+		t.Fatal("una lectura con ID menor no sigue a la apertura")
+	}
+	read = domain.RestoreAccessEvent(3, 1, 1, lectura, funnelBase)
+	if f := ComputeFunnel([]*domain.AccessEvent{read, open}, 0); f.Steps[1].Users != 1 || *f.Steps[1].MedianTime != 0 {
+		t.Fatal("una lectura en el mismo instante y con ID mayor sigue a la apertura")
+	}
+}
+
+func TestComputeFunnelDefaults(t *testing.T) {
+	events := funnelEvents([]funnelEvent{{1, 1, lectura, 0}, {1, 1, apertura, 5}})
+	f := ComputeFunnel(events, 0)
+	if f.Window != domain.DefaultFunnelWindow {
+		t.Fatalf("ventana = %v, se esperaba la predeterminada", f.Window)
+	}
+	// ComputeFunnel no reordena la lista que recibe.
+	if events[0].AccessType() != lectura || events[1].AccessType() != apertura {
+		t.Fatal("ComputeFunnel modificó el orden de los eventos")
+	}
+}
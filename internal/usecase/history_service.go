@@ -0,0 +1,227 @@
+package usecase
+
+import (
+	"time"
+
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/domain"
+)
+
+/*
+   ==========================================================
+   HistoryService
+   ==========================================================
+
+   Capa de negocio del HISTORIAL DE LECTURA de un usuario: qué
+   libros abrió, leyó o descargó y cuándo, y un resumen personal
+   (libros por tipo de acceso, categorías y autores favoritos,
+   días con actividad).
+
+   Todo se arma sobre AccessLogRepository.ListByUser (los
+   eventos del usuario en orden cronológico) y se completa con
+   los datos de cada libro.
+*/
+
+// maxFavorites es cuántas categorías y autores favoritos se muestran.
+const maxFavorites = 5
+
+// HistoryService contiene los repositorios que necesita el historial.
+type HistoryService struct {
+	userRepo      domain.UserRepository
+	bookRepo      domain.BookRepository
+	accessLogRepo domain.AccessLogRepository
+}
+
+// NewHistoryService es el CONSTRUCTOR del servicio de historial.
+func NewHistoryService(
+	userRepo domain.UserRepository,
+	bookRepo domain.BookRepository,
+	accessLogRepo domain.AccessLogRepository,
+) *HistoryService {
+	return &HistoryService{
+		userRepo:      userRepo,
+		bookRepo:      bookRepo,
+		accessLogRepo: accessLogRepo,
+	}
+}
+
+// HistoryEntry es un acceso del historial con su libro.
+// Book es nil si el libro ya no existe; un libro archivado se
+// muestra igual (el acceso ocurrió).
+type HistoryEntry struct {
+	Event *domain.AccessEvent
+	Book  *domain.Book
+}
+
+// HistoryPage es una página del historial.
+type HistoryPage struct {
+	Entries    []HistoryEntry
+	NextCursor string
+}
+
+// UserStats es el resumen de actividad de un usuario.
+type UserStats struct {
+	Accesses           int                       // eventos registrados
+	Books              int                       // libros distintos
+	BooksByType        map[domain.AccessType]int // libros distintos por tipo de acceso
+	FavoriteCategories []domain.FacetCount       // por cantidad de accesos
+	FavoriteAuthors    []domain.FacetCount       // por cantidad de accesos
+	ActiveDays         int                       // días con algún acceso (en la zona pedida)
+	FirstAccess        time.Time                 // cero si no hay accesos
+	LastAccess         time.Time
+}
+
+// userEvents verifica que el usuario exista y devuelve sus eventos
+// en orden cronológico.
+func (s *HistoryService) userEvents(userID domain.UserID) ([]*domain.AccessEvent, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, domain.NotFound("usuario no encontrado")
+	}
+	return s.accessLogRepo.ListByUser(userID)
+}
+
+// bookCache busca cada libro una sola vez.
+type bookCache struct {
+	repo  domain.BookRepository
+	books map[domain.BookID]*domain.Book
+}
+
+func newBookCache(repo domain.BookRepository) *bookCache {
+	return &bookCache{repo: repo, books: make(map[domain.BookID]*domain.Book)}
+}
+
+// get devuelve el libro (nil si no existe).
+func (c *bookCache) get(id domain.BookID) (*domain.Book, error) {
+	if book, ok := c.books[id]; ok {
+		return book, nil
+	}
+	book, err := c.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	c.books[id] = book
+	return book, nil
+}
+
+/*
+UserHistory devuelve el historial de un usuario por páginas, en orden
+cronológico (o del más nuevo al más viejo con SortDesc), con los
+datos de cada libro.
+*/
+func (s *HistoryService) UserHistory(userID domain.UserID, query domain.HistoryQuery) (HistoryPage, error) {
+	sortSpec := domain.SortSpec(domain.HistorySortTimestamp, query.SortDesc)
+	if err := checkCursorSort(query.After, sortSpec); err != nil {
+		return HistoryPage{}, err
+	}
+
+	events, err := s.userEvents(userID)
+	if err != nil {
+		return HistoryPage{}, err
+	}
+	if query.SortDesc {
+		for i, j := 0, len(events)-1; i < j; i, j = i+1, j-1 {
+			events[i], events[j] = events[j], events[i]
+		}
+	}
+
+	// Saltar hasta después del cursor y tomar una página (+1 para
+	// saber si hay otra).
+	limit := pageLimit(query.Limit)
+	selected := make([]*domain.AccessEvent, 0, limit+1)
+	for _, ev := range events {
+		if len(selected) > limit {
+			break
+		}
+		if query.After != nil && !query.After.IsAfter(domain.AccessEventSortKey(ev), int64(ev.ID()), query.SortDesc) {
+			continue
+		}
+		selected = append(selected, ev)
+	}
+
+	page := HistoryPage{}
+	if len(selected) > limit {
+		selected = selected[:limit]
+		last := selected[limit-1]
+		page.NextCursor = domain.EncodeCursor(domain.Cursor{
+			Sort: sortSpec,
+			Key:  domain.AccessEventSortKey(last),
+			ID:   int64(last.ID()),
+		})
+	}
+
+	books := newBookCache(s.bookRepo)
+	page.Entries = make([]HistoryEntry, 0, len(selected))
+	for _, ev := range selected {
+		book, err := books.get(ev.BookID())
+		if err != nil {
+			return HistoryPage{}, err
+		}
+		page.Entries = append(page.Entries, HistoryEntry{Event: ev, Book: book})
+	}
+	return page, nil
+}
+
+/*
+BuildUserStats resume la actividad de un usuario. Los días activos
+se cuentan en la zona horaria loc (nil = UTC).
+
+Las categorías y autores favoritos se ordenan por cantidad de
+accesos; los accesos a libros que ya no existen solo cuentan en
+los totales.
+*/
+func (s *HistoryService) BuildUserStats(userID domain.UserID, loc *time.Location) (UserStats, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	events, err := s.userEvents(userID)
+	if err != nil {
+		return UserStats{}, err
+	}
+
+	stats := UserStats{
+		Accesses:    len(events),
+		BooksByType: newAccessTypeCounts(),
+	}
+	books := newBookCache(s.bookRepo)
+	seen := make(map[domain.BookID]bool)
+	seenByType := make(map[domain.AccessType]map[domain.BookID]bool)
+	days := make(map[string]bool)
+	categories := newTextCounter()
+	authors := newTextCounter()
+
+	for _, ev := range events {
+		seen[ev.BookID()] = true
+		if seenByType[ev.AccessType()] == nil {
+			seenByType[ev.AccessType()] = make(map[domain.BookID]bool)
+		}
+		seenByType[ev.AccessType()][ev.BookID()] = true
+		days[ev.Timestamp().In(loc).Format(time.DateOnly)] = true
+
+		book, err := books.get(ev.BookID())
+		if err != nil {
+			return UserStats{}, err
+		}
+		if book != nil {
+			categories.add(book.CategoryTI(), 1)
+			authors.add(book.Author(), 1)
+		}
+	}
+
+	stats.Books = len(seen)
+	for t, ids := range seenByType {
+		stats.BooksByType[t] = len(ids)
+	}
+	stats.FavoriteCategories = categories.top(maxFavorites)
+	stats.FavoriteAuthors = authors.top(maxFavorites)
+	stats.ActiveDays = len(days)
+	if len(events) > 0 {
+		// ListByUser devuelve los eventos en orden cronológico.
+		stats.FirstAccess = events[0].Timestamp()
+		stats.LastAccess = events[len(events)-1].Timestamp()
+	}
+	return stats, nil
+}
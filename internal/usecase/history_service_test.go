@@ -0,0 +1,199 @@
+package usecase
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/domain"
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/infrastructure/db"
+)
+
+// historyFixture guarda un historial con eventos desordenados, un
+// empate de horario y un libro que ya no existe (ID 99).
+//
+//	ID  libro  tipo      hora (UTC)        Quito
+//	1   Redes  LECTURA   2026-03-04 03:30  03-03 22:30
+//	2   SO     APERTURA  2026-03-04 04:30  03-03 23:30
+//	3   Redes  DESCARGA  2026-03-04 02:30  03-03 21:30  (guardado tarde)
+//	4   99     LECTURA   2026-03-04 05:30  03-04 00:30
+//	5   Redes  LECTURA   2026-03-04 05:30  03-04 00:30  (empata con 4)
+//	6   (otro usuario)
+func historyFixture(t *testing.T) (*HistoryService, domain.UserID) {
+	t.Helper()
+	repos := domain.Repositories{
+		Users:  db.NewInMemoryUserRepo(),
+		Books:  db.NewInMemoryBookRepo(),
+		Access: db.NewInMemoryAccessLogRepo(),
+	}
+	ana := mustCreateUser(t, repos, "Ana", "ana@example.com")
+	beto := mustCreateUser(t, repos, "Beto", "beto@example.com")
+	redes, _ := domain.NewBook("Redes", "Tanenbaum", 2011, "978-1", "Redes", nil)
+	so, _ := domain.NewBook("Sistemas Operativos", "Silberschatz", 2018, "978-2", "Sistemas", nil)
+	for _, b := range []*domain.Book{redes, so} {
+		if err := repos.Books.Create(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t0 := time.Date(2026, 3, 4, 3, 30, 0, 0, time.UTC)
+	mustStoreAccess(t, repos, redes.ID(), ana.ID(), domain.AccessTypeLectura, t0)
+	mustStoreAccess(t, repos, so.ID(), ana.ID(), domain.AccessTypeApertura, t0.Add(time.Hour))
+	mustStoreAccess(t, repos, redes.ID(), ana.ID(), domain.AccessTypeDescarga, t0.Add(-time.Hour))
+	mustStoreAccess(t, repos, 99, ana.ID(), domain.AccessTypeLectura, t0.Add(2*time.Hour))
+	mustStoreAccess(t, repos, redes.ID(), ana.ID(), domain.AccessTypeLectura, t0.Add(2*time.Hour))
+	mustStoreAccess(t, repos, redes.ID(), beto.ID(), domain.AccessTypeLectura, t0)
+
+	return NewHistoryService(repos.Users, repos.Books, repos.Access), ana.ID()
+}
+
+// allHistory recorre todas las páginas y devuelve los IDs de los eventos.
+func allHistory(t *testing.T, s *HistoryService, userID domain.UserID, desc bool, limit int) []domain.AccessEventID {
+	t.Helper()
+	var (
+		ids   []domain.AccessEventID
+		after *domain.Cursor
+	)
+	for pages := 0; pages < 10; pages++ {
+		page, err := s.UserHistory(userID, domain.HistoryQuery{SortDesc: desc, Limit: limit, After: after})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page.Entries) > limit {
+			t.Fatalf("página de %d entradas, límite %d", len(page.Entries), limit)
+		}
+		for _, e := range page.Entries {
+			ids = append(ids, e.Event.ID())
+		}
+		if page.NextCursor == "" {
+			return ids
+		}
+		if after, err = domain.DecodeCursor(page.NextCursor); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Fatal("el cursor no termina")
+	return nil
+}
+
+func TestUserHistoryPaging(t *testing.T) {
+	s, ana := historyFixture(t)
+
+	asc := []domain.AccessEventID{3, 1, 2, 4, 5}
+	desc := []domain.AccessEventID{5, 4, 2, 1, 3}
+	for _, limit := range []int{1, 2, 3, 5, 50} {
+		if got := allHistory(t, s, ana, false, limit); !slices.Equal(got, asc) {
+			t.Errorf("cronológico de a %d = %v, se esperaba %v", limit, got, asc)
+		}
+		if got := allHistory(t, s, ana, true, limit); !slices.Equal(got, desc) {
+			t.Errorf("más nuevo primero de a %d = %v, se esperaba %v", limit, got, desc)
+		}
+	}
+
+	// Cada entrada trae su libro; el que ya no existe viene en nil.
+	page, _ := s.UserHistory(ana, domain.HistoryQuery{})
+	for _, e := range page.Entries {
+		if (e.Book == nil) != (e.Event.BookID() == 99) {
+			t.Fatalf("entrada %d con libro %v", e.Event.ID(), e.Book)
+		}
+		if e.Book != nil && e.Book.ID() != e.Event.BookID() {
+			t.Fatalf("entrada %d con el libro equivocado", e.Event.ID())
+		}
+	}
+}
+
+func TestUserHistoryRejects(t *testing.T) {
+	s, ana := historyFixture(t)
+
+	// Un cursor del orden cronológico no sirve para el inverso.
+	page, _ := s.UserHistory(ana, domain.HistoryQuery{Limit: 2})
+	cursor, err := domain.DecodeCursor(page.NextCursor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.UserHistory(ana, domain.HistoryQuery{Limit: 2, SortDesc: true, After: cursor})
+	var v *domain.ValidationError
+	if !errors.As(err, &v) || v.Fields[0].Field != "cursor" {
+		t.Fatalf("cursor de otro orden: %v", err)
+	}
+
+	// Un cursor de libros tampoco.
+	bookCursor := &domain.Cursor{Sort: domain.SortSpec("title", false), Key: "redes", ID: 1}
+	if _, err := s.UserHistory(ana, domain.HistoryQuery{After: bookCursor}); !errors.Is(err, domain.ErrValidation) {
+		t.Fatalf("cursor de libros: %v", err)
+	}
+
+	if _, err := s.UserHistory(42, domain.HistoryQuery{}); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("usuario inexistente: %v", err)
+	}
+	if _, err := s.BuildUserStats(42, nil); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("estadísticas de un usuario inexistente: %v", err)
+	}
+}
+
+func TestBuildUserStats(t *testing.T) {
+	s, ana := historyFixture(t)
+	quito, _ := time.LoadLocation("America/Guayaquil")
+
+	stats, err := s.BuildUserStats(ana, quito)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// El libro 99 cuenta en los totales, no en los favoritos.
+	if stats.Accesses != 5 || stats.Books != 3 {
+		t.Fatalf("totales = %d accesos, %d libros", stats.Accesses, stats.Books)
+	}
+	wantByType := map[domain.AccessType]int{
+		domain.AccessTypeLectura:  2, // Redes y 99
+		domain.AccessTypeApertura: 1,
+		domain.AccessTypeDescarga: 1,
+	}
+	for typ, n := range wantByType {
+		if stats.BooksByType[typ] != n {
+			t.Fatalf("libros por tipo = %v, se esperaba %v", stats.BooksByType, wantByType)
+		}
+	}
+	wantCategories := []domain.FacetCount{{Value: "Redes", Count: 3}, {Value: "Sistemas", Count: 1}}
+	if !slices.Equal(stats.FavoriteCategories, wantCategories) {
+		t.Fatalf("categorías = %v, se esperaba %v", stats.FavoriteCategories, wantCategories)
+	}
+	wantAuthors := []domain.FacetCount{{Value: "Tanenbaum", Count: 3}, {Value: "Silberschatz", Count: 1}}
+	if !slices.Equal(stats.FavoriteAuthors, wantAuthors) {
+		t.Fatalf("autores = %v, se esperaba %v", stats.FavoriteAuthors, wantAuthors)
+	}
+	if stats.ActiveDays != 2 {
+		t.Fatalf("días activos en Quito = %d, se esperaban 2", stats.ActiveDays)
+	}
+	first := time.Date(2026, 3, 4, 2, 30, 0, 0, time.UTC)
+	last := time.Date(2026, 3, 4, 5, 30, 0, 0, time.UTC)
+	if !stats.FirstAccess.Equal(first) || !stats.LastAccess.Equal(last) {
+		t.Fatalf("primer y último acceso = %v, %v", stats.FirstAccess, stats.LastAccess)
+	}
+
+	// En UTC todo cae el mismo día.
+	if stats, _ := s.BuildUserStats(ana, nil); stats.ActiveDays != 1 {
+		t.Fatalf("días activos en UTC = %d, se esperaba 1", stats.ActiveDays)
+	}
+}
+
+func TestBuildUserStatsWithoutAccesses(t *testing.T) {
+	repos := domain.Repositories{
+		Users:  db.NewInMemoryUserRepo(),
+		Books:  db.NewInMemoryBookRepo(),
+		Access: db.NewInMemoryAccessLogRepo(),
+	}
+	ana := mustCreateUser(t, repos, "Ana", "ana@example.com")
+	s := NewHistoryService(repos.Users, repos.Books, repos.Access)
+
+	stats, err := s.BuildUserStats(ana.ID(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Accesses != 0 || stats.ActiveDays != 0 || !stats.FirstAccess.IsZero() || len(stats.FavoriteCategories) != 0 {
+		t.Fatalf("estadísticas sin accesos = %+v", stats)
+	}
+	if page, _ := s.UserHistory(ana.ID(), domain.HistoryQuery{}); len(page.Entries) != 0 || page.NextCursor != "" {
+		t.Fatalf("historial vacío = %+v", page)
+	}
+}
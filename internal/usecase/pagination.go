@@ -0,0 +1,48 @@
+package usecase
+
+import "github.com/jfmg0509/sistema_libros_funcional_go/internal/domain"
+
+/*
+   ==========================================================
+   PÁGINAS DE RESULTADOS
+   ==========================================================
+
+   Los servicios piden al repositorio UN elemento más que el
+   tamaño de página: si llega, hay otra página y se arma el
+   cursor a partir del último elemento entregado.
+*/
+
+// BookPage es una página de libros.
+// NextCursor está vacío cuando no hay más resultados.
+// DidYouMean solo se completa si la búsqueda no encontró nada
+// y hay una corrección de título o autor que sí encuentra libros.
+type BookPage struct {
+	Books      []*domain.Book
+	NextCursor string
+	DidYouMean *domain.SpellingSuggestion
+}
+
+// UserPage es una página de usuarios.
+type UserPage struct {
+	Users      []*domain.User
+	NextCursor string
+}
+
+// pageLimit aplica el tamaño por defecto y el máximo de página.
+func pageLimit(limit int) int {
+	if limit <= 0 {
+		return domain.DefaultPageLimit
+	}
+	if limit > domain.MaxPageLimit {
+		return domain.MaxPageLimit
+	}
+	return limit
+}
+
+// checkCursorSort verifica que el cursor se haya generado con el mismo orden.
+func checkCursorSort(after *domain.Cursor, sort string) error {
+	if after != nil && after.Sort != sort {
+		return domain.NewValidationError("cursor", "el cursor pertenece a otro orden; vuelva a la primera página")
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package usecase
+
+import (
+	"sort"
+	"time"
+
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/domain"
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/hll"
+)
+
+/*
+   ==========================================================
+   LECTORES DISTINTOS (unique_readers)
+   ==========================================================
+
+   Cuántos usuarios DISTINTOS accedieron a un libro, a una
+   categoría o a todo el catálogo, en total y por día (UTC).
+
+   No se recorren los eventos: el repositorio guarda un sketch
+   HyperLogLog por (libro, día) que se actualiza en cada Store
+   (ver internal/hll). Aquí solo se COMBINAN los sketches del
+   alcance pedido. El resultado es una ESTIMACIÓN con un error
+   típico de ~1,6 % (exacta o casi exacta con pocos lectores).
+
+   El total NO es la suma de los días: un usuario que leyó el
+   lunes y el martes cuenta una vez en el total.
+
+   Los sketches son por día, así que el rango se amplía a días
+   UTC completos: entra todo día que toque [From, To), aunque
+   From o To caigan a mitad del día.
+*/
+
+// UniqueReadersQuery indica de qué alcance y rango contar lectores.
+type UniqueReadersQuery struct {
+	BookID     domain.BookID // 0 = todos los libros
+	CategoryTI string        // "" = todas las categorías
+	From       time.Time     // cero = desde el principio
+	To         time.Time     // cero = hasta hoy (rango [From, To), en días completos)
+}
+
+// DailyReaders son los lectores distintos de un día UTC.
+type DailyReaders struct {
+	Day           string // "2006-01-02"
+	UniqueReaders uint64
+}
+
+// UniqueReadersStats es el resultado de UniqueReaders.
+type UniqueReadersStats struct {
+	UniqueReaders uint64
+	Days          []DailyReaders // solo días con accesos, del más viejo al más nuevo
+}
+
+/*
+UniqueReaders estima los lectores distintos de un libro, una categoría
+o todo el catálogo.
+
+Pasos:
+1. Validar el rango y el alcance (libro o categoría, no ambos).
+2. Traducir el alcance a una lista de libros.
+3. Pedir los sketches por día y combinarlos en el total.
+*/
+func (s *BookService) UniqueReaders(q UniqueReadersQuery) (*UniqueReadersStats, error) {
+	// 1. Validar.
+	v := &domain.ValidationError{}
+	if !q.From.IsZero() && !q.To.IsZero() && !q.From.Before(q.To) {
+		v.Add("to", "to debe ser posterior a from")
+	}
+	if q.BookID != 0 && q.CategoryTI != "" {
+		v.Add("category", "usar book_id o category, no ambos")
+	}
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+
+	// 2. Alcance: una lista vacía significa TODOS los libros.
+	var bookIDs []domain.BookID
+	switch {
+	case q.BookID != 0:
+		if err := s.checkBookExists(q.BookID); err != nil {
+			return nil, err
+		}
+		bookIDs = []domain.BookID{q.BookID}
+
+	case q.CategoryTI != "":
+		books, err := s.bookRepo.SearchByFilters(domain.BookFilter{CategoryTI: q.CategoryTI})
+		if err != nil {
+			return nil, err
+		}
+		if len(books) == 0 {
+			return &UniqueReadersStats{Days: []DailyReaders{}}, nil
+		}
+		for _, b := range books {
+			bookIDs = append(bookIDs, b.ID())
+		}
+	}
+
+	// 3. Combinar.
+	sketches, err := s.accessLogRepo.ReaderSketches(bookIDs, q.From, q.To)
+	if err != nil {
+		return nil, err
+	}
+
+	days := make([]string, 0, len(sketches))
+	for day := range sketches {
+		days = append(days, day)
+	}
+	sort.Strings(days)
+
+	total := hll.New()
+	stats := &UniqueReadersStats{Days: make([]DailyReaders, 0, len(days))}
+	for _, day := range days {
+		sk := sketches[day]
+		stats.Days = append(stats.Days, DailyReaders{Day: day, UniqueReaders: sk.Estimate()})
+		if err := total.Merge(sk); err != nil {
+			return nil, err
+		}
+	}
+	stats.UniqueReaders = total.Estimate()
+	return stats, nil
+}
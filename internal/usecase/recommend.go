@@ -0,0 +1,125 @@
+package usecase
+
+import "github.com/jfmg0509/sistema_libros_funcional_go/internal/domain"
+
+/*
+   ==========================================================
+   RECOMENDACIONES
+   ==========================================================
+
+   El Recommender (ver domain/recommend.go) devuelve IDs con
+   puntaje a partir del registro de accesos. El servicio
+   completa cada ID con su libro y descarta los que ya no
+   existen o están ARCHIVADOS: un libro archivado no se
+   recomienda aunque se haya consultado mucho.
+
+   Un libro NUEVO no tiene accesos: para él RelatedBooks usa
+   la similitud por metadatos del índice de búsqueda
+   (SimilarBooks, ver search/similar.go).
+*/
+
+// Límites de las listas de recomendaciones.
+const (
+	defaultRecommendLimit = 10
+	maxRecommendLimit     = 50
+)
+
+// BookRecommendation es un libro recomendado con su puntaje.
+type BookRecommendation struct {
+	Book  *domain.Book
+	Score float64
+}
+
+// RebuildRecommendations reproduce el registro de accesos en el
+// Recommender. Se llama al arrancar, igual que RebuildSearchIndex.
+func (s *BookService) RebuildRecommendations() error {
+	events, err := s.accessLogRepo.ListAll()
+	if err != nil {
+		return err
+	}
+	for _, ev := range events {
+		s.recommender.ObserveAccess(ev)
+	}
+	return nil
+}
+
+// RelatedBooks devuelve los libros que consultaron los mismos lectores
+// que consultaron el libro indicado ("otros lectores también abrieron").
+// Si nadie lo consultó todavía, devuelve los parecidos por metadatos.
+func (s *BookService) RelatedBooks(id domain.BookID, limit int) ([]BookRecommendation, error) {
+	if err := s.checkBookExists(id); err != nil {
+		return nil, err
+	}
+	scored := s.recommender.Related(id)
+	if len(scored) == 0 {
+		scored = s.searchIndex.Similar(id, 0)
+	}
+	return s.completeRecommendations(scored, limit)
+}
+
+// SimilarBooks devuelve los libros activos más parecidos al indicado
+// por título, tags, categoría, autor y año ("más como este").
+func (s *BookService) SimilarBooks(id domain.BookID, limit int) ([]BookRecommendation, error) {
+	if err := s.checkBookExists(id); err != nil {
+		return nil, err
+	}
+	return s.completeRecommendations(s.searchIndex.Similar(id, recommendLimit(limit)), limit)
+}
+
+// checkBookExists devuelve ErrNotFound si el libro no existe.
+func (s *BookService) checkBookExists(id domain.BookID) error {
+	book, err := s.bookRepo.FindByID(id)
+	if err != nil {
+		return err
+	}
+	if book == nil {
+		return domain.NotFound("libro no encontrado")
+	}
+	return nil
+}
+
+// RecommendBooks devuelve libros que el usuario no consultó, parecidos
+// a los que sí consultó.
+func (s *BookService) RecommendBooks(userID domain.UserID, limit int) ([]BookRecommendation, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, domain.NotFound("usuario no encontrado")
+	}
+	return s.completeRecommendations(s.recommender.ForUser(userID), limit)
+}
+
+// recommendLimit aplica el valor por defecto y el máximo a limit.
+func recommendLimit(limit int) int {
+	if limit <= 0 {
+		return defaultRecommendLimit
+	}
+	if limit > maxRecommendLimit {
+		return maxRecommendLimit
+	}
+	return limit
+}
+
+// completeRecommendations busca los libros de cada puntaje y se queda
+// con los primeros limit que siguen activos.
+func (s *BookService) completeRecommendations(scored []domain.ScoredBook, limit int) ([]BookRecommendation, error) {
+	limit = recommendLimit(limit)
+
+	result := make([]BookRecommendation, 0, limit)
+	for _, sb := range scored {
+		if len(result) == limit {
+			break
+		}
+		book, err := s.bookRepo.FindByID(sb.BookID)
+		if err != nil {
+			return nil, err
+		}
+		if book == nil || !book.Active() {
+			continue
+		}
+		result = append(result, BookRecommendation{Book: book, Score: sb.Score})
+	}
+	return result, nil
+}
@@ -0,0 +1,120 @@
+package usecase
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/domain"
+)
+
+/*
+   ==========================================================
+   AUTOCOMPLETADO POR POPULARIDAD
+   ==========================================================
+
+   El repositorio busca las completaciones de un prefijo
+   (títulos, autores y tags) y las ORDENA con la función de
+   ranking que le pasa el servicio: la popularidad, es decir la
+   suma de accesos registrados de los libros que las tienen.
+   Se puntúan TODAS las completaciones del prefijo, no solo
+   las más cortas.
+
+   Los accesos por libro se cuentan en memoria (popularity):
+   se cargan del registro de accesos al arrancar
+   (RebuildPopularity) y se suman en cada RecordAccess (es un
+   domain.AccessObserver), así
+   sugerir no consulta el registro de accesos en cada tecla.
+*/
+
+// Límites del autocompletado.
+const (
+	defaultSuggestLimit = 10
+	maxSuggestLimit     = 50
+)
+
+// BookSuggestion es una completación con su popularidad.
+type BookSuggestion struct {
+	Kind       domain.SuggestionKind
+	Text       string
+	Popularity int // accesos de los libros que la tienen
+	Books      int // cantidad de libros que la tienen
+}
+
+// popularity cuenta los accesos de cada libro.
+type popularity struct {
+	mu     sync.RWMutex
+	counts map[domain.BookID]int
+}
+
+func newPopularity() *popularity {
+	return &popularity{counts: make(map[domain.BookID]int)}
+}
+
+// reset reemplaza todos los conteos.
+func (p *popularity) reset(counts map[domain.BookID]int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.counts = counts
+}
+
+// ObserveAccess suma un acceso al libro (implementa domain.AccessObserver).
+func (p *popularity) ObserveAccess(event *domain.AccessEvent) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.counts[event.BookID()]++
+}
+
+// sum devuelve el total de accesos de varios libros.
+func (p *popularity) sum(ids []domain.BookID) int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	total := 0
+	for _, id := range ids {
+		total += p.counts[id]
+	}
+	return total
+}
+
+// RebuildPopularity carga los accesos por libro desde el registro.
+// Se llama al arrancar, igual que RebuildSearchIndex.
+func (s *BookService) RebuildPopularity() error {
+	counts, err := s.accessLogRepo.CountByBook()
+	if err != nil {
+		return err
+	}
+	s.popularity.reset(counts)
+	return nil
+}
+
+/*
+SuggestBooks devuelve completaciones de títulos, autores y tags para
+lo que el lector lleva escrito, de la más popular a la menos popular
+(a igual popularidad, la de más libros y luego la más corta).
+*/
+func (s *BookService) SuggestBooks(prefix string, limit int) ([]BookSuggestion, error) {
+	if strings.TrimSpace(prefix) == "" {
+		return nil, domain.NewValidationError("prefix", "el prefijo no puede estar vacío")
+	}
+	if limit <= 0 {
+		limit = defaultSuggestLimit
+	}
+	if limit > maxSuggestLimit {
+		limit = maxSuggestLimit
+	}
+
+	candidates, err := s.bookRepo.SuggestPrefix(prefix, limit, s.popularity.sum)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]BookSuggestion, 0, len(candidates))
+	for _, c := range candidates {
+		result = append(result, BookSuggestion{
+			Kind:       c.Kind,
+			Text:       c.Text,
+			Popularity: c.Score,
+			Books:      len(c.BookIDs),
+		})
+	}
+	return result, nil
+}
@@ -0,0 +1,47 @@
+package usecase
+
+import (
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/domain"
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/textnorm"
+)
+
+// textCounter cuenta por texto (categoría, autor, tag) sin distinguir
+// acentos ni mayúsculas; muestra la primera escritura encontrada.
+// Las cantidades pueden restarse: un texto que llega a cero se olvida.
+type textCounter struct {
+	display map[string]string
+	counts  map[string]int
+}
+
+func newTextCounter() *textCounter {
+	return &textCounter{display: make(map[string]string), counts: make(map[string]int)}
+}
+
+// add suma n (o resta, si n es negativo) al texto.
+func (c *textCounter) add(value string, n int) {
+	key := textnorm.FoldKey(value)
+	if key == "" {
+		return
+	}
+	if _, ok := c.display[key]; !ok {
+		c.display[key] = value
+	}
+	c.counts[key] += n
+	if c.counts[key] <= 0 {
+		delete(c.counts, key)
+		delete(c.display, key)
+	}
+}
+
+// top devuelve los limit textos con más cantidad (el texto desempata).
+func (c *textCounter) top(limit int) []domain.FacetCount {
+	counts := make([]domain.FacetCount, 0, len(c.counts))
+	for key, n := range c.counts {
+		counts = append(counts, domain.FacetCount{Value: c.display[key], Count: n})
+	}
+	counts = domain.SortFacetCounts(counts)
+	if len(counts) > limit {
+		counts = counts[:limit]
+	}
+	return counts
+}
@@ -0,0 +1,141 @@
+package usecase
+
+import (
+	"time"
+
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/domain"
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/textnorm"
+)
+
+/*
+   ==========================================================
+   ESTADÍSTICAS DE ACCESOS POR PERÍODO
+   ==========================================================
+
+   BuildAccessStatsByBook da un solo total histórico. Aquí los
+   accesos de un rango [From, To) se reparten en cubetas (hora,
+   día, semana o mes; ver domain/timeseries.go) y se cuentan por
+   tipo de acceso.
+
+   Alcance: un libro (BookID), una categoría (CategoryTI) o todo
+   el catálogo (ninguno de los dos).
+
+   La serie trae TODAS las cubetas del rango, también las que no
+   tienen accesos, para poder graficarla tal cual. La primera
+   puede empezar antes de From (si From no cae justo al inicio
+   de una cubeta), pero solo cuenta accesos desde From.
+*/
+
+// AccessSeriesQuery indica qué serie de tiempo calcular.
+type AccessSeriesQuery struct {
+	BookID     domain.BookID // 0 = todos los libros
+	CategoryTI string        // "" = todas las categorías
+	From       time.Time
+	To         time.Time
+	Interval   domain.StatsInterval
+	Location   *time.Location // nil = UTC
+}
+
+// AccessSeriesBucket son los accesos de un período.
+type AccessSeriesBucket struct {
+	Start  time.Time // en la zona horaria pedida
+	Counts map[domain.AccessType]int
+	Total  int
+}
+
+// newAccessTypeCounts crea el conteo con todos los tipos en cero.
+func newAccessTypeCounts() map[domain.AccessType]int {
+	counts := make(map[domain.AccessType]int)
+	for _, t := range domain.AccessTypes() {
+		counts[t] = 0
+	}
+	return counts
+}
+
+/*
+AccessTimeSeries cuenta los accesos por tipo en cada período del rango.
+
+Pasos:
+1. Validar el rango y el alcance (libro o categoría, no ambos).
+2. Armar las cubetas vacías desde From hasta To.
+3. Sumar cada evento del rango en su cubeta (si es del alcance pedido).
+*/
+func (s *BookService) AccessTimeSeries(q AccessSeriesQuery) ([]AccessSeriesBucket, error) {
+	// 1. Validar.
+	v := &domain.ValidationError{}
+	if q.From.IsZero() {
+		v.Add("from", "from es obligatorio")
+	}
+	if q.To.IsZero() {
+		v.Add("to", "to es obligatorio")
+	}
+	if !q.From.IsZero() && !q.To.IsZero() && !q.From.Before(q.To) {
+		v.Add("to", "to debe ser posterior a from")
+	}
+	if q.BookID != 0 && q.CategoryTI != "" {
+		v.Add("category", "usar book_id o category, no ambos")
+	}
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+	loc := q.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	if q.BookID != 0 {
+		book, err := s.bookRepo.FindByID(q.BookID)
+		if err != nil {
+			return nil, err
+		}
+		if book == nil {
+			return nil, domain.NotFound("libro no encontrado")
+		}
+	}
+
+	// 2. Cubetas vacías; index lleva del inicio (Unix) a la posición.
+	buckets := make([]AccessSeriesBucket, 0)
+	index := make(map[int64]int)
+	for start := q.Interval.BucketStart(q.From, loc); start.Before(q.To); start = q.Interval.NextBucket(start) {
+		if len(buckets) == domain.MaxSeriesBuckets {
+			return nil, domain.NewValidationError("interval", "el rango tiene demasiados períodos para ese interval")
+		}
+		index[start.Unix()] = len(buckets)
+		buckets = append(buckets, AccessSeriesBucket{Start: start, Counts: newAccessTypeCounts()})
+	}
+
+	// 3. Contar.
+	events, err := s.accessLogRepo.ListBetween(q.From, q.To)
+	if err != nil {
+		return nil, err
+	}
+	category := textnorm.FoldKey(q.CategoryTI)
+	inCategory := make(map[domain.BookID]bool) // libros ya consultados
+	for _, ev := range events {
+		if q.BookID != 0 && ev.BookID() != q.BookID {
+			continue
+		}
+		if category != "" {
+			match, seen := inCategory[ev.BookID()]
+			if !seen {
+				book, err := s.bookRepo.FindByID(ev.BookID())
+				if err != nil {
+					return nil, err
+				}
+				match = book != nil && textnorm.FoldKey(book.CategoryTI()) == category
+				inCategory[ev.BookID()] = match
+			}
+			if !match {
+				continue
+			}
+		}
+
+		i, ok := index[q.Interval.BucketStart(ev.Timestamp(), loc).Unix()]
+		if !ok {
+			continue
+		}
+		buckets[i].Counts[ev.AccessType()]++
+		buckets[i].Total++
+	}
+	return buckets, nil
+}
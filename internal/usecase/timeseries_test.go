@@ -0,0 +1,139 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/domain"
+)
+
+// mustStoreAccess guarda un acceso con la hora indicada.
+func mustStoreAccess(t *testing.T, repos domain.Repositories, bookID domain.BookID, userID domain.UserID, typ domain.AccessType, ts time.Time) {
+	t.Helper()
+	if err := repos.Access.Store(domain.RestoreAccessEvent(0, bookID, userID, typ, ts)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func seriesTotals(buckets []AccessSeriesBucket) []int {
+	totals := make([]int, 0, len(buckets))
+	for _, b := range buckets {
+		totals = append(totals, b.Total)
+	}
+	return totals
+}
+
+func TestAccessTimeSeriesInZone(t *testing.T) {
+	s, repos := newTestBookService(t)
+	redes := mustCreateBook(t, s, "Redes", "Redes")
+	so := mustCreateBook(t, s, "Sistemas", "Sistemas")
+	quito, _ := time.LoadLocation("America/Guayaquil")
+
+	// 03:30 UTC del 4 es el 3 a las 22:30 en Quito.
+	mustStoreAccess(t, repos, redes.ID(), 1, domain.AccessTypeLectura, time.Date(2026, 3, 4, 3, 30, 0, 0, time.UTC))
+	mustStoreAccess(t, repos, redes.ID(), 1, domain.AccessTypeDescarga, time.Date(2026, 3, 4, 5, 0, 0, 0, time.UTC))
+	mustStoreAccess(t, repos, so.ID(), 1, domain.AccessTypeLectura, time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC))
+	// Fuera del rango.
+	mustStoreAccess(t, repos, redes.ID(), 1, domain.AccessTypeLectura, time.Date(2026, 3, 6, 12, 0, 0, 0, time.UTC))
+
+	q := AccessSeriesQuery{
+		From:     time.Date(2026, 3, 3, 0, 0, 0, 0, quito),
+		To:       time.Date(2026, 3, 5, 0, 0, 0, 0, quito),
+		Interval: domain.StatsIntervalDay,
+		Location: quito,
+	}
+	buckets, err := s.AccessTimeSeries(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := seriesTotals(buckets); len(got) != 2 || got[0] != 1 || got[1] != 2 {
+		t.Fatalf("totales por día en Quito = %v, se esperaba [1 2]", got)
+	}
+	if !buckets[0].Start.Equal(q.From) || buckets[0].Start.Location() != quito {
+		t.Fatalf("la primera cubeta empieza %v", buckets[0].Start)
+	}
+	if c := buckets[1].Counts; c[domain.AccessTypeDescarga] != 1 || c[domain.AccessTypeLectura] != 1 || c[domain.AccessTypeApertura] != 0 {
+		t.Fatalf("conteo por tipo = %v", c)
+	}
+
+	// En UTC, el mismo rango reparte distinto.
+	q.Location = nil
+	q.From, q.To = time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC)
+	buckets, _ = s.AccessTimeSeries(q)
+	if got := seriesTotals(buckets); len(got) != 2 || got[0] != 0 || got[1] != 3 {
+		t.Fatalf("totales por día en UTC = %v, se esperaba [0 3]", got)
+	}
+
+	// Alcance: un libro o una categoría (plegada).
+	q.BookID = so.ID()
+	buckets, _ = s.AccessTimeSeries(q)
+	if got := seriesTotals(buckets); got[1] != 1 {
+		t.Fatalf("totales del libro = %v", got)
+	}
+	q.BookID, q.CategoryTI = 0, " REDES "
+	buckets, _ = s.AccessTimeSeries(q)
+	if got := seriesTotals(buckets); got[1] != 2 {
+		t.Fatalf("totales de la categoría = %v", got)
+	}
+}
+
+// Al atrasar el reloj la 1:00 se repite: son dos cubetas por hora
+// distintas, y el día tiene 25.
+func TestAccessTimeSeriesAcrossDST(t *testing.T) {
+	s, repos := newTestBookService(t)
+	book := mustCreateBook(t, s, "Redes", "Redes")
+	newYork, _ := time.LoadLocation("America/New_York")
+
+	mustStoreAccess(t, repos, book.ID(), 1, domain.AccessTypeLectura, time.Date(2026, 11, 1, 5, 30, 0, 0, time.UTC)) // 1:30 EDT
+	mustStoreAccess(t, repos, book.ID(), 1, domain.AccessTypeLectura, time.Date(2026, 11, 1, 6, 30, 0, 0, time.UTC)) // 1:30 EST
+
+	from := time.Date(2026, 11, 1, 0, 0, 0, 0, newYork)
+	buckets, err := s.AccessTimeSeries(AccessSeriesQuery{
+		From: from, To: from.AddDate(0, 0, 1), Interval: domain.StatsIntervalHour, Location: newYork,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(buckets) != 25 {
+		t.Fatalf("el día del cambio tiene %d horas, se esperaban 25", len(buckets))
+	}
+	if buckets[1].Total != 1 || buckets[2].Total != 1 || buckets[1].Start.Hour() != 1 || buckets[2].Start.Hour() != 1 {
+		t.Fatalf("la 1:00 repetida: %v (%d) y %v (%d)", buckets[1].Start, buckets[1].Total, buckets[2].Start, buckets[2].Total)
+	}
+}
+
+func TestAccessTimeSeriesValidation(t *testing.T) {
+	s, _ := newTestBookService(t)
+	mustCreateBook(t, s, "Redes", "Redes")
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// Justo MaxSeriesBuckets cubetas se aceptan; una más, no.
+	q := AccessSeriesQuery{From: from, To: from.Add(domain.MaxSeriesBuckets * time.Hour), Interval: domain.StatsIntervalHour}
+	if buckets, err := s.AccessTimeSeries(q); err != nil || len(buckets) != domain.MaxSeriesBuckets {
+		t.Fatalf("%d cubetas: %v", len(buckets), err)
+	}
+	q.To = q.To.Add(time.Minute)
+	_, err := s.AccessTimeSeries(q)
+	var v *domain.ValidationError
+	if !errors.As(err, &v) || v.Fields[0].Field != "interval" {
+		t.Fatalf("una cubeta de más: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		q    AccessSeriesQuery
+		want error
+	}{
+		{"sin rango", AccessSeriesQuery{Interval: domain.StatsIntervalDay}, domain.ErrValidation},
+		{"to antes de from", AccessSeriesQuery{From: from, To: from.Add(-time.Hour), Interval: domain.StatsIntervalDay}, domain.ErrValidation},
+		{"libro y categoría", AccessSeriesQuery{From: from, To: from.Add(time.Hour), BookID: 1, CategoryTI: "Redes"}, domain.ErrValidation},
+		{"libro inexistente", AccessSeriesQuery{From: from, To: from.Add(time.Hour), BookID: 99, Interval: domain.StatsIntervalDay}, domain.ErrNotFound},
+	}
+	for _, tt := range tests {
+		if _, err := s.AccessTimeSeries(tt.q); !errors.Is(err, tt.want) {
+			t.Errorf("%s: %v, se esperaba %v", tt.name, err, tt.want)
+		}
+	}
+}
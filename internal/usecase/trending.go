@@ -0,0 +1,193 @@
+package usecase
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/domain"
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/textnorm"
+)
+
+/*
+   ==========================================================
+   LIBROS EN TENDENCIA
+   ==========================================================
+
+   BuildAccessStatsByBook cuenta TODA la historia de un libro.
+   Las tendencias cuentan los accesos de TODOS los libros en
+   una ventana reciente (1h, 24h o 7d; ver domain/trending.go).
+
+   Cada libro guarda sus accesos agrupados por MINUTO, de más
+   viejo a más nuevo:
+
+	libro 3 → [ (10:02, 4) (10:05, 1) (11:40, 2) ]
+
+   Contar una ventana es sumar los minutos que caen dentro; los
+   minutos más viejos que la ventana más larga (7 días) se
+   descartan al pasar. Así la memoria depende de la actividad
+   reciente, no del tamaño del registro.
+
+   Los contadores se cargan del registro de accesos al arrancar
+   (RebuildTrending) y se actualizan en cada RecordAccess (es
+   un domain.AccessObserver).
+*/
+
+// Límites de la lista de tendencias.
+const (
+	defaultTrendingLimit = 10
+	maxTrendingLimit     = 50
+)
+
+// TrendingBook es un libro con sus accesos en la ventana.
+type TrendingBook struct {
+	Book     *domain.Book
+	Accesses int
+}
+
+// trendBucket son los accesos de un libro en un minuto.
+type trendBucket struct {
+	minute int64 // minutos desde 1970 (Unix / 60)
+	count  int
+}
+
+// trendCount es el total de un libro en una ventana.
+type trendCount struct {
+	bookID domain.BookID
+	count  int
+}
+
+// trending guarda los minutos recientes de cada libro.
+type trending struct {
+	mu      sync.Mutex
+	buckets map[domain.BookID][]trendBucket
+	now     func() time.Time // reloj (time.Now; los tests lo fijan)
+}
+
+func newTrending() *trending {
+	return &trending{buckets: make(map[domain.BookID][]trendBucket), now: time.Now}
+}
+
+// unixMinute convierte un instante en su minuto.
+func unixMinute(t time.Time) int64 {
+	return t.Unix() / 60
+}
+
+// ObserveAccess suma el acceso al minuto en que ocurrió
+// (implementa domain.AccessObserver).
+func (t *trending) ObserveAccess(event *domain.AccessEvent) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	oldest := unixMinute(t.now().Add(-domain.MaxTrendWindow))
+	minute := unixMinute(event.Timestamp())
+	if minute < oldest {
+		return // demasiado viejo para cualquier ventana
+	}
+
+	id := event.BookID()
+	list := t.buckets[id]
+
+	// Casi siempre el acceso es del último minuto; si llega
+	// desordenado, se inserta en su lugar.
+	i := sort.Search(len(list), func(i int) bool { return list[i].minute >= minute })
+	if i < len(list) && list[i].minute == minute {
+		list[i].count++
+	} else {
+		list = append(list, trendBucket{})
+		copy(list[i+1:], list[i:])
+		list[i] = trendBucket{minute: minute, count: 1}
+	}
+	t.buckets[id] = expireBuckets(list, oldest)
+}
+
+// expireBuckets quita los minutos anteriores a oldest.
+func expireBuckets(list []trendBucket, oldest int64) []trendBucket {
+	i := 0
+	for i < len(list) && list[i].minute < oldest {
+		i++
+	}
+	return list[i:]
+}
+
+// top devuelve los libros con accesos en la ventana que termina en now,
+// del que tiene más al que tiene menos (a igual cantidad, por ID).
+func (t *trending) top(window time.Duration, now time.Time) []trendCount {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	oldest := unixMinute(now.Add(-domain.MaxTrendWindow))
+	from := unixMinute(now.Add(-window))
+
+	result := make([]trendCount, 0, len(t.buckets))
+	for id, list := range t.buckets {
+		list = expireBuckets(list, oldest)
+		if len(list) == 0 {
+			delete(t.buckets, id)
+			continue
+		}
+		t.buckets[id] = list
+
+		total := 0
+		for i := len(list) - 1; i >= 0 && list[i].minute >= from; i-- {
+			total += list[i].count
+		}
+		if total > 0 {
+			result = append(result, trendCount{bookID: id, count: total})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].count != result[j].count {
+			return result[i].count > result[j].count
+		}
+		return result[i].bookID < result[j].bookID
+	})
+	return result
+}
+
+// RebuildTrending carga los accesos de la última semana desde el registro.
+// Se llama al arrancar, igual que RebuildPopularity.
+func (s *BookService) RebuildTrending() error {
+	events, err := s.accessLogRepo.ListAll()
+	if err != nil {
+		return err
+	}
+	for _, ev := range events {
+		s.trending.ObserveAccess(ev)
+	}
+	return nil
+}
+
+/*
+TrendingBooks devuelve los libros activos con más accesos en la
+ventana, opcionalmente solo de una categoría (sin distinguir
+mayúsculas ni acentos).
+*/
+func (s *BookService) TrendingBooks(window domain.TrendWindow, categoryTI string, limit int) ([]TrendingBook, error) {
+	if limit <= 0 {
+		limit = defaultTrendingLimit
+	}
+	if limit > maxTrendingLimit {
+		limit = maxTrendingLimit
+	}
+	category := textnorm.FoldKey(categoryTI)
+
+	result := make([]TrendingBook, 0, limit)
+	for _, tc := range s.trending.top(window.Duration(), s.trending.now()) {
+		if len(result) == limit {
+			break
+		}
+		book, err := s.bookRepo.FindByID(tc.bookID)
+		if err != nil {
+			return nil, err
+		}
+		if book == nil || !book.Active() {
+			continue
+		}
+		if category != "" && textnorm.FoldKey(book.CategoryTI()) != category {
+			continue
+		}
+		result = append(result, TrendingBook{Book: book, Accesses: tc.count})
+	}
+	return result, nil
+}
@@ -0,0 +1,126 @@
+package usecase
+
+import (
+	"slices"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/jfmg0509/sistema_libros_funcional_go/internal/domain"
+)
+
+// trendingSummary resume la lista como "título:accesos".
+func trendingSummary(list []TrendingBook) []string {
+	result := make([]string, 0, len(list))
+	for _, tb := range list {
+		result = append(result, tb.Book.Title()+":"+strconv.Itoa(tb.Accesses))
+	}
+	return result
+}
+
+func TestTrendingWindows(t *testing.T) {
+	s, repos := newTestBookService(t)
+	a := mustCreateBook(t, s, "A", "Redes")
+	b := mustCreateBook(t, s, "B", "Redes")
+	c := mustCreateBook(t, s, "C", "Sistemas")
+	d := mustCreateBook(t, s, "D", "Redes")
+	if _, err := s.ArchiveBook(d.ID(), AnyVersion); err != nil {
+		t.Fatal(err)
+	}
+
+	// Reloj fijo a mitad de un minuto, cerca del real: RecordAccess
+	// guarda la hora real.
+	now := time.Now().UTC().Truncate(time.Minute).Add(30 * time.Second)
+	s.trending.now = func() time.Time { return now }
+	ago := func(d time.Duration) time.Time { return now.Add(-d) }
+	const day = 24 * time.Hour
+
+	for _, ev := range []struct {
+		book *domain.Book
+		at   time.Time
+	}{
+		// Cada ventana cuenta desde el minuto de now - ventana, inclusive.
+		{a, ago(time.Minute)},
+		{a, ago(time.Hour)},
+		{a, ago(time.Hour + time.Minute)},
+		{b, ago(day)},
+		{b, ago(day + time.Minute)},
+		{b, ago(day + time.Minute)},
+		{c, ago(30 * time.Minute)},
+		{c, ago(7 * day)},
+		{c, ago(7*day + time.Minute)}, // más viejo que cualquier ventana
+		{d, ago(time.Minute)},         // archivado: no aparece
+		{d, ago(time.Minute)},
+	} {
+		mustStoreAccess(t, repos, ev.book.ID(), 1, domain.AccessTypeLectura, ev.at)
+	}
+	if err := s.RebuildTrending(); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(s.trending.buckets[c.ID()]); n != 2 {
+		t.Fatalf("C cargó %d minutos, el de hace más de 7 días no debería cargarse", n)
+	}
+
+	check := func(window domain.TrendWindow, category string, want ...string) {
+		t.Helper()
+		list, err := s.TrendingBooks(window, category, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := trendingSummary(list); !slices.Equal(got, want) {
+			t.Fatalf("%s %q = %v, se esperaba %v", window, category, got, want)
+		}
+	}
+	check(domain.TrendWindowHour, "", "A:2", "C:1")
+	check(domain.TrendWindowDay, "", "A:3", "B:1", "C:1")
+	check(domain.TrendWindowWeek, "", "A:3", "B:3", "C:2")
+
+	// Filtro por categoría, sin distinguir mayúsculas ni espacios.
+	check(domain.TrendWindowWeek, " REDES ", "A:3", "B:3")
+	check(domain.TrendWindowWeek, "sistemas", "C:2")
+	check(domain.TrendWindowWeek, "Cocina")
+
+	// Un minuto después, los accesos del borde salen de cada ventana.
+	now = now.Add(time.Minute)
+	check(domain.TrendWindowHour, "", "A:1", "C:1")
+	check(domain.TrendWindowDay, "", "A:3", "C:1")
+	check(domain.TrendWindowWeek, "", "A:3", "B:3", "C:1")
+	if n := len(s.trending.buckets[c.ID()]); n != 1 {
+		t.Fatalf("C conserva %d minutos, el vencido debería descartarse", n)
+	}
+
+	// Un acceso nuevo entra en las tres ventanas.
+	if _, err := s.RecordAccess(b.ID(), mustCreateUser(t, repos, "Ana", "ana@example.com").ID(), domain.AccessTypeLectura); err != nil {
+		t.Fatal(err)
+	}
+	list, _ := s.TrendingBooks(domain.TrendWindowHour, "", 0)
+	if got := trendingSummary(list); !slices.Contains(got, "B:1") {
+		t.Fatalf("el acceso recién registrado no cuenta en la última hora: %v", got)
+	}
+
+	// Pasada la semana no queda nada, ni en memoria.
+	now = now.Add(8 * day)
+	check(domain.TrendWindowWeek, "")
+	if len(s.trending.buckets) != 0 {
+		t.Fatalf("quedaron minutos vencidos de %d libros", len(s.trending.buckets))
+	}
+}
+
+// Los accesos desordenados se insertan en su minuto.
+func TestTrendingOutOfOrder(t *testing.T) {
+	tr := newTrending()
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	tr.now = func() time.Time { return now }
+
+	for _, offset := range []time.Duration{5, 1, 3, 1, 5, 2} {
+		tr.ObserveAccess(domain.RestoreAccessEvent(0, 1, 1, domain.AccessTypeLectura, now.Add(-offset*time.Minute)))
+	}
+	list := tr.buckets[1]
+	if !sort.SliceIsSorted(list, func(i, j int) bool { return list[i].minute < list[j].minute }) || len(list) != 4 {
+		t.Fatalf("minutos = %v", list)
+	}
+	if top := tr.top(time.Hour, now); len(top) != 1 || top[0].count != 6 {
+		t.Fatalf("top = %v", top)
+	}
+}
@@ -1,8 +1,6 @@
 package usecase
 
 import (
-	"fmt"
-
 	"github.com/jfmg0509/sistema_libros_funcional_go/internal/domain"
 )
 
@@ -14,21 +12,35 @@ import (
    Esta estructura representa la "capa de negocio" para usuarios.
    No sabe cómo se guardan los datos (eso lo hace el repositorio).
    Solo sabe QUÉ reglas aplicar al registrar o listar usuarios.
+
+   Las lecturas usan el repositorio directamente; las escrituras
+   (que primero verifican y luego guardan) corren dentro de una
+   unidad de trabajo para que nadie se meta en el medio.
 */
 
 // UserService contiene un repositorio que cumple la interfaz UserRepository.
 type UserService struct {
-	repo domain.UserRepository
+	repo      domain.UserRepository
+	uow       domain.UnitOfWork
+	observers []domain.UserObserver
 }
 
 // NewUserService es el CONSTRUCTOR del servicio de usuarios.
-// Recibe un objeto que implemente domain.UserRepository (por ejemplo, el repositorio en memoria).
-func NewUserService(repo domain.UserRepository) *UserService {
+// Recibe un objeto que implemente domain.UserRepository (por ejemplo, el repositorio en memoria)
+// y la unidad de trabajo con la que se hacen las escrituras.
+func NewUserService(repo domain.UserRepository, uow domain.UnitOfWork) *UserService {
 	return &UserService{
 		repo: repo,
+		uow:  uow,
 	}
 }
 
+// AddUserObserver suma un observador que recibirá cada usuario registrado.
+// Se llama al armar los servicios, antes de atender peticiones.
+func (s *UserService) AddUserObserver(o domain.UserObserver) {
+	s.observers = append(s.observers, o)
+}
+
 /*
 RegisterUser registra un nuevo usuario en el sistema.
 
@@ -37,31 +49,45 @@ Pasos:
 2. Si existe, devuelve un error controlado.
 3. Si no existe, usa el CONSTRUCTOR de dominio (NewUser) para crear el usuario.
 4. Pide al repositorio que lo guarde.
-5. Devuelve el usuario creado.
+5. Avisa a los observadores (UserObserver) y devuelve el usuario creado.
 */
 func (s *UserService) RegisterUser(name, email string, role domain.Role) (*domain.User, error) {
-	// 1. Verificar si ya existe un usuario con ese email.
-	existing, err := s.repo.FindByEmail(email)
-	if err != nil {
-		return nil, err
-	}
-	if existing != nil {
-		// Mensaje que viste cuando probaste con curl.
-		return nil, fmt.Errorf("ya existe un usuario con ese email")
-	}
+	var user *domain.User
 
-	// 2. Crear el usuario usando el constructor del dominio.
-	user, err := domain.NewUser(name, email, role)
-	if err != nil {
-		return nil, err
-	}
+	err := s.uow.Do(func(repos domain.Repositories) error {
+		// 1. Verificar si ya existe un usuario con ese email.
+		existing, err := repos.Users.FindByEmail(email)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			// Mensaje que viste cuando probaste con curl (ahora como conflicto → 409).
+			return domain.Conflict("ya existe un usuario con ese email")
+		}
+
+		// 2. Crear el usuario usando el constructor del dominio.
+		user, err = domain.NewUser(name, email, role)
+		if err != nil {
+			return err
+		}
 
-	// 3. Guardar el usuario en el repositorio.
-	if err := s.repo.Create(user); err != nil {
+		// 3. Guardar el usuario en el repositorio.
+		if err := repos.Users.Create(user); err != nil {
+			return err
+		}
+
+		// 4. Avisar a los observadores (después de confirmar, en orden).
+		repos.AfterCommit(func() {
+			for _, o := range s.observers {
+				o.ObserveUser(user)
+			}
+		})
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	// 4. Devolver el usuario creado.
 	return user, nil
 }
 
@@ -76,13 +102,129 @@ func (s *UserService) ListUsers() ([]*domain.User, error) {
 	return s.repo.ListAll()
 }
 
+/*
+SearchUsers devuelve una página de usuarios en el orden pedido,
+con el cursor de la página siguiente si hay más resultados.
+*/
+func (s *UserService) SearchUsers(query domain.UserQuery) (UserPage, error) {
+	sortSpec := domain.SortSpec(string(query.SortBy), query.SortDesc)
+	if err := checkCursorSort(query.After, sortSpec); err != nil {
+		return UserPage{}, err
+	}
+
+	limit := pageLimit(query.Limit)
+	query.Limit = limit + 1
+
+	users, err := s.repo.Search(query)
+	if err != nil {
+		return UserPage{}, err
+	}
+
+	page := UserPage{Users: users}
+	if len(users) > limit {
+		page.Users = users[:limit]
+		last := page.Users[limit-1]
+		page.NextCursor = domain.EncodeCursor(domain.Cursor{
+			Sort: sortSpec,
+			Key:  domain.UserSortKey(last, query.SortBy),
+			ID:   int64(last.ID()),
+		})
+	}
+	return page, nil
+}
+
 /*
 FindUserByID busca un usuario por su ID.
 
-Esto no lo usamos todavía en los handlers HTTP,
-pero es útil si más adelante quieres agregar endpoints
-como GET /users/{id}.
+Se usa en el endpoint GET /users/{id}.
+Devuelve (nil, nil) si el usuario no existe.
 */
 func (s *UserService) FindUserByID(id domain.UserID) (*domain.User, error) {
 	return s.repo.FindByID(id)
 }
+
+// UserPatch contiene los cambios parciales de un usuario (PATCH).
+// Un campo en nil significa "no cambiar".
+type UserPatch struct {
+	Name   *string
+	Email  *string
+	Role   *domain.Role
+	Active *bool
+}
+
+/*
+UpdateUser aplica cambios parciales a un usuario existente.
+
+Si expectedVersion no es AnyVersion, el usuario debe seguir en esa
+versión; si no, se devuelve un error ErrVersionConflict.
+
+Pasos:
+1. Buscar el usuario (y verificar su versión).
+2. Si cambia el email, verificar que no lo use otro usuario.
+3. Aplicar los cambios en el dominio (valida todo junto).
+4. Activar o desactivar si se pidió.
+5. Guardar en el repositorio.
+*/
+func (s *UserService) UpdateUser(id domain.UserID, expectedVersion int64, patch UserPatch) (*domain.User, error) {
+	var user *domain.User
+
+	err := s.uow.Do(func(repos domain.Repositories) error {
+		// 1. Buscar el usuario.
+		var err error
+		user, err = repos.Users.FindByID(id)
+		if err != nil {
+			return err
+		}
+		if user == nil {
+			return domain.NotFound("usuario no encontrado")
+		}
+		if err := checkVersion(user.Version(), expectedVersion, "el usuario fue modificado por otra operación"); err != nil {
+			return err
+		}
+
+		// Partimos de los valores actuales y sobreescribimos los que vengan.
+		name, email, role := user.Name(), user.Email(), user.Role()
+		if patch.Name != nil {
+			name = *patch.Name
+		}
+		if patch.Email != nil {
+			email = *patch.Email
+		}
+		if patch.Role != nil {
+			role = *patch.Role
+		}
+
+		// 2. Verificar email repetido.
+		if email != user.Email() {
+			existing, err := repos.Users.FindByEmail(email)
+			if err != nil {
+				return err
+			}
+			if existing != nil && existing.ID() != user.ID() {
+				return domain.Conflict("ya existe un usuario con ese email")
+			}
+		}
+
+		// 3. Aplicar cambios.
+		if err := user.ApplyChanges(name, email, role); err != nil {
+			return err
+		}
+
+		// 4. Activar / desactivar.
+		if patch.Active != nil {
+			if *patch.Active {
+				user.Activate()
+			} else {
+				user.Deactivate()
+			}
+		}
+
+		// 5. Guardar.
+		return repos.Users.Update(user)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
@@ -0,0 +1,27 @@
+package usecase
+
+import "github.com/jfmg0509/sistema_libros_funcional_go/internal/domain"
+
+/*
+   ==========================================================
+   CONTROL DE VERSIONES (concurrencia optimista)
+   ==========================================================
+
+   Quien modifica un usuario o un libro puede indicar la versión
+   que leyó (en HTTP, con el header If-Match). Si la entidad ya
+   cambió desde entonces, la operación se rechaza en lugar de
+   pisar el cambio ajeno.
+
+   AnyVersion (0) significa "no verificar la versión".
+*/
+
+// AnyVersion desactiva la verificación de versión.
+const AnyVersion int64 = 0
+
+// checkVersion compara la versión actual con la esperada por el cliente.
+func checkVersion(current, expected int64, msg string) error {
+	if expected != AnyVersion && current != expected {
+		return domain.VersionConflict(msg)
+	}
+	return nil
+}